@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// configEnv is the environment variable to use a config file other than the default one.
+const configEnv = "AWSPUTLOGS_CONFIG"
+
+// aliasPrefix marks the argument as the name of an alias, like @prod-api.
+const aliasPrefix = "@"
+
+// aliasNamePattern matches names of aliases, which are typed on the command line.
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// alias is a named destination with the region and the profile to access it.
+type alias struct {
+	LogGroup  string `json:"logGroup"`
+	LogStream string `json:"logStream,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+}
+
+// args returns the flags of the default command to put logs to the alias.
+func (a alias) args() []string {
+	args := []string{"--log-group", a.LogGroup}
+	if a.LogStream != "" {
+		args = append(args, "--log-stream", a.LogStream)
+	}
+	if a.Region != "" {
+		args = append(args, "--region", a.Region)
+	}
+	if a.Profile != "" {
+		args = append(args, "--profile", a.Profile)
+	}
+	return args
+}
+
+type userConfig struct {
+	Aliases map[string]alias `json:"aliases,omitempty"`
+	// Queries are the saved Insights queries run by awsputlogs query @name
+	Queries map[string]string `json:"queries,omitempty"`
+	// Routes are the rules of --route @name
+	Routes map[string][]string `json:"routes,omitempty"`
+}
+
+// defaultConfigPath returns the path of the config file in the user config directory, or the one in AWSPUTLOGS_CONFIG.
+func defaultConfigPath() (string, error) {
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config error: %w", err)
+	}
+	return filepath.Join(dir, "awsputlogs", "config.json"), nil
+}
+
+// loadUserConfig reads the config file. It returns the empty config if the file does not exist yet.
+func loadUserConfig(path string) (userConfig, error) {
+	c := userConfig{Aliases: map[string]alias{}, Queries: map[string]string{}, Routes: map[string][]string{}}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return userConfig{}, fmt.Errorf("config error: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return userConfig{}, fmt.Errorf("config error: %s is invalid: %w", path, err)
+	}
+	if c.Aliases == nil {
+		c.Aliases = map[string]alias{}
+	}
+	if c.Queries == nil {
+		c.Queries = map[string]string{}
+	}
+	if c.Routes == nil {
+		c.Routes = map[string][]string{}
+	}
+	return c, nil
+}
+
+func saveUserConfig(path string, c userConfig) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+	return nil
+}
+
+func validateAliasName(name string) error {
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("argument error: the name of the alias must consist of letters, digits, '.', '_' and '-', but got %s", name)
+	}
+	return nil
+}
+
+// expandAlias replaces the alias given as the first argument, like @prod-api, with the flags of its destination.
+// Flags given after the alias override the region and the profile of it.
+func expandAlias(args []string) ([]string, error) {
+	if len(args) < 2 || !strings.HasPrefix(args[1], aliasPrefix) {
+		return args, nil
+	}
+	name := strings.TrimPrefix(args[1], aliasPrefix)
+	path, err := defaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := c.Aliases[name]
+	if !ok {
+		return nil, fmt.Errorf("argument error: alias %s is not found. Set it by awsputlogs alias set %s", name, name)
+	}
+	expanded := append([]string{args[0]}, a.args()...)
+	return append(expanded, args[2:]...), nil
+}
+
+func runAlias(args []string) error {
+	return runActions(args, "Manage aliases of destinations.", []subcommand{
+		{name: "set", description: "Create or update an alias.", run: runAliasSet},
+		{name: "list", description: "List aliases.", run: runAliasList},
+		{name: "delete", description: "Delete an alias.", run: runAliasDelete},
+	})
+}
+
+func runAliasSet(args []string) error {
+	a := alias{}
+
+	flags := newSubcommandFlagSet(args[0], "Create or update an alias.", "<ALIAS NAME> --log-group <LOG GROUP NAME> [options]")
+	flags.StringVar(&a.LogGroup, "log-group", "", "The name or the ARN of the log group. It is required.")
+	flags.StringVar(&a.LogStream, "log-stream", "", "The name of the log stream. If you do not use this parameters, logs are uploaded to the latest log stream.")
+	flags.StringVar(&a.Region, "region", "", "The name of the region. If you do not use this parameters, the region configured in config file is used.")
+	flags.StringVar(&a.Profile, "profile", "", "The name of the profile in config file. If you do not use this parameters, AWS_PROFILE environment variable is used.")
+	names := parseInterspersed(flags, args[1:])
+
+	if len(names) != 1 {
+		return errors.New("argument error: an alias name is required")
+	}
+	name := strings.TrimPrefix(names[0], aliasPrefix)
+	if err := validateAliasName(name); err != nil {
+		return err
+	}
+	if a.LogGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+
+	path, err := defaultConfigPath()
+	if err != nil {
+		return err
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		return err
+	}
+	c.Aliases[name] = a
+	if err := saveUserConfig(path, c); err != nil {
+		return err
+	}
+	fmt.Printf("Set alias %s%s to %s\n", aliasPrefix, name, destination{logGroup: a.LogGroup, logStream: a.LogStream})
+	return nil
+}
+
+func runAliasList(args []string) error {
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "List aliases.", "[options]")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	path, err := defaultConfigPath()
+	if err != nil {
+		return err
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return writeJSON(os.Stdout, c.Aliases)
+	}
+
+	names := make([]string, 0, len(c.Aliases))
+	for name := range c.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		a := c.Aliases[name]
+		rows[i] = []string{aliasPrefix + name, a.LogGroup, orDash(a.LogStream), orDash(a.Region), orDash(a.Profile)}
+	}
+	return writeTable(os.Stdout, []string{"ALIAS", "LOG GROUP", "LOG STREAM", "REGION", "PROFILE"}, rows)
+}
+
+func runAliasDelete(args []string) error {
+	flags := newSubcommandFlagSet(args[0], "Delete an alias.", "<ALIAS NAME>")
+	names := parseInterspersed(flags, args[1:])
+
+	if len(names) != 1 {
+		return errors.New("argument error: an alias name is required")
+	}
+	name := strings.TrimPrefix(names[0], aliasPrefix)
+
+	path, err := defaultConfigPath()
+	if err != nil {
+		return err
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Aliases[name]; !ok {
+		return fmt.Errorf("argument error: alias %s is not found", name)
+	}
+	delete(c.Aliases, name)
+	if err := saveUserConfig(path, c); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted alias %s%s\n", aliasPrefix, name)
+	return nil
+}
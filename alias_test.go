@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_expandAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(configEnv, path)
+	if err := runAliasSet([]string{"alias set", "prod-api", "--log-group", "/prod/api", "--region", "eu-west-1", "--profile", "prod"}); err != nil {
+		t.Fatalf("runAliasSet() error = %v", err)
+	}
+	if err := runAliasSet([]string{"alias set", "--log-group", "/dev/api", "--log-stream", "api", "@dev-api"}); err != nil {
+		t.Fatalf("runAliasSet() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Expand alias",
+			args: []string{"awsputlogs", "@prod-api", "sample log message1"},
+			want: []string{"awsputlogs", "--log-group", "/prod/api", "--region", "eu-west-1", "--profile", "prod", "sample log message1"},
+		},
+		{
+			name: "Expand alias with log stream and flags",
+			args: []string{"awsputlogs", "@dev-api", "--region", "us-east-1", "sample log message1"},
+			want: []string{"awsputlogs", "--log-group", "/dev/api", "--log-stream", "api", "--region", "us-east-1", "sample log message1"},
+		},
+		{
+			name: "Keep arguments without alias",
+			args: []string{"awsputlogs", "--log-group", "/prod/api", "@prod-api"},
+			want: []string{"awsputlogs", "--log-group", "/prod/api", "@prod-api"},
+		},
+		{
+			name:    "Expand unknown alias",
+			args:    []string{"awsputlogs", "@stg-api", "sample log message1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandAlias(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expandAlias() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandAlias() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := runAliasDelete([]string{"alias delete", "@prod-api"}); err != nil {
+		t.Fatalf("runAliasDelete() error = %v", err)
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		t.Fatalf("loadUserConfig() error = %v", err)
+	}
+	want := map[string]alias{"dev-api": {LogGroup: "/dev/api", LogStream: "api"}}
+	if !reflect.DeepEqual(c.Aliases, want) {
+		t.Errorf("loadUserConfig() aliases = %v, want %v", c.Aliases, want)
+	}
+}
+
+func Test_runAliasSet(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "Set alias without log group", args: []string{"alias set", "prod-api"}},
+		{name: "Set alias without name", args: []string{"alias set", "--log-group", "/prod/api"}},
+		{name: "Set alias with invalid name", args: []string{"alias set", "prod api", "--log-group", "/prod/api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			t.Setenv(configEnv, path)
+			if err := runAliasSet(tt.args); err == nil {
+				t.Errorf("runAliasSet() error = nil, want error")
+			}
+			if _, err := ioutil.ReadFile(path); err == nil {
+				t.Errorf("runAliasSet() wrote the config file, want not")
+			}
+		})
+	}
+}
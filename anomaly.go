@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type anomalyDetectorInfo struct {
+	Name                string    `json:"name"`
+	ARN                 string    `json:"arn"`
+	Status              string    `json:"status"`
+	EvaluationFrequency string    `json:"evaluationFrequency"`
+	LogGroupARNs        []string  `json:"logGroupArns"`
+	CreationTime        time.Time `json:"creationTime"`
+}
+
+// parseEvaluationFrequency parses the evaluation frequency of anomaly detectors case-insensitively.
+func parseEvaluationFrequency(frequency string) (types.EvaluationFrequency, error) {
+	values := types.EvaluationFrequency("").Values()
+	for _, v := range values {
+		if strings.EqualFold(frequency, string(v)) {
+			return v, nil
+		}
+	}
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return "", fmt.Errorf("argument error: --evaluation-frequency must be one of %s, but got %s", strings.Join(names, ", "), frequency)
+}
+
+func runAnomaly(args []string) error {
+	return runActions(args, "Manage anomaly detectors of log groups.", []subcommand{
+		{name: "create", description: "Create an anomaly detector.", run: runAnomalyCreate},
+		{name: "list", description: "List anomaly detectors.", run: runAnomalyList},
+		{name: "delete", description: "Delete an anomaly detector.", run: runAnomalyDelete},
+	})
+}
+
+func runAnomalyCreate(args []string) error {
+	params := parameters{}
+	logGroup, name, frequency, pattern := "", "", "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Create an anomaly detector.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&name, "name", "", "The name of the anomaly detector.")
+	flags.StringVar(&frequency, "evaluation-frequency", "", "How often the anomaly detector runs. ONE_MIN, FIVE_MIN, TEN_MIN, FIFTEEN_MIN, THIRTY_MIN or ONE_HOUR.")
+	flags.StringVar(&pattern, "filter-pattern", "", "The filter pattern to choose log events the anomaly detector looks at.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	// Anomaly detectors require the ARN of the log group
+	group, err := findLogGroup(client, logGroup)
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.CreateLogAnomalyDetectorInput{
+		LogGroupArnList: []string{group.ARN},
+	}
+	if name != "" {
+		in.DetectorName = aws.String(name)
+	}
+	if frequency != "" {
+		in.EvaluationFrequency, err = parseEvaluationFrequency(frequency)
+		if err != nil {
+			return err
+		}
+	}
+	if pattern != "" {
+		in.FilterPattern = aws.String(pattern)
+	}
+	out, err := client.CreateLogAnomalyDetector(context.Background(), in)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created anomaly detector %s\n", aws.ToString(out.AnomalyDetectorArn))
+	return nil
+}
+
+func listAnomalyDetectors(client *cloudwatchlogs.Client, logGroupARN string) ([]anomalyDetectorInfo, error) {
+	param := &cloudwatchlogs.ListLogAnomalyDetectorsInput{}
+	if logGroupARN != "" {
+		param.FilterLogGroupArn = aws.String(logGroupARN)
+	}
+
+	detectors := []anomalyDetectorInfo{}
+	paginator := cloudwatchlogs.NewListLogAnomalyDetectorsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, detector := range res.AnomalyDetectors {
+			detectors = append(detectors, anomalyDetectorInfo{
+				Name:                aws.ToString(detector.DetectorName),
+				ARN:                 aws.ToString(detector.AnomalyDetectorArn),
+				Status:              string(detector.AnomalyDetectorStatus),
+				EvaluationFrequency: string(detector.EvaluationFrequency),
+				LogGroupARNs:        detector.LogGroupArnList,
+				CreationTime:        fromMillis(detector.CreationTimeStamp),
+			})
+		}
+	}
+	return detectors, nil
+}
+
+func runAnomalyList(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "List anomaly detectors.", "[options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. If you do not use this parameters, it lists all anomaly detectors.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	logGroupARN := ""
+	if logGroup != "" {
+		group, err := findLogGroup(client, logGroup)
+		if err != nil {
+			return err
+		}
+		logGroupARN = group.ARN
+	}
+
+	detectors, err := listAnomalyDetectors(client, logGroupARN)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return writeJSON(os.Stdout, detectors)
+	}
+
+	rows := make([][]string, len(detectors))
+	for i, detector := range detectors {
+		rows[i] = []string{detector.Name, detector.Status, detector.EvaluationFrequency, detector.ARN}
+	}
+	return writeTable(os.Stdout, []string{"NAME", "STATUS", "FREQUENCY", "ARN"}, rows)
+}
+
+func runAnomalyDelete(args []string) error {
+	params := parameters{}
+	arn := ""
+
+	flags := newSubcommandFlagSet(args[0], "Delete an anomaly detector.", "--arn <ANOMALY DETECTOR ARN> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&arn, "arn", "", "The ARN of the anomaly detector. It is required.")
+	flags.Parse(args[1:])
+
+	if arn == "" {
+		return errors.New("argument error: --arn is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.DeleteLogAnomalyDetectorInput{
+		AnomalyDetectorArn: aws.String(arn),
+	}
+	if _, err := client.DeleteLogAnomalyDetector(context.Background(), in); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted anomaly detector %s\n", arn)
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseEvaluationFrequency(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		want      types.EvaluationFrequency
+		wantErr   bool
+	}{
+		{name: "Parse one minute", frequency: "ONE_MIN", want: types.EvaluationFrequencyOneMin},
+		{name: "Parse five minutes", frequency: "FIVE_MIN", want: types.EvaluationFrequencyFiveMin},
+		{name: "Parse ten minutes", frequency: "TEN_MIN", want: types.EvaluationFrequencyTenMin},
+		{name: "Parse fifteen minutes", frequency: "FIFTEEN_MIN", want: types.EvaluationFrequencyFifteenMin},
+		{name: "Parse thirty minutes", frequency: "THIRTY_MIN", want: types.EvaluationFrequencyThirtyMin},
+		{name: "Parse one hour", frequency: "ONE_HOUR", want: types.EvaluationFrequencyOneHour},
+		{name: "Parse frequency in lower case", frequency: "fifteen_min", want: types.EvaluationFrequencyFifteenMin},
+		{name: "Parse frequency in mixed case", frequency: "One_Hour", want: types.EvaluationFrequencyOneHour},
+		{name: "Parse duration", frequency: "5m", wantErr: true},
+		{name: "Parse frequency with spaces", frequency: " ONE_MIN ", wantErr: true},
+		{name: "Parse unknown frequency", frequency: "ONE_DAY", wantErr: true},
+		{name: "Parse empty frequency", frequency: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEvaluationFrequency(tt.frequency)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseEvaluationFrequency() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseEvaluationFrequency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
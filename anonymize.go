@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ipPattern matches candidates of IPv4 and IPv6 addresses, including IPv4-mapped IPv6 addresses.
+// They are anonymized only if they are valid addresses.
+var ipPattern = regexp.MustCompile(`(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f:]*:[0-9A-Fa-f:]*:[0-9A-Fa-f:]*(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f]*:[0-9A-Fa-f:]*:[0-9A-Fa-f]*`)
+
+// The number of bits kept by anonymizing addresses. The last octet of IPv4 and the low 80 bits of IPv6 are masked.
+const (
+	anonymizedIPv4Bits = 24
+	anonymizedIPv6Bits = 48
+)
+
+// anonymizeIP masks the address. IPv4-mapped IPv6 addresses are masked as IPv4.
+func anonymizeIP(addr netip.Addr) netip.Addr {
+	addr = addr.WithZone("")
+	if addr.Is4In6() {
+		masked, _ := addr.Unmap().Prefix(anonymizedIPv4Bits)
+		return netip.AddrFrom16(masked.Addr().As16())
+	}
+	bits := anonymizedIPv4Bits
+	if addr.Is6() {
+		bits = anonymizedIPv6Bits
+	}
+	masked, _ := addr.Prefix(bits)
+	return masked.Addr()
+}
+
+// anonymizeIPs masks all addresses in s.
+func anonymizeIPs(s string) string {
+	return ipPattern.ReplaceAllStringFunc(s, func(candidate string) string {
+		addr, err := netip.ParseAddr(candidate)
+		if err != nil {
+			return candidate
+		}
+		return anonymizeIP(addr).String()
+	})
+}
+
+// ipAnonymizer masks IP addresses in log events. The nil ipAnonymizer does not change log events.
+type ipAnonymizer struct {
+	// fields are the paths of fields of JSON log events whose addresses are masked. If it is empty, all addresses are masked.
+	fields [][]string
+}
+
+// newIPAnonymizer creates the anonymizer for the comma-separated paths of fields.
+func newIPAnonymizer(fields string) (*ipAnonymizer, error) {
+	a := &ipAnonymizer{}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		path, err := parseFieldPath("--ip-fields", field)
+		if err != nil {
+			return nil, err
+		}
+		a.fields = append(a.fields, path)
+	}
+	if fields != "" && len(a.fields) == 0 {
+		return nil, errors.New("argument error: --ip-fields must have the paths of fields such as client_ip,remote_addr")
+	}
+	return a, nil
+}
+
+// update replaces the value of the field at the path of keys with f.
+func (o jsonObject) update(path []string, f func([]byte) []byte) jsonObject {
+	updated := make(jsonObject, len(o))
+	for i, field := range o {
+		if field.key == path[0] {
+			if len(path) == 1 {
+				field.value = f(field.value)
+			} else if child, ok := parseJSONObject(field.value); ok {
+				field.value = child.update(path[1:], f).marshal()
+			}
+		}
+		updated[i] = field
+	}
+	return updated
+}
+
+// anonymize returns the log event whose addresses are masked. Addresses in all of the log event are masked
+// if no fields are given or the log event is not a JSON object.
+func (a *ipAnonymizer) anonymize(event types.InputLogEvent) types.InputLogEvent {
+	if a == nil {
+		return event
+	}
+	message := aws.ToString(event.Message)
+	o, ok := parseJSONObject([]byte(message))
+	if len(a.fields) == 0 || !ok {
+		event.Message = aws.String(anonymizeIPs(message))
+		return event
+	}
+	for _, path := range a.fields {
+		// Addresses in JSON values are not escaped, so they are masked in the raw values including strings and arrays
+		o = o.update(path, func(value []byte) []byte { return []byte(anonymizeIPs(string(value))) })
+	}
+	event.Message = aws.String(string(o.marshal()))
+	return event
+}
+
+// anonymizeLogEvents returns log events whose addresses are masked.
+func anonymizeLogEvents(events []types.InputLogEvent, a *ipAnonymizer) []types.InputLogEvent {
+	if a == nil {
+		return events
+	}
+	anonymized := make([]types.InputLogEvent, len(events))
+	for i, event := range events {
+		anonymized[i] = a.anonymize(event)
+	}
+	return anonymized
+}
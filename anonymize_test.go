@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_anonymizeIPs(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "Mask IPv4", s: "192.0.2.10 - - GET /", want: "192.0.2.0 - - GET /"},
+		{name: "Mask IPv4 with port", s: "remote=192.0.2.10:443.", want: "remote=192.0.2.0:443."},
+		{name: "Mask IPv6", s: "from 2001:db8:85a3:1234:5678:8a2e:370:7334", want: "from 2001:db8:85a3::"},
+		{name: "Mask compressed IPv6 in brackets", s: "[2001:db8::1]:443", want: "[2001:db8::]:443"},
+		{name: "Mask IPv4-mapped IPv6", s: "::ffff:192.0.2.10", want: "::ffff:192.0.2.0"},
+		{name: "Mask several addresses", s: "192.0.2.10, 198.51.100.7", want: "192.0.2.0, 198.51.100.0"},
+		{name: "Don't mask time", s: "13:25:15 INFO", want: "13:25:15 INFO"},
+		{name: "Don't mask MAC address", s: "aa:bb:cc:dd:ee:ff", want: "aa:bb:cc:dd:ee:ff"},
+		{name: "Don't mask invalid IPv4", s: "version 999.1.2.3", want: "version 999.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anonymizeIPs(tt.s); got != tt.want {
+				t.Errorf("anonymizeIPs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ipAnonymizer_anonymize(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		message string
+		want    string
+	}{
+		{
+			name:    "Mask addresses in all of JSON log event",
+			message: `{"client_ip":"192.0.2.10","message":"forwarded by 198.51.100.7"}`,
+			want:    `{"client_ip":"192.0.2.0","message":"forwarded by 198.51.100.0"}`,
+		},
+		{
+			name:    "Mask addresses in fields",
+			fields:  "client_ip, http.forwarded_for",
+			message: `{"client_ip":"192.0.2.10","server_ip":"203.0.113.5","http":{"forwarded_for":["198.51.100.7","2001:db8::1"]}}`,
+			want:    `{"client_ip":"192.0.2.0","server_ip":"203.0.113.5","http":{"forwarded_for":["198.51.100.0","2001:db8::"]}}`,
+		},
+		{
+			name:    "Mask addresses in text log event with fields",
+			fields:  "client_ip",
+			message: `192.0.2.10 - frank "GET / HTTP/1.0" 200`,
+			want:    `192.0.2.0 - frank "GET / HTTP/1.0" 200`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newIPAnonymizer(tt.fields)
+			if err != nil {
+				t.Fatalf("newIPAnonymizer() error = %v", err)
+			}
+			got := a.anonymize(types.InputLogEvent{Message: aws.String(tt.message)})
+			if aws.ToString(got.Message) != tt.want {
+				t.Errorf("ipAnonymizer.anonymize() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+
+	if _, err := newIPAnonymizer(" , "); err == nil {
+		t.Errorf("newIPAnonymizer() with no fields error = nil, want error")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// apiCallStats is the statistics of calls of an API.
+type apiCallStats struct {
+	calls    int
+	failures int
+	total    time.Duration
+	max      time.Duration
+}
+
+// apiMetrics counts API calls and their latencies during the run.
+type apiMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*apiCallStats
+}
+
+// apiCalls records all API calls of clients created by loadConfig.
+var apiCalls = newAPIMetrics()
+
+func newAPIMetrics() *apiMetrics {
+	return &apiMetrics{stats: map[string]*apiCallStats{}}
+}
+
+func (m *apiMetrics) record(operation string, latency time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stats[operation]
+	if !ok {
+		s = &apiCallStats{}
+		m.stats[operation] = s
+	}
+	s.calls++
+	if failed {
+		s.failures++
+	}
+	s.total += latency
+	if latency > s.max {
+		s.max = latency
+	}
+}
+
+// addRecording adds the middleware to record API calls to the stack.
+func (m *apiMetrics) addRecording(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("APIMetrics", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		m.record(awsmiddleware.GetOperationName(ctx), time.Since(start), err != nil)
+		return out, metadata, err
+	}), middleware.Before)
+}
+
+// write writes the number of calls, failures and latencies of each API in the order of the names.
+func (m *apiMetrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operations := make([]string, 0, len(m.stats))
+	for operation := range m.stats {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	rows := make([][]string, len(operations))
+	for i, operation := range operations {
+		s := m.stats[operation]
+		avg := s.total / time.Duration(s.calls)
+		rows[i] = []string{
+			operation,
+			strconv.Itoa(s.calls),
+			strconv.Itoa(s.failures),
+			avg.Round(time.Millisecond).String(),
+			s.max.Round(time.Millisecond).String(),
+		}
+	}
+	return writeTable(w, []string{"API", "CALLS", "FAILURES", "AVG LATENCY", "MAX LATENCY"}, rows)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_apiMetrics_write(t *testing.T) {
+	m := newAPIMetrics()
+	m.record("PutLogEvents", 100*time.Millisecond, false)
+	m.record("PutLogEvents", 300*time.Millisecond, true)
+	m.record("DescribeLogStreams", 50*time.Millisecond, false)
+
+	buf := &bytes.Buffer{}
+	if err := m.write(buf); err != nil {
+		t.Fatalf("apiMetrics.write() error = %v", err)
+	}
+	want := `API                 CALLS  FAILURES  AVG LATENCY  MAX LATENCY
+DescribeLogStreams  1      0         50ms         50ms
+PutLogEvents        2      1         200ms        300ms
+`
+	if buf.String() != want {
+		t.Errorf("apiMetrics.write() = %v, want %v", buf.String(), want)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inputFile is a file of --logs-file or a member file of an archive given as --logs-file.
+type inputFile struct {
+	name string
+	data []byte
+}
+
+// archiveFilter chooses member files of archives by the globs matched to their paths or base names.
+type archiveFilter struct {
+	// include has the globs of member files read. If it is empty, all member files are read.
+	include []string
+	// exclude has the globs of member files skipped even if they match include.
+	exclude []string
+}
+
+// validate returns the error if the globs are invalid.
+func (f archiveFilter) validate() error {
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("argument error: --archive-include and --archive-exclude have the invalid glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// match reports whether the member file is read.
+func (f archiveFilter) match(name string) bool {
+	for _, pattern := range f.exclude {
+		if matchPath(pattern, name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchPath(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArchive reports whether the file is a zip or gzipped tar archive by its extension.
+func isArchive(fileName string) bool {
+	name := strings.ToLower(fileName)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// readInputFiles reads the file. If it is an archive, it reads member files chosen by the filter in order of the archive.
+// Member files are named like bundle.zip:app/api.log.
+func readInputFiles(fileName string, filter archiveFilter) ([]inputFile, error) {
+	if !isArchive(fileName) {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		return []inputFile{{name: fileName, data: data}}, nil
+	}
+
+	var files []inputFile
+	var err error
+	if strings.HasSuffix(strings.ToLower(fileName), ".zip") {
+		files, err = readZip(fileName, filter)
+	} else {
+		files, err = readTarGz(fileName, filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive error: failed to read %s: %w", fileName, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("archive error: no member files of %s match --archive-include and --archive-exclude", fileName)
+	}
+	return files, nil
+}
+
+func readZip(fileName string, filter archiveFilter) ([]inputFile, error) {
+	r, err := zip.OpenReader(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	files := []inputFile{}
+	for _, member := range r.File {
+		if member.FileInfo().IsDir() || !filter.match(member.Name) {
+			continue
+		}
+		f, err := member.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, inputFile{name: fileName + ":" + member.Name, data: data})
+	}
+	return files, nil
+}
+
+func readTarGz(fileName string, filter archiveFilter) ([]inputFile, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files := []inputFile{}
+	r := tar.NewReader(gz)
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !filter.match(header.Name) {
+			continue
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, inputFile{name: fileName + ":" + header.Name, data: data})
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var archiveMembers = []inputFile{
+	{name: "app/api.log", data: []byte("[INFO] Start API\n")},
+	{name: "app/worker.log", data: []byte("[INFO] Start Worker\n")},
+	{name: "app/old/api.log.gz", data: []byte("gzipped")},
+	{name: "README.txt", data: []byte("Support bundle")},
+}
+
+func newZip(t *testing.T, members []inputFile) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	if _, err := w.Create("app/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range members {
+		f, err := w.Create(m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(m.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func newTarGz(t *testing.T, members []inputFile) []byte {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	w := tar.NewWriter(gz)
+	if err := w.WriteHeader(&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range members {
+		if err := w.WriteHeader(&tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(m.data))}); err != nil {
+			t.Fatal(err)
+		}
+		w.Write(m.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func Test_readInputFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		data      []byte
+		filter    archiveFilter
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "Read file",
+			fileName:  "app.log",
+			data:      []byte("[INFO] Start API\n"),
+			wantNames: []string{"app.log"},
+		},
+		{
+			name:      "Read all member files of zip",
+			fileName:  "bundle.zip",
+			data:      newZip(t, archiveMembers),
+			wantNames: []string{"app/api.log", "app/worker.log", "app/old/api.log.gz", "README.txt"},
+		},
+		{
+			name:      "Read member files of tar.gz matching globs",
+			fileName:  "bundle.tar.gz",
+			data:      newTarGz(t, archiveMembers),
+			filter:    archiveFilter{include: []string{"*.log*"}, exclude: []string{"*.gz", "app/worker.log"}},
+			wantNames: []string{"app/api.log"},
+		},
+		{
+			name:      "Read member files of tgz",
+			fileName:  "bundle.tgz",
+			data:      newTarGz(t, archiveMembers),
+			filter:    archiveFilter{include: []string{"README.txt"}},
+			wantNames: []string{"README.txt"},
+		},
+		{
+			name:     "Read archive without matching member files",
+			fileName: "bundle.zip",
+			data:     newZip(t, archiveMembers),
+			filter:   archiveFilter{include: []string{"*.json"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Read invalid archive",
+			fileName: "bundle.tar.gz",
+			data:     []byte("not gzip"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := writeFile(t, tt.fileName, tt.data)
+			got, err := readInputFiles(fileName, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readInputFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			names := make([]string, len(got))
+			for i, file := range got {
+				// Member files are named after the archive
+				names[i] = strings.TrimPrefix(strings.TrimPrefix(file.name, filepath.Dir(fileName)+"/"), tt.fileName+":")
+				for _, m := range archiveMembers {
+					if m.name == names[i] && !bytes.Equal(m.data, file.data) {
+						t.Errorf("readInputFiles() %s = %q, want %q", m.name, file.data, m.data)
+					}
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("readInputFiles() = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
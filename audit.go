@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// The types of audit records
+const (
+	auditBatch = "batch"
+	auditRun   = "run"
+)
+
+// auditRecord is a line of the audit file, which records what logs were put where by whom.
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	RunID     string    `json:"runId"`
+	BatchID   string    `json:"batchId,omitempty"`
+	BatchIDs  []string  `json:"batchIds,omitempty"`
+	User      string    `json:"user"`
+	Principal string    `json:"principal,omitempty"`
+	LogGroup  string    `json:"logGroup"`
+	LogStream string    `json:"logStream"`
+	Region    string    `json:"region"`
+	Events    int       `json:"events"`
+	Bytes     int       `json:"bytes"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// auditLog appends records of batches and runs to the audit file. The methods do nothing on nil.
+type auditLog struct {
+	mu    sync.Mutex
+	f     *os.File
+	now   func() time.Time
+	runID string
+	user  string
+	seq   int
+	// principals are the ARNs of the callers for each destination
+	principals map[destination]string
+	// batchIDs are the IDs of batches put to each destination in order
+	batchIDs map[destination][]string
+	// err is the first error to write records
+	err error
+}
+
+// openAuditLog opens the audit file to append records of the run.
+func openAuditLog(name string) (*auditLog, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("audit error: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		f.Close()
+		return nil, err
+	}
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	return &auditLog{
+		f:          f,
+		now:        time.Now,
+		runID:      hex.EncodeToString(id),
+		user:       username,
+		principals: map[destination]string{},
+		batchIDs:   map[destination][]string{},
+	}, nil
+}
+
+// setPrincipal sets the ARN of the caller for the destination. It is left empty if it can not be found.
+func (a *auditLog) setPrincipal(params parameters, dst destination) {
+	if a == nil {
+		return
+	}
+	cfg, err := loadDestinationConfig(params, dst)
+	if err != nil {
+		return
+	}
+	out, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.principals[dst] = aws.ToString(out.Arn)
+}
+
+func (a *auditLog) write(record auditRecord) {
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = a.f.Write(append(data, '\n'))
+	}
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("audit error: %w", err)
+	}
+}
+
+func (a *auditLog) newRecord(kind string, u *uploader, events, bytes int, err error) auditRecord {
+	record := auditRecord{
+		Time:      a.now().UTC(),
+		Type:      kind,
+		RunID:     a.runID,
+		User:      a.user,
+		Principal: a.principals[u.dst],
+		LogGroup:  u.dst.logGroup,
+		LogStream: u.dst.logStream,
+		Region:    u.ingested.region,
+		Events:    events,
+		Bytes:     bytes,
+		Result:    "succeeded",
+	}
+	if err != nil {
+		record.Result = "failed"
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// recordBatch appends the record of the batch put by the uploader.
+func (a *auditLog) recordBatch(u *uploader, batch []types.InputLogEvent, err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.seq++
+	record := a.newRecord(auditBatch, u, len(batch), ingestedBytes(batch), err)
+	record.BatchID = fmt.Sprintf("%s-%06d", a.runID, a.seq)
+	a.batchIDs[u.dst] = append(a.batchIDs[u.dst], record.BatchID)
+	a.write(record)
+}
+
+// recordRun appends the record of the run for the uploader with the IDs of its batches.
+func (a *auditLog) recordRun(u *uploader) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record := a.newRecord(auditRun, u, u.ingested.events, u.ingested.bytes, u.err)
+	record.BatchIDs = a.batchIDs[u.dst]
+	a.write(record)
+}
+
+// close closes the audit file, and returns the first error to write records.
+func (a *auditLog) close() error {
+	if a == nil {
+		return nil
+	}
+	if err := a.f.Close(); err != nil && a.err == nil {
+		a.err = fmt.Errorf("audit error: %w", err)
+	}
+	return a.err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_auditLog(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.ndjson")
+	a, err := openAuditLog(name)
+	if err != nil {
+		t.Fatalf("openAuditLog() error = %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	a.runID = "run"
+	a.user = "alice"
+
+	dst := destination{logGroup: "/app/api", logStream: "web-1"}
+	a.principals[dst] = "arn:aws:iam::123456789012:user/alice"
+	u := &uploader{dst: dst, ingested: ingestion{dst: dst, region: "us-east-1"}}
+	batch := []types.InputLogEvent{{Message: aws.String("log1"), Timestamp: aws.Int64(1)}}
+
+	a.recordBatch(u, batch, nil)
+	u.ingested.events, u.ingested.bytes = 1, ingestedBytes(batch)
+	a.recordBatch(u, batch, errors.New("throttled"))
+	u.err = errors.New("throttled")
+	a.recordRun(u)
+	if err := a.close(); err != nil {
+		t.Fatalf("auditLog.close() error = %v", err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got := []auditRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := auditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("auditLog wrote invalid record %s: %v", scanner.Text(), err)
+		}
+		got = append(got, record)
+	}
+
+	base := auditRecord{
+		Time:      now,
+		RunID:     "run",
+		User:      "alice",
+		Principal: "arn:aws:iam::123456789012:user/alice",
+		LogGroup:  "/app/api",
+		LogStream: "web-1",
+		Region:    "us-east-1",
+		Events:    1,
+		Bytes:     30,
+	}
+	want := []auditRecord{base, base, base}
+	want[0].Type, want[0].BatchID, want[0].Result = auditBatch, "run-000001", "succeeded"
+	want[1].Type, want[1].BatchID, want[1].Result, want[1].Error = auditBatch, "run-000002", "failed", "throttled"
+	want[2].Type, want[2].BatchIDs, want[2].Result, want[2].Error = auditRun, []string{"run-000001", "run-000002"}, "failed", "throttled"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("auditLog wrote %+v, want %+v", got, want)
+	}
+}
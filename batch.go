@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The limits of a PutLogEvents call.
+// See https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	maxBatchEvents     = 10000
+	maxBatchBytes      = 1048576
+	eventOverheadBytes = 26
+	maxBatchSpan       = 24 * time.Hour
+)
+
+// batchLimits are the limits of log events uploaded by a PutLogEvents call given by users.
+type batchLimits struct {
+	maxEvents int
+	maxBytes  int
+}
+
+var defaultBatchLimits = batchLimits{
+	maxEvents: maxBatchEvents,
+	maxBytes:  maxBatchBytes,
+}
+
+func (l batchLimits) validate() error {
+	if l.maxEvents <= 0 || maxBatchEvents < l.maxEvents {
+		return fmt.Errorf("argument error: --max-batch-events must be between 1 and %d, but got %d", maxBatchEvents, l.maxEvents)
+	}
+	if l.maxBytes <= eventOverheadBytes || maxBatchBytes < l.maxBytes {
+		return fmt.Errorf("argument error: --max-batch-bytes must be between %d and %d, but got %d", eventOverheadBytes+1, maxBatchBytes, l.maxBytes)
+	}
+	return nil
+}
+
+// parseByteSize parses the size such as 512, 512k, 1m or 1g. k, m and g are 1024, 1024^2 and 1024^3 bytes.
+func parseByteSize(s string) (int, error) {
+	unit := 1
+	switch {
+	case strings.HasSuffix(strings.ToLower(s), "k"):
+		unit = 1024
+	case strings.HasSuffix(strings.ToLower(s), "m"):
+		unit = 1024 * 1024
+	case strings.HasSuffix(strings.ToLower(s), "g"):
+		unit = 1024 * 1024 * 1024
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %s", s)
+	}
+	return n * unit, nil
+}
+
+func eventSize(event types.InputLogEvent) int {
+	return len(aws.ToString(event.Message)) + eventOverheadBytes
+}
+
+// The reasons why a batch ends before the next log event.
+const (
+	splitByEvents = "max-batch-events"
+	splitByBytes  = "max-batch-bytes"
+	splitBySpan   = "24h-span"
+	splitByOrder  = "timestamp-order"
+)
+
+// splitReason returns why events[i] does not fit the batch of events[start:i] whose size is size, or "" if it fits.
+func splitReason(events []types.InputLogEvent, start, i, size int, limits batchLimits) string {
+	n := i - start
+	if n == 0 {
+		return ""
+	}
+	event := events[i]
+	span := time.Duration(aws.ToInt64(event.Timestamp)-aws.ToInt64(events[start].Timestamp)) * time.Millisecond
+	switch {
+	case n == limits.maxEvents:
+		return splitByEvents
+	case size+eventSize(event) > limits.maxBytes:
+		return splitByBytes
+	case span >= maxBatchSpan:
+		return splitBySpan
+	// Log events in a batch must be in order of the timestamp
+	case aws.ToInt64(event.Timestamp) < aws.ToInt64(events[i-1].Timestamp):
+		return splitByOrder
+	}
+	return ""
+}
+
+// splitBatches splits log events into batches within the limits. A batch also ends where the timestamp goes back.
+func splitBatches(events []types.InputLogEvent, limits batchLimits) [][]types.InputLogEvent {
+	batches := [][]types.InputLogEvent{}
+	start, size := 0, 0
+	for i, event := range events {
+		if splitReason(events, start, i, size, limits) != "" {
+			batches = append(batches, events[start:i])
+			start, size = i, 0
+		}
+		size += eventSize(event)
+	}
+	if start < len(events) {
+		batches = append(batches, events[start:])
+	}
+	return batches
+}
+
+// batchPlan describes a batch of log events which would be put by a PutLogEvents call.
+type batchPlan struct {
+	// First and Last are the indexes of the first and the last log events of the batch
+	First          int       `json:"first"`
+	Last           int       `json:"last"`
+	Events         int       `json:"events"`
+	Bytes          int       `json:"bytes"`
+	FirstEventTime time.Time `json:"firstEventTime"`
+	LastEventTime  time.Time `json:"lastEventTime"`
+	// SplitBy is why the batch ends before the next log event. It is empty for the last batch.
+	SplitBy string `json:"splitBy,omitempty"`
+}
+
+// planBatches returns the batches which splitBatches splits log events into.
+func planBatches(events []types.InputLogEvent, limits batchLimits) []batchPlan {
+	plans := []batchPlan{}
+	start := 0
+	for _, batch := range splitBatches(events, limits) {
+		plan := batchPlan{First: start, Last: start + len(batch) - 1, Events: len(batch), Bytes: ingestedBytes(batch)}
+		for i, event := range batch {
+			t := fromMillis(aws.ToInt64(event.Timestamp)).UTC()
+			if i == 0 || t.Before(plan.FirstEventTime) {
+				plan.FirstEventTime = t
+			}
+			if i == 0 || t.After(plan.LastEventTime) {
+				plan.LastEventTime = t
+			}
+		}
+		end := start + len(batch)
+		if end < len(events) {
+			plan.SplitBy = splitReason(events, start, end, plan.Bytes, limits)
+		}
+		plans = append(plans, plan)
+		start = end
+	}
+	return plans
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func newTestEvents(n int, message string, interval time.Duration) []types.InputLogEvent {
+	events := make([]types.InputLogEvent, n)
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := range events {
+		events[i] = types.InputLogEvent{
+			Message:   aws.String(message),
+			Timestamp: aws.Int64(toMillis(start.Add(time.Duration(i) * interval))),
+		}
+	}
+	return events
+}
+
+func Test_splitBatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []types.InputLogEvent
+		limits batchLimits
+		want   []int
+	}{
+		{
+			name:   "Split no events",
+			events: []types.InputLogEvent{},
+			limits: defaultBatchLimits,
+			want:   []int{},
+		},
+		{
+			name:   "Split events within limits",
+			events: newTestEvents(3, "[INFO] Start Server", time.Second),
+			limits: defaultBatchLimits,
+			want:   []int{3},
+		},
+		{
+			name:   "Split events by count",
+			events: newTestEvents(maxBatchEvents+1, "[INFO] Start Server", 0),
+			limits: defaultBatchLimits,
+			want:   []int{maxBatchEvents, 1},
+		},
+		{
+			name:   "Split events by size",
+			events: newTestEvents(3, strings.Repeat("a", maxBatchBytes/2), 0),
+			limits: defaultBatchLimits,
+			want:   []int{1, 1, 1},
+		},
+		{
+			name:   "Split events by given limits",
+			events: newTestEvents(5, "[INFO] Start Server", 0),
+			limits: batchLimits{maxEvents: 2, maxBytes: maxBatchBytes},
+			want:   []int{2, 2, 1},
+		},
+		{
+			name:   "Split events going back in time",
+			events: append(newTestEvents(2, "[INFO] Start Server", time.Second), newTestEvents(2, "[INFO] Start Server", time.Second)...),
+			limits: defaultBatchLimits,
+			want:   []int{2, 2},
+		},
+		{
+			name:   "Split events by time span",
+			events: newTestEvents(5, "[INFO] Start Server", 12*time.Hour),
+			limits: defaultBatchLimits,
+			want:   []int{2, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitBatches(tt.events, tt.limits)
+			if len(got) != len(tt.want) {
+				t.Errorf("splitBatches() returns %d batches, want %d", len(got), len(tt.want))
+				return
+			}
+			for i, batch := range got {
+				if len(batch) != tt.want[i] {
+					t.Errorf("splitBatches() batch %d has %d events, want %d", i, len(batch), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_planBatches(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := append(newTestEvents(5, "[INFO] Start Server", 12*time.Hour)[:3], newTestEvents(3, "[INFO] Start Server", time.Second)...)
+	got := planBatches(events, batchLimits{maxEvents: 2, maxBytes: maxBatchBytes})
+	want := []batchPlan{
+		{First: 0, Last: 1, Events: 2, Bytes: 90, FirstEventTime: start, LastEventTime: start.Add(12 * time.Hour), SplitBy: splitByEvents},
+		{First: 2, Last: 2, Events: 1, Bytes: 45, FirstEventTime: start.Add(24 * time.Hour), LastEventTime: start.Add(24 * time.Hour), SplitBy: splitByOrder},
+		{First: 3, Last: 4, Events: 2, Bytes: 90, FirstEventTime: start, LastEventTime: start.Add(time.Second), SplitBy: splitByEvents},
+		{First: 5, Last: 5, Events: 1, Bytes: 45, FirstEventTime: start.Add(2 * time.Second), LastEventTime: start.Add(2 * time.Second)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planBatches() = %+v, want %+v", got, want)
+	}
+
+	got = planBatches(newTestEvents(3, "[INFO] Start Server", 12*time.Hour), defaultBatchLimits)
+	if len(got) != 2 || got[0].SplitBy != splitBySpan {
+		t.Errorf("planBatches() = %+v, want 2 batches split by the time span", got)
+	}
+	got = planBatches(newTestEvents(2, strings.Repeat("a", maxBatchBytes/2), 0), defaultBatchLimits)
+	if len(got) != 2 || got[0].SplitBy != splitByBytes {
+		t.Errorf("planBatches() = %+v, want 2 batches split by the size", got)
+	}
+}
+
+func Test_parseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{name: "Parse bytes", s: "512", want: 512},
+		{name: "Parse kilobytes", s: "512k", want: 512 * 1024},
+		{name: "Parse megabytes", s: "1M", want: 1024 * 1024},
+		{name: "Parse gigabytes", s: "2g", want: 2 * 1024 * 1024 * 1024},
+		{name: "Parse invalid size", s: "1t", wantErr: true},
+		{name: "Parse negative size", s: "-1k", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseByteSize(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// chunkPlaceholder is replaced with the time chunk of log events in the name of the log stream.
+const chunkPlaceholder = "{chunk}"
+
+// The periods to chunk log events into log streams by their timestamps
+const (
+	chunkByDay  = "day"
+	chunkByHour = "hour"
+)
+
+// chunkLayouts are the layouts of chunks in the names of log streams, in UTC.
+// Log stream names can not include colons, so hours are joined with a hyphen.
+var chunkLayouts = map[string]string{
+	chunkByDay:  "2006-01-02",
+	chunkByHour: "2006-01-02-15",
+}
+
+func validateChunkBy(period string) error {
+	if _, ok := chunkLayouts[period]; !ok {
+		return fmt.Errorf("argument error: --chunk-by must be %s or %s, but got %s", chunkByDay, chunkByHour, period)
+	}
+	return nil
+}
+
+// chunkLogEvents groups log events by the chunk of their timestamps, keeping the order in each chunk.
+// It returns the chunks in chronological order.
+func chunkLogEvents(events []types.InputLogEvent, period string) ([]string, map[string][]types.InputLogEvent) {
+	layout := chunkLayouts[period]
+	chunks := map[string][]types.InputLogEvent{}
+	for _, event := range events {
+		chunk := fromMillis(aws.ToInt64(event.Timestamp)).UTC().Format(layout)
+		chunks[chunk] = append(chunks[chunk], event)
+	}
+	names := make([]string, 0, len(chunks))
+	for chunk := range chunks {
+		names = append(names, chunk)
+	}
+	sort.Strings(names)
+	return names, chunks
+}
+
+// chunkDestinations expands each destination into the destinations of chunks which have log events,
+// and returns log events of each of them in the same way as --map.
+func chunkDestinations(dsts []destination, events []types.InputLogEvent, period string) ([]destination, [][]types.InputLogEvent, error) {
+	names, chunks := chunkLogEvents(events, period)
+	chunked := make([]destination, 0, len(dsts)*len(names))
+	mapped := make([][]types.InputLogEvent, 0, len(dsts)*len(names))
+	for _, dst := range dsts {
+		if !strings.Contains(dst.logStream, chunkPlaceholder) {
+			return nil, nil, fmt.Errorf("argument error: --log-stream must include %s with --chunk-by, but got %q for %s", chunkPlaceholder, dst.logStream, dst.logGroup)
+		}
+		for _, name := range names {
+			chunk := dst
+			chunk.logStream = strings.ReplaceAll(dst.logStream, chunkPlaceholder, name)
+			chunked = append(chunked, chunk)
+			mapped = append(mapped, chunks[name])
+		}
+	}
+	return chunked, mapped, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_chunkDestinations(t *testing.T) {
+	event := func(t time.Time, message string) types.InputLogEvent {
+		return types.InputLogEvent{Timestamp: aws.Int64(toMillis(t)), Message: aws.String(message)}
+	}
+	start := time.Date(2024, 1, 2, 23, 30, 0, 0, time.UTC)
+	events := []types.InputLogEvent{
+		event(start, "[INFO] Start Server"),
+		event(start.Add(time.Hour), "[INFO] Connect DB"),
+		event(start.Add(10*time.Minute), "[ERROR] Failed to Connect DB"),
+		event(start.Add(2*time.Hour), "[INFO] Stop Server"),
+	}
+	tests := []struct {
+		name       string
+		dsts       []destination
+		period     string
+		want       []destination
+		wantEvents [][]types.InputLogEvent
+		wantErr    bool
+	}{
+		{
+			name:   "Chunk destinations by day",
+			dsts:   []destination{{logGroup: "/test/group", logStream: "import-{chunk}", region: "eu-west-1"}},
+			period: chunkByDay,
+			want: []destination{
+				{logGroup: "/test/group", logStream: "import-2024-01-02", region: "eu-west-1"},
+				{logGroup: "/test/group", logStream: "import-2024-01-03", region: "eu-west-1"},
+			},
+			wantEvents: [][]types.InputLogEvent{{events[0], events[2]}, {events[1], events[3]}},
+		},
+		{
+			name:   "Chunk destinations by hour",
+			dsts:   []destination{{logGroup: "/test/group", logStream: "import-{chunk}"}, {logGroup: "/test/audit", logStream: "{chunk}"}},
+			period: chunkByHour,
+			want: []destination{
+				{logGroup: "/test/group", logStream: "import-2024-01-02-23"},
+				{logGroup: "/test/group", logStream: "import-2024-01-03-00"},
+				{logGroup: "/test/group", logStream: "import-2024-01-03-01"},
+				{logGroup: "/test/audit", logStream: "2024-01-02-23"},
+				{logGroup: "/test/audit", logStream: "2024-01-03-00"},
+				{logGroup: "/test/audit", logStream: "2024-01-03-01"},
+			},
+			wantEvents: [][]types.InputLogEvent{{events[0], events[2]}, {events[1]}, {events[3]}, {events[0], events[2]}, {events[1]}, {events[3]}},
+		},
+		{
+			name:    "Chunk destination without the placeholder",
+			dsts:    []destination{{logGroup: "/test/group", logStream: "import"}},
+			period:  chunkByDay,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotEvents, err := chunkDestinations(tt.dsts, events, tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("chunkDestinations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkDestinations() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotEvents, tt.wantEvents) {
+				t.Errorf("chunkDestinations() events = %v, want %v", gotEvents, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// The states of the circuit breaker
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+const maxCircuitCooldown = 10 * time.Minute
+
+// circuitBreaker stops calling the API for a destination after consecutive failures.
+// When the cooldown passes, it lets a call through as a probe. It closes if the probe succeeds,
+// and opens again with the doubled cooldown if the probe fails. The nil circuitBreaker is always closed.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	state     string
+	failures  int
+	// backoff is the current cooldown, and probeAt is when the next probe is allowed in the open state
+	backoff time.Duration
+	probeAt time.Time
+}
+
+// newCircuitBreaker creates the circuit breaker which opens after threshold consecutive failures.
+// If threshold is not positive, it returns nil.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     circuitClosed,
+	}
+}
+
+// allow reports whether a call is allowed. It returns the event if the state changes.
+func (b *circuitBreaker) allow() (bool, string) {
+	if b == nil {
+		return true, ""
+	}
+	if b.state != circuitOpen {
+		return true, ""
+	}
+	if b.now().Before(b.probeAt) {
+		return false, ""
+	}
+	b.state = circuitHalfOpen
+	return true, "is half-open and probing the destination"
+}
+
+// record records the result of the allowed call. It returns the event if the state changes.
+func (b *circuitBreaker) record(err error) string {
+	if b == nil {
+		return ""
+	}
+	if err == nil {
+		b.failures = 0
+		if b.state == circuitClosed {
+			return ""
+		}
+		b.state = circuitClosed
+		b.backoff = 0
+		return "is closed"
+	}
+
+	b.failures++
+	switch {
+	case b.state == circuitHalfOpen:
+		b.backoff *= 2
+		if b.backoff > maxCircuitCooldown {
+			b.backoff = maxCircuitCooldown
+		}
+	case b.failures >= b.threshold:
+		b.backoff = b.cooldown
+	default:
+		return ""
+	}
+	b.state = circuitOpen
+	b.probeAt = b.now().Add(b.backoff)
+	return fmt.Sprintf("is open after %d consecutive failures, and the next probe is in %s", b.failures, b.backoff)
+}
+
+// isOpen reports whether calls are stopped now.
+func (b *circuitBreaker) isOpen() bool {
+	return b != nil && b.state == circuitOpen
+}
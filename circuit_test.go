@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_circuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	errPut := errors.New("put error")
+
+	steps := []struct {
+		name    string
+		elapsed time.Duration
+		err     error
+		allowed bool
+		state   string
+	}{
+		{name: "Keep closed after a failure", err: errPut, allowed: true, state: circuitClosed},
+		{name: "Open after consecutive failures", err: errPut, allowed: true, state: circuitOpen},
+		{name: "Stop calls in the cooldown", elapsed: 59 * time.Second, allowed: false, state: circuitOpen},
+		{name: "Open again after the failed probe", elapsed: time.Second, err: errPut, allowed: true, state: circuitOpen},
+		{name: "Stop calls in the doubled cooldown", elapsed: time.Minute, allowed: false, state: circuitOpen},
+		{name: "Close after the succeeded probe", elapsed: time.Minute, allowed: true, state: circuitClosed},
+		{name: "Keep closed after a failure again", err: errPut, allowed: true, state: circuitClosed},
+	}
+	for _, step := range steps {
+		now = now.Add(step.elapsed)
+		allowed, _ := b.allow()
+		if allowed {
+			b.record(step.err)
+		}
+		if allowed != step.allowed || b.state != step.state {
+			t.Errorf("%s: allow() = %v and state = %s, want %v and %s", step.name, allowed, b.state, step.allowed, step.state)
+		}
+	}
+}
+
+func Test_circuitBreaker_maxCooldown(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(1, 4*time.Minute)
+	b.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		now = b.probeAt
+		b.allow()
+		b.record(errors.New("put error"))
+	}
+	if b.backoff != maxCircuitCooldown {
+		t.Errorf("circuitBreaker backoff = %s, want %s", b.backoff, maxCircuitCooldown)
+	}
+}
+
+func Test_uploader_upload_circuit(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"InvalidParameterException","message":"invalid"}`))
+	}))
+	defer server.Close()
+
+	client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	u := &uploader{
+		client:  client,
+		dst:     destination{logGroup: "group", logStream: "stream"},
+		limits:  defaultBatchLimits,
+		circuit: newCircuitBreaker(2, time.Hour),
+	}
+	for i := 0; i < 5; i++ {
+		if err := u.upload(context.Background(), newTestEvents(1, "[INFO] Start Server", time.Second)); err != nil {
+			t.Fatalf("uploader.upload() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("uploader.upload() called PutLogEvents %d times, want 2", calls)
+	}
+	if u.failed != 5 {
+		t.Errorf("uploader.upload() failed %d log events, want 5", u.failed)
+	}
+	if !u.circuit.isOpen() {
+		t.Errorf("uploader.upload() left the circuit breaker %s, want open", u.circuit.state)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI escape sequences to color text on the terminal.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	// ansiClearLine moves the cursor to the start of the line and clears it, to redraw the progress
+	ansiClearLine = "\r\x1b[K"
+)
+
+// uploadStatus is the result of uploading log events to a destination.
+type uploadStatus string
+
+const (
+	// statusSucceeded means all log events were put.
+	statusSucceeded uploadStatus = "succeeded"
+	// statusPartial means some log events were rejected or not put.
+	statusPartial uploadStatus = "partial"
+	// statusFailed means uploading to the destination was stopped by an error.
+	statusFailed uploadStatus = "failed"
+)
+
+// isTerminal reports whether f is the terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// palette colors text written to the terminal. The zero palette writes text as it is.
+type palette struct {
+	enabled bool
+}
+
+// newPalette returns the palette for f. Text is colored only on the terminal, and never with --no-color,
+// NO_COLOR environment variable (https://no-color.org) or TERM=dumb.
+func newPalette(f *os.File, noColor bool) palette {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return palette{}
+	}
+	return palette{enabled: isTerminal(f)}
+}
+
+// colors is the palette of stdout. It is replaced after --no-color is parsed.
+var colors = newPalette(os.Stdout, false)
+
+// paint colors s with the escape sequence. Empty code or s is written as it is, not to leave bare resets.
+func (p palette) paint(code, s string) string {
+	if !p.enabled || code == "" || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// status colors the line by the status. Lines without the status, such as ones of --dry-run, are not colored.
+func (p palette) status(status uploadStatus, line string) string {
+	switch status {
+	case statusSucceeded:
+		return p.paint(ansiGreen, line)
+	case statusPartial:
+		return p.paint(ansiYellow, line)
+	case statusFailed:
+		return p.paint(ansiRed, line)
+	}
+	return line
+}
+
+// statusOf returns the result of uploading log events by the uploader.
+func statusOf(u *uploader) uploadStatus {
+	switch {
+	case u.err != nil:
+		return statusFailed
+	case u.rejected > 0 || u.failed > 0:
+		return statusPartial
+	}
+	return statusSucceeded
+}
+
+// formatError formats the error to print. On the terminal, the first line is highlighted, and the following lines,
+// such as errors of each destination, are listed under it.
+func formatError(p palette, err error) string {
+	if !p.enabled {
+		return err.Error()
+	}
+	lines := strings.Split(strings.TrimRight(err.Error(), "\n"), "\n")
+	b := &strings.Builder{}
+	b.WriteString(p.paint(ansiBold+ansiRed, lines[0]))
+	for _, line := range lines[1:] {
+		b.WriteString("\n  " + p.paint(ansiRed, "-") + " " + line)
+	}
+	return b.String()
+}
+
+// batchProgress shows the number of batches put to the destination on the terminal.
+// It is nil if the progress is not shown, and the methods of nil do nothing.
+type batchProgress struct {
+	w     io.Writer
+	dst   destination
+	done  int
+	total int
+}
+
+// newBatchProgress returns the progress written to stderr, or nil if stderr is not the terminal.
+func newBatchProgress(dst destination) *batchProgress {
+	if !isTerminal(os.Stderr) {
+		return nil
+	}
+	return &batchProgress{w: os.Stderr, dst: dst}
+}
+
+// add adds the batches to put.
+func (p *batchProgress) add(batches int) {
+	if p == nil {
+		return
+	}
+	p.total += batches
+	p.draw()
+}
+
+// advance counts the batch put.
+func (p *batchProgress) advance() {
+	if p == nil {
+		return
+	}
+	p.done++
+	p.draw()
+}
+
+// clear removes the progress, so that it does not remain above the summary.
+func (p *batchProgress) clear() {
+	if p == nil {
+		return
+	}
+	fmt.Fprint(p.w, ansiClearLine)
+}
+
+func (p *batchProgress) draw() {
+	fmt.Fprintf(p.w, "%sPutting batches to %s: %d/%d", ansiClearLine, p.dst, p.done, p.total)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_palette_status(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		status  uploadStatus
+		want    string
+	}{
+		{name: "Color succeeded line", enabled: true, status: statusSucceeded, want: "\x1b[32mPut\x1b[0m"},
+		{name: "Color partial line", enabled: true, status: statusPartial, want: "\x1b[33mPut\x1b[0m"},
+		{name: "Color failed line", enabled: true, status: statusFailed, want: "\x1b[31mPut\x1b[0m"},
+		{name: "Keep line without status", enabled: true, want: "Put"},
+		{name: "Keep line without colors", enabled: false, status: statusFailed, want: "Put"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (palette{enabled: tt.enabled}).status(tt.status, "Put"); got != tt.want {
+				t.Errorf("palette.status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_statusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		u    *uploader
+		want uploadStatus
+	}{
+		{name: "All log events are put", u: &uploader{}, want: statusSucceeded},
+		{name: "Some log events are rejected", u: &uploader{rejected: 1}, want: statusPartial},
+		{name: "Some log events are not put", u: &uploader{failed: 1}, want: statusPartial},
+		{name: "Uploading is stopped", u: &uploader{failed: 1, err: errors.New("upload error")}, want: statusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusOf(tt.u); got != tt.want {
+				t.Errorf("statusOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatError(t *testing.T) {
+	err := errors.New("upload error: failed to put logs to 2 of 3 destinations\n/app/api: denied\n/app/worker: throttled")
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{
+			name:    "Format error on terminal",
+			enabled: true,
+			want: "\x1b[1m\x1b[31mupload error: failed to put logs to 2 of 3 destinations\x1b[0m\n" +
+				"  \x1b[31m-\x1b[0m /app/api: denied\n" +
+				"  \x1b[31m-\x1b[0m /app/worker: throttled",
+		},
+		{
+			name:    "Keep error without colors",
+			enabled: false,
+			want:    err.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatError(palette{enabled: tt.enabled}, err); got != tt.want {
+				t.Errorf("formatError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
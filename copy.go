@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func runCopy(args []string) error {
+	params := parameters{}
+	q := eventQuery{}
+	to := destination{}
+	since, until := "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Copy log events to another log stream with the original timestamps.", "--from-group <LOG GROUP NAME> --to-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&q.logGroup, "from-group", "", "The name of the log group where log events are copied from. It is required.")
+	flags.StringVar(&q.logStream, "from-stream", "", "The name of the log stream where log events are copied from. If you do not use this parameters, it copies log events in all log streams.")
+	flags.StringVar(&to.logGroup, "to-group", "", "The name of the log group where log events are copied to. It is required.")
+	flags.StringVar(&to.logStream, "to-stream", "", "The name of the log stream where log events are copied to. If you do not use this parameters, it is the same as --from-stream.")
+	flags.StringVar(&to.region, "to-region", "", "The name of the region where log events are copied to. If you do not use this parameters, it is the same as --region.")
+	flags.StringVar(&to.roleARN, "to-role-arn", "", "The ARN of the IAM role assumed to put log events.")
+	addTimeRangeFlags(flags, &since, &until)
+	flags.Parse(args[1:])
+
+	if q.logGroup == "" {
+		return errors.New("argument error: --from-group is required")
+	}
+	if to.logGroup == "" {
+		return errors.New("argument error: --to-group is required")
+	}
+	if to.logStream == "" {
+		to.logStream = q.logStream
+	}
+	if to.logStream == "" {
+		return errors.New("argument error: --to-stream is required when --from-stream is not given")
+	}
+	var err error
+	q.start, q.end, err = parseTimeRange(since, until)
+	if err != nil {
+		return err
+	}
+
+	fromClient, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+	toClient, err := newClient(params, to)
+	if err != nil {
+		return err
+	}
+
+	created, err := ensureLogStream(toClient, to.logGroup, to.logStream)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Printf("Created log stream %s\n", to)
+	}
+	u, err := newUploader(toClient, to, "", defaultBatchLimits, nil)
+	if err != nil {
+		return err
+	}
+
+	// Each page is put as soon as it is read, so that the log stream is not kept in memory
+	err = fetchLogEventPages(fromClient, q, func(page []storedEvent) error {
+		if len(page) == 0 {
+			return nil
+		}
+		events := make([]types.InputLogEvent, len(page))
+		for i, event := range page {
+			events[i] = types.InputLogEvent{
+				Message:   aws.String(event.Message),
+				Timestamp: aws.Int64(event.Timestamp),
+			}
+		}
+		// Log events in several log streams are not always sorted, but PutLogEvents requires sorted ones
+		sort.SliceStable(events, func(i, j int) bool {
+			return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+		})
+		return u.put(context.Background(), events)
+	})
+	copied := u.ingested.events - u.rejected
+	if err != nil {
+		return fmt.Errorf("copied %d log events to %s: %w", copied, to, err)
+	}
+	fmt.Printf("Copied %d log events to %s\n", copied, to)
+	if u.rejected > 0 {
+		fmt.Printf("Rejected %d log events by %s\n", u.rejected, to)
+		return fmt.Errorf("copy error: %d of %d log events are rejected by %s", u.rejected, u.ingested.events, to)
+	}
+	return nil
+}
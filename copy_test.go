@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_runCopy(t *testing.T) {
+	now := time.Now()
+	fake := fakelogs.New()
+	// The small pages make the log events span several pages
+	fake.PageSize = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	fromGroup, fromStreams, err := setUpLogGroupAndStreams(cli, 3)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	toGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	base := now.Add(-time.Hour).Truncate(time.Second)
+	messages, err := setUpStoredEvents(cli, fromGroup, fromStreams[:2], 5, base)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	// The old log event is stored while the fake thinks it is 20 days ago, so that the copy of it is rejected as too old
+	old := now.Add(-20 * 24 * time.Hour)
+	fake.Now = func() time.Time { return old }
+	oldEvent := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(fromGroup),
+		LogStreamName: aws.String(fromStreams[2]),
+		LogEvents:     []types.InputLogEvent{{Timestamp: aws.Int64(toMillis(old)), Message: aws.String("[INFO] Old event")}},
+	}
+	if _, err := cli.PutLogEvents(context.Background(), oldEvent); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	fake.Now = time.Now
+	if _, err := setUpStoredEvents(cli, fromGroup, fromStreams[2:], 1, base); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	newMessage := fmt.Sprintf("[INFO] Event 0 in %s", fromStreams[2])
+
+	tests := []struct {
+		name     string
+		args     []string
+		toStream string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "Copy log stream over pages to missing log stream",
+			args:     []string{"--from-group", fromGroup, "--from-stream", fromStreams[0], "--to-group", toGroup},
+			toStream: fromStreams[0],
+			want:     []string{messages[0], messages[2], messages[4]},
+		},
+		{
+			name:     "Copy log stream to existing log stream",
+			args:     []string{"--from-group", fromGroup, "--from-stream", fromStreams[1], "--to-group", toGroup, "--to-stream", fromStreams[0]},
+			toStream: fromStreams[0],
+			want:     []string{messages[0], messages[1], messages[2], messages[3], messages[4]},
+		},
+		{
+			name:     "Copy log events in log group within time range",
+			args:     []string{"--from-group", fromGroup, "--to-group", toGroup, "--to-stream", "window", "--since", base.Add(90 * time.Second).Format(time.RFC3339), "--until", base.Add(210 * time.Second).Format(time.RFC3339)},
+			toStream: "window",
+			want:     []string{messages[2], messages[3]},
+		},
+		{
+			name:     "Copy rejected log events",
+			args:     []string{"--from-group", fromGroup, "--from-stream", fromStreams[2], "--to-group", toGroup, "--to-stream", "rejected"},
+			toStream: "rejected",
+			want:     []string{newMessage},
+			wantErr:  true,
+		},
+		{
+			name:    "Copy log events to unknown log group",
+			args:    []string{"--from-group", fromGroup, "--from-stream", fromStreams[0], "--to-group", "unknown"},
+			wantErr: true,
+		},
+		{name: "Copy log events without source log group", args: []string{"--to-group", toGroup, "--to-stream", "copy"}, wantErr: true},
+		{name: "Copy log events without target log group", args: []string{"--from-group", fromGroup, "--from-stream", fromStreams[0]}, wantErr: true},
+		{name: "Copy log events in log group without target log stream", args: []string{"--from-group", fromGroup, "--to-group", toGroup}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"copy", "--endpoint-url", server.URL, "--region", "us-east-1"}, tt.args...)
+			if err := runCopy(args); (err != nil) != tt.wantErr {
+				t.Errorf("runCopy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.toStream == "" {
+				return
+			}
+			got := []string{}
+			err := fetchLogEvents(cli, eventQuery{logGroup: toGroup, logStream: tt.toStream}, func(event storedEvent) error {
+				got = append(got, event.Message)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runCopy() copied %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
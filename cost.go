@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// ingestionPricePerGB is the price in USD to ingest 1 GB of logs into the Standard log class in each region.
+// It can be out of date, so --price-per-gb overrides it.
+var ingestionPricePerGB = map[string]float64{
+	"us-east-1":      0.50,
+	"us-east-2":      0.50,
+	"us-west-1":      0.50,
+	"us-west-2":      0.50,
+	"eu-west-1":      0.50,
+	"eu-central-1":   0.63,
+	"ap-northeast-1": 0.76,
+	"ap-southeast-1": 0.70,
+	"ap-southeast-2": 0.70,
+}
+
+// defaultIngestionPricePerGB is used for regions which are not in ingestionPricePerGB.
+const defaultIngestionPricePerGB = 0.50
+
+// ingestedBytes returns the size of log events billed by CloudWatch Logs.
+func ingestedBytes(events []types.InputLogEvent) int {
+	size := 0
+	for _, event := range events {
+		size += eventSize(event)
+	}
+	return size
+}
+
+// estimateIngestionCost returns the cost in USD to ingest the size of logs into the region.
+// If pricePerGB is positive, it is used instead of the price of the region.
+func estimateIngestionCost(size int, region string, pricePerGB float64) float64 {
+	if pricePerGB <= 0 {
+		var ok bool
+		if pricePerGB, ok = ingestionPricePerGB[region]; !ok {
+			pricePerGB = defaultIngestionPricePerGB
+		}
+	}
+	return float64(size) / (1 << 30) * pricePerGB
+}
+
+// ingestion is the number and the size of log events ingested into a destination.
+type ingestion struct {
+	dst    destination
+	region string
+	events int
+	bytes  int
+	// levels counts log events by the detected level
+	levels map[string]int
+	// oldest and newest are the timestamps of the log events
+	oldest, newest int64
+	// batches are the batches which log events would be put in by --dry-run
+	batches []batchPlan
+	// status is the result of uploading log events, and rejected and failed count log events not stored.
+	// They are not set by --dry-run.
+	status    uploadStatus
+	rejected  int
+	failed    int
+	ambiguous int
+}
+
+// writeIngestionSummary writes the size and the estimated cost of log events ingested into each destination.
+func writeIngestionSummary(w io.Writer, verb string, ingestions []ingestion, pricePerGB float64) {
+	total := 0.0
+	for _, in := range ingestions {
+		cost := estimateIngestionCost(in.bytes, in.region, pricePerGB)
+		total += cost
+		line := fmt.Sprintf("%s %d log events (%d bytes) to %s in %s: $%.6f", verb, in.events, in.bytes, in.dst, in.region, cost)
+		if in.rejected > 0 || in.failed > 0 {
+			line += fmt.Sprintf(" (%d rejected, %d failed)", in.rejected, in.failed)
+		}
+		fmt.Fprintln(w, colors.status(in.status, line))
+	}
+	fmt.Fprintf(w, "Estimated ingestion cost: $%.6f\n", total)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_estimateIngestionCost(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		region     string
+		pricePerGB float64
+		want       float64
+	}{
+		{name: "Estimate cost in the region", size: 1 << 30, region: "ap-northeast-1", want: 0.76},
+		{name: "Estimate cost in an unknown region", size: 1 << 30, region: "xx-east-1", want: defaultIngestionPricePerGB},
+		{name: "Estimate cost with the given price", size: 1 << 29, region: "us-east-1", pricePerGB: 1, want: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateIngestionCost(tt.size, tt.region, tt.pricePerGB); got != tt.want {
+				t.Errorf("estimateIngestionCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeIngestionSummary(t *testing.T) {
+	ingestions := []ingestion{
+		{dst: destination{logGroup: "/test/group", logStream: "test-stream"}, region: "us-east-1", events: 2, bytes: 1 << 30},
+		{dst: destination{logGroup: "/test/group2"}, region: "us-east-1", events: 2, bytes: 1 << 30, status: statusPartial, rejected: 1, failed: 2},
+	}
+	want := "Put 2 log events (1073741824 bytes) to /test/group:test-stream in us-east-1: $0.500000\n" +
+		"Put 2 log events (1073741824 bytes) to /test/group2 in us-east-1: $0.500000 (1 rejected, 2 failed)\n" +
+		"Estimated ingestion cost: $1.000000\n"
+
+	buf := &bytes.Buffer{}
+	writeIngestionSummary(buf, "Put", ingestions, 0)
+	if got := buf.String(); got != want {
+		t.Errorf("writeIngestionSummary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// tagsFlag stores tags given as key=value each time the flag is given.
+type tagsFlag map[string]string
+
+func (f tagsFlag) String() string {
+	tags := make([]string, 0, len(f))
+	for k, v := range f {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+func (f tagsFlag) Set(v string) error {
+	kv := strings.SplitN(v, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("tag must be key=value, but got %s", v)
+	}
+	f[kv[0]] = kv[1]
+	return nil
+}
+
+type logGroupOptions struct {
+	retentionInDays int
+	kmsKeyID        string
+	class           string
+	tags            map[string]string
+}
+
+// parseLogGroupClass parses the class of log groups case-insensitively.
+func parseLogGroupClass(class string) (types.LogGroupClass, error) {
+	for _, c := range []types.LogGroupClass{types.LogGroupClassStandard, types.LogGroupClassInfrequentAccess} {
+		if strings.EqualFold(class, string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("argument error: --class must be %s or %s, but got %s", types.LogGroupClassStandard, types.LogGroupClassInfrequentAccess, class)
+}
+
+func createLogGroup(client *cloudwatchlogs.Client, logGroup string, opts logGroupOptions) error {
+	in := &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(logGroup),
+	}
+	if opts.kmsKeyID != "" {
+		in.KmsKeyId = aws.String(opts.kmsKeyID)
+	}
+	if len(opts.tags) > 0 {
+		in.Tags = opts.tags
+	}
+	if opts.class != "" {
+		class, err := parseLogGroupClass(opts.class)
+		if err != nil {
+			return err
+		}
+		in.LogGroupClass = class
+	}
+	if _, err := client.CreateLogGroup(context.Background(), in); err != nil {
+		return err
+	}
+
+	if opts.retentionInDays > 0 {
+		return putRetentionPolicy(client, logGroup, opts.retentionInDays)
+	}
+	return nil
+}
+
+func createLogStream(client *cloudwatchlogs.Client, logGroup, logStream string) error {
+	in := &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+	}
+	_, err := client.CreateLogStream(context.Background(), in)
+	return err
+}
+
+func runCreateGroup(args []string) error {
+	params := parameters{}
+	opts := logGroupOptions{tags: tagsFlag{}}
+
+	flags := newSubcommandFlagSet(args[0], "Create a log group.", "<LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.IntVar(&opts.retentionInDays, "retention", 0, "The number of days to retain log events. If you do not use this parameters, log events never expire.")
+	flags.StringVar(&opts.kmsKeyID, "kms-key-id", "", "The ARN of the KMS key to encrypt log events.")
+	flags.StringVar(&opts.class, "class", "", "The class of the log group. STANDARD or INFREQUENT_ACCESS. INFREQUENT_ACCESS is cheaper for archived logs. If you do not use this parameters, it is STANDARD.")
+	flags.Var(tagsFlag(opts.tags), "tag", "The tag of the log group as key=value. Repeat it to add several tags.")
+	names := parseInterspersed(flags, args[1:])
+
+	if len(names) != 1 {
+		return errors.New("argument error: a log group name is required")
+	}
+	if opts.retentionInDays != 0 {
+		if err := validateRetentionDays("--retention", opts.retentionInDays); err != nil {
+			return err
+		}
+	}
+	if opts.class != "" {
+		if _, err := parseLogGroupClass(opts.class); err != nil {
+			return err
+		}
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if err := createLogGroup(client, names[0], opts); err != nil {
+		return err
+	}
+	fmt.Printf("Created log group %s\n", names[0])
+	return nil
+}
+
+func runCreateStream(args []string) error {
+	params := parameters{}
+	logGroup := ""
+
+	flags := newSubcommandFlagSet(args[0], "Create a log stream in the log group.", "--log-group <LOG GROUP NAME> <LOG STREAM NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group where the log stream is created. It is required.")
+	names := parseInterspersed(flags, args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if len(names) != 1 {
+		return errors.New("argument error: a log stream name is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if err := createLogStream(client, logGroup, names[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Created log stream %s in %s\n", names[0], logGroup)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseLogGroupClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		class   string
+		want    types.LogGroupClass
+		wantErr bool
+	}{
+		{
+			name:    "Parse standard class",
+			class:   "STANDARD",
+			want:    types.LogGroupClassStandard,
+			wantErr: false,
+		},
+		{
+			name:    "Parse infrequent access class in lower case",
+			class:   "infrequent_access",
+			want:    types.LogGroupClassInfrequentAccess,
+			wantErr: false,
+		},
+		{
+			name:    "Parse unknown class",
+			class:   "ARCHIVE",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogGroupClass(tt.class)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLogGroupClass() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseLogGroupClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// credentialsCacheMargin is the time before the expiration when cached credentials are not used anymore,
+// so that they do not expire while uploading.
+const credentialsCacheMargin = 5 * time.Minute
+
+// cachedCredentials is the temporary credentials cached in ~/.aws/awsputlogs/cache, in the same format as the AWS CLI.
+type cachedCredentials struct {
+	Credentials struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		SessionToken    string    `json:"SessionToken"`
+		Expiration      time.Time `json:"Expiration"`
+	} `json:"Credentials"`
+}
+
+// credentialsCacheDir returns the directory where temporary credentials are cached, next to the cache of the AWS CLI.
+func credentialsCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".aws", "awsputlogs", "cache"), nil
+}
+
+// credentialsCacheKey returns the name of the cache file of credentials identified by the parts, such as the profile and the role.
+func credentialsCacheKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// fileCredentialsProvider caches temporary credentials retrieved by the provider in the file, so that repeated runs,
+// such as in backfill scripts, do not call STS or SSO each time. Credentials which do not expire are not cached.
+type fileCredentialsProvider struct {
+	provider aws.CredentialsProvider
+	path     string
+	now      func() time.Time
+}
+
+// newFileCredentialsCache wraps the provider with the cache in the file of the key. It returns the provider as it is
+// if the cache directory is not available.
+func newFileCredentialsCache(provider aws.CredentialsProvider, key string) aws.CredentialsProvider {
+	dir, err := credentialsCacheDir()
+	if err != nil {
+		return provider
+	}
+	return aws.NewCredentialsCache(&fileCredentialsProvider{provider: provider, path: filepath.Join(dir, key), now: time.Now})
+}
+
+func (p *fileCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if creds, ok := p.load(); ok {
+		return creds, nil
+	}
+	creds, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if creds.CanExpire {
+		// Failing to cache credentials only makes the next run call STS again
+		p.save(creds)
+	}
+	return creds, nil
+}
+
+// load returns the cached credentials. It returns false if they are not cached or expire soon.
+func (p *fileCredentialsProvider) load() (aws.Credentials, bool) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return aws.Credentials{}, false
+	}
+	cached := cachedCredentials{}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return aws.Credentials{}, false
+	}
+	c := cached.Credentials
+	if c.AccessKeyID == "" || !c.Expiration.After(p.now().Add(credentialsCacheMargin)) {
+		return aws.Credentials{}, false
+	}
+	return aws.Credentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+		Source:          "awsputlogs cache",
+		CanExpire:       true,
+		Expires:         c.Expiration.Add(-credentialsCacheMargin),
+	}, true
+}
+
+// save writes the credentials to a temporary file and renames it, so that runs in parallel do not read a partial file.
+func (p *fileCredentialsProvider) save(creds aws.Credentials) error {
+	cached := cachedCredentials{}
+	cached.Credentials.AccessKeyID = creds.AccessKeyID
+	cached.Credentials.SecretAccessKey = creds.SecretAccessKey
+	cached.Credentials.SessionToken = creds.SessionToken
+	cached.Credentials.Expiration = creds.Expires
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(p.path), filepath.Base(p.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p.path)
+}
+
+// profileCredentialsCacheKey returns the cache key of credentials of the profile loaded in cfg. It returns false if the
+// profile does not assume a role or use SSO, or if credentials are given by the environment variables, which the key
+// can not tell apart.
+func profileCredentialsCacheKey(cfg aws.Config, params parameters) (string, bool) {
+	if params.noCredentialCache || params.noSignRequest || usesEmulator(params) {
+		return "", false
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" || os.Getenv("AWS_ROLE_ARN") != "" {
+		return "", false
+	}
+	for _, source := range cfg.ConfigSources {
+		shared, ok := source.(config.SharedConfig)
+		if !ok {
+			continue
+		}
+		if shared.RoleARN == "" && shared.SSOAccountID == "" {
+			return "", false
+		}
+		return credentialsCacheKey("profile", shared.Profile, shared.RoleARN, shared.SSOAccountID, shared.SSORoleName), true
+	}
+	return "", false
+}
+
+// roleCredentialsCacheKey returns the cache key of credentials of the role assumed by --role-arn with the credentials of
+// the profile or the environment variables.
+func roleCredentialsCacheKey(params parameters, roleARN string) (string, bool) {
+	if params.noCredentialCache || params.noSignRequest || usesEmulator(params) {
+		return "", false
+	}
+	profile := params.profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	return credentialsCacheKey("role", profile, os.Getenv("AWS_ACCESS_KEY_ID"), roleARN), true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// countingProvider returns the credentials and counts the calls.
+type countingProvider struct {
+	creds aws.Credentials
+	calls int
+}
+
+func (p *countingProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.calls++
+	return p.creds, nil
+}
+
+func Test_fileCredentialsProvider(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		creds     aws.Credentials
+		wantCalls int
+	}{
+		{
+			name:      "Cache temporary credentials",
+			creds:     aws.Credentials{AccessKeyID: "ASIA1", SecretAccessKey: "SECRET", SessionToken: "SESSION", CanExpire: true, Expires: now.Add(time.Hour)},
+			wantCalls: 1,
+		},
+		{
+			name:      "Do not use credentials expiring soon",
+			creds:     aws.Credentials{AccessKeyID: "ASIA1", SecretAccessKey: "SECRET", SessionToken: "SESSION", CanExpire: true, Expires: now.Add(time.Minute)},
+			wantCalls: 2,
+		},
+		{
+			name:      "Do not cache credentials without expiration",
+			creds:     aws.Credentials{AccessKeyID: "AKIA1", SecretAccessKey: "SECRET"},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache", credentialsCacheKey("role", "dev", "arn:aws:iam::123456789012:role/logs"))
+			provider := &countingProvider{creds: tt.creds}
+			for i := 0; i < 2; i++ {
+				// Each run has its own provider reading the file
+				p := &fileCredentialsProvider{provider: provider, path: path, now: func() time.Time { return now }}
+				creds, err := p.Retrieve(context.Background())
+				if err != nil {
+					t.Fatalf("fileCredentialsProvider.Retrieve() error = %v", err)
+				}
+				if creds.AccessKeyID != tt.creds.AccessKeyID || creds.SessionToken != tt.creds.SessionToken {
+					t.Errorf("fileCredentialsProvider.Retrieve() = %v, want %v", creds.AccessKeyID, tt.creds.AccessKeyID)
+				}
+			}
+			if provider.calls != tt.wantCalls {
+				t.Errorf("fileCredentialsProvider.Retrieve() called the provider %d times, want %d", provider.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_profileCredentialsCacheKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		params parameters
+		env    map[string]string
+		wantOK bool
+	}{
+		{name: "Cache credentials of the profile assuming the role", params: parameters{profile: "logs"}, wantOK: true},
+		{name: "Cache credentials of the SSO profile", params: parameters{profile: "sso"}, wantOK: true},
+		{name: "Do not cache credentials of the profile with keys", params: parameters{profile: "dev"}},
+		{name: "Do not cache credentials with --no-credential-cache", params: parameters{profile: "logs", noCredentialCache: true}},
+		{name: "Do not cache credentials with the environment variables", params: parameters{profile: "logs"}, env: map[string]string{"AWS_ACCESS_KEY_ID": "AKIA1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUpAWSConfig(t, `[profile dev]
+region = us-east-1
+aws_access_key_id = AKIA1
+aws_secret_access_key = SECRET
+
+[profile logs]
+region = us-east-1
+role_arn = arn:aws:iam::123456789012:role/logs
+source_profile = dev
+
+[profile sso]
+region = us-east-1
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_account_id = 123456789012
+sso_role_name = logs
+`)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := loadConfig(parameters{profile: tt.params.profile})
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if _, ok := profileCredentialsCacheKey(cfg, tt.params); ok != tt.wantOK {
+				t.Errorf("profileCredentialsCacheKey() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// dashboardInterval is the interval to redraw the dashboard of --tui.
+const dashboardInterval = time.Second
+
+// dashboardRow is the counters of a destination shown on the dashboard.
+// The methods do nothing on nil, so the dashboard is optional.
+type dashboardRow struct {
+	dst     destination
+	metrics shipperMetrics
+	mu      sync.Mutex
+	lastErr string
+	// uploaded and bytesSent are the counters at the previous redraw, to calculate the rates
+	uploaded, bytesSent int64
+}
+
+func (r *dashboardRow) addPut(batch []types.InputLogEvent, res *types.RejectedLogEventsInfo, attempts int) {
+	if r != nil {
+		r.metrics.addPut(batch, res, attempts)
+	}
+}
+
+func (r *dashboardRow) setError(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err.Error()
+}
+
+// dashboard shows live counters of each destination with --tui, redrawn in place on the terminal.
+type dashboard struct {
+	w       io.Writer
+	shared  *shipperMetrics
+	rows    []*dashboardRow
+	started time.Time
+	// lines is the number of lines drawn last time, to move the cursor back over them
+	lines int
+}
+
+func newDashboard(w io.Writer, shared *shipperMetrics, now time.Time) *dashboard {
+	return &dashboard{w: w, shared: shared, started: now}
+}
+
+// addRow adds the row of the destination.
+func (d *dashboard) addRow(dst destination) *dashboardRow {
+	r := &dashboardRow{dst: dst}
+	d.rows = append(d.rows, r)
+	return r
+}
+
+// formatRate formats the number per second like 1.5k, so that columns stay narrow.
+func formatRate(n float64) string {
+	switch {
+	case n >= 1<<20:
+		return strconv.FormatFloat(n/(1<<20), 'f', 1, 64) + "m"
+	case n >= 1<<10:
+		return strconv.FormatFloat(n/(1<<10), 'f', 1, 64) + "k"
+	}
+	return strconv.FormatFloat(n, 'f', 1, 64)
+}
+
+// render writes the dashboard. The rates are the averages since the previous render, elapsed ago.
+func (d *dashboard) render(w io.Writer, now time.Time, elapsed time.Duration) {
+	fmt.Fprintf(w, "awsputlogs  received %d  queue %d  dropped %d  circuit opened %d  uptime %s\n",
+		d.shared.received.Load(), d.shared.queueDepth.Load(), d.shared.dropped.Load(), d.shared.circuitOpened.Load(),
+		now.Sub(d.started).Truncate(time.Second))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DESTINATION\tEVENTS/S\tBYTES/S\tUPLOADED\tREJECTED\tRETRIES\tLAST ERROR")
+	for _, r := range d.rows {
+		uploaded, bytesSent := r.metrics.uploaded.Load(), r.metrics.bytesSent.Load()
+		eventRate, byteRate := 0.0, 0.0
+		if elapsed > 0 {
+			eventRate = float64(uploaded-r.uploaded) / elapsed.Seconds()
+			byteRate = float64(bytesSent-r.bytesSent) / elapsed.Seconds()
+		}
+		r.uploaded, r.bytesSent = uploaded, bytesSent
+
+		r.mu.Lock()
+		lastErr := r.lastErr
+		r.mu.Unlock()
+		if lastErr == "" {
+			lastErr = "-"
+		}
+		// The error must fit in a line not to break redrawing in place
+		lastErr = strings.ReplaceAll(lastErr, "\n", " ")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			r.dst, formatRate(eventRate), formatRate(byteRate), uploaded, r.metrics.rejected.Load(), r.metrics.retried.Load(), lastErr)
+	}
+	tw.Flush()
+}
+
+// draw redraws the dashboard over the previous one.
+func (d *dashboard) draw(now time.Time, elapsed time.Duration) {
+	buf := &bytes.Buffer{}
+	d.render(buf, now, elapsed)
+	if d.lines > 0 {
+		fmt.Fprintf(d.w, "\x1b[%dA", d.lines)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for _, line := range lines {
+		fmt.Fprint(d.w, ansiClearLine+line+"\n")
+	}
+	d.lines = len(lines)
+}
+
+// start redraws the dashboard in the background. The returned function stops it after drawing the final counters.
+// It does nothing on nil.
+func (d *dashboard) start(ctx context.Context) func() {
+	if d == nil {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		d.run(ctx)
+		close(stopped)
+	}()
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// run redraws the dashboard every dashboardInterval until ctx is done, then draws the final counters.
+func (d *dashboard) run(ctx context.Context) {
+	ticker := time.NewTicker(dashboardInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	d.draw(last, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			now := time.Now()
+			d.draw(now, now.Sub(last))
+			return
+		case now := <-ticker.C:
+			d.draw(now, now.Sub(last))
+			last = now
+		}
+	}
+}
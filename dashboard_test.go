@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_dashboard_render(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	shared := &shipperMetrics{}
+	shared.addReceived(30)
+	shared.setQueueDepth(5)
+	d := newDashboard(&bytes.Buffer{}, shared, now)
+	api := d.addRow(destination{logGroup: "/app/api", logStream: "api"})
+	worker := d.addRow(destination{logGroup: "/app/worker", logStream: "worker"})
+
+	api.addPut(newTestEvents(20, "[INFO] Start Server", time.Second), nil, 2)
+	worker.setError(errors.New("upload error: access denied\nfor the log group"))
+
+	buf := &bytes.Buffer{}
+	d.render(buf, now.Add(90*time.Second), 2*time.Second)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"awsputlogs  received 30  queue 5  dropped 0  circuit opened 0  uptime 1m30s",
+		"DESTINATION         EVENTS/S  BYTES/S  UPLOADED  REJECTED  RETRIES  LAST ERROR",
+		"/app/api:api        10.0      450.0    20        0         1        -",
+		"/app/worker:worker  0.0       0.0      0         0         0        upload error: access denied for the log group",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("dashboard.render() = %q, want %d lines", buf.String(), len(want))
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("dashboard.render() line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+
+	// The rates are calculated from the counters since the previous render
+	buf.Reset()
+	d.render(buf, now.Add(91*time.Second), time.Second)
+	if fields := strings.Fields(strings.Split(buf.String(), "\n")[2]); fields[1] != "0.0" {
+		t.Errorf("dashboard.render() = %q, want the rate of the api to be 0", buf.String())
+	}
+}
+
+func Test_formatRate(t *testing.T) {
+	tests := []struct {
+		n    float64
+		want string
+	}{
+		{n: 12.34, want: "12.3"},
+		{n: 1536, want: "1.5k"},
+		{n: 3 << 20, want: "3.0m"},
+	}
+	for _, tt := range tests {
+		if got := formatRate(tt.n); got != tt.want {
+			t.Errorf("formatRate(%v) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// loadDataProtectionPolicy reads the data protection policy document from the file. The document must be a JSON object
+// with statements, which CloudWatch Logs requires.
+func loadDataProtectionPolicy(path string) (string, error) {
+	policy, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !json.Valid(policy) {
+		return "", fmt.Errorf("policy error: %s is not valid JSON", path)
+	}
+	var document struct {
+		Statement []interface{}
+	}
+	if err := json.Unmarshal(policy, &document); err != nil {
+		return "", fmt.Errorf("policy error: %s is not a JSON object", path)
+	}
+	if len(document.Statement) == 0 {
+		return "", fmt.Errorf("policy error: %s has no Statement", path)
+	}
+	return string(policy), nil
+}
+
+func runDataProtection(args []string) error {
+	return runActions(args, "Manage the data protection policy of the log group.", []subcommand{
+		{name: "put", description: "Create or update the data protection policy.", run: runDataProtectionPut},
+		{name: "get", description: "Show the data protection policy.", run: runDataProtectionGet},
+		{name: "delete", description: "Delete the data protection policy.", run: runDataProtectionDelete},
+	})
+}
+
+func runDataProtectionPut(args []string) error {
+	params := parameters{}
+	logGroup, policyFile := "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Create or update the data protection policy.", "--log-group <LOG GROUP NAME> --policy-file <FILE PATH> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name or the ARN of the log group. It is required.")
+	flags.StringVar(&policyFile, "policy-file", "", "The path of JSON file that includes the data protection policy. It is required.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if policyFile == "" {
+		return errors.New("argument error: --policy-file is required")
+	}
+
+	policy, err := loadDataProtectionPolicy(policyFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.PutDataProtectionPolicyInput{
+		LogGroupIdentifier: aws.String(logGroup),
+		PolicyDocument:     aws.String(policy),
+	}
+	if _, err := client.PutDataProtectionPolicy(context.Background(), in); err != nil {
+		return err
+	}
+	fmt.Printf("Put data protection policy to %s\n", logGroup)
+	return nil
+}
+
+func runDataProtectionGet(args []string) error {
+	params := parameters{}
+	logGroup := ""
+
+	flags := newSubcommandFlagSet(args[0], "Show the data protection policy.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name or the ARN of the log group. It is required.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.GetDataProtectionPolicyInput{
+		LogGroupIdentifier: aws.String(logGroup),
+	}
+	out, err := client.GetDataProtectionPolicy(context.Background(), in)
+	if err != nil {
+		return err
+	}
+	if aws.ToString(out.PolicyDocument) == "" {
+		return fmt.Errorf("no policy error: data protection policy is not found in %s", logGroup)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, []byte(aws.ToString(out.PolicyDocument)), "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(buf.String())
+	return nil
+}
+
+func runDataProtectionDelete(args []string) error {
+	params := parameters{}
+	logGroup := ""
+
+	flags := newSubcommandFlagSet(args[0], "Delete the data protection policy.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name or the ARN of the log group. It is required.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.DeleteDataProtectionPolicyInput{
+		LogGroupIdentifier: aws.String(logGroup),
+	}
+	if _, err := client.DeleteDataProtectionPolicy(context.Background(), in); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted data protection policy of %s\n", logGroup)
+	return nil
+}
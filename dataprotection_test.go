@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+const testDataProtectionPolicy = `{
+  "Name": "data-protection-policy",
+  "Version": "2021-06-01",
+  "Statement": [
+    {
+      "Sid": "audit-policy",
+      "DataIdentifier": ["arn:aws:dataprotection::aws:data-identifier/EmailAddress"],
+      "Operation": {"Audit": {"FindingsDestination": {}}}
+    }
+  ]
+}`
+
+func Test_loadDataProtectionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{name: "Load policy", policy: testDataProtectionPolicy},
+		{name: "Load policy which is not JSON", policy: `Name: data-protection-policy`, wantErr: true},
+		{name: "Load policy which is not JSON object", policy: `["data-protection-policy"]`, wantErr: true},
+		{name: "Load policy without statements", policy: `{"Name": "data-protection-policy", "Version": "2021-06-01", "Statement": []}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.json")
+			if err := ioutil.WriteFile(path, []byte(tt.policy), 0600); err != nil {
+				t.Fatalf("failed to set up: %v", err)
+			}
+			got, err := loadDataProtectionPolicy(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadDataProtectionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.policy {
+				t.Errorf("loadDataProtectionPolicy() = %v, want %v", got, tt.policy)
+			}
+		})
+	}
+
+	t.Run("Load missing policy", func(t *testing.T) {
+		if _, err := loadDataProtectionPolicy(filepath.Join(t.TempDir(), "policy.json")); err == nil {
+			t.Error("loadDataProtectionPolicy() error = nil, want error")
+		}
+	})
+}
+
+func Test_runDataProtection(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	if err := ioutil.WriteFile(policyFile, []byte(testDataProtectionPolicy), 0600); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	client := []string{"--endpoint-url", server.URL, "--region", "us-east-1"}
+
+	// The steps share the log group, so they run in order
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    bool
+		wantPolicy string
+	}{
+		{name: "Put policy without log group", args: []string{"put", "--policy-file", policyFile}, wantErr: true},
+		{name: "Put policy without policy file", args: []string{"put", "--log-group", logGroup}, wantErr: true},
+		{name: "Put policy to unknown log group", args: []string{"put", "--log-group", "unknown", "--policy-file", policyFile}, wantErr: true},
+		{name: "Get policy before it is put", args: []string{"get", "--log-group", logGroup}, wantErr: true},
+		{name: "Put policy", args: []string{"put", "--log-group", logGroup, "--policy-file", policyFile}, wantPolicy: testDataProtectionPolicy},
+		{name: "Get policy", args: []string{"get", "--log-group", logGroup}, wantPolicy: testDataProtectionPolicy},
+		{name: "Get policy without log group", args: []string{"get"}, wantErr: true, wantPolicy: testDataProtectionPolicy},
+		{name: "Delete policy without log group", args: []string{"delete"}, wantErr: true, wantPolicy: testDataProtectionPolicy},
+		{name: "Delete policy", args: []string{"delete", "--log-group", logGroup}},
+		{name: "Delete policy after it is deleted", args: []string{"delete", "--log-group", logGroup}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"data-protection", tt.args[0]}, append(client, tt.args[1:]...)...)
+			if err := runDataProtection(args); (err != nil) != tt.wantErr {
+				t.Errorf("runDataProtection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			out, err := cli.GetDataProtectionPolicy(context.Background(), &cloudwatchlogs.GetDataProtectionPolicyInput{LogGroupIdentifier: aws.String(logGroup)})
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			if got := aws.ToString(out.PolicyDocument); got != tt.wantPolicy {
+				t.Errorf("runDataProtection() policy = %v, want %v", got, tt.wantPolicy)
+			}
+		})
+	}
+}
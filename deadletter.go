@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// exitDeadlineExceeded is the exit code when the run exceeds --deadline, the same as timeout(1).
+const exitDeadlineExceeded = 124
+
+// errDeadlineExceeded means that log events were not put by --deadline.
+var errDeadlineExceeded = errors.New("deadline error: the run exceeded --deadline")
+
+// deadLetter is a line of the dead-letter file, which is a log event not put to the destination by the deadline,
+// or a log event which does not match the schema with the error.
+type deadLetter struct {
+	LogGroup  string `json:"logGroup,omitempty"`
+	LogStream string `json:"logStream,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
+}
+
+// deadLetterFile appends log events which are not uploaded to the file. The methods do nothing on nil.
+type deadLetterFile struct {
+	mu sync.Mutex
+	f  *os.File
+	// err is the first error to write log events
+	err error
+}
+
+// openDeadLetterFile opens the dead-letter file to append log events.
+func openDeadLetterFile(name string) (*deadLetterFile, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter error: %w", err)
+	}
+	return &deadLetterFile{f: f}, nil
+}
+
+// write appends log events for the destination.
+func (d *deadLetterFile) write(dst destination, events []types.InputLogEvent) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, event := range events {
+		d.append(deadLetter{
+			LogGroup:  dst.logGroup,
+			LogStream: dst.logStream,
+			Timestamp: aws.ToInt64(event.Timestamp),
+			Message:   aws.ToString(event.Message),
+		})
+	}
+}
+
+// writeInvalid appends the log event which is not uploaded because of the error.
+func (d *deadLetterFile) writeInvalid(event types.InputLogEvent, err error) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.append(deadLetter{
+		Timestamp: aws.ToInt64(event.Timestamp),
+		Message:   aws.ToString(event.Message),
+		Error:     err.Error(),
+	})
+}
+
+func (d *deadLetterFile) append(record deadLetter) {
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = d.f.Write(append(data, '\n'))
+	}
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("dead-letter error: %w", err)
+	}
+}
+
+// close closes the dead-letter file, and returns the first error to write log events.
+func (d *deadLetterFile) close() error {
+	if d == nil {
+		return nil
+	}
+	if err := d.f.Close(); err != nil && d.err == nil {
+		d.err = fmt.Errorf("dead-letter error: %w", err)
+	}
+	return d.err
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// newSlowServer returns the server which puts the first log events, and blocks the rest until the test ends.
+func newSlowServer(t *testing.T) *httptest.Server {
+	calls := 0
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			<-released
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(released) })
+	return server
+}
+
+func Test_uploader_put_deadline(t *testing.T) {
+	setUpAWSConfig(t, "")
+	server := newSlowServer(t)
+	client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "dead.ndjson")
+	deadLetters, err := openDeadLetterFile(name)
+	if err != nil {
+		t.Fatalf("openDeadLetterFile() error = %v", err)
+	}
+	dst := destination{logGroup: "group", logStream: "stream"}
+	u := &uploader{
+		client:      client,
+		dst:         dst,
+		limits:      batchLimits{maxEvents: 1, maxBytes: maxBatchBytes},
+		deadLetters: deadLetters,
+	}
+	events := []types.InputLogEvent{
+		{Message: aws.String("log1"), Timestamp: aws.Int64(1)},
+		{Message: aws.String("log2"), Timestamp: aws.Int64(2)},
+		{Message: aws.String("log3"), Timestamp: aws.Int64(3)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := u.put(ctx, events); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("uploader.put() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := deadLetters.close(); err != nil {
+		t.Fatalf("deadLetterFile.close() error = %v", err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got := []deadLetter{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := deadLetter{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("deadLetterFile wrote invalid record %s: %v", scanner.Text(), err)
+		}
+		got = append(got, record)
+	}
+	want := []deadLetter{
+		{LogGroup: "group", LogStream: "stream", Timestamp: 2, Message: "log2"},
+		{LogGroup: "group", LogStream: "stream", Timestamp: 3, Message: "log3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("deadLetterFile wrote %+v, want %+v", got, want)
+	}
+	if u.ingested.events != 1 || u.failed != 2 {
+		t.Errorf("uploader.put() put %d and failed %d log events, want 1 and 2", u.ingested.events, u.failed)
+	}
+}
+
+func Test_uploader_put_batchTimeout(t *testing.T) {
+	setUpAWSConfig(t, "")
+	server := newSlowServer(t)
+	client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+
+	u := &uploader{
+		client:       client,
+		dst:          destination{logGroup: "group", logStream: "stream"},
+		limits:       batchLimits{maxEvents: 1, maxBytes: maxBatchBytes},
+		batchTimeout: 100 * time.Millisecond,
+	}
+	events := []types.InputLogEvent{
+		{Message: aws.String("log1"), Timestamp: aws.Int64(1)},
+		{Message: aws.String("log2"), Timestamp: aws.Int64(2)},
+	}
+	if err := u.put(context.Background(), events); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("uploader.put() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if u.ingested.events != 1 || u.failed != 1 {
+		t.Errorf("uploader.put() put %d and failed %d log events, want 1 and 1", u.ingested.events, u.failed)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// debugHTTPLogMode logs signed requests and responses with their bodies, and retry attempts.
+const debugHTTPLogMode = aws.LogSigning | aws.LogRetries | aws.LogRequestWithBody | aws.LogResponseWithBody
+
+// credentialPatterns match credentials in logged requests and responses, such as the signature,
+// the session token and credentials returned by STS and SSO.
+var credentialPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(Signature=)[0-9a-f]+`),
+	regexp.MustCompile(`(?im)^(X-Amz-Security-Token: ).*$`),
+	regexp.MustCompile(`(<SecretAccessKey>)[^<]*`),
+	regexp.MustCompile(`(<SessionToken>)[^<]*`),
+	regexp.MustCompile(`("(?:accessToken|refreshToken|clientSecret|secretAccessKey|sessionToken)"\s*:\s*")[^"]*`),
+}
+
+// redactCredentials replaces credentials in the log message with REDACTED.
+func redactCredentials(message string) string {
+	for _, pattern := range credentialPatterns {
+		message = pattern.ReplaceAllString(message, "${1}REDACTED")
+	}
+	return message
+}
+
+// redactingLogger writes logs of the SDK to w without credentials.
+type redactingLogger struct {
+	w io.Writer
+}
+
+func (l redactingLogger) Logf(classification logging.Classification, format string, v ...interface{}) {
+	fmt.Fprintf(l.w, "SDK %s %s %s\n", time.Now().Format(time.RFC3339), classification, redactCredentials(fmt.Sprintf(format, v...)))
+}
+
+// addLatencyLogging adds the middleware to log the latency and the number of attempts of each API call to the stack.
+func addLatencyLogging(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("LatencyLogging", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		attempts := 1
+		if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 0 {
+			attempts = len(results.Results)
+		}
+		result := "succeeded"
+		if err != nil {
+			result = fmt.Sprintf("failed: %v", err)
+		}
+		middleware.GetLogger(ctx).Logf(logging.Debug, "%s %s took %s with %d attempts and %s",
+			awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), time.Since(start), attempts, result)
+		return out, metadata, err
+	}), middleware.Before)
+}
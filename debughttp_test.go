@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func Test_redactCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "Redact signature",
+			message: "Authorization: AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/logs/aws4_request, SignedHeaders=host, Signature=0123abcd",
+			want:    "Authorization: AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/logs/aws4_request, SignedHeaders=host, Signature=REDACTED",
+		},
+		{
+			name:    "Redact session token",
+			message: "Host: logs.us-east-1.amazonaws.com\nX-Amz-Security-Token: token\nX-Amz-Target: Logs_20140328.PutLogEvents",
+			want:    "Host: logs.us-east-1.amazonaws.com\nX-Amz-Security-Token: REDACTED\nX-Amz-Target: Logs_20140328.PutLogEvents",
+		},
+		{
+			name:    "Redact credentials returned by STS",
+			message: "<AccessKeyId>AKID</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>",
+			want:    "<AccessKeyId>AKID</AccessKeyId><SecretAccessKey>REDACTED</SecretAccessKey><SessionToken>REDACTED</SessionToken>",
+		},
+		{
+			name:    "Redact tokens returned by SSO",
+			message: `{"accessToken": "token","expiresIn":3600,"refreshToken":"refresh"}`,
+			want:    `{"accessToken": "REDACTED","expiresIn":3600,"refreshToken":"REDACTED"}`,
+		},
+		{
+			name:    "Keep log events",
+			message: `{"logEvents":[{"message":"sample log message1","timestamp":1}]}`,
+			want:    `{"logEvents":[{"message":"sample log message1","timestamp":1}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactCredentials(tt.message); got != tt.want {
+				t.Errorf("redactCredentials() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newClient_debugHTTP(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"logGroups":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := newClient(parameters{endpointURL: server.URL, debugHTTP: true}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	buf := &bytes.Buffer{}
+	_, err = client.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{}, func(o *cloudwatchlogs.Options) {
+		o.Logger = redactingLogger{w: buf}
+	})
+	if err != nil {
+		t.Fatalf("DescribeLogGroups() error = %v", err)
+	}
+	for _, want := range []string{"Logs_20140328.DescribeLogGroups", "Signature=REDACTED", `{"logGroups":[]}`, "DescribeLogGroups took"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("newClient() logged %v, want to include %v", buf.String(), want)
+		}
+	}
+}
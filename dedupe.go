@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// deduper drops consecutive identical log events within the window like syslog.
+// Dropped log events are summarized into a "last message repeated N times" log event.
+type deduper struct {
+	window time.Duration
+	// last is the last log event which is not dropped
+	last     *types.InputLogEvent
+	repeated int
+	// lastRepeated is the timestamp of the last dropped log event
+	lastRepeated int64
+}
+
+func newDeduper(window time.Duration) *deduper {
+	return &deduper{window: window}
+}
+
+// add returns log events to upload instead of the log event.
+func (d *deduper) add(event types.InputLogEvent) []types.InputLogEvent {
+	if d.window <= 0 {
+		return []types.InputLogEvent{event}
+	}
+
+	timestamp := aws.ToInt64(event.Timestamp)
+	if d.last != nil && aws.ToString(d.last.Message) == aws.ToString(event.Message) &&
+		timestamp-aws.ToInt64(d.last.Timestamp) < d.window.Milliseconds() {
+		d.repeated++
+		d.lastRepeated = timestamp
+		return nil
+	}
+
+	events := d.flush()
+	d.last = &event
+	return append(events, event)
+}
+
+// flush returns the summary of dropped log events if there are.
+func (d *deduper) flush() []types.InputLogEvent {
+	if d.repeated == 0 {
+		return []types.InputLogEvent{}
+	}
+	summary := types.InputLogEvent{
+		Message:   aws.String(fmt.Sprintf("last message repeated %d times", d.repeated)),
+		Timestamp: aws.Int64(d.lastRepeated),
+	}
+	d.repeated = 0
+	return []types.InputLogEvent{summary}
+}
+
+// dedupeLogEvents drops consecutive identical log events within the window.
+func dedupeLogEvents(events []types.InputLogEvent, window time.Duration) []types.InputLogEvent {
+	d := newDeduper(window)
+	deduped := []types.InputLogEvent{}
+	for _, event := range events {
+		deduped = append(deduped, d.add(event)...)
+	}
+	return append(deduped, d.flush()...)
+}
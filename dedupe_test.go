@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_dedupeLogEvents(t *testing.T) {
+	event := func(message string, timestamp int64) types.InputLogEvent {
+		return types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(timestamp)}
+	}
+	tests := []struct {
+		name   string
+		events []types.InputLogEvent
+		window time.Duration
+		want   []types.InputLogEvent
+	}{
+		{
+			name:   "Dedupe nothing without the window",
+			events: []types.InputLogEvent{event("retry", 0), event("retry", 1000)},
+			window: 0,
+			want:   []types.InputLogEvent{event("retry", 0), event("retry", 1000)},
+		},
+		{
+			name:   "Dedupe consecutive identical log events",
+			events: []types.InputLogEvent{event("retry", 0), event("retry", 1000), event("retry", 2000), event("failed", 3000)},
+			window: 10 * time.Second,
+			want:   []types.InputLogEvent{event("retry", 0), event("last message repeated 2 times", 2000), event("failed", 3000)},
+		},
+		{
+			name:   "Dedupe log events at the end",
+			events: []types.InputLogEvent{event("retry", 0), event("retry", 1000)},
+			window: 10 * time.Second,
+			want:   []types.InputLogEvent{event("retry", 0), event("last message repeated 1 times", 1000)},
+		},
+		{
+			name:   "Dedupe log events within the window",
+			events: []types.InputLogEvent{event("retry", 0), event("retry", 5000), event("retry", 10000), event("retry", 12000)},
+			window: 10 * time.Second,
+			want:   []types.InputLogEvent{event("retry", 0), event("last message repeated 1 times", 5000), event("retry", 10000), event("last message repeated 1 times", 12000)},
+		},
+		{
+			name:   "Dedupe no different log events",
+			events: []types.InputLogEvent{event("retry", 0), event("failed", 1000), event("retry", 2000)},
+			window: 10 * time.Second,
+			want:   []types.InputLogEvent{event("retry", 0), event("failed", 1000), event("retry", 2000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeLogEvents(tt.events, tt.window); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dedupeLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
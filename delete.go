@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func deleteLogGroup(client *cloudwatchlogs.Client, logGroup string) error {
+	in := &cloudwatchlogs.DeleteLogGroupInput{
+		LogGroupName: aws.String(logGroup),
+	}
+	_, err := client.DeleteLogGroup(context.Background(), in)
+	return err
+}
+
+func deleteLogStream(client *cloudwatchlogs.Client, logGroup, logStream string) error {
+	in := &cloudwatchlogs.DeleteLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+	}
+	_, err := client.DeleteLogStream(context.Background(), in)
+	return err
+}
+
+// confirmDeletion asks the user to confirm the deletion.
+// It fails when the user can not be asked through the terminal.
+func confirmDeletion(message string) error {
+	if !isInteractive() {
+		return errors.New("confirmation error: use --yes to delete without confirmation")
+	}
+	ok, err := confirm(bufio.NewReader(os.Stdin), os.Stdout, message)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("canceled")
+	}
+	return nil
+}
+
+// describeGroupDeletion describes the log group to delete with the number of log streams and the stored bytes.
+func describeGroupDeletion(client *cloudwatchlogs.Client, logGroup string) (string, error) {
+	group, err := findLogGroup(client, logGroup)
+	if err != nil {
+		return "", err
+	}
+	streams, err := listLogStreams(client, logGroup, types.OrderByLogStreamName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("log group %s with %d log streams (%d stored bytes)", logGroup, len(streams), group.StoredBytes), nil
+}
+
+// describeStreamDeletion describes the log stream to delete with the time of the last log event.
+// The stored bytes are not shown, because StoredBytes of log streams is deprecated and always 0.
+func describeStreamDeletion(client *cloudwatchlogs.Client, logGroup, logStream string) (string, error) {
+	stream, err := describeLogStream(context.Background(), client, logGroup, logStream)
+	if err != nil {
+		return "", err
+	}
+	if stream == nil {
+		return "", fmt.Errorf("not log stream error: %s is not found in %s", logStream, logGroup)
+	}
+	if stream.LastEventTimestamp == nil {
+		return fmt.Sprintf("log stream %s in %s (no log events)", logStream, logGroup), nil
+	}
+	return fmt.Sprintf("log stream %s in %s (last event at %s)", logStream, logGroup, fromMillis(*stream.LastEventTimestamp).Format(time.RFC3339)), nil
+}
+
+func runDeleteGroup(args []string) error {
+	params := parameters{}
+	yes := false
+	dryRun := false
+
+	flags := newSubcommandFlagSet(args[0], "Delete a log group and all log streams in it.", "<LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.BoolVar(&yes, "yes", false, "Delete without confirmation.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Show the log group to delete without deleting it.")
+	names := parseInterspersed(flags, args[1:])
+
+	if len(names) != 1 {
+		return errors.New("argument error: a log group name is required")
+	}
+	logGroup := names[0]
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if dryRun || !yes {
+		summary, err := describeGroupDeletion(client, logGroup)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Printf("Would delete %s\n", summary)
+			return nil
+		}
+		if err := confirmDeletion("Delete " + summary + "?"); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteLogGroup(client, logGroup); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted log group %s\n", logGroup)
+	return nil
+}
+
+func runDeleteStream(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	yes := false
+	dryRun := false
+
+	flags := newSubcommandFlagSet(args[0], "Delete a log stream in the log group.", "--log-group <LOG GROUP NAME> <LOG STREAM NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group where the log stream is deleted. It is required.")
+	flags.BoolVar(&yes, "yes", false, "Delete without confirmation.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Show the log stream to delete without deleting it.")
+	names := parseInterspersed(flags, args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if len(names) != 1 {
+		return errors.New("argument error: a log stream name is required")
+	}
+	logStream := names[0]
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if dryRun || !yes {
+		summary, err := describeStreamDeletion(client, logGroup, logStream)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Printf("Would delete %s\n", summary)
+			return nil
+		}
+		if err := confirmDeletion("Delete " + summary + "?"); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteLogStream(client, logGroup, logStream); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted log stream %s in %s\n", logStream, logGroup)
+	return nil
+}
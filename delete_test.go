@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+// setUpAnswer makes the user answer the confirmation through stdin if interactive is true.
+func setUpAnswer(t *testing.T, interactive bool, answer string) {
+	defaultInteractive, defaultStdin := isInteractive, os.Stdin
+	t.Cleanup(func() { isInteractive, os.Stdin = defaultInteractive, defaultStdin })
+	isInteractive = func() bool { return interactive }
+
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := ioutil.WriteFile(path, []byte(answer), 0600); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	os.Stdin = f
+}
+
+func Test_describeGroupDeletion(t *testing.T) {
+	fake := fakelogs.New()
+	// The small pages make the log streams span several pages
+	fake.PageSize = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 5)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	in := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStreams[0]),
+		LogEvents:     []types.InputLogEvent{{Timestamp: aws.Int64(toMillis(time.Now())), Message: aws.String("[INFO] Start API")}},
+	}
+	if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		logGroup string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Describe log group over pages", logGroup: logGroup, want: fmt.Sprintf("log group %s with 5 log streams (42 stored bytes)", logGroup)},
+		{name: "Describe unknown log group", logGroup: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := describeGroupDeletion(cli, tt.logGroup)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("describeGroupDeletion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("describeGroupDeletion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_describeStreamDeletion(t *testing.T) {
+	fake := fakelogs.New()
+	fake.PageSize = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 2)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	last := time.Now().Add(-time.Minute).Truncate(time.Second)
+	in := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStreams[1]),
+		LogEvents:     []types.InputLogEvent{{Timestamp: aws.Int64(toMillis(last)), Message: aws.String("[INFO] Start API")}},
+	}
+	if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		logStream string
+		want      string
+		wantErr   bool
+	}{
+		{name: "Describe empty log stream", logStream: logStreams[0], want: fmt.Sprintf("log stream %s in %s (no log events)", logStreams[0], logGroup)},
+		{name: "Describe log stream", logStream: logStreams[1], want: fmt.Sprintf("log stream %s in %s (last event at %s)", logStreams[1], logGroup, last.Format(time.RFC3339))},
+		{name: "Describe unknown log stream", logStream: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := describeStreamDeletion(cli, logGroup, tt.logStream)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("describeStreamDeletion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("describeStreamDeletion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runDeleteGroup(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	client := []string{"--endpoint-url", server.URL, "--region", "us-east-1"}
+
+	// The steps share the log group, so they run in order
+	tests := []struct {
+		name        string
+		args        []string
+		interactive bool
+		answer      string
+		wantErr     bool
+		wantExists  bool
+	}{
+		{name: "Delete log group without name", args: []string{"--yes"}, wantErr: true, wantExists: true},
+		{name: "Delete log group in dry run", args: []string{logGroup, "--dry-run"}, wantExists: true},
+		{name: "Delete log group in dry run with yes", args: []string{logGroup, "--dry-run", "--yes"}, wantExists: true},
+		{name: "Delete log group without confirmation in non-interactive mode", args: []string{logGroup}, wantErr: true, wantExists: true},
+		{name: "Delete log group denied by the user", args: []string{logGroup}, interactive: true, answer: "n\n", wantErr: true, wantExists: true},
+		{name: "Delete log group without answer", args: []string{logGroup}, interactive: true, wantErr: true, wantExists: true},
+		{name: "Delete log group confirmed by the user", args: []string{logGroup}, interactive: true, answer: "y\n"},
+		{name: "Delete log group after it is deleted", args: []string{logGroup, "--yes"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUpAnswer(t, tt.interactive, tt.answer)
+			args := append([]string{"delete-group"}, append(client, tt.args...)...)
+			if err := runDeleteGroup(args); (err != nil) != tt.wantErr {
+				t.Errorf("runDeleteGroup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			groups, err := listLogGroups(cli, logGroup)
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			if got := len(groups) == 1; got != tt.wantExists {
+				t.Errorf("runDeleteGroup() log group exists = %v, want %v", got, tt.wantExists)
+			}
+		})
+	}
+}
+
+func Test_runDeleteStream(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 2)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	client := []string{"--endpoint-url", server.URL, "--region", "us-east-1"}
+
+	// The steps share the log streams, so they run in order
+	tests := []struct {
+		name        string
+		args        []string
+		interactive bool
+		answer      string
+		wantErr     bool
+		want        int
+	}{
+		{name: "Delete log stream without log group", args: []string{logStreams[0], "--yes"}, wantErr: true, want: 2},
+		{name: "Delete log stream without name", args: []string{"--log-group", logGroup, "--yes"}, wantErr: true, want: 2},
+		{name: "Delete log stream in dry run", args: []string{"--log-group", logGroup, logStreams[0], "--dry-run"}, want: 2},
+		{name: "Delete unknown log stream in dry run", args: []string{"--log-group", logGroup, "unknown", "--dry-run"}, wantErr: true, want: 2},
+		{name: "Delete log stream without confirmation in non-interactive mode", args: []string{"--log-group", logGroup, logStreams[0]}, wantErr: true, want: 2},
+		{name: "Delete log stream denied by the user", args: []string{"--log-group", logGroup, logStreams[0]}, interactive: true, answer: "no\n", wantErr: true, want: 2},
+		{name: "Delete log stream confirmed by the user", args: []string{"--log-group", logGroup, logStreams[0]}, interactive: true, answer: "yes\n", want: 1},
+		{name: "Delete log stream with yes", args: []string{"--log-group", logGroup, logStreams[1], "--yes"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUpAnswer(t, tt.interactive, tt.answer)
+			args := append([]string{"delete-stream"}, append(client, tt.args...)...)
+			if err := runDeleteStream(args); (err != nil) != tt.wantErr {
+				t.Errorf("runDeleteStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			streams, err := listLogStreams(cli, logGroup, types.OrderByLogStreamName)
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			if got := len(streams); got != tt.want {
+				t.Errorf("runDeleteStream() log streams = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
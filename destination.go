@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type destination struct {
+	logGroup  string
+	logStream string
+	region    string
+	account   string
+	roleARN   string
+}
+
+func (d destination) String() string {
+	if d.logStream == "" {
+		return d.logGroup
+	}
+	return d.logGroup + ":" + d.logStream
+}
+
+// parseLogGroupARN parses the ARN of a log group or a log stream like
+// arn:aws:logs:<REGION>:<ACCOUNT>:log-group:<LOG GROUP>[:log-stream:<LOG STREAM>].
+func parseLogGroupARN(arn string) (destination, error) {
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) != 7 || parts[0] != "arn" || parts[2] != "logs" || parts[5] != "log-group" {
+		return destination{}, fmt.Errorf("arn error: %s is not the ARN of a log group", arn)
+	}
+	if parts[3] == "" {
+		return destination{}, fmt.Errorf("arn error: region is not found in %s", arn)
+	}
+
+	dst := destination{
+		region:  parts[3],
+		account: parts[4],
+	}
+
+	resource := parts[6]
+	// The ARN of a log group shown in the console ends with ":*"
+	resource = strings.TrimSuffix(resource, ":*")
+	if i := strings.Index(resource, ":log-stream:"); i >= 0 {
+		dst.logStream = resource[i+len(":log-stream:"):]
+		resource = resource[:i]
+	}
+	dst.logGroup = resource
+
+	if dst.logGroup == "" {
+		return destination{}, fmt.Errorf("arn error: log group is not found in %s", arn)
+	}
+	return dst, nil
+}
+
+// logGroupFlag adds a destination each time --log-group is given.
+// The name of the log group can be given as the ARN, then the regions in ARNs are stored to regions.
+type logGroupFlag struct {
+	destinations *[]destination
+	regions      *[]string
+}
+
+func (f logGroupFlag) String() string {
+	if f.destinations == nil {
+		return ""
+	}
+	groups := make([]string, len(*f.destinations))
+	for i, dst := range *f.destinations {
+		groups[i] = dst.logGroup
+	}
+	return strings.Join(groups, ",")
+}
+
+func (f logGroupFlag) Set(v string) error {
+	dst := destination{logGroup: v}
+	if strings.HasPrefix(v, "arn:") {
+		var err error
+		dst, err = parseLogGroupARN(v)
+		if err != nil {
+			return err
+		}
+		if f.regions != nil {
+			*f.regions = append(*f.regions, dst.region)
+		}
+	}
+
+	dsts := *f.destinations
+	// A log stream given before any log group is kept until the log group is given
+	if n := len(dsts); n > 0 && dsts[n-1].logGroup == "" {
+		if dst.logStream == "" {
+			dst.logStream = dsts[n-1].logStream
+		}
+		dsts[n-1] = dst
+		return nil
+	}
+	*f.destinations = append(dsts, dst)
+	return nil
+}
+
+// destFlag adds a destination given as the ARN of a log group each time --dest is given.
+type destFlag struct {
+	destinations *[]destination
+}
+
+func (f destFlag) String() string {
+	return logGroupFlag{destinations: f.destinations}.String()
+}
+
+func (f destFlag) Set(v string) error {
+	dst, err := parseLogGroupARN(v)
+	if err != nil {
+		return err
+	}
+	*f.destinations = append(*f.destinations, dst)
+	return nil
+}
+
+// logStreamFlag sets the log stream of the destination added by the preceding --log-group.
+// If the destination already has a log stream, it adds a new destination with the same log group.
+type logStreamFlag struct {
+	destinations *[]destination
+}
+
+func (f logStreamFlag) String() string {
+	if f.destinations == nil {
+		return ""
+	}
+	streams := make([]string, len(*f.destinations))
+	for i, dst := range *f.destinations {
+		streams[i] = dst.logStream
+	}
+	return strings.Join(streams, ",")
+}
+
+func (f logStreamFlag) Set(v string) error {
+	dsts := *f.destinations
+	n := len(dsts)
+	switch {
+	case n == 0:
+		*f.destinations = append(dsts, destination{logStream: v})
+	case dsts[n-1].logStream == "":
+		dsts[n-1].logStream = v
+	default:
+		next := dsts[n-1]
+		next.logStream = v
+		*f.destinations = append(dsts, next)
+	}
+	return nil
+}
+
+// roleARNFlag sets the IAM role assumed to put logs to the destination added by the preceding --log-group or --dest.
+type roleARNFlag struct {
+	destinations *[]destination
+}
+
+func (f roleARNFlag) String() string {
+	if f.destinations == nil {
+		return ""
+	}
+	roles := make([]string, len(*f.destinations))
+	for i, dst := range *f.destinations {
+		roles[i] = dst.roleARN
+	}
+	return strings.Join(roles, ",")
+}
+
+func (f roleARNFlag) Set(v string) error {
+	dsts := *f.destinations
+	n := len(dsts)
+	if n == 0 {
+		return errors.New("--role-arn must follow --log-group or --dest")
+	}
+	// The role also applies to destinations derived from the same --log-group by repeating --log-stream
+	for i := n - 1; i >= 0 && dsts[i].logGroup == dsts[n-1].logGroup && dsts[i].roleARN == ""; i-- {
+		dsts[i].roleARN = v
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseLogGroupARN(t *testing.T) {
+	tests := []struct {
+		name    string
+		arn     string
+		want    destination
+		wantErr bool
+	}{
+		{
+			name: "Parse ARN of log group",
+			arn:  "arn:aws:logs:eu-west-1:123456789012:log-group:/central",
+			want: destination{
+				logGroup: "/central",
+				region:   "eu-west-1",
+				account:  "123456789012",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse ARN of log group copied from console",
+			arn:  "arn:aws:logs:eu-west-1:123456789012:log-group:/central:*",
+			want: destination{
+				logGroup: "/central",
+				region:   "eu-west-1",
+				account:  "123456789012",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse ARN of log stream",
+			arn:  "arn:aws:logs:us-east-1:123456789012:log-group:/app/api:log-stream:api-1",
+			want: destination{
+				logGroup:  "/app/api",
+				logStream: "api-1",
+				region:    "us-east-1",
+				account:   "123456789012",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Parse name of log group",
+			arn:     "/central",
+			wantErr: true,
+		},
+		{
+			name:    "Parse ARN of other service",
+			arn:     "arn:aws:s3:::bucket",
+			wantErr: true,
+		},
+		{
+			name:    "Parse ARN without region",
+			arn:     "arn:aws:logs::123456789012:log-group:/central",
+			wantErr: true,
+		},
+		{
+			name:    "Parse ARN without log group",
+			arn:     "arn:aws:logs:eu-west-1:123456789012:log-group:",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogGroupARN(tt.arn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLogGroupARN() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLogGroupARN() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
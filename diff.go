@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type eventDiff struct {
+	missing []string
+	extra   []storedEvent
+}
+
+// diffEvents compares the local log events with the stored log events by their messages.
+// missing are local log events not stored, and extra are stored log events not in local.
+func diffEvents(local []string, stored []storedEvent) eventDiff {
+	storedCounts := map[string]int{}
+	for _, event := range stored {
+		storedCounts[event.Message]++
+	}
+	localCounts := map[string]int{}
+	for _, event := range local {
+		localCounts[event]++
+	}
+
+	d := eventDiff{
+		missing: findMissingEvents(local, storedCounts),
+		extra:   []storedEvent{},
+	}
+	for _, event := range stored {
+		if localCounts[event.Message] > 0 {
+			localCounts[event.Message]--
+			continue
+		}
+		d.extra = append(d.extra, event)
+	}
+	return d
+}
+
+func runDiff(args []string) error {
+	params := parameters{}
+	q := eventQuery{}
+	fileName := ""
+	since, until := "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Show differences between log events in the file and in CloudWatch Logs.", "--log-group <LOG GROUP NAME> --logs-file <FILE PATH> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&q.logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&q.logStream, "log-stream", "", "The name of the log stream. If you do not use this parameters, it compares with log events in all log streams.")
+	flags.StringVar(&fileName, "logs-file", "", "The path of file that includes log events. It is required.")
+	addTimeRangeFlags(flags, &since, &until)
+	flags.Parse(args[1:])
+
+	if q.logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if fileName == "" {
+		return errors.New("argument error: --logs-file is required")
+	}
+	var err error
+	q.start, q.end, err = parseTimeRange(since, until)
+	if err != nil {
+		return err
+	}
+
+	logs, err := getLogEventsFromFile(fileName, inputFormatAuto)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	stored := []storedEvent{}
+	err = fetchLogEvents(client, q, func(event storedEvent) error {
+		stored = append(stored, event)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d := diffEvents(logs, stored)
+	for _, event := range d.missing {
+		fmt.Printf("- %s\n", event)
+	}
+	for _, event := range d.extra {
+		fmt.Printf("+ %s %s\n", fromMillis(event.Timestamp).Format(time.RFC3339Nano), event.Message)
+	}
+	fmt.Printf("%d missing, %d extra log events\n", len(d.missing), len(d.extra))
+	return nil
+}
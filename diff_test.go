@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diffEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  []string
+		stored []storedEvent
+		want   eventDiff
+	}{
+		{
+			name:  "No differences",
+			local: []string{"[INFO] Start Server", "[ERROR] Failed to Start Server"},
+			stored: []storedEvent{
+				{Timestamp: 1, Message: "[INFO] Start Server"},
+				{Timestamp: 2, Message: "[ERROR] Failed to Start Server"},
+			},
+			want: eventDiff{
+				missing: []string{},
+				extra:   []storedEvent{},
+			},
+		},
+		{
+			name:  "Missing and extra events",
+			local: []string{"[INFO] Start Server", "[ERROR] Failed to Start Server"},
+			stored: []storedEvent{
+				{Timestamp: 1, Message: "[INFO] Start Server"},
+				{Timestamp: 3, Message: "[INFO] Stop Server"},
+			},
+			want: eventDiff{
+				missing: []string{"[ERROR] Failed to Start Server"},
+				extra: []storedEvent{
+					{Timestamp: 3, Message: "[INFO] Stop Server"},
+				},
+			},
+		},
+		{
+			name:  "Duplicated events",
+			local: []string{"[INFO] Start Server"},
+			stored: []storedEvent{
+				{Timestamp: 1, Message: "[INFO] Start Server"},
+				{Timestamp: 2, Message: "[INFO] Start Server"},
+			},
+			want: eventDiff{
+				missing: []string{},
+				extra: []storedEvent{
+					{Timestamp: 2, Message: "[INFO] Start Server"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffEvents(tt.local, tt.stored); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// The results of checks of doctor
+const (
+	checkPassed  = "pass"
+	checkFailed  = "fail"
+	checkSkipped = "skip"
+)
+
+// doctorTimeout is the timeout of each check calling AWS APIs.
+const doctorTimeout = 10 * time.Second
+
+// doctorCheck is the result of a check of the environment.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	Detail string `json:"detail"`
+}
+
+// doctor checks the environment to use awsputlogs step by step. Checks after a failed one are skipped if they need it.
+type doctor struct {
+	params   parameters
+	logGroup string
+	checks   []doctorCheck
+}
+
+func (d *doctor) add(name, result, detail string) {
+	d.checks = append(d.checks, doctorCheck{Name: name, Result: result, Detail: detail})
+}
+
+// skip adds the checks skipped because of the failed check.
+func (d *doctor) skip(reason string, names ...string) {
+	for _, name := range names {
+		d.add(name, checkSkipped, reason)
+	}
+}
+
+func (d *doctor) run() {
+	cfg, err := loadConfig(d.params)
+	var noRegion noRegionError
+	if errors.As(err, &noRegion) {
+		d.add("config", checkPassed, "loaded")
+		d.add("region", checkFailed, strings.ReplaceAll(noRegion.Error(), "\n", "; "))
+		d.skip("the region is not configured", "credentials", "identity", "endpoint", "permissions")
+		return
+	}
+	if err != nil {
+		d.add("config", checkFailed, err.Error())
+		d.skip("the config is not loaded", "region", "credentials", "identity", "endpoint", "permissions")
+		return
+	}
+	d.add("config", checkPassed, "loaded")
+	d.add("region", checkPassed, cfg.Region)
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		d.add("credentials", checkFailed, err.Error())
+		d.skip("credentials are not resolved", "identity", "endpoint", "permissions")
+		return
+	}
+	detail := creds.Source
+	if creds.CanExpire {
+		detail += fmt.Sprintf(", expires at %s", creds.Expires.Format(time.RFC3339))
+	}
+	d.add("credentials", checkPassed, detail)
+
+	if usesEmulator(d.params) {
+		d.skip("emulators do not have STS", "identity")
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		defer cancel()
+		identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			d.add("identity", checkFailed, err.Error())
+		} else {
+			d.add("identity", checkPassed, aws.ToString(identity.Arn))
+		}
+	}
+
+	client := newClientFromConfig(cfg, d.params)
+	ctx, cancel = context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	start := time.Now()
+	_, err = client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{Limit: aws.Int32(1)})
+	latency := time.Since(start).Round(time.Millisecond)
+	var apiErr smithy.APIError
+	switch {
+	case err == nil:
+		d.add("endpoint", checkPassed, fmt.Sprintf("reached CloudWatch Logs in %s", latency))
+	case errors.As(err, &apiErr):
+		// The endpoint is reachable even if the call is not allowed
+		d.add("endpoint", checkPassed, fmt.Sprintf("reached CloudWatch Logs in %s, but %s", latency, apiErr.ErrorCode()))
+	default:
+		d.add("endpoint", checkFailed, err.Error())
+		d.skip("the endpoint is not reachable", "permissions")
+		return
+	}
+
+	if d.logGroup == "" {
+		d.skip("--log-group is not given", "permissions")
+		return
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	for _, check := range probePutPermissions(ctx, client, d.logGroup) {
+		switch check.Result {
+		case permissionAllowed:
+			d.add(check.Action, checkPassed, "allowed on "+d.logGroup)
+		case permissionDenied:
+			d.add(check.Action, checkFailed, check.Detail)
+		default:
+			d.add(check.Action, checkSkipped, check.Detail)
+		}
+	}
+}
+
+// failed returns the number of failed checks.
+func (d *doctor) failed() int {
+	n := 0
+	for _, check := range d.checks {
+		if check.Result == checkFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// writeDoctorReport writes the checks colored by the results.
+func writeDoctorReport(checks []doctorCheck, p palette) error {
+	rows := make([][]string, len(checks))
+	for i, check := range checks {
+		result := check.Result
+		switch result {
+		case checkPassed:
+			result = p.paint(ansiGreen, result)
+		case checkFailed:
+			result = p.paint(ansiRed, result)
+		case checkSkipped:
+			result = p.paint(ansiYellow, result)
+		}
+		rows[i] = []string{check.Name, result, check.Detail}
+	}
+	return writeTable(os.Stdout, []string{"CHECK", "RESULT", "DETAIL"}, rows)
+}
+
+func runDoctor(args []string) error {
+	d := &doctor{}
+	output := outputTable
+	noColor := false
+
+	flags := newSubcommandFlagSet(args[0], "Check the config, the credentials, the endpoint and the permissions to use awsputlogs.", "[--log-group <LOG GROUP NAME>] [options]")
+	addClientFlags(flags, &d.params)
+	flags.StringVar(&d.logGroup, "log-group", "", "The name of the log group to check the permissions to put logs. If you do not use this parameters, the permissions are not checked.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.BoolVar(&noColor, "no-color", false, "Do not color the results on the terminal. NO_COLOR environment variable also turns colors off.")
+	flags.Parse(args[1:])
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	d.run()
+	if output == outputJSON {
+		if err := writeJSON(os.Stdout, d.checks); err != nil {
+			return err
+		}
+	} else if err := writeDoctorReport(d.checks, newPalette(os.Stdout, noColor)); err != nil {
+		return err
+	}
+	if n := d.failed(); n > 0 {
+		return fmt.Errorf("doctor error: %d checks failed", n)
+	}
+	return nil
+}
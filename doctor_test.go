@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+// denyActions returns the handler which denies the actions of CloudWatch Logs, such as PutLogEvents, and passes others to h.
+func denyActions(h http.Handler, actions ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, action := range actions {
+			if strings.HasSuffix(r.Header.Get("X-Amz-Target"), "."+action) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"AccessDeniedException","message":"User is not authorized to perform: logs:` + action + `"}`))
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func Test_doctor_run(t *testing.T) {
+	tests := []struct {
+		name       string
+		deny       []string
+		logGroup   string
+		noStream   bool
+		want       map[string]string
+		wantFailed int
+	}{
+		{
+			name:     "Check environment",
+			logGroup: "/app/api",
+			want: map[string]string{
+				"config": checkPassed, "region": checkPassed, "credentials": checkPassed, "identity": checkSkipped, "endpoint": checkPassed,
+				"logs:DescribeLogStreams": checkPassed, "logs:PutLogEvents": checkPassed, "logs:CreateLogStream": checkPassed,
+			},
+		},
+		{
+			name:     "Check environment with denied actions",
+			deny:     []string{"PutLogEvents", "CreateLogStream"},
+			logGroup: "/app/api",
+			want: map[string]string{
+				"config": checkPassed, "region": checkPassed, "credentials": checkPassed, "identity": checkSkipped, "endpoint": checkPassed,
+				"logs:DescribeLogStreams": checkPassed, "logs:PutLogEvents": checkFailed, "logs:CreateLogStream": checkFailed,
+			},
+			wantFailed: 2,
+		},
+		{
+			name:     "Check environment with log group without log streams",
+			logGroup: "/app/api",
+			noStream: true,
+			want: map[string]string{
+				"config": checkPassed, "region": checkPassed, "credentials": checkPassed, "identity": checkSkipped, "endpoint": checkPassed,
+				"logs:DescribeLogStreams": checkPassed, "logs:PutLogEvents": checkPassed, "logs:CreateLogStream": checkSkipped,
+			},
+		},
+		{
+			name: "Check environment without log group",
+			want: map[string]string{
+				"config": checkPassed, "region": checkPassed, "credentials": checkPassed, "identity": checkSkipped, "endpoint": checkPassed,
+				"permissions": checkSkipped,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := fakelogs.New()
+			server := httptest.NewServer(denyActions(fake, tt.deny...))
+			defer server.Close()
+			setUpServer := httptest.NewServer(fake)
+			defer setUpServer.Close()
+			cli, err := setUpClient(setUpServer.URL, "us-east-1")
+			if err != nil {
+				t.Fatalf("failed to set up: %v", err)
+			}
+			if _, err := cli.CreateLogGroup(context.Background(), &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("/app/api")}); err != nil {
+				t.Fatalf("failed to set up: %v", err)
+			}
+			if !tt.noStream {
+				if _, err := cli.CreateLogStream(context.Background(), &cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api")}); err != nil {
+					t.Fatalf("failed to set up: %v", err)
+				}
+			}
+
+			d := &doctor{params: parameters{endpointURL: server.URL, region: "us-east-1"}, logGroup: tt.logGroup}
+			d.run()
+			got := map[string]string{}
+			for _, check := range d.checks {
+				got[check.Name] = check.Result
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("doctor.run() = %v, want %v", d.checks, tt.want)
+			}
+			if n := d.failed(); n != tt.wantFailed {
+				t.Errorf("doctor.failed() = %d, want %d", n, tt.wantFailed)
+			}
+		})
+	}
+}
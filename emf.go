@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The limits of the Embedded Metric Format.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+const (
+	maxEMFDimensions = 30
+	maxEMFMetrics    = 100
+)
+
+// emfMetric is the metric extracted from the field of log events.
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit,omitempty"`
+}
+
+// parseEMFMetric parses the metric such as latency_ms:Milliseconds. The unit is optional.
+func parseEMFMetric(s string) (emfMetric, error) {
+	name, unit, _ := strings.Cut(s, ":")
+	if name == "" {
+		return emfMetric{}, fmt.Errorf("argument error: --emf-metric must be NAME[:UNIT], but got %s", s)
+	}
+	if unit != "" {
+		valid := false
+		for _, u := range cwtypes.StandardUnit("").Values() {
+			valid = valid || string(u) == unit
+		}
+		if !valid {
+			return emfMetric{}, fmt.Errorf("argument error: the unit of --emf-metric %s must be a CloudWatch unit such as Milliseconds, Bytes or Count, but got %s", name, unit)
+		}
+	}
+	return emfMetric{Name: name, Unit: unit}, nil
+}
+
+// emfFormat wraps JSON log events into the Embedded Metric Format, so that CloudWatch extracts metrics from them.
+// The nil emfFormat does not change log events.
+type emfFormat struct {
+	namespace  string
+	dimensions []string
+	metrics    []emfMetric
+}
+
+// newEMFFormat creates the format with the namespace, the comma-separated dimensions and the metrics.
+func newEMFFormat(namespace, dimensions string, metrics []string) (*emfFormat, error) {
+	if namespace == "" {
+		return nil, errors.New("argument error: --emf requires --emf-namespace")
+	}
+	f := &emfFormat{namespace: namespace, dimensions: []string{}}
+	for _, d := range strings.Split(dimensions, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			f.dimensions = append(f.dimensions, d)
+		}
+	}
+	if len(f.dimensions) > maxEMFDimensions {
+		return nil, fmt.Errorf("argument error: --emf-dimensions must be %d or less, but got %d", maxEMFDimensions, len(f.dimensions))
+	}
+	for _, m := range metrics {
+		for _, s := range strings.Split(m, ",") {
+			metric, err := parseEMFMetric(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			f.metrics = append(f.metrics, metric)
+		}
+	}
+	if len(f.metrics) == 0 {
+		return nil, errors.New("argument error: --emf requires --emf-metric")
+	}
+	if len(f.metrics) > maxEMFMetrics {
+		return nil, fmt.Errorf("argument error: --emf-metric must be %d or less, but got %d", maxEMFMetrics, len(f.metrics))
+	}
+	return f, nil
+}
+
+// isEMFValue reports whether the value is a number or an array of numbers, which are the values of metrics in EMF.
+func isEMFValue(value json.RawMessage) bool {
+	var number float64
+	if json.Unmarshal(value, &number) == nil {
+		return true
+	}
+	var numbers []float64
+	return json.Unmarshal(value, &numbers) == nil && len(numbers) > 0
+}
+
+// format returns the log event wrapped into EMF with the metrics in it.
+// Log events which are not JSON objects, already have the _aws field, lack any string dimension or have none of the metrics are not changed.
+func (f *emfFormat) format(event types.InputLogEvent) types.InputLogEvent {
+	if f == nil {
+		return event
+	}
+	message := strings.TrimSpace(aws.ToString(event.Message))
+	fields := map[string]json.RawMessage{}
+	if !strings.HasPrefix(message, "{") || json.Unmarshal([]byte(message), &fields) != nil {
+		return event
+	}
+	if _, ok := fields["_aws"]; ok {
+		return event
+	}
+	for _, d := range f.dimensions {
+		var value string
+		if json.Unmarshal(fields[d], &value) != nil {
+			return event
+		}
+	}
+	metrics := []emfMetric{}
+	for _, m := range f.metrics {
+		if isEMFValue(fields[m.Name]) {
+			metrics = append(metrics, m)
+		}
+	}
+	if len(metrics) == 0 {
+		return event
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"Timestamp": aws.ToInt64(event.Timestamp),
+		"CloudWatchMetrics": []interface{}{map[string]interface{}{
+			"Namespace":  f.namespace,
+			"Dimensions": [][]string{f.dimensions},
+			"Metrics":    metrics,
+		}},
+	})
+	if err != nil {
+		return event
+	}
+	// The metadata is prepended to keep the order of fields in the log event, which has the metrics at least
+	event.Message = aws.String(fmt.Sprintf(`{"_aws":%s,%s}`, metadata, strings.TrimSpace(message[1:len(message)-1])))
+	return event
+}
+
+// formatEMFLogEvents returns log events wrapped into EMF.
+func formatEMFLogEvents(events []types.InputLogEvent, f *emfFormat) []types.InputLogEvent {
+	if f == nil {
+		return events
+	}
+	formatted := make([]types.InputLogEvent, len(events))
+	for i, event := range events {
+		formatted[i] = f.format(event)
+	}
+	return formatted
+}
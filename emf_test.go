@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_newEMFFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespace  string
+		dimensions string
+		metrics    []string
+		want       *emfFormat
+		wantErr    bool
+	}{
+		{
+			name:       "Create EMF format",
+			namespace:  "App",
+			dimensions: "service, env",
+			metrics:    []string{"latency_ms:Milliseconds,bytes:Bytes", "errors"},
+			want: &emfFormat{
+				namespace:  "App",
+				dimensions: []string{"service", "env"},
+				metrics:    []emfMetric{{Name: "latency_ms", Unit: "Milliseconds"}, {Name: "bytes", Unit: "Bytes"}, {Name: "errors"}},
+			},
+		},
+		{name: "Create EMF format without namespace", metrics: []string{"latency_ms"}, wantErr: true},
+		{name: "Create EMF format without metrics", namespace: "App", wantErr: true},
+		{name: "Create EMF format with unknown unit", namespace: "App", metrics: []string{"latency_ms:ms"}, wantErr: true},
+		{name: "Create EMF format with empty metric name", namespace: "App", metrics: []string{":Count"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEMFFormat(tt.namespace, tt.dimensions, tt.metrics)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newEMFFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newEMFFormat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_emfFormat_format(t *testing.T) {
+	f := &emfFormat{
+		namespace:  "App",
+		dimensions: []string{"service"},
+		metrics:    []emfMetric{{Name: "latency_ms", Unit: "Milliseconds"}, {Name: "bytes", Unit: "Bytes"}},
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "Wrap JSON log event",
+			message: `{"service":"api","latency_ms":12.5,"bytes":[10,20]}`,
+			want:    `{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["service"]],"Metrics":[{"Name":"latency_ms","Unit":"Milliseconds"},{"Name":"bytes","Unit":"Bytes"}],"Namespace":"App"}],"Timestamp":1704067200000},"service":"api","latency_ms":12.5,"bytes":[10,20]}`,
+		},
+		{
+			name:    "Wrap JSON log event with some of metrics",
+			message: `{"service":"api","latency_ms":12.5,"bytes":"unknown"}`,
+			want:    `{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["service"]],"Metrics":[{"Name":"latency_ms","Unit":"Milliseconds"}],"Namespace":"App"}],"Timestamp":1704067200000},"service":"api","latency_ms":12.5,"bytes":"unknown"}`,
+		},
+		{name: "Don't wrap log event without metrics", message: `{"service":"api","level":"INFO"}`, want: `{"service":"api","level":"INFO"}`},
+		{name: "Don't wrap log event without dimensions", message: `{"latency_ms":12.5}`, want: `{"latency_ms":12.5}`},
+		{name: "Don't wrap log event with non-string dimension", message: `{"service":1,"latency_ms":12.5}`, want: `{"service":1,"latency_ms":12.5}`},
+		{name: "Don't wrap EMF log event", message: `{"_aws":{},"service":"api","latency_ms":12.5}`, want: `{"_aws":{},"service":"api","latency_ms":12.5}`},
+		{name: "Don't wrap text log event", message: `[INFO] latency_ms=12.5`, want: `[INFO] latency_ms=12.5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := f.format(types.InputLogEvent{Message: aws.String(tt.message), Timestamp: aws.Int64(1704067200000)})
+			if aws.ToString(got.Message) != tt.want {
+				t.Errorf("emfFormat.format() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// emulatorRegion is the region used for emulators such as LocalStack if no region is configured.
+const emulatorRegion = "us-east-1"
+
+// isEmulatorURL reports whether the endpoint URL looks like an emulator, such as LocalStack. Endpoints of AWS are
+// served only over HTTPS, so other endpoints on the loopback or the port of LocalStack count only over HTTP,
+// not to take SSH tunnels or proxies to AWS for emulators.
+func isEmulatorURL(endpointURL string) bool {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localstack" || host == "localhost.localstack.cloud" || strings.HasSuffix(host, ".localhost.localstack.cloud") {
+		return true
+	}
+	if u.Scheme != "http" {
+		return false
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	// 4566 is the default port of LocalStack
+	return u.Port() == "4566"
+}
+
+// usesEmulator reports whether the endpoint of CloudWatch Logs given by the parameters or the environment variables is an emulator.
+func usesEmulator(params parameters) bool {
+	for _, endpointURL := range []string{
+		params.endpointURL,
+		os.Getenv("AWS_ENDPOINT_URL_CLOUDWATCH_LOGS"),
+		os.Getenv("AWS_ENDPOINT_URL_LOGS"),
+		os.Getenv("AWS_ENDPOINT_URL"),
+	} {
+		if endpointURL != "" {
+			return isEmulatorURL(endpointURL)
+		}
+	}
+	return false
+}
+
+// emulatorCredentials retrieves credentials from the default credential chain, and falls back to the static
+// credentials of emulators only if the chain finds none, so that profiles, SSO and instance credentials are kept.
+type emulatorCredentials struct {
+	provider aws.CredentialsProvider
+}
+
+func (c emulatorCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if c.provider != nil {
+		if creds, err := c.provider.Retrieve(ctx); err == nil {
+			return creds, nil
+		}
+	}
+	return credentials.NewStaticCredentialsProvider("test", "test", "").Retrieve(ctx)
+}
+
+// withQuickInstanceCredentials looks up credentials of the EC2 instance without retries in the same way as the region,
+// since emulators mostly run outside EC2, where IMDS is not reachable and emulatorCredentials falls back after it.
+func withQuickInstanceCredentials(o *ec2rolecreds.Options) {
+	o.Client = imds.New(imds.Options{Retryer: aws.NopRetryer{}, HTTPClient: &http.Client{Timeout: imdsRegionTimeout}})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func Test_isEmulatorURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpointURL string
+		want        bool
+	}{
+		{name: "localhost", endpointURL: "http://localhost:4566", want: true},
+		{name: "Loopback address", endpointURL: "http://127.0.0.1:8000", want: true},
+		{name: "LocalStack container", endpointURL: "http://localstack:4566", want: true},
+		{name: "LocalStack domain", endpointURL: "https://logs.us-east-1.localhost.localstack.cloud:4566", want: true},
+		{name: "LocalStack port", endpointURL: "http://192.168.0.10:4566", want: true},
+		{name: "SSH tunnel to AWS", endpointURL: "https://localhost:8443", want: false},
+		{name: "Proxy to AWS on LocalStack port", endpointURL: "https://127.0.0.1:4566", want: false},
+		{name: "AWS endpoint", endpointURL: "https://logs.us-east-1.amazonaws.com", want: false},
+		{name: "VPC endpoint", endpointURL: "https://logs.internal.example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmulatorURL(tt.endpointURL); got != tt.want {
+				t.Errorf("isEmulatorURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadConfig_emulator(t *testing.T) {
+	setUpAWSConfig(t, "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	cfg, err := loadConfig(parameters{endpointURL: "http://localhost:4566"})
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.Region != emulatorRegion {
+		t.Errorf("loadConfig() region = %v, want %v", cfg.Region, emulatorRegion)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("loadConfig() credentials error = %v", err)
+	}
+	if creds.AccessKeyID != "test" {
+		t.Errorf("loadConfig() access key = %v, want %v", creds.AccessKeyID, "test")
+	}
+}
+
+func Test_loadConfig_emulatorWithProfile(t *testing.T) {
+	setUpAWSConfig(t, "[default]\naws_access_key_id = AKIDPROFILE\naws_secret_access_key = secret\n")
+
+	cfg, err := loadConfig(parameters{endpointURL: "http://localhost:4566"})
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("loadConfig() credentials error = %v", err)
+	}
+	if creds.AccessKeyID != "AKIDPROFILE" {
+		t.Errorf("loadConfig() access key = %v, want %v", creds.AccessKeyID, "AKIDPROFILE")
+	}
+}
+
+func Test_newClient_noSignRequest(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	authorization := "unknown"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"logGroups":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := newClient(parameters{endpointURL: server.URL, noSignRequest: true}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	if _, err := client.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{}); err != nil {
+		t.Fatalf("DescribeLogGroups() error = %v", err)
+	}
+	if authorization != "" {
+		t.Errorf("newClient() signed the request with %v", authorization)
+	}
+}
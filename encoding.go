@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// inputEncodingUTF8 is the default encoding of input, which is not transcoded.
+const inputEncodingUTF8 = "utf-8"
+
+// inputEncodings are the encodings of input transcoded into UTF-8 with --input-encoding.
+var inputEncodings = map[string]encoding.Encoding{
+	inputEncodingUTF8: encoding.Nop,
+	"shift-jis":       japanese.ShiftJIS,
+	"euc-jp":          japanese.EUCJP,
+	"latin-1":         charmap.ISO8859_1,
+	"windows-1252":    charmap.Windows1252,
+	"utf-16":          unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16le":        unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":        unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+func validateInputEncoding(name string) error {
+	if _, ok := inputEncodings[strings.ToLower(name)]; ok {
+		return nil
+	}
+	names := make([]string, 0, len(inputEncodings))
+	for name := range inputEncodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("argument error: --input-encoding must be %s, but got %s", strings.Join(names, ", "), name)
+}
+
+// newInputDecoder returns the transformer from the encoding into UTF-8. A leading BOM is stripped, and it switches
+// the decoding to UTF-8 or UTF-16 of the BOM whatever the encoding is, so that BOMs do not remain in log events.
+func newInputDecoder(name string) transform.Transformer {
+	e, ok := inputEncodings[strings.ToLower(name)]
+	if !ok {
+		e = encoding.Nop
+	}
+	return unicode.BOMOverride(e.NewDecoder())
+}
+
+// decodeInput transcodes the input in the encoding into UTF-8.
+func decodeInput(data []byte, name string) ([]byte, error) {
+	decoded, _, err := transform.Bytes(newInputDecoder(name), data)
+	if err != nil {
+		return nil, fmt.Errorf("encoding error: failed to decode input as %s: %w", name, err)
+	}
+	return decoded, nil
+}
+
+// newDecodingReader returns the reader which transcodes the input in the encoding into UTF-8 while reading it.
+func newDecodingReader(r io.Reader, name string) io.Reader {
+	return transform.NewReader(r, newInputDecoder(name))
+}
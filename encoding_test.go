@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func Test_decodeInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		want     string
+	}{
+		{name: "Decode UTF-8", data: []byte("[INFO] ログ\n"), encoding: "utf-8", want: "[INFO] ログ\n"},
+		{name: "Decode UTF-8 with BOM", data: []byte("\xef\xbb\xbf[INFO] Start\n"), encoding: "utf-8", want: "[INFO] Start\n"},
+		{name: "Decode Shift_JIS", data: []byte("[INFO] \x83\x8d\x83\x4f\n"), encoding: "shift-jis", want: "[INFO] ログ\n"},
+		{name: "Decode Latin-1", data: []byte("caf\xe9\n"), encoding: "LATIN-1", want: "café\n"},
+		{name: "Decode UTF-16 with BOM", data: []byte("\xfe\xff\x00h\x00i"), encoding: "utf-16", want: "hi"},
+		{name: "Decode UTF-16LE", data: []byte("h\x00i\x00"), encoding: "utf-16le", want: "hi"},
+		{name: "Decode UTF-16 with BOM as UTF-8", data: []byte("\xff\xfeh\x00i\x00"), encoding: "utf-8", want: "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeInput(tt.data, tt.encoding)
+			if err != nil {
+				t.Fatalf("decodeInput() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeInput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newDecodingReader(t *testing.T) {
+	r := newDecodingReader(bytes.NewReader([]byte("\x83\x8d\x83\x4f\n")), "shift-jis")
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("newDecodingReader() error = %v", err)
+	}
+	if string(got) != "ログ\n" {
+		t.Errorf("newDecodingReader() = %q, want %q", got, "ログ\n")
+	}
+}
+
+func Test_validateInputEncoding(t *testing.T) {
+	for _, name := range []string{"utf-8", "Shift-JIS", "latin-1", "utf-16"} {
+		if err := validateInputEncoding(name); err != nil {
+			t.Errorf("validateInputEncoding(%s) error = %v", name, err)
+		}
+	}
+	if err := validateInputEncoding("ebcdic"); err == nil {
+		t.Errorf("validateInputEncoding(ebcdic) error = nil, want error")
+	}
+}
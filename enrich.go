@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The sources of metadata injected into log events
+const (
+	enrichEC2   = "ec2"
+	enrichECS   = "ecs"
+	enrichTrace = "trace"
+)
+
+// traceIDField is the field of the trace ID injected by --enrich trace.
+const traceIDField = "trace_id"
+
+// defaultTraceHeaderEnv is the environment variable where Lambda and the X-Ray SDKs set the X-Ray trace header.
+const defaultTraceHeaderEnv = "_X_AMZN_TRACE_ID"
+
+// metadataTimeout limits getting the metadata, so that it fails soon outside EC2 or ECS.
+const metadataTimeout = 5 * time.Second
+
+func validateEnrich(source string) error {
+	switch source {
+	case "", enrichEC2, enrichECS, enrichTrace:
+		return nil
+	}
+	return fmt.Errorf("argument error: --enrich must be %s, %s or %s, but got %s", enrichEC2, enrichECS, enrichTrace, source)
+}
+
+// ec2Metadata is the identity of the EC2 instance injected into log events.
+type ec2Metadata struct {
+	InstanceID       string            `json:"instanceId"`
+	AvailabilityZone string            `json:"availabilityZone"`
+	InstanceType     string            `json:"instanceType"`
+	Tags             map[string]string `json:"tags,omitempty"`
+}
+
+// getEC2Metadata gets the identity and the tags of the instance with IMDSv2.
+// Tags are empty if they are not allowed in the instance metadata.
+func getEC2Metadata(ctx context.Context, client *imds.Client) (ec2Metadata, error) {
+	doc, err := client.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
+	if err != nil {
+		return ec2Metadata{}, err
+	}
+	metadata := ec2Metadata{
+		InstanceID:       doc.InstanceID,
+		AvailabilityZone: doc.AvailabilityZone,
+		InstanceType:     doc.InstanceType,
+	}
+
+	keys, err := getMetadataString(ctx, client, "tags/instance")
+	if err != nil {
+		var statusErr interface{ HTTPStatusCode() int }
+		if errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == 404 {
+			return metadata, nil
+		}
+		return ec2Metadata{}, err
+	}
+	metadata.Tags = map[string]string{}
+	for _, key := range strings.Fields(keys) {
+		value, err := getMetadataString(ctx, client, "tags/instance/"+key)
+		if err != nil {
+			return ec2Metadata{}, err
+		}
+		metadata.Tags[key] = value
+	}
+	return metadata, nil
+}
+
+func getMetadataString(ctx context.Context, client *imds.Client, path string) (string, error) {
+	out, err := client.GetMetadata(ctx, &imds.GetMetadataInput{Path: path})
+	if err != nil {
+		return "", err
+	}
+	defer out.Content.Close()
+	data, err := ioutil.ReadAll(out.Content)
+	return string(data), err
+}
+
+// ecsMetadata is the identity of the ECS task and the container injected into log events.
+type ecsMetadata struct {
+	Cluster       string `json:"cluster"`
+	TaskARN       string `json:"taskArn"`
+	ContainerName string `json:"containerName"`
+	Image         string `json:"image"`
+}
+
+// ecsMetadataEndpoint returns the container metadata endpoint set by the ECS agent. It prefers the version 4.
+func ecsMetadataEndpoint() string {
+	if endpoint := os.Getenv("ECS_CONTAINER_METADATA_URI_V4"); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("ECS_CONTAINER_METADATA_URI")
+}
+
+// getECSMetadata gets the task and the container running this tool from the container metadata endpoint.
+func getECSMetadata(ctx context.Context, endpoint string) (ecsMetadata, error) {
+	if endpoint == "" {
+		return ecsMetadata{}, errors.New("ECS_CONTAINER_METADATA_URI_V4 is not set")
+	}
+	var container struct {
+		Name  string
+		Image string
+	}
+	if err := getMetadataJSON(ctx, endpoint, &container); err != nil {
+		return ecsMetadata{}, err
+	}
+	var task struct {
+		Cluster string
+		TaskARN string
+	}
+	if err := getMetadataJSON(ctx, endpoint+"/task", &task); err != nil {
+		return ecsMetadata{}, err
+	}
+	return ecsMetadata{
+		Cluster:       task.Cluster,
+		TaskARN:       task.TaskARN,
+		ContainerName: container.Name,
+		Image:         container.Image,
+	}, nil
+}
+
+func getMetadataJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// traceparentPattern matches the W3C traceparent header, whose second part is the trace ID.
+// See https://www.w3.org/TR/trace-context/#traceparent-header
+var traceparentPattern = regexp.MustCompile(`\b00-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}\b`)
+
+// parseTraceHeader returns the trace ID in the X-Ray trace header, such as Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1,
+// or in the W3C traceparent header.
+func parseTraceHeader(header string) (string, bool) {
+	for _, part := range strings.Split(header, ";") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok && key == "Root" && value != "" {
+			return value, true
+		}
+	}
+	return findTraceparent(header)
+}
+
+// findTraceparent returns the trace ID of the W3C traceparent in the message.
+func findTraceparent(message string) (string, bool) {
+	m := traceparentPattern.FindStringSubmatch(message)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// enricher injects the metadata into log events as the field. The nil enricher does not change log events.
+type enricher struct {
+	field string
+	value json.RawMessage
+	// detectTraceparent uses the trace ID of the W3C traceparent in log events instead of value
+	detectTraceparent bool
+}
+
+// newEnricher gets the metadata of the source. If source is empty, it returns nil.
+// The trace source uses the trace header in the environment variable traceHeaderEnv.
+func newEnricher(source, traceHeaderEnv string) (*enricher, error) {
+	if source == "" {
+		return nil, nil
+	}
+	if source == enrichTrace {
+		e := &enricher{field: traceIDField, detectTraceparent: true}
+		header := os.Getenv(traceHeaderEnv)
+		if header == "" {
+			return e, nil
+		}
+		traceID, ok := parseTraceHeader(header)
+		if !ok {
+			return nil, fmt.Errorf("enrich error: %s has no X-Ray trace header or W3C traceparent: %s", traceHeaderEnv, header)
+		}
+		value, err := json.Marshal(traceID)
+		if err != nil {
+			return nil, err
+		}
+		e.value = value
+		return e, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+
+	var metadata interface{}
+	switch source {
+	case enrichEC2:
+		m, err := getEC2Metadata(ctx, imds.New(imds.Options{}))
+		if err != nil {
+			return nil, fmt.Errorf("enrich error: failed to get the metadata of the EC2 instance with IMDSv2. --enrich ec2 works only on EC2 instances: %w", err)
+		}
+		metadata = m
+	case enrichECS:
+		m, err := getECSMetadata(ctx, ecsMetadataEndpoint())
+		if err != nil {
+			return nil, fmt.Errorf("enrich error: failed to get the metadata of the ECS task from the container metadata endpoint. --enrich ecs works only in ECS tasks: %w", err)
+		}
+		metadata = m
+	}
+	value, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &enricher{field: source, value: value}, nil
+}
+
+// enrich returns the log event with the metadata. The metadata is added to JSON objects as the field,
+// and other log events are wrapped into JSON objects with the message field. The field in log events is not overwritten.
+// Log events are not changed if there is no metadata, such as no trace ID.
+func (e *enricher) enrich(event types.InputLogEvent) types.InputLogEvent {
+	if e == nil {
+		return event
+	}
+	value := e.value
+	if e.detectTraceparent {
+		if traceID, ok := findTraceparent(aws.ToString(event.Message)); ok {
+			value, _ = json.Marshal(traceID)
+		}
+	}
+	if value == nil {
+		return event
+	}
+	message := strings.TrimSpace(aws.ToString(event.Message))
+	fields := map[string]json.RawMessage{}
+	if strings.HasPrefix(message, "{") && json.Unmarshal([]byte(message), &fields) == nil {
+		if _, ok := fields[e.field]; ok {
+			return event
+		}
+		// The metadata is appended to keep the order of fields in the log event
+		body := strings.TrimSpace(message[1 : len(message)-1])
+		separator := ","
+		if body == "" {
+			separator = ""
+		}
+		event.Message = aws.String(fmt.Sprintf("{%s%s%q:%s}", body, separator, e.field, value))
+		return event
+	}
+
+	original, err := json.Marshal(aws.ToString(event.Message))
+	if err != nil {
+		return event
+	}
+	event.Message = aws.String(fmt.Sprintf(`{"message":%s,%q:%s}`, original, e.field, value))
+	return event
+}
+
+// enrichLogEvents returns log events with the metadata.
+func enrichLogEvents(events []types.InputLogEvent, e *enricher) []types.InputLogEvent {
+	if e == nil {
+		return events
+	}
+	enriched := make([]types.InputLogEvent, len(events))
+	for i, event := range events {
+		enriched[i] = e.enrich(event)
+	}
+	return enriched
+}
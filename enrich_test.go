@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// newIMDSServer returns the fake IMDSv2 server. If tags is nil, tags are not allowed in the instance metadata.
+func newIMDSServer(t *testing.T, tags map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+			w.Write([]byte("token"))
+			return
+		}
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token") != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/dynamic/instance-identity/document":
+			w.Write([]byte(`{"instanceId":"i-0123456789abcdef0","availabilityZone":"us-east-1a","instanceType":"t3.micro","region":"us-east-1"}`))
+		case "/latest/meta-data/tags/instance":
+			if tags == nil {
+				http.NotFound(w, r)
+				return
+			}
+			for key := range tags {
+				w.Write([]byte(key + "\n"))
+			}
+		default:
+			value, ok := tags[r.URL.Path[len("/latest/meta-data/tags/instance/"):]]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(value))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_newEnricher(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{
+			name: "Get metadata with tags",
+			tags: map[string]string{"Name": "web-1"},
+			want: `{"instanceId":"i-0123456789abcdef0","availabilityZone":"us-east-1a","instanceType":"t3.micro","tags":{"Name":"web-1"}}`,
+		},
+		{
+			name: "Get metadata without tags",
+			tags: nil,
+			want: `{"instanceId":"i-0123456789abcdef0","availabilityZone":"us-east-1a","instanceType":"t3.micro"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newIMDSServer(t, tt.tags)
+			t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", server.URL)
+			t.Setenv("AWS_EC2_METADATA_DISABLED", "")
+
+			e, err := newEnricher(enrichEC2, defaultTraceHeaderEnv)
+			if err != nil {
+				t.Fatalf("newEnricher() error = %v", err)
+			}
+			if got := string(e.value); got != tt.want {
+				t.Errorf("newEnricher() value = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// newECSMetadataServer returns the fake container metadata endpoint version 4.
+func newECSMetadataServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4/abc":
+			w.Write([]byte(`{"DockerId":"abc","Name":"log-router","Image":"public.ecr.aws/x-color/awsputlogs:latest","Labels":{}}`))
+		case "/v4/abc/task":
+			w.Write([]byte(`{"Cluster":"arn:aws:ecs:us-east-1:123456789012:cluster/default","TaskARN":"arn:aws:ecs:us-east-1:123456789012:task/default/0123","Family":"app"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_getECSMetadata(t *testing.T) {
+	server := newECSMetadataServer(t)
+	tests := []struct {
+		name     string
+		endpoint string
+		want     ecsMetadata
+		wantErr  bool
+	}{
+		{
+			name:     "Get metadata of the task and the container",
+			endpoint: server.URL + "/v4/abc",
+			want: ecsMetadata{
+				Cluster:       "arn:aws:ecs:us-east-1:123456789012:cluster/default",
+				TaskARN:       "arn:aws:ecs:us-east-1:123456789012:task/default/0123",
+				ContainerName: "log-router",
+				Image:         "public.ecr.aws/x-color/awsputlogs:latest",
+			},
+		},
+		{name: "Get metadata from unknown endpoint", endpoint: server.URL + "/v4/unknown", wantErr: true},
+		{name: "Get metadata outside ECS", endpoint: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getECSMetadata(context.Background(), tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getECSMetadata() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("getECSMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newEnricher_ecs(t *testing.T) {
+	server := newECSMetadataServer(t)
+	t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL+"/v4/abc")
+
+	e, err := newEnricher(enrichECS, defaultTraceHeaderEnv)
+	if err != nil {
+		t.Fatalf("newEnricher() error = %v", err)
+	}
+	got := e.enrich(types.InputLogEvent{Message: aws.String(`{"level":"INFO"}`)})
+	want := `{"level":"INFO","ecs":{"cluster":"arn:aws:ecs:us-east-1:123456789012:cluster/default","taskArn":"arn:aws:ecs:us-east-1:123456789012:task/default/0123","containerName":"log-router","image":"public.ecr.aws/x-color/awsputlogs:latest"}}`
+	if aws.ToString(got.Message) != want {
+		t.Errorf("enricher.enrich() = %s, want %s", aws.ToString(got.Message), want)
+	}
+}
+
+func Test_enricher_enrich(t *testing.T) {
+	e := &enricher{field: enrichEC2, value: []byte(`{"instanceId":"i-1"}`)}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "Enrich JSON log event", message: `{"level":"INFO","message":"Start"}`, want: `{"level":"INFO","message":"Start","ec2":{"instanceId":"i-1"}}`},
+		{name: "Enrich empty JSON log event", message: `{ }`, want: `{"ec2":{"instanceId":"i-1"}}`},
+		{name: "Enrich text log event", message: `[INFO] Start "Server"`, want: `{"message":"[INFO] Start \"Server\"","ec2":{"instanceId":"i-1"}}`},
+		{name: "Enrich JSON array log event", message: `[1, 2]`, want: `{"message":"[1, 2]","ec2":{"instanceId":"i-1"}}`},
+		{name: "Don't overwrite the field", message: `{"ec2":"local"}`, want: `{"ec2":"local"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.enrich(types.InputLogEvent{Message: aws.String(tt.message), Timestamp: aws.Int64(1)})
+			if aws.ToString(got.Message) != tt.want || aws.ToInt64(got.Timestamp) != 1 {
+				t.Errorf("enricher.enrich() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+
+	var nilEnricher *enricher
+	if got := nilEnricher.enrich(types.InputLogEvent{Message: aws.String("log")}); aws.ToString(got.Message) != "log" {
+		t.Errorf("enricher.enrich() on nil = %s, want log", aws.ToString(got.Message))
+	}
+}
+
+func Test_parseTraceHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOk bool
+	}{
+		{name: "Parse X-Ray trace header", header: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", want: "1-5759e988-bd862e3fe1be46a994272793", wantOk: true},
+		{name: "Parse X-Ray trace header with Root after Parent", header: "Parent=53995c3f42cd8ad8; Root=1-5759e988-bd862e3fe1be46a994272793", want: "1-5759e988-bd862e3fe1be46a994272793", wantOk: true},
+		{name: "Parse W3C traceparent", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736", wantOk: true},
+		{name: "Parse invalid header", header: "Parent=53995c3f42cd8ad8", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseTraceHeader(tt.header)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseTraceHeader() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_newEnricher_trace(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		message string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Inject trace ID in the trace header",
+			header:  "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1",
+			message: `{"level":"INFO"}`,
+			want:    `{"level":"INFO","trace_id":"1-5759e988-bd862e3fe1be46a994272793"}`,
+		},
+		{
+			name:    "Inject trace ID of traceparent in log event",
+			header:  "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1",
+			message: `GET /users traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`,
+			want:    `{"message":"GET /users traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+		{
+			name:    "Don't change log event without trace ID",
+			header:  "",
+			message: `{"level":"INFO"}`,
+			want:    `{"level":"INFO"}`,
+		},
+		{
+			name:    "Invalid trace header",
+			header:  "Sampled=1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_TRACE_HEADER", tt.header)
+			e, err := newEnricher(enrichTrace, "TEST_TRACE_HEADER")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newEnricher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := e.enrich(types.InputLogEvent{Message: aws.String(tt.message)})
+			if aws.ToString(got.Message) != tt.want {
+				t.Errorf("enricher.enrich() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The formats of log events written by get and tail
+const (
+	formatNDJSON = "ndjson"
+	formatText   = "text"
+	formatShort  = "short"
+	formatJSON   = "json"
+	formatRaw    = "raw"
+)
+
+// shortTimeLayout is the layout of timestamps in the short format, which omits the year and the time zone.
+const shortTimeLayout = "01-02 15:04:05.000"
+
+// ANSI escape sequences to render log events on the terminal, in addition to ones of the summary.
+const (
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// levelColors are the colors of log events by the level detected by detectLogLevel.
+var levelColors = map[string]string{
+	"TRACE": ansiDim,
+	"DEBUG": ansiDim,
+	"WARN":  ansiYellow,
+	"ERROR": ansiRed,
+	"FATAL": ansiBold + ansiRed,
+}
+
+// eventView is the way to render log events to read them, such as on the terminal.
+type eventView struct {
+	format   string
+	location *time.Location
+	// highlight matches the parts of messages to highlight. It is nil if nothing is highlighted.
+	highlight *regexp.Regexp
+	colors    palette
+	// source prefixes lines of the text and short formats with the log group and the log stream
+	source bool
+}
+
+// addEventViewFlags adds the flags of the view. The value of format is the default format.
+func addEventViewFlags(flags *flag.FlagSet, format, timezone, highlight *string, noColor *bool) {
+	flags.StringVar(format, "format", *format, "The format of log events. ndjson, text, short (time and message), json (indented, with JSON messages expanded) or raw (messages only).")
+	flags.StringVar(timezone, "timezone", "Local", "The time zone to render timestamps in text, short and json formats, such as UTC or Asia/Tokyo. If you do not use this parameters, the local time zone is used.")
+	flags.StringVar(highlight, "highlight", "", "The regular expression of parts of messages to highlight on the terminal.")
+	flags.BoolVar(noColor, "no-color", false, "Do not color log events by the level on the terminal. NO_COLOR environment variable also turns colors off.")
+}
+
+// parse sets the format, the time zone and the pattern to highlight given by flags.
+func (v *eventView) parse(format, timezone, highlight string) error {
+	switch format {
+	case formatNDJSON, formatText, formatShort, formatJSON, formatRaw:
+		v.format = format
+	default:
+		return fmt.Errorf("argument error: --format must be %s, %s, %s, %s or %s, but got %s", formatNDJSON, formatText, formatShort, formatJSON, formatRaw, format)
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("argument error: --timezone is invalid: %w", err)
+	}
+	v.location = location
+
+	if highlight != "" {
+		if v.highlight, err = regexp.Compile(highlight); err != nil {
+			return fmt.Errorf("argument error: --highlight is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// time returns the timestamp of the log event in the time zone of the view.
+func (v eventView) time(ms int64) time.Time {
+	t := fromMillis(ms)
+	if v.location != nil {
+		t = t.In(v.location)
+	}
+	return t
+}
+
+// prefix returns the time and, if the source is shown, the log group and the log stream of the log event.
+func (v eventView) prefix(event storedEvent, layout string) string {
+	prefix := v.colors.paint(ansiDim, v.time(event.Timestamp).Format(layout))
+	if v.source {
+		prefix += " " + v.colors.paint(ansiCyan, event.LogGroup+"/"+event.LogStream)
+	}
+	return prefix
+}
+
+// message colors the message by its level, and highlights the parts matching --highlight.
+func (v eventView) message(message string) string {
+	if !v.colors.enabled {
+		return message
+	}
+	color := levelColors[detectLogLevel(message)]
+	if v.highlight == nil {
+		return v.colors.paint(color, message)
+	}
+
+	b := &strings.Builder{}
+	last := 0
+	for _, loc := range v.highlight.FindAllStringIndex(message, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		b.WriteString(v.colors.paint(color, message[last:loc[0]]))
+		b.WriteString(v.colors.paint(ansiReverse+color, message[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(v.colors.paint(color, message[last:]))
+	return b.String()
+}
+
+// renderedEvent is a log event written in the json format. The message is expanded if it is JSON.
+type renderedEvent struct {
+	Timestamp     string      `json:"timestamp"`
+	LogGroup      string      `json:"logGroup,omitempty"`
+	LogStream     string      `json:"logStream"`
+	Message       interface{} `json:"message"`
+	IngestionTime string      `json:"ingestionTime"`
+}
+
+// newEventWriter returns the function writing a log event in the format of the view.
+func newEventWriter(w io.Writer, v eventView) func(storedEvent) error {
+	switch v.format {
+	case formatText:
+		return func(event storedEvent) error {
+			_, err := fmt.Fprintf(w, "%s %s\n", v.prefix(event, time.RFC3339Nano), v.message(event.Message))
+			return err
+		}
+	case formatShort:
+		return func(event storedEvent) error {
+			_, err := fmt.Fprintf(w, "%s %s\n", v.prefix(event, shortTimeLayout), v.message(strings.TrimRight(event.Message, "\n")))
+			return err
+		}
+	case formatJSON:
+		return func(event storedEvent) error {
+			var message interface{} = event.Message
+			if json.Valid([]byte(event.Message)) && strings.HasPrefix(strings.TrimSpace(event.Message), "{") {
+				message = json.RawMessage(event.Message)
+			}
+			return writeJSON(w, renderedEvent{
+				Timestamp:     v.time(event.Timestamp).Format(time.RFC3339Nano),
+				LogGroup:      event.LogGroup,
+				LogStream:     event.LogStream,
+				Message:       message,
+				IngestionTime: v.time(event.IngestionTime).Format(time.RFC3339Nano),
+			})
+		}
+	case formatRaw:
+		return func(event storedEvent) error {
+			_, err := fmt.Fprintln(w, event.Message)
+			return err
+		}
+	}
+	enc := json.NewEncoder(w)
+	return func(event storedEvent) error {
+		return enc.Encode(event)
+	}
+}
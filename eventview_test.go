@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_eventView_parse(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		timezone  string
+		highlight string
+		wantErr   bool
+	}{
+		{name: "Parse short format in UTC", format: formatShort, timezone: "UTC", highlight: "timeout|refused"},
+		{name: "Parse raw format in local time", format: formatRaw, timezone: "Local"},
+		{name: "Parse unknown format", format: "yaml", timezone: "UTC", wantErr: true},
+		{name: "Parse unknown time zone", format: formatShort, timezone: "Mars/Olympus", wantErr: true},
+		{name: "Parse invalid highlight", format: formatShort, timezone: "UTC", highlight: "(timeout", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := eventView{}
+			if err := v.parse(tt.format, tt.timezone, tt.highlight); (err != nil) != tt.wantErr {
+				t.Errorf("eventView.parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_newEventWriter(t *testing.T) {
+	events := []storedEvent{
+		{Timestamp: 1704164645000, Message: "[ERROR] connection timeout", LogStream: "api", IngestionTime: 1704164646000},
+		{Timestamp: 1704164646500, Message: `{"level":"info","message":"Start"}`, LogStream: "api", IngestionTime: 1704164647000},
+	}
+	tests := []struct {
+		name      string
+		format    string
+		timezone  string
+		highlight string
+		colored   bool
+		source    bool
+		want      string
+	}{
+		{
+			name:     "Write ndjson",
+			format:   formatNDJSON,
+			timezone: "UTC",
+			want: `{"timestamp":1704164645000,"message":"[ERROR] connection timeout","logStream":"api","ingestionTime":1704164646000}` + "\n" +
+				`{"timestamp":1704164646500,"message":"{\"level\":\"info\",\"message\":\"Start\"}","logStream":"api","ingestionTime":1704164647000}` + "\n",
+		},
+		{
+			name:     "Write text in time zone",
+			format:   formatText,
+			timezone: "Asia/Tokyo",
+			want:     "2024-01-02T12:04:05+09:00 [ERROR] connection timeout\n2024-01-02T12:04:06.5+09:00 {\"level\":\"info\",\"message\":\"Start\"}\n",
+		},
+		{
+			name:     "Write short",
+			format:   formatShort,
+			timezone: "UTC",
+			want:     "01-02 03:04:05.000 [ERROR] connection timeout\n01-02 03:04:06.500 {\"level\":\"info\",\"message\":\"Start\"}\n",
+		},
+		{
+			name:      "Write short with colors and highlight",
+			format:    formatShort,
+			timezone:  "UTC",
+			highlight: "timeout",
+			colored:   true,
+			want: "\x1b[2m01-02 03:04:05.000\x1b[0m \x1b[31m[ERROR] connection \x1b[0m\x1b[7m\x1b[31mtimeout\x1b[0m\n" +
+				"\x1b[2m01-02 03:04:06.500\x1b[0m {\"level\":\"info\",\"message\":\"Start\"}\n",
+		},
+		{
+			name:     "Write short with log groups and log streams",
+			format:   formatShort,
+			timezone: "UTC",
+			source:   true,
+			want:     "01-02 03:04:05.000 /app/api/api [ERROR] connection timeout\n01-02 03:04:06.500 /app/api/api {\"level\":\"info\",\"message\":\"Start\"}\n",
+		},
+		{
+			name:     "Write json",
+			format:   formatJSON,
+			timezone: "UTC",
+			want: "{\n  \"timestamp\": \"2024-01-02T03:04:05Z\",\n  \"logStream\": \"api\",\n  \"message\": \"[ERROR] connection timeout\",\n  \"ingestionTime\": \"2024-01-02T03:04:06Z\"\n}\n" +
+				"{\n  \"timestamp\": \"2024-01-02T03:04:06.5Z\",\n  \"logStream\": \"api\",\n  \"message\": {\n    \"level\": \"info\",\n    \"message\": \"Start\"\n  },\n  \"ingestionTime\": \"2024-01-02T03:04:07Z\"\n}\n",
+		},
+		{
+			name:     "Write raw",
+			format:   formatRaw,
+			timezone: "UTC",
+			colored:  true,
+			want:     "[ERROR] connection timeout\n{\"level\":\"info\",\"message\":\"Start\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := eventView{colors: palette{enabled: tt.colored}, source: tt.source}
+			if err := v.parse(tt.format, tt.timezone, tt.highlight); err != nil {
+				t.Fatalf("eventView.parse() error = %v", err)
+			}
+			buf := &bytes.Buffer{}
+			write := newEventWriter(buf, v)
+			for _, event := range events {
+				if tt.source {
+					event.LogGroup = "/app/api"
+				}
+				if err := write(event); err != nil {
+					t.Fatalf("write() error = %v", err)
+				}
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("newEventWriter() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// existingEventKey is the key of a log event compared with ones stored in the log stream.
+func existingEventKey(timestamp int64, message string) string {
+	return strconv.FormatInt(timestamp, 10) + "\x00" + message
+}
+
+// timeRangeOf returns the oldest and the newest timestamps of log events.
+func timeRangeOf(events []types.InputLogEvent) (int64, int64) {
+	oldest, newest := int64(0), int64(0)
+	for i, event := range events {
+		timestamp := aws.ToInt64(event.Timestamp)
+		if i == 0 || timestamp < oldest {
+			oldest = timestamp
+		}
+		if i == 0 || timestamp > newest {
+			newest = timestamp
+		}
+	}
+	return oldest, newest
+}
+
+// getExistingEvents returns the number of log events stored in the log stream by the timestamp and the message,
+// within the time range of log events.
+func getExistingEvents(client *cloudwatchlogs.Client, dst destination, events []types.InputLogEvent) (map[string]int, error) {
+	existing := map[string]int{}
+	if len(events) == 0 {
+		return existing, nil
+	}
+	oldest, newest := timeRangeOf(events)
+	q := eventQuery{
+		logGroup:  dst.logGroup,
+		logStream: dst.logStream,
+		start:     fromMillis(oldest),
+		// The end time is exclusive
+		end: fromMillis(newest + 1),
+	}
+	err := fetchLogEvents(client, q, func(event storedEvent) error {
+		existing[existingEventKey(event.Timestamp, event.Message)]++
+		return nil
+	})
+	return existing, err
+}
+
+// skipExistingEvents returns log events not stored in the log stream.
+// Each stored log event matches only one log event, so identical log events are put as many times as they are missing.
+func skipExistingEvents(events []types.InputLogEvent, existing map[string]int) []types.InputLogEvent {
+	remaining := make(map[string]int, len(existing))
+	for key, n := range existing {
+		remaining[key] = n
+	}
+
+	missing := []types.InputLogEvent{}
+	for _, event := range events {
+		key := existingEventKey(aws.ToInt64(event.Timestamp), aws.ToString(event.Message))
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		missing = append(missing, event)
+	}
+	return missing
+}
+
+// putSkippingExisting puts log events not stored in the log stream of each uploader yet.
+func putSkippingExisting(ctx context.Context, uploaders []*uploader, events []types.InputLogEvent) {
+	for _, u := range uploaders {
+		existing, err := getExistingEvents(u.client, u.dst, events)
+		if err != nil {
+			u.err = err
+			continue
+		}
+		pending := skipExistingEvents(events, existing)
+		if skipped := len(events) - len(pending); skipped > 0 {
+			fmt.Printf("Skipped %d log events already in %s\n", skipped, u.dst)
+		}
+		u.err = u.put(ctx, pending)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func testEvent(timestamp int64, message string) types.InputLogEvent {
+	return types.InputLogEvent{Timestamp: aws.Int64(timestamp), Message: aws.String(message)}
+}
+
+func Test_skipExistingEvents(t *testing.T) {
+	events := []types.InputLogEvent{
+		testEvent(1000, "[INFO] Start Server"),
+		testEvent(2000, "[INFO] Retry"),
+		testEvent(2000, "[INFO] Retry"),
+		testEvent(3000, "[ERROR] Failed to Start Server"),
+	}
+	tests := []struct {
+		name     string
+		existing map[string]int
+		want     []types.InputLogEvent
+	}{
+		{name: "Skip no log events", existing: map[string]int{}, want: events},
+		{
+			name:     "Skip existing log events",
+			existing: map[string]int{existingEventKey(1000, "[INFO] Start Server"): 1, existingEventKey(3000, "[ERROR] Failed to Start Server"): 1},
+			want:     events[1:3],
+		},
+		{
+			name:     "Skip identical log events as many times as they exist",
+			existing: map[string]int{existingEventKey(2000, "[INFO] Retry"): 1},
+			want:     []types.InputLogEvent{events[0], events[2], events[3]},
+		},
+		{
+			name:     "Don't skip log events with the same message at another time",
+			existing: map[string]int{existingEventKey(5000, "[INFO] Start Server"): 1},
+			want:     events,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skipExistingEvents(events, tt.existing); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("skipExistingEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_putSkippingExisting(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 1)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	// The log stream has a part of log events, and one outside the time range of the file
+	base := toMillis(time.Now().Add(-time.Hour))
+	in := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStreams[0]),
+		LogEvents: []types.InputLogEvent{
+			testEvent(base, "[INFO] Start Server"),
+			testEvent(base+1000, "[INFO] Connect DB"),
+			testEvent(base+9000, "[INFO] Stop Server"),
+		},
+	}
+	if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	u, err := newUploader(cli, destination{logGroup: logGroup, logStream: logStreams[0]}, "", defaultBatchLimits, nil)
+	if err != nil {
+		t.Fatalf("newUploader() error = %v", err)
+	}
+	events := []types.InputLogEvent{
+		testEvent(base, "[INFO] Start Server"),
+		testEvent(base+1000, "[INFO] Connect DB"),
+		testEvent(base+2000, "[ERROR] Failed to Connect DB"),
+	}
+	putSkippingExisting(context.Background(), []*uploader{u}, events)
+	if u.err != nil {
+		t.Fatalf("putSkippingExisting() error = %v", u.err)
+	}
+
+	got := []string{}
+	err = fetchLogEvents(cli, eventQuery{logGroup: logGroup, logStream: logStreams[0]}, func(event storedEvent) error {
+		got = append(got, event.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to get log events: %v", err)
+	}
+	want := []string{"[INFO] Start Server", "[INFO] Connect DB", "[ERROR] Failed to Connect DB", "[INFO] Stop Server"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("putSkippingExisting() stored %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// exportPollInterval is the interval to check if the export task is completed.
+var exportPollInterval = 5 * time.Second
+
+type exportOptions struct {
+	logGroup string
+	from     time.Time
+	to       time.Time
+	bucket   string
+	prefix   string
+}
+
+func createExportTask(ctx context.Context, client *cloudwatchlogs.Client, opts exportOptions) (string, error) {
+	in := &cloudwatchlogs.CreateExportTaskInput{
+		LogGroupName: aws.String(opts.logGroup),
+		From:         aws.Int64(toMillis(opts.from)),
+		To:           aws.Int64(toMillis(opts.to)),
+		Destination:  aws.String(opts.bucket),
+	}
+	if opts.prefix != "" {
+		in.DestinationPrefix = aws.String(opts.prefix)
+	}
+	out, err := client.CreateExportTask(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.TaskId), nil
+}
+
+// waitExportTask waits until the export task finishes. It fails if the export task does not complete,
+// or returns the error of ctx if ctx is done first.
+func waitExportTask(ctx context.Context, client *cloudwatchlogs.Client, taskID string) error {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+	for {
+		out, err := client.DescribeExportTasks(ctx, &cloudwatchlogs.DescribeExportTasksInput{
+			TaskId: aws.String(taskID),
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if len(out.ExportTasks) == 0 {
+			return fmt.Errorf("export error: the export task %s is not found", taskID)
+		}
+
+		status := out.ExportTasks[0].Status
+		if status != nil {
+			switch status.Code {
+			case types.ExportTaskStatusCodeCompleted:
+				return nil
+			case types.ExportTaskStatusCodeCancelled, types.ExportTaskStatusCodeFailed:
+				return fmt.Errorf("export error: the export task %s is %s: %s", taskID, status.Code, aws.ToString(status.Message))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func runExport(args []string) error {
+	params := parameters{}
+	opts := exportOptions{}
+	from, to := "", "now"
+	wait := false
+	waitTimeout := time.Hour
+
+	flags := newSubcommandFlagSet(args[0], "Export log events in the log group to S3.", "--log-group <LOG GROUP NAME> --s3-bucket <BUCKET NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&opts.logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&from, "from", "", "The start of the time range. now, duration before now such as 2h or 7d, RFC3339 or 2006-01-02. If you do not use this parameters, it exports log events from the beginning.")
+	flags.StringVar(&to, "to", "now", "The end of the time range. now, duration before now such as 2h or 7d, RFC3339 or 2006-01-02.")
+	flags.StringVar(&opts.bucket, "s3-bucket", "", "The name of the S3 bucket where log events are exported. It is required.")
+	flags.StringVar(&opts.prefix, "s3-prefix", "", "The prefix of S3 objects where log events are exported.")
+	flags.BoolVar(&wait, "wait", false, "Wait until the export task is completed. SIGINT and SIGTERM stop waiting, but not the export task.")
+	flags.DurationVar(&waitTimeout, "wait-timeout", time.Hour, "The maximum time to wait for the export task with --wait, such as 30m. 0 waits without the limit.")
+	flags.Parse(args[1:])
+
+	if opts.logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if opts.bucket == "" {
+		return errors.New("argument error: --s3-bucket is required")
+	}
+	if waitTimeout < 0 {
+		return fmt.Errorf("argument error: --wait-timeout must not be negative, but got %s", waitTimeout)
+	}
+	var err error
+	opts.from, opts.to, err = parseTimeRange(from, to)
+	if err != nil {
+		return err
+	}
+	if opts.from.IsZero() {
+		opts.from = time.Unix(0, 0)
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	// Stop waiting on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	taskID, err := createExportTask(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created export task %s\n", taskID)
+
+	if !wait {
+		return nil
+	}
+	if waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+	}
+	err = waitExportTask(ctx, client, taskID)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("export error: the export task %s is not completed in %s, but it is still running", taskID, waitTimeout)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("export error: stopped waiting for the export task %s, but it is still running", taskID)
+	case err != nil:
+		return err
+	}
+	fmt.Printf("Completed export task %s\n", taskID)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_createExportTask(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    exportOptions
+		wantErr bool
+	}{
+		{
+			name: "Create export task",
+			opts: exportOptions{logGroup: logGroup, from: time.Unix(0, 0), to: time.Now(), bucket: "logs", prefix: "api"},
+		},
+		{
+			name:    "Create export task of unknown log group",
+			opts:    exportOptions{logGroup: "unknown", from: time.Unix(0, 0), to: time.Now(), bucket: "logs"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createExportTask(context.Background(), cli, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createExportTask() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got == "" {
+				t.Error("createExportTask() returned no task ID")
+			}
+		})
+	}
+}
+
+func Test_waitExportTask(t *testing.T) {
+	defer func(d time.Duration) { exportPollInterval = d }(exportPollInterval)
+	exportPollInterval = time.Millisecond
+
+	fake := fakelogs.New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		status  string
+		timeout time.Duration
+		want    error
+		wantErr bool
+	}{
+		{name: "Wait export task until it is completed"},
+		{name: "Wait failed export task", status: fakelogs.ExportTaskFailed, wantErr: true},
+		{name: "Wait cancelled export task", status: fakelogs.ExportTaskCancelled, wantErr: true},
+		{name: "Wait stuck export task", status: fakelogs.ExportTaskRunning, timeout: 50 * time.Millisecond, want: context.DeadlineExceeded, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID, err := createExportTask(context.Background(), cli, exportOptions{logGroup: logGroup, from: time.Unix(0, 0), to: time.Now(), bucket: "logs"})
+			if err != nil {
+				t.Fatalf("failed to set up: %v", err)
+			}
+			if tt.status != "" {
+				fake.SetExportTaskStatus(taskID, tt.status, "stopped by the test")
+			}
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			err = waitExportTask(ctx, cli, taskID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("waitExportTask() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("waitExportTask() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Wait unknown export task", func(t *testing.T) {
+		if err := waitExportTask(context.Background(), cli, "unknown"); err == nil {
+			t.Error("waitExportTask() error = nil, want error")
+		}
+	})
+}
+
+func Test_runExport(t *testing.T) {
+	defer func(d time.Duration) { exportPollInterval = d }(exportPollInterval)
+	exportPollInterval = time.Millisecond
+
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "Export log events", args: []string{"--log-group", logGroup, "--s3-bucket", "logs", "--from", "1d"}},
+		{name: "Export log events and wait", args: []string{"--log-group", logGroup, "--s3-bucket", "logs", "--wait", "--wait-timeout", "10s"}},
+		{name: "Export log events of unknown log group", args: []string{"--log-group", "unknown", "--s3-bucket", "logs", "--wait"}, wantErr: true},
+		{name: "Export log events without bucket", args: []string{"--log-group", logGroup}, wantErr: true},
+		{name: "Export log events with negative wait timeout", args: []string{"--log-group", logGroup, "--s3-bucket", "logs", "--wait", "--wait-timeout", "-1s"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"export", "--endpoint-url", server.URL, "--region", "us-east-1"}, tt.args...)
+			if err := runExport(args); (err != nil) != tt.wantErr {
+				t.Errorf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
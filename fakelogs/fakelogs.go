@@ -0,0 +1,955 @@
+// Package fakelogs is an in-process fake of the subset of the CloudWatch Logs API used by awsputlogs.
+//
+// Server keeps log groups, log streams and log events in memory, and serves CreateLogGroup, CreateLogStream,
+// DeleteLogGroup, DeleteLogStream, DescribeLogGroups, DescribeLogStreams, PutLogEvents, GetLogEvents,
+// FilterLogEvents, CreateExportTask, DescribeExportTasks, PutDataProtectionPolicy, GetDataProtectionPolicy,
+// DeleteDataProtectionPolicy, PutMetricFilter, DescribeMetricFilters, DeleteMetricFilter, PutRetentionPolicy and
+// DeleteRetentionPolicy over HTTP with the JSON protocol of the AWS SDKs. It can be used in Go tests with httptest:
+//
+//	server := httptest.NewServer(fakelogs.New())
+//	defer server.Close()
+//	client := cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+//		o.BaseEndpoint = aws.String(server.URL)
+//	})
+//
+// or by the fake-server command of awsputlogs with --endpoint-url.
+package fakelogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The limits of PutLogEvents checked by Server.
+const (
+	maxBatchEvents     = 10000
+	maxBatchBytes      = 1048576
+	eventOverheadBytes = 26
+	maxBatchSpan       = 24 * time.Hour
+	// Log events older than maxEventAge or newer than maxEventLead are rejected
+	maxEventAge  = 14 * 24 * time.Hour
+	maxEventLead = 2 * time.Hour
+)
+
+// targetPrefix is the prefix of the X-Amz-Target header of CloudWatch Logs.
+const targetPrefix = "Logs_20140328."
+
+// Event is a log event stored in a log stream.
+type Event struct {
+	LogStreamName string `json:"logStreamName,omitempty"`
+	Timestamp     int64  `json:"timestamp"`
+	Message       string `json:"message"`
+	IngestionTime int64  `json:"ingestionTime"`
+	EventID       string `json:"eventId,omitempty"`
+}
+
+type logStream struct {
+	name         string
+	creationTime int64
+	events       []Event
+	// token is the sequence token returned by the last PutLogEvents call
+	token int
+}
+
+type logGroup struct {
+	name         string
+	creationTime int64
+	streams      map[string]*logStream
+	// retentionInDays is the retention, which does not expire log events. 0 means no retention.
+	retentionInDays int
+	// dataProtectionPolicy is the policy document, which is not applied to log events
+	dataProtectionPolicy string
+	policyUpdatedTime    int64
+	// metricFilters are metric filters by the names, which publish no metrics
+	metricFilters map[string]*metricFilter
+}
+
+type metricFilter struct {
+	name            string
+	pattern         string
+	transformations []map[string]interface{}
+	creationTime    int64
+}
+
+// The status codes of export tasks
+const (
+	ExportTaskPending   = "PENDING"
+	ExportTaskRunning   = "RUNNING"
+	ExportTaskCompleted = "COMPLETED"
+	ExportTaskFailed    = "FAILED"
+	ExportTaskCancelled = "CANCELLED"
+)
+
+// exportTask is an export task, which exports nothing. Its status advances from PENDING to RUNNING and COMPLETED
+// each time it is described, unless the status is set by SetExportTaskStatus.
+type exportTask struct {
+	id           string
+	logGroup     string
+	from         int64
+	to           int64
+	destination  string
+	prefix       string
+	code         string
+	message      string
+	pinned       bool
+	creationTime int64
+}
+
+// Server is a fake CloudWatch Logs. It is safe for concurrent use.
+type Server struct {
+	// Region is used in the ARNs of log groups. It is us-east-1 by default.
+	Region string
+	// AccountID is used in the ARNs of log groups. It is 000000000000 by default.
+	AccountID string
+	// Now returns the current time used as the creation and ingestion time. It is time.Now by default.
+	Now func() time.Time
+	// PageSize is the number of items in a page of the APIs listing items without the limit.
+	// The default limits of CloudWatch Logs are used if it is 0.
+	PageSize int
+
+	mu     sync.Mutex
+	groups map[string]*logGroup
+	tasks  map[string]*exportTask
+	nextID int
+}
+
+// New returns the Server without log groups. The zero value of the Server is also ready to use.
+func New() *Server {
+	return &Server{Region: "us-east-1", AccountID: "000000000000", Now: time.Now, groups: map[string]*logGroup{}, tasks: map[string]*exportTask{}}
+}
+
+// setDefaults sets the defaults to the zero value of the Server. It is called with the lock held.
+func (s *Server) setDefaults() {
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if s.AccountID == "" {
+		s.AccountID = "000000000000"
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	if s.groups == nil {
+		s.groups = map[string]*logGroup{}
+	}
+	if s.tasks == nil {
+		s.tasks = map[string]*exportTask{}
+	}
+}
+
+// apiError is the error returned to clients with the type of the exception.
+type apiError struct {
+	status  int
+	errType string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.errType + ": " + e.message
+}
+
+func notFound(format string, args ...interface{}) *apiError {
+	return &apiError{status: http.StatusBadRequest, errType: "ResourceNotFoundException", message: fmt.Sprintf(format, args...)}
+}
+
+func invalidParameter(format string, args ...interface{}) *apiError {
+	return &apiError{status: http.StatusBadRequest, errType: "InvalidParameterException", message: fmt.Sprintf(format, args...)}
+}
+
+func alreadyExists(format string, args ...interface{}) *apiError {
+	return &apiError{status: http.StatusBadRequest, errType: "ResourceAlreadyExistsException", message: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP serves an API call of CloudWatch Logs.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operations := map[string]func(*json.Decoder) (interface{}, error){
+		"CreateLogGroup":             s.createLogGroup,
+		"CreateLogStream":            s.createLogStream,
+		"DeleteLogGroup":             s.deleteLogGroup,
+		"DeleteLogStream":            s.deleteLogStream,
+		"DescribeLogGroups":          s.describeLogGroups,
+		"DescribeLogStreams":         s.describeLogStreams,
+		"PutLogEvents":               s.putLogEvents,
+		"GetLogEvents":               s.getLogEvents,
+		"FilterLogEvents":            s.filterLogEvents,
+		"CreateExportTask":           s.createExportTask,
+		"DescribeExportTasks":        s.describeExportTasks,
+		"PutDataProtectionPolicy":    s.putDataProtectionPolicy,
+		"GetDataProtectionPolicy":    s.getDataProtectionPolicy,
+		"DeleteDataProtectionPolicy": s.deleteDataProtectionPolicy,
+		"PutMetricFilter":            s.putMetricFilter,
+		"DescribeMetricFilters":      s.describeMetricFilters,
+		"DeleteMetricFilter":         s.deleteMetricFilter,
+		"PutRetentionPolicy":         s.putRetentionPolicy,
+		"DeleteRetentionPolicy":      s.deleteRetentionPolicy,
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	operation, ok := operations[strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)]
+	if r.Method != http.MethodPost || !ok {
+		writeError(w, &apiError{status: http.StatusBadRequest, errType: "UnknownOperationException", message: "unsupported operation " + r.Header.Get("X-Amz-Target")})
+		return
+	}
+
+	s.mu.Lock()
+	s.setDefaults()
+	out, err := operation(json.NewDecoder(r.Body))
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = &apiError{status: http.StatusBadRequest, errType: "SerializationException", message: err.Error()}
+	}
+	w.WriteHeader(e.status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": e.errType, "message": e.message})
+}
+
+func (s *Server) now() int64 {
+	return s.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func (s *Server) group(name string) (*logGroup, error) {
+	g, ok := s.groups[name]
+	if !ok {
+		return nil, notFound("The specified log group does not exist.")
+	}
+	return g, nil
+}
+
+func (s *Server) stream(groupName, streamName string) (*logStream, error) {
+	g, err := s.group(groupName)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := g.streams[streamName]
+	if !ok {
+		return nil, notFound("The specified log stream does not exist.")
+	}
+	return stream, nil
+}
+
+// groupByIdentifier returns the log group by the name or the ARN.
+func (s *Server) groupByIdentifier(identifier string) (*logGroup, error) {
+	if strings.HasPrefix(identifier, "arn:") {
+		if i := strings.Index(identifier, ":log-group:"); i >= 0 {
+			identifier = strings.TrimSuffix(identifier[i+len(":log-group:"):], ":*")
+		}
+	}
+	return s.group(identifier)
+}
+
+// page returns the range of n items from nextToken within limit, and the token of the next page.
+func page(n int, nextToken string, limit, defaultLimit int) (int, int, *string, error) {
+	start := 0
+	if nextToken != "" {
+		var err error
+		if start, err = strconv.Atoi(nextToken); err != nil || start < 0 || start > n {
+			return 0, 0, nil, invalidParameter("The specified nextToken is invalid.")
+		}
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	end := start + limit
+	if end >= n {
+		return start, n, nil, nil
+	}
+	token := strconv.Itoa(end)
+	return start, end, &token, nil
+}
+
+// pageSize returns the number of items in a page without the limit.
+func (s *Server) pageSize(defaultLimit int) int {
+	if s.PageSize > 0 {
+		return s.PageSize
+	}
+	return defaultLimit
+}
+
+// withNextToken adds the token of the next page to the output if there is the next page.
+func withNextToken(out map[string]interface{}, next *string) map[string]interface{} {
+	if next != nil {
+		out["nextToken"] = *next
+	}
+	return out
+}
+
+func (s *Server) createLogGroup(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName string `json:"logGroupName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	if in.LogGroupName == "" {
+		return nil, invalidParameter("logGroupName is required.")
+	}
+	if _, ok := s.groups[in.LogGroupName]; ok {
+		return nil, alreadyExists("The specified log group already exists")
+	}
+	s.groups[in.LogGroupName] = &logGroup{name: in.LogGroupName, creationTime: s.now(), streams: map[string]*logStream{}, metricFilters: map[string]*metricFilter{}}
+	return struct{}{}, nil
+}
+
+func (s *Server) createLogStream(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName  string `json:"logGroupName"`
+		LogStreamName string `json:"logStreamName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if in.LogStreamName == "" || strings.ContainsAny(in.LogStreamName, ":*") {
+		return nil, invalidParameter("logStreamName must not be empty or include ':' or '*'.")
+	}
+	if _, ok := g.streams[in.LogStreamName]; ok {
+		return nil, alreadyExists("The specified log stream already exists")
+	}
+	g.streams[in.LogStreamName] = &logStream{name: in.LogStreamName, creationTime: s.now()}
+	return struct{}{}, nil
+}
+
+func (s *Server) deleteLogGroup(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName string `json:"logGroupName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	if _, err := s.group(in.LogGroupName); err != nil {
+		return nil, err
+	}
+	delete(s.groups, in.LogGroupName)
+	return struct{}{}, nil
+}
+
+func (s *Server) deleteLogStream(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName  string `json:"logGroupName"`
+		LogStreamName string `json:"logStreamName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	if _, err := s.stream(in.LogGroupName, in.LogStreamName); err != nil {
+		return nil, err
+	}
+	delete(s.groups[in.LogGroupName].streams, in.LogStreamName)
+	return struct{}{}, nil
+}
+
+func (s *Server) describeLogGroups(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupNamePrefix string `json:"logGroupNamePrefix"`
+		Limit              int    `json:"limit"`
+		NextToken          string `json:"nextToken"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	groups := []*logGroup{}
+	for name, g := range s.groups {
+		if strings.HasPrefix(name, in.LogGroupNamePrefix) {
+			groups = append(groups, g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	start, end, next, err := page(len(groups), in.NextToken, in.Limit, s.pageSize(50))
+	if err != nil {
+		return nil, err
+	}
+
+	out := []map[string]interface{}{}
+	for _, g := range groups[start:end] {
+		storedBytes := 0
+		for _, stream := range g.streams {
+			storedBytes += stream.storedBytes()
+		}
+		group := map[string]interface{}{
+			"logGroupName": g.name,
+			"arn":          fmt.Sprintf("arn:aws:logs:%s:%s:log-group:%s:*", s.Region, s.AccountID, g.name),
+			"logGroupArn":  fmt.Sprintf("arn:aws:logs:%s:%s:log-group:%s", s.Region, s.AccountID, g.name),
+			"creationTime": g.creationTime,
+			"storedBytes":  storedBytes,
+		}
+		if g.retentionInDays > 0 {
+			group["retentionInDays"] = g.retentionInDays
+		}
+		out = append(out, group)
+	}
+	return withNextToken(map[string]interface{}{"logGroups": out}, next), nil
+}
+
+func (stream *logStream) storedBytes() int {
+	size := 0
+	for _, event := range stream.events {
+		size += len(event.Message) + eventOverheadBytes
+	}
+	return size
+}
+
+func (stream *logStream) lastEventTimestamp() int64 {
+	last := int64(0)
+	for _, event := range stream.events {
+		if event.Timestamp > last {
+			last = event.Timestamp
+		}
+	}
+	return last
+}
+
+func (s *Server) describeLogStreams(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName        string `json:"logGroupName"`
+		LogStreamNamePrefix string `json:"logStreamNamePrefix"`
+		OrderBy             string `json:"orderBy"`
+		Descending          bool   `json:"descending"`
+		Limit               int    `json:"limit"`
+		NextToken           string `json:"nextToken"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if in.OrderBy == "LastEventTime" && in.LogStreamNamePrefix != "" {
+		return nil, invalidParameter("Cannot order by LastEventTime with a logStreamNamePrefix.")
+	}
+
+	streams := []*logStream{}
+	for name, stream := range g.streams {
+		if strings.HasPrefix(name, in.LogStreamNamePrefix) {
+			streams = append(streams, stream)
+		}
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		a, b := streams[i], streams[j]
+		if in.Descending {
+			a, b = b, a
+		}
+		if in.OrderBy == "LastEventTime" && a.lastEventTimestamp() != b.lastEventTimestamp() {
+			return a.lastEventTimestamp() < b.lastEventTimestamp()
+		}
+		return a.name < b.name
+	})
+	start, end, next, err := page(len(streams), in.NextToken, in.Limit, s.pageSize(50))
+	if err != nil {
+		return nil, err
+	}
+
+	out := []map[string]interface{}{}
+	for _, stream := range streams[start:end] {
+		info := map[string]interface{}{
+			"logStreamName":       stream.name,
+			"arn":                 fmt.Sprintf("arn:aws:logs:%s:%s:log-group:%s:log-stream:%s", s.Region, s.AccountID, g.name, stream.name),
+			"creationTime":        stream.creationTime,
+			"storedBytes":         stream.storedBytes(),
+			"uploadSequenceToken": strconv.Itoa(stream.token),
+		}
+		if len(stream.events) > 0 {
+			first := stream.events[0].Timestamp
+			for _, event := range stream.events {
+				if event.Timestamp < first {
+					first = event.Timestamp
+				}
+			}
+			info["firstEventTimestamp"] = first
+			info["lastEventTimestamp"] = stream.lastEventTimestamp()
+			info["lastIngestionTime"] = stream.events[len(stream.events)-1].IngestionTime
+		}
+		out = append(out, info)
+	}
+	return withNextToken(map[string]interface{}{"logStreams": out}, next), nil
+}
+
+func (s *Server) putLogEvents(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName  string  `json:"logGroupName"`
+		LogStreamName string  `json:"logStreamName"`
+		LogEvents     []Event `json:"logEvents"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	stream, err := s.stream(in.LogGroupName, in.LogStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.LogEvents) == 0 || len(in.LogEvents) > maxBatchEvents {
+		return nil, invalidParameter("The number of log events must be between 1 and %d.", maxBatchEvents)
+	}
+	size := 0
+	for i, event := range in.LogEvents {
+		size += len(event.Message) + eventOverheadBytes
+		if i > 0 && event.Timestamp < in.LogEvents[i-1].Timestamp {
+			return nil, invalidParameter("Log events in a single PutLogEvents request must be in chronological order.")
+		}
+	}
+	if size > maxBatchBytes {
+		return nil, invalidParameter("The size of log events must be %d bytes or less.", maxBatchBytes)
+	}
+	if time.Duration(in.LogEvents[len(in.LogEvents)-1].Timestamp-in.LogEvents[0].Timestamp)*time.Millisecond > maxBatchSpan {
+		return nil, invalidParameter("Log events in a single PutLogEvents request must not span more than 24 hours.")
+	}
+
+	// Log events are in chronological order, so rejected ones are at the beginning or the end
+	now := s.now()
+	tooOld, tooNew := -1, len(in.LogEvents)
+	for i, event := range in.LogEvents {
+		if event.Timestamp < now-int64(maxEventAge/time.Millisecond) {
+			tooOld = i
+		}
+		if event.Timestamp > now+int64(maxEventLead/time.Millisecond) && i < tooNew {
+			tooNew = i
+		}
+	}
+	for _, event := range in.LogEvents[tooOld+1 : tooNew] {
+		s.nextID++
+		stream.events = append(stream.events, Event{
+			Timestamp:     event.Timestamp,
+			Message:       event.Message,
+			IngestionTime: now,
+			EventID:       fmt.Sprintf("%020d", s.nextID),
+		})
+	}
+	stream.token++
+
+	out := map[string]interface{}{"nextSequenceToken": strconv.Itoa(stream.token)}
+	rejected := map[string]interface{}{}
+	if tooOld >= 0 {
+		rejected["tooOldLogEventEndIndex"] = tooOld
+	}
+	if tooNew < len(in.LogEvents) {
+		rejected["tooNewLogEventStartIndex"] = tooNew
+	}
+	if len(rejected) > 0 {
+		out["rejectedLogEventsInfo"] = rejected
+	}
+	return out, nil
+}
+
+// inRange reports whether the timestamp is between start inclusive and end exclusive. Zero does not bound the range.
+func inRange(timestamp, start, end int64) bool {
+	return (start == 0 || timestamp >= start) && (end == 0 || timestamp < end)
+}
+
+func (s *Server) getLogEvents(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName  string `json:"logGroupName"`
+		LogStreamName string `json:"logStreamName"`
+		StartTime     int64  `json:"startTime"`
+		EndTime       int64  `json:"endTime"`
+		Limit         int    `json:"limit"`
+		NextToken     string `json:"nextToken"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	stream, err := s.stream(in.LogGroupName, in.LogStreamName)
+	if err != nil {
+		return nil, err
+	}
+	events := []Event{}
+	for _, event := range sortedEvents(stream.events) {
+		if inRange(event.Timestamp, in.StartTime, in.EndTime) {
+			events = append(events, Event{Timestamp: event.Timestamp, Message: event.Message, IngestionTime: event.IngestionTime})
+		}
+	}
+	start, end, _, err := page(len(events), strings.TrimPrefix(in.NextToken, "f/"), in.Limit, s.pageSize(maxBatchEvents))
+	if err != nil {
+		return nil, err
+	}
+	// The forward token of the last page is the same as the given token, so that paginators stop
+	return map[string]interface{}{
+		"events":            events[start:end],
+		"nextForwardToken":  "f/" + strconv.Itoa(end),
+		"nextBackwardToken": "b/" + strconv.Itoa(start),
+	}, nil
+}
+
+func sortedEvents(events []Event) []Event {
+	sorted := append([]Event{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	return sorted
+}
+
+// matchTerms reports whether the message includes all terms of the filter pattern.
+// Only patterns of terms, such as ERROR "Failed to", are supported.
+func matchTerms(pattern, message string) bool {
+	for _, term := range splitTerms(pattern) {
+		if !strings.Contains(message, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTerms splits the filter pattern into terms separated by spaces. Quoted terms can include spaces.
+func splitTerms(pattern string) []string {
+	terms := []string{}
+	quoted := false
+	term := strings.Builder{}
+	for _, r := range pattern {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms
+}
+
+func (s *Server) filterLogEvents(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName        string   `json:"logGroupName"`
+		LogStreamNames      []string `json:"logStreamNames"`
+		LogStreamNamePrefix string   `json:"logStreamNamePrefix"`
+		StartTime           int64    `json:"startTime"`
+		EndTime             int64    `json:"endTime"`
+		FilterPattern       string   `json:"filterPattern"`
+		Limit               int      `json:"limit"`
+		NextToken           string   `json:"nextToken"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.LogStreamNames) > 0 && in.LogStreamNamePrefix != "" {
+		return nil, invalidParameter("logStreamNames and logStreamNamePrefix can not be used together.")
+	}
+	names := map[string]bool{}
+	for _, name := range in.LogStreamNames {
+		if _, ok := g.streams[name]; !ok {
+			return nil, notFound("The specified log stream does not exist.")
+		}
+		names[name] = true
+	}
+
+	events := []Event{}
+	for name, stream := range g.streams {
+		if (len(names) > 0 && !names[name]) || !strings.HasPrefix(name, in.LogStreamNamePrefix) {
+			continue
+		}
+		for _, event := range stream.events {
+			if inRange(event.Timestamp, in.StartTime, in.EndTime) && matchTerms(in.FilterPattern, event.Message) {
+				event.LogStreamName = name
+				events = append(events, event)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp != events[j].Timestamp {
+			return events[i].Timestamp < events[j].Timestamp
+		}
+		return events[i].EventID < events[j].EventID
+	})
+	start, end, next, err := page(len(events), in.NextToken, in.Limit, s.pageSize(maxBatchEvents))
+	if err != nil {
+		return nil, err
+	}
+	return withNextToken(map[string]interface{}{"events": events[start:end]}, next), nil
+}
+
+func (s *Server) createExportTask(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName      string `json:"logGroupName"`
+		From              int64  `json:"from"`
+		To                int64  `json:"to"`
+		Destination       string `json:"destination"`
+		DestinationPrefix string `json:"destinationPrefix"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	if _, err := s.group(in.LogGroupName); err != nil {
+		return nil, err
+	}
+	if in.Destination == "" {
+		return nil, invalidParameter("destination is required.")
+	}
+	if in.From > in.To {
+		return nil, invalidParameter("from must not be after to.")
+	}
+	s.nextID++
+	task := &exportTask{
+		id:           fmt.Sprintf("%08d-0000-0000-0000-000000000000", s.nextID),
+		logGroup:     in.LogGroupName,
+		from:         in.From,
+		to:           in.To,
+		destination:  in.Destination,
+		prefix:       in.DestinationPrefix,
+		code:         ExportTaskPending,
+		creationTime: s.now(),
+	}
+	s.tasks[task.id] = task
+	return map[string]interface{}{"taskId": task.id}, nil
+}
+
+func (s *Server) describeExportTasks(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		TaskID string `json:"taskId"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	tasks := []*exportTask{}
+	for id, task := range s.tasks {
+		if in.TaskID == "" || id == in.TaskID {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].id < tasks[j].id })
+
+	out := []map[string]interface{}{}
+	for _, task := range tasks {
+		out = append(out, map[string]interface{}{
+			"taskId":            task.id,
+			"logGroupName":      task.logGroup,
+			"from":              task.from,
+			"to":                task.to,
+			"destination":       task.destination,
+			"destinationPrefix": task.prefix,
+			"status":            map[string]string{"code": task.code, "message": task.message},
+			"executionInfo":     map[string]int64{"creationTime": task.creationTime},
+		})
+		if !task.pinned {
+			switch task.code {
+			case ExportTaskPending:
+				task.code = ExportTaskRunning
+			case ExportTaskRunning:
+				task.code = ExportTaskCompleted
+			}
+		}
+	}
+	return map[string]interface{}{"exportTasks": out}, nil
+}
+
+func (s *Server) putDataProtectionPolicy(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupIdentifier string `json:"logGroupIdentifier"`
+		PolicyDocument     string `json:"policyDocument"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.groupByIdentifier(in.LogGroupIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(in.PolicyDocument)) {
+		return nil, invalidParameter("policyDocument is not valid JSON.")
+	}
+	g.dataProtectionPolicy, g.policyUpdatedTime = in.PolicyDocument, s.now()
+	return map[string]interface{}{"logGroupIdentifier": in.LogGroupIdentifier, "policyDocument": in.PolicyDocument, "lastUpdatedTime": g.policyUpdatedTime}, nil
+}
+
+func (s *Server) getDataProtectionPolicy(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupIdentifier string `json:"logGroupIdentifier"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.groupByIdentifier(in.LogGroupIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{"logGroupIdentifier": in.LogGroupIdentifier}
+	if g.dataProtectionPolicy != "" {
+		out["policyDocument"] = g.dataProtectionPolicy
+		out["lastUpdatedTime"] = g.policyUpdatedTime
+	}
+	return out, nil
+}
+
+func (s *Server) deleteDataProtectionPolicy(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupIdentifier string `json:"logGroupIdentifier"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.groupByIdentifier(in.LogGroupIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	if g.dataProtectionPolicy == "" {
+		return nil, notFound("The specified log group has no data protection policy.")
+	}
+	g.dataProtectionPolicy, g.policyUpdatedTime = "", 0
+	return struct{}{}, nil
+}
+
+func (s *Server) putMetricFilter(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName          string                   `json:"logGroupName"`
+		FilterName            string                   `json:"filterName"`
+		FilterPattern         string                   `json:"filterPattern"`
+		MetricTransformations []map[string]interface{} `json:"metricTransformations"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if in.FilterName == "" {
+		return nil, invalidParameter("filterName is required.")
+	}
+	if len(in.MetricTransformations) != 1 {
+		return nil, invalidParameter("metricTransformations must have a metric transformation.")
+	}
+	creationTime := s.now()
+	if f, ok := g.metricFilters[in.FilterName]; ok {
+		creationTime = f.creationTime
+	}
+	g.metricFilters[in.FilterName] = &metricFilter{name: in.FilterName, pattern: in.FilterPattern, transformations: in.MetricTransformations, creationTime: creationTime}
+	return struct{}{}, nil
+}
+
+func (s *Server) describeMetricFilters(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName     string `json:"logGroupName"`
+		FilterNamePrefix string `json:"filterNamePrefix"`
+		Limit            int    `json:"limit"`
+		NextToken        string `json:"nextToken"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	filters := []*metricFilter{}
+	for name, f := range g.metricFilters {
+		if strings.HasPrefix(name, in.FilterNamePrefix) {
+			filters = append(filters, f)
+		}
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].name < filters[j].name })
+	start, end, next, err := page(len(filters), in.NextToken, in.Limit, s.pageSize(50))
+	if err != nil {
+		return nil, err
+	}
+
+	out := []map[string]interface{}{}
+	for _, f := range filters[start:end] {
+		out = append(out, map[string]interface{}{
+			"filterName":            f.name,
+			"filterPattern":         f.pattern,
+			"metricTransformations": f.transformations,
+			"creationTime":          f.creationTime,
+			"logGroupName":          g.name,
+		})
+	}
+	return withNextToken(map[string]interface{}{"metricFilters": out}, next), nil
+}
+
+func (s *Server) deleteMetricFilter(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName string `json:"logGroupName"`
+		FilterName   string `json:"filterName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.metricFilters[in.FilterName]; !ok {
+		return nil, notFound("The specified metric filter does not exist.")
+	}
+	delete(g.metricFilters, in.FilterName)
+	return struct{}{}, nil
+}
+
+func (s *Server) putRetentionPolicy(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName    string `json:"logGroupName"`
+		RetentionInDays int    `json:"retentionInDays"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if in.RetentionInDays <= 0 {
+		return nil, invalidParameter("retentionInDays must be positive.")
+	}
+	g.retentionInDays = in.RetentionInDays
+	return struct{}{}, nil
+}
+
+func (s *Server) deleteRetentionPolicy(dec *json.Decoder) (interface{}, error) {
+	var in struct {
+		LogGroupName string `json:"logGroupName"`
+	}
+	if err := dec.Decode(&in); err != nil {
+		return nil, err
+	}
+	g, err := s.group(in.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+	g.retentionInDays = 0
+	return struct{}{}, nil
+}
+
+// SetExportTaskStatus sets the status of the export task, which does not advance any more. It returns false if
+// the export task does not exist.
+func (s *Server) SetExportTaskStatus(taskID, code, message string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return false
+	}
+	task.code, task.message, task.pinned = code, message, true
+	return true
+}
+
+// Events returns log events in the log stream in order of the timestamp, or nil if the log stream does not exist.
+func (s *Server) Events(logGroup, logStream string) []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.stream(logGroup, logStream)
+	if err != nil {
+		return nil
+	}
+	return sortedEvents(stream.events)
+}
@@ -0,0 +1,461 @@
+package fakelogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+var now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// newTestClient starts the Server, and returns the client of it.
+func newTestClient(t *testing.T) (*cloudwatchlogs.Client, *Server) {
+	s := New()
+	s.Now = func() time.Time { return now }
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	client := cloudwatchlogs.New(cloudwatchlogs.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+	})
+	return client, s
+}
+
+func setUp(t *testing.T, client *cloudwatchlogs.Client, logGroup string, logStreams ...string) {
+	ctx := context.Background()
+	if _, err := client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(logGroup)}); err != nil {
+		t.Fatalf("CreateLogGroup() error = %v", err)
+	}
+	for _, logStream := range logStreams {
+		if _, err := client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String(logGroup), LogStreamName: aws.String(logStream)}); err != nil {
+			t.Fatalf("CreateLogStream() error = %v", err)
+		}
+	}
+}
+
+func newEvents(messages ...string) []types.InputLogEvent {
+	events := make([]types.InputLogEvent, len(messages))
+	for i, message := range messages {
+		events[i] = types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(toMillis(now) + int64(i))}
+	}
+	return events
+}
+
+func Test_Server_zeroValue(t *testing.T) {
+	s := &Server{}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	client := cloudwatchlogs.New(cloudwatchlogs.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+	})
+	setUp(t, client, "/app/api", "api")
+
+	out, err := client.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{})
+	if err != nil {
+		t.Fatalf("DescribeLogGroups() error = %v", err)
+	}
+	if got, want := aws.ToString(out.LogGroups[0].LogGroupArn), "arn:aws:logs:us-east-1:000000000000:log-group:/app/api"; got != want {
+		t.Errorf("DescribeLogGroups() ARN = %s, want %s", got, want)
+	}
+	if got := s.Events("/app/worker", "worker"); got != nil {
+		t.Errorf("Events() of unknown log stream = %v, want nil", got)
+	}
+}
+
+func Test_Server_create(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api", "api")
+	ctx := context.Background()
+
+	var exists *types.ResourceAlreadyExistsException
+	if _, err := client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("/app/api")}); !errors.As(err, &exists) {
+		t.Errorf("CreateLogGroup() of existing log group error = %v, want ResourceAlreadyExistsException", err)
+	}
+	if _, err := client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api")}); !errors.As(err, &exists) {
+		t.Errorf("CreateLogStream() of existing log stream error = %v, want ResourceAlreadyExistsException", err)
+	}
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String("/app/worker"), LogStreamName: aws.String("worker")}); !errors.As(err, &notFound) {
+		t.Errorf("CreateLogStream() in unknown log group error = %v, want ResourceNotFoundException", err)
+	}
+}
+
+func Test_Server_describe(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api", "api-1", "api-2", "api-3", "worker")
+	setUp(t, client, "/app/worker")
+	ctx := context.Background()
+	if _, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api-2"), LogEvents: newEvents("[INFO] Start API")}); err != nil {
+		t.Fatalf("PutLogEvents() error = %v", err)
+	}
+
+	groups := []string{}
+	groupPaginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String("/app/"), Limit: aws.Int32(1)})
+	for groupPaginator.HasMorePages() {
+		out, err := groupPaginator.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("DescribeLogGroups() error = %v", err)
+		}
+		for _, g := range out.LogGroups {
+			groups = append(groups, aws.ToString(g.LogGroupName))
+		}
+	}
+	if want := []string{"/app/api", "/app/worker"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("DescribeLogGroups() = %v, want %v", groups, want)
+	}
+
+	tests := []struct {
+		name string
+		in   cloudwatchlogs.DescribeLogStreamsInput
+		want []string
+	}{
+		{name: "Describe log streams by name", in: cloudwatchlogs.DescribeLogStreamsInput{}, want: []string{"api-1", "api-2", "api-3", "worker"}},
+		{name: "Describe log streams with prefix", in: cloudwatchlogs.DescribeLogStreamsInput{LogStreamNamePrefix: aws.String("api-"), Descending: aws.Bool(true)}, want: []string{"api-3", "api-2", "api-1"}},
+		{name: "Describe log streams by last event time", in: cloudwatchlogs.DescribeLogStreamsInput{OrderBy: types.OrderByLastEventTime, Descending: aws.Bool(true), Limit: aws.Int32(2)}, want: []string{"api-2", "worker", "api-3", "api-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.LogGroupName = aws.String("/app/api")
+			got := []string{}
+			paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, &tt.in)
+			for paginator.HasMorePages() {
+				out, err := paginator.NextPage(ctx)
+				if err != nil {
+					t.Fatalf("DescribeLogStreams() error = %v", err)
+				}
+				for _, stream := range out.LogStreams {
+					got = append(got, aws.ToString(stream.LogStreamName))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DescribeLogStreams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var invalid *types.InvalidParameterException
+	_, err := client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{LogGroupName: aws.String("/app/api"), LogStreamNamePrefix: aws.String("api-"), OrderBy: types.OrderByLastEventTime})
+	if !errors.As(err, &invalid) {
+		t.Errorf("DescribeLogStreams() ordered by last event time with prefix error = %v, want InvalidParameterException", err)
+	}
+}
+
+func Test_Server_put(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	tests := []struct {
+		name         string
+		events       []types.InputLogEvent
+		want         []string
+		wantRejected *types.RejectedLogEventsInfo
+		wantErr      bool
+	}{
+		{
+			name:   "Put log events",
+			events: newEvents("[INFO] Start API", "[ERROR] Failed to Start API"),
+			want:   []string{"[INFO] Start API", "[ERROR] Failed to Start API"},
+		},
+		{
+			name: "Put log events rejecting too old ones",
+			events: []types.InputLogEvent{
+				{Message: aws.String("too old"), Timestamp: aws.Int64(toMillis(now) - 14*day - 1)},
+				{Message: aws.String("[INFO] Start API"), Timestamp: aws.Int64(toMillis(now) - 14*day + 1)},
+			},
+			want:         []string{"[INFO] Start API"},
+			wantRejected: &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(0)},
+		},
+		{
+			name: "Put log events rejecting too new ones",
+			events: []types.InputLogEvent{
+				{Message: aws.String("[INFO] Start API"), Timestamp: aws.Int64(toMillis(now))},
+				{Message: aws.String("too new"), Timestamp: aws.Int64(toMillis(now.Add(3 * time.Hour)))},
+			},
+			want:         []string{"[INFO] Start API"},
+			wantRejected: &types.RejectedLogEventsInfo{TooNewLogEventStartIndex: aws.Int32(1)},
+		},
+		{
+			name:   "Put log events of the same timestamp",
+			events: append(newEvents("[INFO] Stop API")[:1], newEvents("[INFO] Start API")...),
+			want:   []string{"[INFO] Stop API", "[INFO] Start API"},
+		},
+		{
+			name: "Put log events going back in time",
+			events: []types.InputLogEvent{
+				{Message: aws.String("[INFO] Stop API"), Timestamp: aws.Int64(toMillis(now))},
+				{Message: aws.String("[INFO] Start API"), Timestamp: aws.Int64(toMillis(now) - 1)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Put log events spanning more than 24 hours",
+			events: []types.InputLogEvent{
+				{Message: aws.String("[INFO] Start API"), Timestamp: aws.Int64(toMillis(now) - 2*day)},
+				{Message: aws.String("[INFO] Stop API"), Timestamp: aws.Int64(toMillis(now))},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Put no log events",
+			events:  []types.InputLogEvent{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, s := newTestClient(t)
+			setUp(t, client, "/app/api", "api")
+
+			out, err := client.PutLogEvents(context.Background(), &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api"), LogEvents: tt.events})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PutLogEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(out.RejectedLogEventsInfo, tt.wantRejected) {
+				t.Errorf("PutLogEvents() rejected %+v, want %+v", out.RejectedLogEventsInfo, tt.wantRejected)
+			}
+			got := []string{}
+			for _, event := range s.Events("/app/api", "api") {
+				got = append(got, event.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Server.Events() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Server_filter(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api", "api-1", "api-2")
+	ctx := context.Background()
+	for i, stream := range []string{"api-1", "api-2"} {
+		events := newEvents(fmt.Sprintf("[INFO] Start API %d", i+1), fmt.Sprintf("[ERROR] Failed to Start API %d", i+1))
+		if _, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String(stream), LogEvents: events}); err != nil {
+			t.Fatalf("PutLogEvents() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		in   cloudwatchlogs.FilterLogEventsInput
+		want []string
+	}{
+		{name: "Filter all log events", want: []string{"[INFO] Start API 1", "[INFO] Start API 2", "[ERROR] Failed to Start API 1", "[ERROR] Failed to Start API 2"}},
+		{name: "Filter log events of log stream", in: cloudwatchlogs.FilterLogEventsInput{LogStreamNames: []string{"api-2"}}, want: []string{"[INFO] Start API 2", "[ERROR] Failed to Start API 2"}},
+		{name: "Filter log events by pattern", in: cloudwatchlogs.FilterLogEventsInput{FilterPattern: aws.String(`ERROR "API 1"`)}, want: []string{"[ERROR] Failed to Start API 1"}},
+		{name: "Filter log events by time", in: cloudwatchlogs.FilterLogEventsInput{StartTime: aws.Int64(toMillis(now) + 1), Limit: aws.Int32(1)}, want: []string{"[ERROR] Failed to Start API 1", "[ERROR] Failed to Start API 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.LogGroupName = aws.String("/app/api")
+			got := []string{}
+			paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, &tt.in)
+			for paginator.HasMorePages() {
+				out, err := paginator.NextPage(ctx)
+				if err != nil {
+					t.Fatalf("FilterLogEvents() error = %v", err)
+				}
+				for _, event := range out.Events {
+					got = append(got, aws.ToString(event.Message))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Server_get(t *testing.T) {
+	client, s := newTestClient(t)
+	setUp(t, client, "/app/api", "api")
+	ctx := context.Background()
+	events := newEvents("[INFO] Start API", "[INFO] Stop API", "[INFO] Start API")
+	if _, err := client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api"), LogEvents: events}); err != nil {
+		t.Fatalf("PutLogEvents() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		limit    *int32
+		pageSize int
+	}{
+		{name: "Get log events with limit", limit: aws.Int32(2)},
+		{name: "Get log events with page size", pageSize: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.PageSize = tt.pageSize
+			got := []string{}
+			paginator := cloudwatchlogs.NewGetLogEventsPaginator(client, &cloudwatchlogs.GetLogEventsInput{LogGroupName: aws.String("/app/api"), LogStreamName: aws.String("api"), StartFromHead: aws.Bool(true), Limit: tt.limit}, func(o *cloudwatchlogs.GetLogEventsPaginatorOptions) {
+				// GetLogEvents returns the same token at the end of the log stream
+				o.StopOnDuplicateToken = true
+			})
+			pages := 0
+			for paginator.HasMorePages() {
+				out, err := paginator.NextPage(ctx)
+				if err != nil {
+					t.Fatalf("GetLogEvents() error = %v", err)
+				}
+				pages++
+				for _, event := range out.Events {
+					got = append(got, aws.ToString(event.Message))
+				}
+			}
+			if want := []string{"[INFO] Start API", "[INFO] Stop API", "[INFO] Start API"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("GetLogEvents() = %v, want %v", got, want)
+			}
+			if pages < 3 {
+				t.Errorf("GetLogEvents() pages = %d, want more than 2", pages)
+			}
+		})
+	}
+}
+
+func Test_Server_export(t *testing.T) {
+	client, s := newTestClient(t)
+	setUp(t, client, "/app/api", "api")
+	ctx := context.Background()
+
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.CreateExportTask(ctx, &cloudwatchlogs.CreateExportTaskInput{LogGroupName: aws.String("/app/worker"), From: aws.Int64(0), To: aws.Int64(1), Destination: aws.String("bucket")}); !errors.As(err, &notFound) {
+		t.Errorf("CreateExportTask() of unknown log group error = %v, want ResourceNotFoundException", err)
+	}
+
+	statuses := func(taskID string, n int) []string {
+		codes := []string{}
+		for i := 0; i < n; i++ {
+			out, err := client.DescribeExportTasks(ctx, &cloudwatchlogs.DescribeExportTasksInput{TaskId: aws.String(taskID)})
+			if err != nil {
+				t.Fatalf("DescribeExportTasks() error = %v", err)
+			}
+			if len(out.ExportTasks) != 1 {
+				t.Fatalf("DescribeExportTasks() = %d tasks, want 1", len(out.ExportTasks))
+			}
+			codes = append(codes, string(out.ExportTasks[0].Status.Code))
+		}
+		return codes
+	}
+	create := func() string {
+		out, err := client.CreateExportTask(ctx, &cloudwatchlogs.CreateExportTaskInput{LogGroupName: aws.String("/app/api"), From: aws.Int64(0), To: aws.Int64(toMillis(now)), Destination: aws.String("bucket")})
+		if err != nil {
+			t.Fatalf("CreateExportTask() error = %v", err)
+		}
+		return aws.ToString(out.TaskId)
+	}
+
+	completed := create()
+	if got, want := statuses(completed, 4), []string{ExportTaskPending, ExportTaskRunning, ExportTaskCompleted, ExportTaskCompleted}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeExportTasks() statuses = %v, want %v", got, want)
+	}
+	failed := create()
+	if !s.SetExportTaskStatus(failed, ExportTaskFailed, "access denied") {
+		t.Fatalf("SetExportTaskStatus() = false, want true")
+	}
+	if got, want := statuses(failed, 2), []string{ExportTaskFailed, ExportTaskFailed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeExportTasks() statuses = %v, want %v", got, want)
+	}
+	if s.SetExportTaskStatus("unknown", ExportTaskFailed, "") {
+		t.Errorf("SetExportTaskStatus() of unknown task = true, want false")
+	}
+}
+
+func Test_Server_dataProtection(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api")
+	ctx := context.Background()
+	policy := `{"Name":"policy","Version":"2021-06-01","Statement":[]}`
+
+	if _, err := client.PutDataProtectionPolicy(ctx, &cloudwatchlogs.PutDataProtectionPolicyInput{LogGroupIdentifier: aws.String("/app/api"), PolicyDocument: aws.String(policy)}); err != nil {
+		t.Fatalf("PutDataProtectionPolicy() error = %v", err)
+	}
+	out, err := client.GetDataProtectionPolicy(ctx, &cloudwatchlogs.GetDataProtectionPolicyInput{LogGroupIdentifier: aws.String("arn:aws:logs:us-east-1:000000000000:log-group:/app/api:*")})
+	if err != nil {
+		t.Fatalf("GetDataProtectionPolicy() error = %v", err)
+	}
+	if got := aws.ToString(out.PolicyDocument); got != policy {
+		t.Errorf("GetDataProtectionPolicy() = %v, want %v", got, policy)
+	}
+	if _, err := client.DeleteDataProtectionPolicy(ctx, &cloudwatchlogs.DeleteDataProtectionPolicyInput{LogGroupIdentifier: aws.String("/app/api")}); err != nil {
+		t.Fatalf("DeleteDataProtectionPolicy() error = %v", err)
+	}
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.DeleteDataProtectionPolicy(ctx, &cloudwatchlogs.DeleteDataProtectionPolicyInput{LogGroupIdentifier: aws.String("/app/api")}); !errors.As(err, &notFound) {
+		t.Errorf("DeleteDataProtectionPolicy() of deleted policy error = %v, want ResourceNotFoundException", err)
+	}
+}
+
+func Test_Server_metricFilter(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api")
+	ctx := context.Background()
+
+	transformation := types.MetricTransformation{MetricName: aws.String("Errors"), MetricNamespace: aws.String("App"), MetricValue: aws.String("1"), DefaultValue: aws.Float64(0)}
+	for _, name := range []string{"errors", "api-errors"} {
+		if _, err := client.PutMetricFilter(ctx, &cloudwatchlogs.PutMetricFilterInput{LogGroupName: aws.String("/app/api"), FilterName: aws.String(name), FilterPattern: aws.String("ERROR"), MetricTransformations: []types.MetricTransformation{transformation}}); err != nil {
+			t.Fatalf("PutMetricFilter() error = %v", err)
+		}
+	}
+	if _, err := client.DeleteMetricFilter(ctx, &cloudwatchlogs.DeleteMetricFilterInput{LogGroupName: aws.String("/app/api"), FilterName: aws.String("errors")}); err != nil {
+		t.Fatalf("DeleteMetricFilter() error = %v", err)
+	}
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.DeleteMetricFilter(ctx, &cloudwatchlogs.DeleteMetricFilterInput{LogGroupName: aws.String("/app/api"), FilterName: aws.String("errors")}); !errors.As(err, &notFound) {
+		t.Errorf("DeleteMetricFilter() of deleted metric filter error = %v, want ResourceNotFoundException", err)
+	}
+
+	out, err := client.DescribeMetricFilters(ctx, &cloudwatchlogs.DescribeMetricFiltersInput{LogGroupName: aws.String("/app/api")})
+	if err != nil {
+		t.Fatalf("DescribeMetricFilters() error = %v", err)
+	}
+	if len(out.MetricFilters) != 1 || aws.ToString(out.MetricFilters[0].FilterName) != "api-errors" {
+		t.Fatalf("DescribeMetricFilters() = %v, want api-errors", out.MetricFilters)
+	}
+	if got := out.MetricFilters[0].MetricTransformations[0]; aws.ToString(got.MetricName) != "Errors" || aws.ToFloat64(got.DefaultValue) != 0 || got.DefaultValue == nil {
+		t.Errorf("DescribeMetricFilters() transformation = %+v, want %+v", got, transformation)
+	}
+}
+
+func Test_Server_retention(t *testing.T) {
+	client, _ := newTestClient(t)
+	setUp(t, client, "/app/api")
+	ctx := context.Background()
+
+	retention := func() int32 {
+		out, err := client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String("/app/api")})
+		if err != nil {
+			t.Fatalf("DescribeLogGroups() error = %v", err)
+		}
+		return aws.ToInt32(out.LogGroups[0].RetentionInDays)
+	}
+	if _, err := client.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{LogGroupName: aws.String("/app/api"), RetentionInDays: aws.Int32(14)}); err != nil {
+		t.Fatalf("PutRetentionPolicy() error = %v", err)
+	}
+	if got := retention(); got != 14 {
+		t.Errorf("DescribeLogGroups() retention = %d, want 14", got)
+	}
+	if _, err := client.DeleteRetentionPolicy(ctx, &cloudwatchlogs.DeleteRetentionPolicyInput{LogGroupName: aws.String("/app/api")}); err != nil {
+		t.Fatalf("DeleteRetentionPolicy() error = %v", err)
+	}
+	if got := retention(); got != 0 {
+		t.Errorf("DescribeLogGroups() retention = %d, want 0", got)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func runFakeServer(args []string) error {
+	addr := ""
+
+	flags := newSubcommandFlagSet(args[0], "Run a fake CloudWatch Logs in memory for testing.", "[options]")
+	flags.StringVar(&addr, "addr", "127.0.0.1:4566", "The address where the fake CloudWatch Logs listens. Give it to --endpoint-url of other commands as http://ADDR.")
+	flags.Parse(args[1:])
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+	fmt.Printf("Serving a fake CloudWatch Logs on http://%s\n", ln.Addr())
+	return http.Serve(ln, fakelogs.New())
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// jsonField is the field of the JSON object. The value is kept raw so that it is not changed unless the field is transformed.
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+// jsonObject is the JSON object which keeps the order of fields.
+type jsonObject []jsonField
+
+// parseJSONObject parses the data if it is a JSON object.
+func parseJSONObject(data []byte) (jsonObject, bool) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) || !json.Valid(data) {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	o := jsonObject{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		o = append(o, jsonField{key: token.(string), value: value})
+	}
+	return o, true
+}
+
+func (o jsonObject) marshal() []byte {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(f.value)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// drop removes the field at the path of keys.
+func (o jsonObject) drop(path []string) jsonObject {
+	dropped := jsonObject{}
+	for _, f := range o {
+		if f.key != path[0] {
+			dropped = append(dropped, f)
+			continue
+		}
+		if len(path) == 1 {
+			continue
+		}
+		if child, ok := parseJSONObject(f.value); ok {
+			f.value = child.drop(path[1:]).marshal()
+		}
+		dropped = append(dropped, f)
+	}
+	return dropped
+}
+
+// rename renames the field from to the key to. It does not rename the field if the object already has the key to.
+func (o jsonObject) rename(from, to string) jsonObject {
+	for _, f := range o {
+		if f.key == to {
+			return o
+		}
+	}
+	renamed := make(jsonObject, len(o))
+	for i, f := range o {
+		if f.key == from {
+			f.key = to
+		}
+		renamed[i] = f
+	}
+	return renamed
+}
+
+// fieldTree is the tree of the paths of fields. A leaf keeps the whole value of the field.
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	child, ok := t[path[0]]
+	if len(path) == 1 {
+		t[path[0]] = nil
+		return
+	}
+	if ok && child == nil {
+		// The whole value of the field is already kept
+		return
+	}
+	if !ok {
+		child = fieldTree{}
+		t[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+// keep returns the object with only the fields in the tree.
+func (o jsonObject) keep(t fieldTree) jsonObject {
+	kept := jsonObject{}
+	for _, f := range o {
+		child, ok := t[f.key]
+		if !ok {
+			continue
+		}
+		if child != nil {
+			nested, isObject := parseJSONObject(f.value)
+			if !isObject {
+				continue
+			}
+			f.value = nested.keep(child).marshal()
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// flatten replaces nested objects with their fields whose keys are joined with dots, such as {"a.b":1} for {"a":{"b":1}}.
+// Objects nested deeper than depth are kept as they are. If depth is 0, all objects are flattened.
+func (o jsonObject) flatten(depth int) jsonObject {
+	return o.flattenWithPrefix("", 1, depth)
+}
+
+func (o jsonObject) flattenWithPrefix(prefix string, level, depth int) jsonObject {
+	flattened := jsonObject{}
+	for _, f := range o {
+		key := prefix + f.key
+		child, ok := parseJSONObject(f.value)
+		if ok && len(child) > 0 && (depth == 0 || level <= depth) {
+			flattened = append(flattened, child.flattenWithPrefix(key+".", level+1, depth)...)
+			continue
+		}
+		flattened = append(flattened, jsonField{key: key, value: f.value})
+	}
+	return flattened
+}
+
+// fieldRename renames the field from to the key to.
+type fieldRename struct {
+	from string
+	to   string
+}
+
+// fieldTransform normalizes and slims JSON log events by renaming, keeping or dropping fields and flattening nested objects.
+// The nil fieldTransform does not change log events.
+type fieldTransform struct {
+	renames      []fieldRename
+	keepFields   fieldTree
+	dropFields   [][]string
+	flatten      bool
+	flattenDepth int
+}
+
+// parseFieldPath parses the path of the nested field such as a.b.c.
+func parseFieldPath(flagName, path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("argument error: %s must be the path of the field such as a.b.c, but got %s", flagName, path)
+		}
+	}
+	return keys, nil
+}
+
+// newFieldTransform creates the transform. renames are such as msg=message, and keepFields are comma-separated paths of fields.
+// It returns nil if there are no transforms.
+func newFieldTransform(renames, keepFields, dropFields []string, flatten bool, flattenDepth int) (*fieldTransform, error) {
+	if flattenDepth < 0 {
+		return nil, fmt.Errorf("argument error: --flatten-depth must be 0 or more, but got %d", flattenDepth)
+	}
+	if flattenDepth > 0 && !flatten {
+		return nil, fmt.Errorf("argument error: --flatten-depth requires --flatten")
+	}
+	if len(renames) == 0 && len(keepFields) == 0 && len(dropFields) == 0 && !flatten {
+		return nil, nil
+	}
+
+	t := &fieldTransform{flatten: flatten, flattenDepth: flattenDepth}
+	for _, rename := range renames {
+		from, to, ok := strings.Cut(rename, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("argument error: --rename-field must be OLD=NEW such as msg=message, but got %s", rename)
+		}
+		t.renames = append(t.renames, fieldRename{from: from, to: to})
+	}
+	for _, fields := range keepFields {
+		for _, field := range strings.Split(fields, ",") {
+			path, err := parseFieldPath("--keep-fields", strings.TrimSpace(field))
+			if err != nil {
+				return nil, err
+			}
+			if t.keepFields == nil {
+				t.keepFields = fieldTree{}
+			}
+			t.keepFields.add(path)
+		}
+	}
+	for _, field := range dropFields {
+		path, err := parseFieldPath("--drop-field", field)
+		if err != nil {
+			return nil, err
+		}
+		t.dropFields = append(t.dropFields, path)
+	}
+	return t, nil
+}
+
+// transform returns the log event with the transformed fields. Log events which are not JSON objects are not changed.
+func (t *fieldTransform) transform(event types.InputLogEvent) types.InputLogEvent {
+	if t == nil {
+		return event
+	}
+	o, ok := parseJSONObject([]byte(aws.ToString(event.Message)))
+	if !ok {
+		return event
+	}
+	for _, r := range t.renames {
+		o = o.rename(r.from, r.to)
+	}
+	if t.keepFields != nil {
+		o = o.keep(t.keepFields)
+	}
+	for _, path := range t.dropFields {
+		o = o.drop(path)
+	}
+	if t.flatten {
+		o = o.flatten(t.flattenDepth)
+	}
+	event.Message = aws.String(string(o.marshal()))
+	return event
+}
+
+// transformLogEvents returns log events with the transformed fields.
+func transformLogEvents(events []types.InputLogEvent, t *fieldTransform) []types.InputLogEvent {
+	if t == nil {
+		return events
+	}
+	transformed := make([]types.InputLogEvent, len(events))
+	for i, event := range events {
+		transformed[i] = t.transform(event)
+	}
+	return transformed
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_newFieldTransform(t *testing.T) {
+	tests := []struct {
+		name         string
+		renames      []string
+		keepFields   []string
+		dropFields   []string
+		flatten      bool
+		flattenDepth int
+		wantNil      bool
+		wantErr      bool
+	}{
+		{name: "Create no transform", wantNil: true},
+		{name: "Create transform", keepFields: []string{"level,http.status"}, dropFields: []string{"http.headers"}, flatten: true, flattenDepth: 2},
+		{name: "Create transform with renames", renames: []string{"msg=message", "lvl=level"}},
+		{name: "Create transform with invalid rename", renames: []string{"msg"}, wantErr: true},
+		{name: "Create transform with empty new name", renames: []string{"msg="}, wantErr: true},
+		{name: "Create transform with empty key", dropFields: []string{"http..headers"}, wantErr: true},
+		{name: "Create transform with negative depth", flatten: true, flattenDepth: -1, wantErr: true},
+		{name: "Create transform with depth without flatten", flattenDepth: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newFieldTransform(tt.renames, tt.keepFields, tt.dropFields, tt.flatten, tt.flattenDepth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newFieldTransform() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newFieldTransform() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_fieldTransform_transform(t *testing.T) {
+	message := `{"level":"INFO","http":{"status":200,"headers":{"accept":"*/*"}},"ctx":{"a":{"b":{"c":1}}},"tags":["x"]}`
+	tests := []struct {
+		name         string
+		renames      []string
+		keepFields   []string
+		dropFields   []string
+		flatten      bool
+		flattenDepth int
+		message      string
+		want         string
+	}{
+		{
+			name:       "Drop nested fields",
+			dropFields: []string{"http.headers", "ctx", "unknown.field"},
+			message:    message,
+			want:       `{"level":"INFO","http":{"status":200},"tags":["x"]}`,
+		},
+		{
+			name:       "Keep fields",
+			keepFields: []string{"level, http.status", "ctx.a"},
+			message:    message,
+			want:       `{"level":"INFO","http":{"status":200},"ctx":{"a":{"b":{"c":1}}}}`,
+		},
+		{
+			name:       "Keep and drop fields",
+			keepFields: []string{"http"},
+			dropFields: []string{"http.headers.accept"},
+			message:    message,
+			want:       `{"http":{"status":200,"headers":{}}}`,
+		},
+		{
+			name:    "Rename fields",
+			renames: []string{"msg=message", "lvl=level", "unknown=field"},
+			message: `{"lvl":"INFO","msg":"Start","http":{"status":200}}`,
+			want:    `{"level":"INFO","message":"Start","http":{"status":200}}`,
+		},
+		{
+			name:    "Don't rename to existing field",
+			renames: []string{"msg=message"},
+			message: `{"msg":"Start","message":"Started"}`,
+			want:    `{"msg":"Start","message":"Started"}`,
+		},
+		{
+			name:       "Rename fields before keeping fields",
+			renames:    []string{"lvl=level"},
+			keepFields: []string{"level"},
+			message:    `{"lvl":"INFO","msg":"Start"}`,
+			want:       `{"level":"INFO"}`,
+		},
+		{
+			name:    "Flatten all nested objects",
+			flatten: true,
+			message: message,
+			want:    `{"level":"INFO","http.status":200,"http.headers.accept":"*/*","ctx.a.b.c":1,"tags":["x"]}`,
+		},
+		{
+			name:         "Flatten nested objects within depth",
+			flatten:      true,
+			flattenDepth: 2,
+			message:      message,
+			want:         `{"level":"INFO","http.status":200,"http.headers.accept":"*/*","ctx.a.b":{"c":1},"tags":["x"]}`,
+		},
+		{
+			name:    "Don't flatten empty object",
+			flatten: true,
+			message: `{"a":{},"b":{"c":{}}}`,
+			want:    `{"a":{},"b.c":{}}`,
+		},
+		{
+			name:       "Don't change text log event",
+			dropFields: []string{"level"},
+			message:    "[INFO] Start Server",
+			want:       "[INFO] Start Server",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft, err := newFieldTransform(tt.renames, tt.keepFields, tt.dropFields, tt.flatten, tt.flattenDepth)
+			if err != nil {
+				t.Fatalf("newFieldTransform() error = %v", err)
+			}
+			got := ft.transform(types.InputLogEvent{Message: aws.String(tt.message)})
+			if aws.ToString(got.Message) != tt.want {
+				t.Errorf("fieldTransform.transform() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,830 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// filterPattern is a CloudWatch Logs filter pattern which matches messages of log events.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/FilterAndPatternSyntax.html
+type filterPattern interface {
+	match(message string) bool
+}
+
+// The kinds of filter patterns
+const (
+	patternMatchAll       = "match all"
+	patternTerms          = "terms"
+	patternJSON           = "JSON"
+	patternSpaceDelimited = "space-delimited"
+)
+
+// parseFilterPattern parses the filter pattern, and returns it with the kind.
+func parseFilterPattern(pattern string) (filterPattern, string, error) {
+	trimmed := strings.TrimSpace(pattern)
+	switch {
+	case trimmed == "":
+		return matchAllPattern{}, patternMatchAll, nil
+	case strings.HasPrefix(trimmed, "{"):
+		p, err := parseJSONPattern(trimmed)
+		return p, patternJSON, err
+	case strings.HasPrefix(trimmed, "["):
+		p, err := parseSpaceDelimitedPattern(trimmed)
+		return p, patternSpaceDelimited, err
+	}
+	p, err := parseTermsPattern(trimmed)
+	return p, patternTerms, err
+}
+
+type matchAllPattern struct{}
+
+func (matchAllPattern) match(string) bool { return true }
+
+// patternError is the error of the filter pattern at the position.
+func patternError(pos int, format string, v ...interface{}) error {
+	return fmt.Errorf("pattern error: %s at position %d", fmt.Sprintf(format, v...), pos+1)
+}
+
+// termsPattern matches messages including all terms and any of the optional terms, and excluding the excluded terms.
+type termsPattern struct {
+	required []termMatcher
+	optional []termMatcher
+	excluded []termMatcher
+}
+
+type termMatcher func(message string) bool
+
+func (p termsPattern) match(message string) bool {
+	for _, m := range p.required {
+		if !m(message) {
+			return false
+		}
+	}
+	for _, m := range p.excluded {
+		if m(message) {
+			return false
+		}
+	}
+	if len(p.optional) == 0 {
+		return true
+	}
+	for _, m := range p.optional {
+		if m(message) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTermsPattern(pattern string) (filterPattern, error) {
+	p := termsPattern{}
+	for i := 0; i < len(pattern); {
+		if pattern[i] == ' ' || pattern[i] == '\t' {
+			i++
+			continue
+		}
+		prefix := byte(0)
+		if pattern[i] == '?' || pattern[i] == '-' {
+			prefix = pattern[i]
+			i++
+		}
+		if i == len(pattern) {
+			return nil, patternError(i, "a term is expected after %c", prefix)
+		}
+
+		var m termMatcher
+		start := i
+		switch pattern[i] {
+		case '"':
+			term, end, err := scanQuoted(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			m = func(message string) bool { return strings.Contains(message, term) }
+			i = end
+		case '%':
+			re, end, err := scanRegexp(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			m = re.MatchString
+			i = end
+		default:
+			for i < len(pattern) && pattern[i] != ' ' && pattern[i] != '\t' {
+				i++
+			}
+			term := pattern[start:i]
+			for _, r := range term {
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					return nil, patternError(start, "the term %s has non-alphanumeric characters, so it must be in double quotes", term)
+				}
+			}
+			m = func(message string) bool { return strings.Contains(message, term) }
+		}
+
+		switch prefix {
+		case '?':
+			p.optional = append(p.optional, m)
+		case '-':
+			p.excluded = append(p.excluded, m)
+		default:
+			p.required = append(p.required, m)
+		}
+	}
+	return p, nil
+}
+
+// scanQuoted returns the string in double quotes from start, and the position after the closing quote.
+func scanQuoted(pattern string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(pattern[i])
+		}
+	}
+	return "", 0, patternError(start, "the double quote is not closed")
+}
+
+// scanRegexp returns the regular expression in % from start, and the position after the closing %.
+func scanRegexp(pattern string, start int) (*regexp.Regexp, int, error) {
+	end := strings.IndexByte(pattern[start+1:], '%')
+	if end < 0 {
+		return nil, 0, patternError(start, "the regular expression is not closed by %%")
+	}
+	expr := pattern[start+1 : start+1+end]
+	if expr == "" {
+		return nil, 0, patternError(start, "the regular expression is empty")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, 0, patternError(start, "invalid regular expression %s: %v", expr, err)
+	}
+	return re, start + end + 2, nil
+}
+
+// patternToken is a token of JSON and space-delimited patterns.
+type patternToken struct {
+	kind string
+	text string
+	pos  int
+	// re is the regular expression of the regexp token
+	re *regexp.Regexp
+}
+
+// The kinds of patternToken
+const (
+	tokenSymbol   = "symbol"
+	tokenOperator = "operator"
+	tokenSelector = "selector"
+	tokenString   = "string"
+	tokenWord     = "word"
+	tokenRegexp   = "regexp"
+	tokenEnd      = "end"
+)
+
+// tokenizePattern splits JSON and space-delimited patterns into tokens.
+func tokenizePattern(pattern string) ([]patternToken, error) {
+	tokens := []patternToken{}
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(pattern[i:], "&&") || strings.HasPrefix(pattern[i:], "||"):
+			tokens = append(tokens, patternToken{kind: tokenSymbol, text: pattern[i : i+2], pos: i})
+			i += 2
+		case strings.HasPrefix(pattern[i:], "..."):
+			tokens = append(tokens, patternToken{kind: tokenSymbol, text: "...", pos: i})
+			i += 3
+		case strings.ContainsRune("{}[](),", rune(c)):
+			tokens = append(tokens, patternToken{kind: tokenSymbol, text: string(c), pos: i})
+			i++
+		case strings.ContainsRune("=!<>", rune(c)):
+			op := string(c)
+			if i+1 < len(pattern) && pattern[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, patternError(i, "! must be followed by =")
+			}
+			tokens = append(tokens, patternToken{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		case c == '"':
+			s, end, err := scanQuoted(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, patternToken{kind: tokenString, text: s, pos: i})
+			i = end
+		case c == '%':
+			re, end, err := scanRegexp(pattern, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, patternToken{kind: tokenRegexp, text: pattern[i:end], pos: i, re: re})
+			i = end
+		default:
+			// Selectors have brackets of indexes such as $.roles[0]
+			kind, delimiters := tokenWord, " \t{}[](),=!<>\"&|"
+			if c == '$' {
+				kind, delimiters = tokenSelector, " \t{}(),=!<>\"&|"
+			}
+			start := i
+			for i < len(pattern) && !strings.ContainsRune(delimiters, rune(pattern[i])) {
+				i++
+			}
+			if i == start {
+				return nil, patternError(i, "unexpected %c", c)
+			}
+			tokens = append(tokens, patternToken{kind: kind, text: pattern[start:i], pos: start})
+		}
+	}
+	return append(tokens, patternToken{kind: tokenEnd, pos: len(pattern)}), nil
+}
+
+// patternParser parses tokens of JSON and space-delimited patterns.
+type patternParser struct {
+	tokens []patternToken
+	i      int
+}
+
+func (p *patternParser) peek() patternToken {
+	return p.tokens[p.i]
+}
+
+func (p *patternParser) next() patternToken {
+	t := p.tokens[p.i]
+	if t.kind != tokenEnd {
+		p.i++
+	}
+	return t
+}
+
+func (p *patternParser) expect(symbol string) error {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != symbol {
+		return patternError(t.pos, "%s is expected, but got %s", symbol, describeToken(t))
+	}
+	return nil
+}
+
+func describeToken(t patternToken) string {
+	if t.kind == tokenEnd {
+		return "the end of the pattern"
+	}
+	return t.text
+}
+
+// valueCondition compares a value of the log event with the value in the pattern.
+type valueCondition struct {
+	op string
+	// number is set if the value in the pattern is a number
+	number *float64
+	text   string
+	re     *regexp.Regexp
+}
+
+// parseValueCondition parses the operator and the value.
+func (p *patternParser) parseValueCondition() (valueCondition, error) {
+	opToken := p.next()
+	if opToken.kind != tokenOperator {
+		return valueCondition{}, patternError(opToken.pos, "an operator such as = is expected, but got %s", describeToken(opToken))
+	}
+	c := valueCondition{op: opToken.text}
+	value := p.next()
+	switch value.kind {
+	case tokenString:
+		c.text = value.text
+	case tokenWord:
+		c.text = value.text
+		if n, err := strconv.ParseFloat(value.text, 64); err == nil {
+			c.number = &n
+		}
+	case tokenRegexp:
+		if c.op != "=" && c.op != "!=" {
+			return valueCondition{}, patternError(value.pos, "a regular expression can be used only with = or !=")
+		}
+		c.re = value.re
+	default:
+		return valueCondition{}, patternError(value.pos, "a value is expected, but got %s", describeToken(value))
+	}
+	if c.op != "=" && c.op != "!=" && c.number == nil {
+		return valueCondition{}, patternError(value.pos, "%s requires a number, but got %s", c.op, value.text)
+	}
+	return c, nil
+}
+
+// matchGlob reports whether s matches the pattern in which * matches any characters.
+func matchGlob(pattern, s string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == s
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+func compareNumbers(op string, a, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// matchString reports whether the string value of the log event meets the condition.
+// The value is compared as a number if the value in the pattern is a number.
+func (c valueCondition) matchString(s string) bool {
+	if c.re != nil {
+		return c.re.MatchString(s) == (c.op == "=")
+	}
+	if c.number != nil {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return c.op == "!="
+		}
+		return compareNumbers(c.op, n, *c.number)
+	}
+	return matchGlob(c.text, s) == (c.op == "=")
+}
+
+// matchJSON reports whether the JSON value of the log event meets the condition.
+func (c valueCondition) matchJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		if c.number != nil && c.re == nil {
+			// A number in the pattern does not match a string
+			return c.op == "!="
+		}
+		return c.matchString(v)
+	case float64:
+		if c.number == nil {
+			return c.op == "!="
+		}
+		return compareNumbers(c.op, v, *c.number)
+	case bool:
+		return (strconv.FormatBool(v) == strings.ToLower(c.text)) == (c.op == "=")
+	}
+	return false
+}
+
+// jsonPattern matches JSON log events by the conditions on their fields.
+type jsonPattern struct {
+	expr jsonExpr
+}
+
+func (p jsonPattern) match(message string) bool {
+	var v interface{}
+	if err := json.Unmarshal([]byte(message), &v); err != nil {
+		return false
+	}
+	return p.expr.eval(v)
+}
+
+type jsonExpr interface {
+	eval(v interface{}) bool
+}
+
+type jsonAnd []jsonExpr
+
+func (e jsonAnd) eval(v interface{}) bool {
+	for _, expr := range e {
+		if !expr.eval(v) {
+			return false
+		}
+	}
+	return true
+}
+
+type jsonOr []jsonExpr
+
+func (e jsonOr) eval(v interface{}) bool {
+	for _, expr := range e {
+		if expr.eval(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonSelectorSegment is a field name or an index of an array in a selector such as $.user.roles[0].
+type jsonSelectorSegment struct {
+	field string
+	index int
+}
+
+type jsonSelector []jsonSelectorSegment
+
+func parseJSONSelector(t patternToken) (jsonSelector, error) {
+	s := strings.TrimPrefix(t.text, "$")
+	selector := jsonSelector{}
+	for s != "" {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			if end == 0 {
+				return nil, patternError(t.pos, "the selector %s has an empty field", t.text)
+			}
+			selector = append(selector, jsonSelectorSegment{field: s[1 : end+1], index: -1})
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, patternError(t.pos, "the selector %s has an unclosed [", t.text)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, patternError(t.pos, "the selector %s has an invalid index %s", t.text, s[1:end])
+			}
+			selector = append(selector, jsonSelectorSegment{index: index})
+			s = s[end+1:]
+		default:
+			return nil, patternError(t.pos, "the selector %s must be like $.field", t.text)
+		}
+	}
+	if len(selector) == 0 {
+		return nil, patternError(t.pos, "the selector %s has no fields", t.text)
+	}
+	return selector, nil
+}
+
+// lookup returns the value selected in v, and false if it does not exist.
+func (s jsonSelector) lookup(v interface{}) (interface{}, bool) {
+	for _, segment := range s {
+		if segment.index < 0 {
+			object, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = object[segment.field]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		array, ok := v.([]interface{})
+		if !ok || segment.index >= len(array) {
+			return nil, false
+		}
+		v = array[segment.index]
+	}
+	return v, true
+}
+
+// The tests of fields by IS and NOT EXISTS
+const (
+	jsonIsNull    = "IS NULL"
+	jsonIsTrue    = "IS TRUE"
+	jsonIsFalse   = "IS FALSE"
+	jsonNotExists = "NOT EXISTS"
+)
+
+// jsonCondition is a condition on a field such as $.status = 200 or $.error IS NULL.
+type jsonCondition struct {
+	selector jsonSelector
+	test     string
+	value    valueCondition
+}
+
+func (c jsonCondition) eval(v interface{}) bool {
+	field, ok := c.selector.lookup(v)
+	switch c.test {
+	case jsonNotExists:
+		return !ok
+	case jsonIsNull:
+		return ok && field == nil
+	case jsonIsTrue:
+		return ok && field == true
+	case jsonIsFalse:
+		return ok && field == false
+	}
+	return ok && c.value.matchJSON(field)
+}
+
+func parseJSONPattern(pattern string) (filterPattern, error) {
+	tokens, err := tokenizePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	p := &patternParser{tokens: tokens}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseJSONOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenEnd {
+		return nil, patternError(t.pos, "unexpected %s after }", t.text)
+	}
+	return jsonPattern{expr: expr}, nil
+}
+
+func (p *patternParser) parseJSONOr() (jsonExpr, error) {
+	or := jsonOr{}
+	for {
+		expr, err := p.parseJSONAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, expr)
+		if t := p.peek(); t.kind != tokenSymbol || t.text != "||" {
+			break
+		}
+		p.next()
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *patternParser) parseJSONAnd() (jsonExpr, error) {
+	and := jsonAnd{}
+	for {
+		expr, err := p.parseJSONTerm()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, expr)
+		if t := p.peek(); t.kind != tokenSymbol || t.text != "&&" {
+			break
+		}
+		p.next()
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *patternParser) parseJSONTerm() (jsonExpr, error) {
+	t := p.next()
+	if t.kind == tokenSymbol && t.text == "(" {
+		expr, err := p.parseJSONOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	}
+	if t.kind != tokenSelector {
+		return nil, patternError(t.pos, "a selector such as $.field is expected, but got %s", describeToken(t))
+	}
+	selector, err := parseJSONSelector(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyword := p.peek(); keyword.kind == tokenWord {
+		p.next()
+		operand := p.next()
+		test := strings.ToUpper(keyword.text + " " + operand.text)
+		switch test {
+		case jsonIsNull, jsonIsTrue, jsonIsFalse, jsonNotExists:
+			return jsonCondition{selector: selector, test: test}, nil
+		}
+		return nil, patternError(keyword.pos, "IS NULL, IS TRUE, IS FALSE or NOT EXISTS is expected, but got %s %s", keyword.text, describeToken(operand))
+	}
+	value, err := p.parseValueCondition()
+	if err != nil {
+		return nil, err
+	}
+	return jsonCondition{selector: selector, value: value}, nil
+}
+
+// spaceDelimitedField is a field of the space-delimited pattern. The ellipsis matches any number of fields.
+type spaceDelimitedField struct {
+	name     string
+	ellipsis bool
+	// conditions are ORed, and each of them is ANDed conditions
+	conditions [][]valueCondition
+}
+
+func (f spaceDelimitedField) match(value string) bool {
+	if len(f.conditions) == 0 {
+		return true
+	}
+	for _, and := range f.conditions {
+		matched := true
+		for _, c := range and {
+			if !c.matchString(value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// spaceDelimitedPattern matches log events whose fields delimited by spaces meet the conditions.
+type spaceDelimitedPattern struct {
+	fields []spaceDelimitedField
+}
+
+// splitSpaceDelimited splits the message into fields delimited by spaces.
+// Characters in double quotes or brackets are a field without them.
+func splitSpaceDelimited(message string) []string {
+	fields := []string{}
+	for i := 0; i < len(message); {
+		switch message[i] {
+		case ' ', '\t':
+			i++
+			continue
+		case '"', '[':
+			closing := byte('"')
+			if message[i] == '[' {
+				closing = ']'
+			}
+			if end := strings.IndexByte(message[i+1:], closing); end >= 0 {
+				fields = append(fields, message[i+1:i+1+end])
+				i += end + 2
+				continue
+			}
+		}
+		end := strings.IndexAny(message[i:], " \t")
+		if end < 0 {
+			end = len(message) - i
+		}
+		fields = append(fields, message[i:i+end])
+		i += end
+	}
+	return fields
+}
+
+func (p spaceDelimitedPattern) match(message string) bool {
+	return matchFields(p.fields, splitSpaceDelimited(message))
+}
+
+func matchFields(fields []spaceDelimitedField, values []string) bool {
+	if len(fields) == 0 {
+		return len(values) == 0
+	}
+	if fields[0].ellipsis {
+		for i := 0; i <= len(values); i++ {
+			if matchFields(fields[1:], values[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(values) > 0 && fields[0].match(values[0]) && matchFields(fields[1:], values[1:])
+}
+
+func parseSpaceDelimitedPattern(pattern string) (filterPattern, error) {
+	tokens, err := tokenizePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	p := &patternParser{tokens: tokens}
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	fields := []spaceDelimitedField{}
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenSymbol && t.text == "...":
+			fields = append(fields, spaceDelimitedField{ellipsis: true})
+		case t.kind == tokenWord:
+			field := spaceDelimitedField{name: t.text}
+			if next := p.peek(); next.kind == tokenOperator {
+				if field.conditions, err = p.parseFieldConditions(); err != nil {
+					return nil, err
+				}
+			}
+			fields = append(fields, field)
+		default:
+			return nil, patternError(t.pos, "a field name or ... is expected, but got %s", describeToken(t))
+		}
+
+		t = p.next()
+		if t.kind == tokenSymbol && t.text == "]" {
+			break
+		}
+		if t.kind != tokenSymbol || t.text != "," {
+			return nil, patternError(t.pos, ", or ] is expected, but got %s", describeToken(t))
+		}
+	}
+	if t := p.next(); t.kind != tokenEnd {
+		return nil, patternError(t.pos, "unexpected %s after ]", t.text)
+	}
+	return spaceDelimitedPattern{fields: fields}, nil
+}
+
+// parseFieldConditions parses conditions of a field such as status = 4* || status = 5*.
+func (p *patternParser) parseFieldConditions() ([][]valueCondition, error) {
+	or := [][]valueCondition{}
+	and := []valueCondition{}
+	for {
+		c, err := p.parseValueCondition()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, c)
+
+		t := p.peek()
+		if t.kind != tokenSymbol || (t.text != "&&" && t.text != "||") {
+			return append(or, and), nil
+		}
+		p.next()
+		if t.text == "||" {
+			or = append(or, and)
+			and = []valueCondition{}
+		}
+		// The field name is repeated before the next condition
+		if name := p.peek(); name.kind == tokenWord {
+			p.next()
+		}
+	}
+}
+
+func runCheckPattern(args []string) error {
+	fileName := ""
+	inputFormat := inputFormatAuto
+	showAll := false
+
+	flags := newSubcommandFlagSet(args[0], "Check the syntax of the filter pattern, and test it against log events in the file.", "<FILTER PATTERN> [--logs-file <FILE PATH>] [options]")
+	flags.StringVar(&fileName, "logs-file", "", "The path of file that includes log events to test the filter pattern against.")
+	flags.StringVar(&inputFormat, "input-format", inputFormatAuto, "The format of --logs-file. auto, json, ndjson, logfmt or text.")
+	flags.BoolVar(&showAll, "show-all", false, "Show log events which do not match the filter pattern as well.")
+	positionals := parseInterspersed(flags, args[1:])
+
+	if len(positionals) != 1 {
+		return errors.New("argument error: a filter pattern is required. quote it such as '?ERROR ?WARN'")
+	}
+	if err := validateInputFormat(inputFormat); err != nil {
+		return err
+	}
+
+	pattern, kind, err := parseFilterPattern(positionals[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("The filter pattern is valid. It is a %s pattern.\n", kind)
+	if fileName == "" {
+		return nil
+	}
+
+	logs, err := getLogEventsFromFile(fileName, inputFormat)
+	if err != nil {
+		return err
+	}
+	matched := 0
+	for _, log := range logs {
+		ok := pattern.match(log)
+		if ok {
+			matched++
+		}
+		switch {
+		case ok && showAll:
+			fmt.Printf("+ %s\n", log)
+		case ok:
+			fmt.Println(log)
+		case showAll:
+			fmt.Printf("- %s\n", log)
+		}
+	}
+	fmt.Printf("Matched %d of %d log events\n", matched, len(logs))
+	return nil
+}
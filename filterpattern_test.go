@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseFilterPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		wantKind string
+		wantErr  bool
+	}{
+		{name: "Parse empty pattern", pattern: "", wantKind: patternMatchAll},
+		{name: "Parse terms", pattern: `?ERROR ?WARN -"health check"`, wantKind: patternTerms},
+		{name: "Parse regular expression", pattern: `%ERR(OR)?%`, wantKind: patternTerms},
+		{name: "Parse unquoted non-alphanumeric term", pattern: `user-agent`, wantErr: true},
+		{name: "Parse unclosed quote", pattern: `"ERROR`, wantErr: true},
+		{name: "Parse invalid regular expression", pattern: `%ERR(%`, wantErr: true},
+		{name: "Parse JSON pattern", pattern: `{ ($.status >= 500 || $.level = "ERROR") && $.roles[0] = admin* }`, wantKind: patternJSON},
+		{name: "Parse JSON pattern with IS", pattern: `{ $.error IS NULL && $.user NOT EXISTS }`, wantKind: patternJSON},
+		{name: "Parse JSON pattern without selector", pattern: `{ status = 500 }`, wantErr: true},
+		{name: "Parse JSON pattern without closing brace", pattern: `{ $.status = 500`, wantErr: true},
+		{name: "Parse JSON pattern comparing string", pattern: `{ $.status > high }`, wantErr: true},
+		{name: "Parse JSON pattern with unknown keyword", pattern: `{ $.error IS MISSING }`, wantErr: true},
+		{name: "Parse space-delimited pattern", pattern: `[ip, user, ..., status = 4* || status = 5*, bytes > 1000]`, wantKind: patternSpaceDelimited},
+		{name: "Parse space-delimited pattern without comma", pattern: `[ip user]`, wantErr: true},
+		{name: "Parse space-delimited pattern without closing bracket", pattern: `[ip, user`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, kind, err := parseFilterPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFilterPattern() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && kind != tt.wantKind {
+				t.Errorf("parseFilterPattern() kind = %v, want %v", kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func Test_filterPattern_match(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		message string
+		want    bool
+	}{
+		{name: "Match all", pattern: "", message: "anything", want: true},
+		{name: "Match all terms", pattern: "ERROR Exception", message: "[ERROR] Caught IllegalArgumentException", want: true},
+		{name: "Don't match lacking term", pattern: "ERROR Timeout", message: "[ERROR] Caught IllegalArgumentException", want: false},
+		{name: "Match any optional term", pattern: "?ERROR ?WARN", message: "[WARN] Disk is almost full", want: true},
+		{name: "Don't match no optional terms", pattern: "?ERROR ?WARN", message: "[INFO] Start Server", want: false},
+		{name: "Don't match excluded term", pattern: `ERROR -"health check"`, message: "[ERROR] health check failed", want: false},
+		{name: "Match terms case-sensitively", pattern: "ERROR", message: "[error] Caught Exception", want: false},
+		{name: "Match regular expression", pattern: `%ERR(OR)?:%`, message: "ERR: timeout", want: true},
+		{name: "Match JSON string", pattern: `{ $.level = "ERROR" }`, message: `{"level":"ERROR"}`, want: true},
+		{name: "Match JSON wildcard", pattern: `{ $.user.name = adm* }`, message: `{"user":{"name":"admin"}}`, want: true},
+		{name: "Match JSON number", pattern: `{ $.status >= 500 }`, message: `{"status":503}`, want: true},
+		{name: "Don't match JSON number as string", pattern: `{ $.status = 503 }`, message: `{"status":"503"}`, want: false},
+		{name: "Match JSON array", pattern: `{ $.roles[1] = "admin" }`, message: `{"roles":["user","admin"]}`, want: true},
+		{name: "Match JSON or", pattern: `{ $.status >= 500 || $.level = ERROR }`, message: `{"status":200,"level":"ERROR"}`, want: true},
+		{name: "Don't match JSON and", pattern: `{ $.status >= 500 && $.level = ERROR }`, message: `{"status":200,"level":"ERROR"}`, want: false},
+		{name: "Match JSON not equal", pattern: `{ $.level != "INFO" }`, message: `{"level":"WARN"}`, want: true},
+		{name: "Match JSON IS NULL", pattern: `{ $.error IS NULL }`, message: `{"error":null}`, want: true},
+		{name: "Match JSON IS TRUE", pattern: `{ $.retried IS TRUE }`, message: `{"retried":true}`, want: true},
+		{name: "Match JSON NOT EXISTS", pattern: `{ $.error NOT EXISTS }`, message: `{"level":"INFO"}`, want: true},
+		{name: "Match JSON regular expression", pattern: `{ $.path = %^/api/% }`, message: `{"path":"/api/users"}`, want: true},
+		{name: "Don't match non-JSON", pattern: `{ $.level = "ERROR" }`, message: `level=ERROR`, want: false},
+		{name: "Match space-delimited fields", pattern: `[ip, user, status = 404, bytes]`, message: `127.0.0.1 frank 404 2326`, want: true},
+		{name: "Don't match number of space-delimited fields", pattern: `[ip, user, status = 404]`, message: `127.0.0.1 frank 404 2326`, want: false},
+		{
+			name:    "Match space-delimited fields with ellipsis",
+			pattern: `[ip, ..., status = 4* || status = 5*, bytes > 1000]`,
+			message: `127.0.0.1 - frank [10/Oct/2000:13:25:15 -0700] "GET /index.html HTTP/1.0" 404 2326`,
+			want:    true,
+		},
+		{
+			name:    "Match quoted space-delimited field",
+			pattern: `[ip, user, username, timestamp, request = "GET /index.html*", ...]`,
+			message: `127.0.0.1 - frank [10/Oct/2000:13:25:15 -0700] "GET /index.html HTTP/1.0" 404 2326`,
+			want:    true,
+		},
+		{name: "Don't match space-delimited condition", pattern: `[..., bytes > 1000 && bytes < 2000]`, message: `GET 2326`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, err := parseFilterPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("parseFilterPattern() error = %v", err)
+			}
+			if got := p.match(tt.message); got != tt.want {
+				t.Errorf("filterPattern.match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitSpaceDelimited(t *testing.T) {
+	message := `127.0.0.1 - frank [10/Oct/2000:13:25:15 -0700] "GET /index.html HTTP/1.0" 404`
+	want := []string{"127.0.0.1", "-", "frank", "10/Oct/2000:13:25:15 -0700", "GET /index.html HTTP/1.0", "404"}
+	if got := splitSpaceDelimited(message); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSpaceDelimited() = %q, want %q", got, want)
+	}
+}
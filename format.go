@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The formats of files that include log events
+const (
+	inputFormatAuto   = "auto"
+	inputFormatJSON   = "json"
+	inputFormatNDJSON = "ndjson"
+	inputFormatLogfmt = "logfmt"
+	inputFormatText   = "text"
+)
+
+func validateInputFormat(format string) error {
+	switch format {
+	case inputFormatAuto, inputFormatJSON, inputFormatNDJSON, inputFormatLogfmt, inputFormatText:
+		return nil
+	}
+	return fmt.Errorf("argument error: --input-format must be %s, %s, %s, %s or %s, but got %s", inputFormatAuto, inputFormatJSON, inputFormatNDJSON, inputFormatLogfmt, inputFormatText, format)
+}
+
+// detectInputFormat guesses the format from the data. It falls back to text if the data is not in the other formats,
+// but returns the error if the data looks like JSON and is not valid, since it is a malformed or truncated file rather than text.
+func detectInputFormat(data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) && json.Valid(trimmed) {
+		return inputFormatJSON, nil
+	}
+
+	line := firstLine(trimmed)
+	if strings.HasPrefix(line, "{") && json.Valid([]byte(line)) {
+		return inputFormatNDJSON, nil
+	}
+	if looksLikeJSON(trimmed) {
+		var v interface{}
+		err := json.Unmarshal(trimmed, &v)
+		if err == nil {
+			// A single JSON object is neither an array of log events nor NDJSON
+			err = errors.New("log events must be a JSON array or JSON objects line by line")
+		}
+		return "", fmt.Errorf("format error: the data looks like JSON but is not valid: %w", err)
+	}
+	if _, ok := parseLogfmt(line); ok {
+		return inputFormatLogfmt, nil
+	}
+	return inputFormatText, nil
+}
+
+// looksLikeJSON reports whether the data starts like a JSON array or a JSON object, such as [" or {",
+// unlike text such as [INFO] Start Server.
+func looksLikeJSON(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte("[")) && !bytes.HasPrefix(data, []byte("{")) {
+		return false
+	}
+	rest := bytes.TrimLeftFunc(data[1:], unicode.IsSpace)
+	return len(rest) == 0 || strings.ContainsRune(`"[]{}`, rune(rest[0]))
+}
+
+func firstLine(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// parseLogs parses log events in the format. JSON objects are converted to compact strings.
+// Lines of text formats are limited by lines before they are parsed.
+func parseLogs(data []byte, format string, lines *lineLimiter) ([]string, error) {
+	if format == inputFormatAuto {
+		var err error
+		if format, err = detectInputFormat(data); err != nil {
+			return nil, err
+		}
+	}
+	if format == inputFormatJSON {
+		return parseLogEvents(data)
+	}
+
+	logs := []string{}
+	err := parseLogLines(bytes.NewReader(data), format, lines, func(log string) error {
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// parseLogLines calls f with each log event of r in the line-based format as soon as the line is read.
+// Lines are limited by lines before they are parsed.
+func parseLogLines(r io.Reader, format string, lines *lineLimiter, f func(log string) error) error {
+	n := 0
+	return lines.scan(r, func(raw string) error {
+		n++
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			return nil
+		}
+		switch format {
+		case inputFormatNDJSON:
+			var event interface{}
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				return fmt.Errorf("format error: line %d is not JSON: %w", n, err)
+			}
+			log, err := logEventString(event)
+			if err != nil {
+				return err
+			}
+			return f(log)
+		case inputFormatLogfmt:
+			fields, ok := parseLogfmt(line)
+			if !ok {
+				return fmt.Errorf("format error: line %d is not logfmt", n)
+			}
+			b, err := json.Marshal(fields)
+			if err != nil {
+				return err
+			}
+			return f(string(b))
+		}
+		return f(raw)
+	})
+}
+
+// parseLogfmt parses the line such as `level=info msg="Start Server"`.
+// It returns false if the line has a word which is not key=value.
+func parseLogfmt(line string) (map[string]string, bool) {
+	fields := map[string]string{}
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		i := strings.IndexFunc(rest, func(r rune) bool { return r == '=' || unicode.IsSpace(r) })
+		if i <= 0 || rest[i] != '=' {
+			return nil, false
+		}
+		key := rest[:i]
+		rest = rest[i+1:]
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			// Find the closing quote skipping escaped ones
+			end := 1
+			for ; end < len(rest) && rest[end] != '"'; end++ {
+				if rest[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(rest) {
+				return nil, false
+			}
+			unquoted, err := unquote(rest[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			value, rest = unquoted, rest[end+1:]
+		} else if j := strings.IndexFunc(rest, unicode.IsSpace); j >= 0 {
+			value, rest = rest[:j], rest[j:]
+		} else {
+			value, rest = rest, ""
+		}
+		if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+			return nil, false
+		}
+		fields[key] = value
+		rest = strings.TrimSpace(rest)
+	}
+	return fields, len(fields) > 0
+}
+
+func unquote(s string) (string, error) {
+	var v string
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+// wrapLogEvent wraps the log event which is not a JSON object into a JSON object with the message in the key.
+// If key is empty, it does not change the log event.
+func wrapLogEvent(event types.InputLogEvent, key string) types.InputLogEvent {
+	message := aws.ToString(event.Message)
+	trimmed := strings.TrimSpace(message)
+	if key == "" || (strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed))) {
+		return event
+	}
+	b, err := json.Marshal(map[string]string{key: message})
+	if err != nil {
+		return event
+	}
+	event.Message = aws.String(string(b))
+	return event
+}
+
+// wrapLogEvents returns log events wrapped into JSON objects with the key.
+func wrapLogEvents(events []types.InputLogEvent, key string) []types.InputLogEvent {
+	if key == "" {
+		return events
+	}
+	wrapped := make([]types.InputLogEvent, len(events))
+	for i, event := range events {
+		wrapped[i] = wrapLogEvent(event, key)
+	}
+	return wrapped
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_detectInputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Detect JSON array", data: "\n[\"[INFO] Start Server\"]\n", want: inputFormatJSON},
+		{name: "Detect NDJSON", data: "{\"level\":\"info\"}\n{\"level\":\"error\"}\n", want: inputFormatNDJSON},
+		{name: "Detect logfmt", data: "level=info msg=\"Start Server\"\n", want: inputFormatLogfmt},
+		{name: "Detect text", data: "[INFO] Start Server\n", want: inputFormatText},
+		{name: "Detect text looking like JSON", data: "{broken\n", want: inputFormatText},
+		{name: "Detect empty data", data: "", want: inputFormatText},
+		{name: "Detect truncated JSON array", data: "[\n  \"[INFO] Start Server\",\n  \"[INFO] Stop", wantErr: true},
+		{name: "Detect malformed JSON array", data: "[\"[INFO] Start Server\",]", wantErr: true},
+		{name: "Detect JSON object", data: "{\n  \"level\": \"info\"\n}\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectInputFormat([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("detectInputFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("detectInputFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseLogs(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "Parse JSON array",
+			data:   `[{"level":"info"},"[ERROR] Failed to Start Server"]`,
+			format: inputFormatAuto,
+			want:   []string{`{"level":"info"}`, "[ERROR] Failed to Start Server"},
+		},
+		{
+			name:   "Parse NDJSON",
+			data:   "{\"level\": \"info\"}\n\n\"[ERROR] Failed to Start Server\"\n",
+			format: inputFormatNDJSON,
+			want:   []string{`{"level":"info"}`, "[ERROR] Failed to Start Server"},
+		},
+		{
+			name:   "Parse logfmt",
+			data:   "level=info msg=\"Start Server\"\nlevel=error msg=failed\n",
+			format: inputFormatAuto,
+			want:   []string{`{"level":"info","msg":"Start Server"}`, `{"level":"error","msg":"failed"}`},
+		},
+		{
+			name:   "Parse text",
+			data:   "[INFO] Start Server\n[ERROR] Failed to Start Server\n",
+			format: inputFormatAuto,
+			want:   []string{"[INFO] Start Server", "[ERROR] Failed to Start Server"},
+		},
+		{
+			name:    "Parse invalid NDJSON",
+			data:    "{\"level\":\"info\"}\n[INFO] Start Server\n",
+			format:  inputFormatNDJSON,
+			wantErr: true,
+		},
+		{
+			name:    "Parse truncated JSON array",
+			data:    "[\"[INFO] Start Server\", \"[ERROR] Failed",
+			format:  inputFormatAuto,
+			wantErr: true,
+		},
+		{
+			name:   "Parse truncated JSON array as text",
+			data:   "[\"[INFO] Start Server\", \"[ERROR] Failed",
+			format: inputFormatText,
+			want:   []string{"[\"[INFO] Start Server\", \"[ERROR] Failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogs([]byte(tt.data), tt.format, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLogs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLogs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseLogfmt(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   map[string]string
+		wantOK bool
+	}{
+		{
+			name:   "Parse key and value",
+			line:   "level=info status=200",
+			want:   map[string]string{"level": "info", "status": "200"},
+			wantOK: true,
+		},
+		{
+			name:   "Parse quoted value",
+			line:   `msg="Start \"api\" Server" level=info`,
+			want:   map[string]string{"msg": `Start "api" Server`, "level": "info"},
+			wantOK: true,
+		},
+		{
+			name:   "Parse empty value",
+			line:   "user= level=info",
+			want:   map[string]string{"user": "", "level": "info"},
+			wantOK: true,
+		},
+		{
+			name:   "Parse text",
+			line:   "[INFO] Start Server",
+			wantOK: false,
+		},
+		{
+			name:   "Parse unclosed quote",
+			line:   `msg="Start Server`,
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLogfmt(tt.line)
+			if ok != tt.wantOK || (ok && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("parseLogfmt() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_wrapLogEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		key     string
+		want    string
+	}{
+		{name: "Wrap text log event", message: `[INFO] Start "Server"`, key: "message", want: `{"message":"[INFO] Start \"Server\""}`},
+		{name: "Wrap text log event with key", message: "Start Server", key: "msg", want: `{"msg":"Start Server"}`},
+		{name: "Wrap JSON array log event", message: `["a","b"]`, key: "message", want: `{"message":"[\"a\",\"b\"]"}`},
+		{name: "Don't wrap JSON object log event", message: `{"level":"INFO"}`, key: "message", want: `{"level":"INFO"}`},
+		{name: "Don't wrap without key", message: "Start Server", key: "", want: "Start Server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapLogEvent(types.InputLogEvent{Message: aws.String(tt.message), Timestamp: aws.Int64(1)}, tt.key)
+			if aws.ToString(got.Message) != tt.want || aws.ToInt64(got.Timestamp) != 1 {
+				t.Errorf("wrapLogEvent() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const defaultGenerateTemplate = `{"level":"{{randChoice "info" "warn" "error"}}","msg":"{{randWord}} {{randWord}}","seq":{{seq}}}`
+
+var randWords = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliett",
+	"kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango",
+}
+
+// parseRate parses the rate such as 500/s, 30/m or 10/h into the number of log events per second.
+func parseRate(s string) (float64, error) {
+	per := time.Second
+	n := s
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		n = s[:i]
+		switch s[i+1:] {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return 0, fmt.Errorf("invalid rate %s", s)
+		}
+	}
+	rate, err := strconv.ParseFloat(n, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid rate %s", s)
+	}
+	return rate / per.Seconds(), nil
+}
+
+// logGenerator generates log events from the template. The template can use randWord, randInt, randChoice, seq and now.
+type logGenerator struct {
+	tmpl *template.Template
+	seq  int
+}
+
+func newLogGenerator(text string, r *rand.Rand) (*logGenerator, error) {
+	g := &logGenerator{}
+	funcs := template.FuncMap{
+		"randWord": func() string {
+			return randWords[r.Intn(len(randWords))]
+		},
+		"randInt": func(min, max int) int {
+			return min + r.Intn(max-min+1)
+		},
+		"randChoice": func(choices ...string) string {
+			return choices[r.Intn(len(choices))]
+		},
+		"seq": func() int {
+			return g.seq
+		},
+		"now": func() string {
+			return time.Now().UTC().Format(time.RFC3339Nano)
+		},
+	}
+	tmpl, err := template.New("log").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("argument error: --template: %w", err)
+	}
+	g.tmpl = tmpl
+	return g, nil
+}
+
+// generate generates n log events happened at the time.
+func (g *logGenerator) generate(n int, t time.Time) ([]types.InputLogEvent, error) {
+	events := make([]types.InputLogEvent, n)
+	for i := range events {
+		g.seq++
+		buf := &bytes.Buffer{}
+		if err := g.tmpl.Execute(buf, nil); err != nil {
+			return nil, err
+		}
+		events[i] = types.InputLogEvent{
+			Message:   aws.String(buf.String()),
+			Timestamp: aws.Int64(toMillis(t)),
+		}
+	}
+	return events, nil
+}
+
+func runGenerate(args []string) error {
+	params := parameters{}
+	dst := destination{}
+	rate := ""
+	duration := time.Minute
+	text := ""
+
+	flags := newSubcommandFlagSet(args[0], "Generate log events for load testing.", "--log-group <LOG GROUP NAME> --rate <RATE> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&dst.logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&dst.logStream, "log-stream", "", "The name of the log stream. If you do not use this parameters, it uploads logs to latest log stream.")
+	flags.StringVar(&rate, "rate", "", "The number of log events generated such as 500/s, 30/m or 10/h. It is required.")
+	flags.DurationVar(&duration, "duration", time.Minute, "The duration to generate log events.")
+	flags.StringVar(&text, "template", defaultGenerateTemplate, "The Go template of log events. It can use randWord, randInt MIN MAX, randChoice CHOICES..., seq and now.")
+	flags.Parse(args[1:])
+
+	if dst.logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if rate == "" {
+		return errors.New("argument error: --rate is required")
+	}
+	perSecond, err := parseRate(rate)
+	if err != nil {
+		return fmt.Errorf("argument error: --rate: %w", err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("argument error: --duration must be positive, but got %s", duration)
+	}
+	g, err := newLogGenerator(text, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(params, dst)
+	if err != nil {
+		return err
+	}
+	u, err := newUploader(client, dst, "", defaultBatchLimits, nil)
+	if err != nil {
+		return err
+	}
+
+	// Stop generating on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	start := time.Now()
+	generated := 0
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+		}
+
+		// Generate log events which are due by now, so the rate is kept even if uploading is slow
+		now := time.Now()
+		n := int(now.Sub(start).Seconds()*perSecond) - generated
+		if n <= 0 {
+			continue
+		}
+		events, err := g.generate(n, now)
+		if err != nil {
+			return err
+		}
+		if err := u.put(context.Background(), events); err != nil {
+			return err
+		}
+		generated += n
+	}
+
+	writeIngestionSummary(os.Stdout, "Put", []ingestion{u.ingested}, 0)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{name: "Parse rate per second", s: "500/s", want: 500},
+		{name: "Parse rate per minute", s: "30/m", want: 0.5},
+		{name: "Parse rate per hour", s: "7200/h", want: 2},
+		{name: "Parse rate without unit", s: "10", want: 10},
+		{name: "Parse rate with invalid unit", s: "10/d", wantErr: true},
+		{name: "Parse zero rate", s: "0/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRate(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_logGenerator_generate(t *testing.T) {
+	g, err := newLogGenerator(`{"seq":{{seq}},"n":{{randInt 1 1}},"level":"{{randChoice "info"}}"}`, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("newLogGenerator() error = %v", err)
+	}
+	events, err := g.generate(2, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	want := []string{`{"seq":1,"n":1,"level":"info"}`, `{"seq":2,"n":1,"level":"info"}`}
+	if got := messages(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("generate() = %v, want %v", got, want)
+	}
+}
+
+func Test_newLogGenerator(t *testing.T) {
+	if _, err := newLogGenerator("{{randWord", rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("newLogGenerator() error = %v, wantErr %v", err, true)
+	}
+	if _, err := newLogGenerator(defaultGenerateTemplate, rand.New(rand.NewSource(1))); err != nil {
+		t.Errorf("newLogGenerator() error = %v, wantErr %v", err, false)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// eventQuery specifies log events fetched from CloudWatch Logs. The zero start or end means it is unbounded.
+type eventQuery struct {
+	logGroup  string
+	logStream string
+	start     time.Time
+	end       time.Time
+	// filterPattern is the filter pattern of FilterLogEvents. It is not used for the log stream.
+	filterPattern string
+}
+
+// storedEvent is a log event stored in CloudWatch Logs.
+type storedEvent struct {
+	Timestamp     int64  `json:"timestamp"`
+	Message       string `json:"message"`
+	LogStream     string `json:"logStream"`
+	IngestionTime int64  `json:"ingestionTime"`
+	// LogGroup is set only when log events are read from several log groups
+	LogGroup string `json:"logGroup,omitempty"`
+	// EventID is the ID given by FilterLogEvents, used to skip log events already read
+	EventID string `json:"-"`
+}
+
+// fetchLogEvents calls fn for each log event matching the query in order of the timestamp.
+func fetchLogEvents(client *cloudwatchlogs.Client, q eventQuery, fn func(storedEvent) error) error {
+	return fetchLogEventPages(client, q, eachLogEvent(fn))
+}
+
+// fetchLogEventPages calls fn for each page of log events matching the query as soon as the page is read.
+// It reads the log stream with GetLogEvents if the log stream is given, otherwise it reads the log group with FilterLogEvents.
+func fetchLogEventPages(client *cloudwatchlogs.Client, q eventQuery, fn func([]storedEvent) error) error {
+	if q.logStream != "" {
+		return getLogEvents(client, q, fn)
+	}
+	return filterLogEvents(client, q, fn)
+}
+
+// eachLogEvent returns the function calling fn for each log event in a page.
+func eachLogEvent(fn func(storedEvent) error) func([]storedEvent) error {
+	return func(events []storedEvent) error {
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func getLogEvents(client *cloudwatchlogs.Client, q eventQuery, fn func([]storedEvent) error) error {
+	param := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(q.logGroup),
+		LogStreamName: aws.String(q.logStream),
+		StartFromHead: aws.Bool(true),
+	}
+	if !q.start.IsZero() {
+		param.StartTime = aws.Int64(toMillis(q.start))
+	}
+	if !q.end.IsZero() {
+		param.EndTime = aws.Int64(toMillis(q.end))
+	}
+
+	paginator := cloudwatchlogs.NewGetLogEventsPaginator(client, param, func(o *cloudwatchlogs.GetLogEventsPaginatorOptions) {
+		// GetLogEvents returns the same token at the end of the log stream
+		o.StopOnDuplicateToken = true
+	})
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return err
+		}
+		events := make([]storedEvent, len(res.Events))
+		for i, event := range res.Events {
+			events[i] = storedEvent{
+				Timestamp:     aws.ToInt64(event.Timestamp),
+				Message:       aws.ToString(event.Message),
+				LogStream:     q.logStream,
+				IngestionTime: aws.ToInt64(event.IngestionTime),
+			}
+		}
+		if err := fn(events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func filterLogEvents(client *cloudwatchlogs.Client, q eventQuery, fn func([]storedEvent) error) error {
+	param := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(q.logGroup),
+	}
+	if q.filterPattern != "" {
+		param.FilterPattern = aws.String(q.filterPattern)
+	}
+	if !q.start.IsZero() {
+		param.StartTime = aws.Int64(toMillis(q.start))
+	}
+	if !q.end.IsZero() {
+		param.EndTime = aws.Int64(toMillis(q.end))
+	}
+
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return err
+		}
+		events := make([]storedEvent, len(res.Events))
+		for i, event := range res.Events {
+			events[i] = storedEvent{
+				Timestamp:     aws.ToInt64(event.Timestamp),
+				Message:       aws.ToString(event.Message),
+				LogStream:     aws.ToString(event.LogStreamName),
+				IngestionTime: aws.ToInt64(event.IngestionTime),
+				EventID:       aws.ToString(event.EventId),
+			}
+		}
+		if err := fn(events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runGet(args []string) error {
+	params := parameters{}
+	q := eventQuery{}
+	since, until := "", ""
+	outputFile := ""
+	view := eventView{}
+	format, timezone, highlight := formatNDJSON, "Local", ""
+	noColor := false
+
+	flags := newSubcommandFlagSet(args[0], "Download log events.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&q.logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&q.logStream, "log-stream", "", "The name of the log stream. If you do not use this parameters, it downloads log events in all log streams.")
+	addTimeRangeFlags(flags, &since, &until)
+	flags.StringVar(&outputFile, "output-file", "", "The path of file where log events are written. If you do not use this parameters, it writes log events to stdout.")
+	addEventViewFlags(flags, &format, &timezone, &highlight, &noColor)
+	flags.Parse(args[1:])
+
+	if q.logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	var err error
+	q.start, q.end, err = parseTimeRange(since, until)
+	if err != nil {
+		return err
+	}
+
+	if outputFile == "" {
+		view.colors = newPalette(os.Stdout, noColor)
+	}
+	if err := view.parse(format, timezone, highlight); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	write := newEventWriter(w, view)
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w.Reset(f)
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if err := fetchLogEvents(client, q, write); err != nil {
+		w.Flush()
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+// setUpStoredEvents puts log events a minute apart from base into the log streams in turn, and returns the messages.
+func setUpStoredEvents(cli *cloudwatchlogs.Client, logGroup string, logStreams []string, n int, base time.Time) ([]string, error) {
+	messages := make([]string, n)
+	for i, logStream := range logStreams {
+		events := []types.InputLogEvent{}
+		for j := i; j < n; j += len(logStreams) {
+			messages[j] = fmt.Sprintf("[INFO] Event %d in %s", j, logStream)
+			events = append(events, types.InputLogEvent{
+				Timestamp: aws.Int64(toMillis(base.Add(time.Duration(j) * time.Minute))),
+				Message:   aws.String(messages[j]),
+			})
+		}
+		in := &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(logGroup),
+			LogStreamName: aws.String(logStream),
+			LogEvents:     events,
+		}
+		if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+func Test_fetchLogEvents(t *testing.T) {
+	fake := fakelogs.New()
+	// The small pages make the log events span several pages
+	fake.PageSize = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 2)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	messages, err := setUpStoredEvents(cli, logGroup, logStreams, 10, base)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		q       eventQuery
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Fetch log events in log stream",
+			q:    eventQuery{logGroup: logGroup, logStream: logStreams[0]},
+			want: []string{messages[0], messages[2], messages[4], messages[6], messages[8]},
+		},
+		{
+			name: "Fetch log events in log stream within time range",
+			q:    eventQuery{logGroup: logGroup, logStream: logStreams[0], start: base.Add(90 * time.Second), end: base.Add(390 * time.Second)},
+			want: []string{messages[2], messages[4], messages[6]},
+		},
+		{
+			name: "Fetch log events in log stream since time",
+			q:    eventQuery{logGroup: logGroup, logStream: logStreams[1], start: base.Add(330 * time.Second)},
+			want: []string{messages[7], messages[9]},
+		},
+		{
+			name: "Fetch log events in log group",
+			q:    eventQuery{logGroup: logGroup},
+			want: messages,
+		},
+		{
+			name: "Fetch log events in log group within time range",
+			q:    eventQuery{logGroup: logGroup, start: base.Add(90 * time.Second), end: base.Add(390 * time.Second)},
+			want: messages[2:7],
+		},
+		{
+			name: "Fetch log events in log group until time",
+			q:    eventQuery{logGroup: logGroup, end: base.Add(150 * time.Second)},
+			want: messages[:3],
+		},
+		{
+			name: "Fetch log events in log group by filter pattern",
+			q:    eventQuery{logGroup: logGroup, filterPattern: fmt.Sprintf(`"Event 3 in %s"`, logStreams[1])},
+			want: []string{messages[3]},
+		},
+		{
+			name:    "Fetch log events in unknown log stream",
+			q:       eventQuery{logGroup: logGroup, logStream: "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "Fetch log events in unknown log group",
+			q:       eventQuery{logGroup: "unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			err := fetchLogEvents(cli, tt.q, func(event storedEvent) error {
+				got = append(got, event.Message)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchLogEvents() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fetchLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runGet(t *testing.T) {
+	fake := fakelogs.New()
+	fake.PageSize = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 1)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	messages, err := setUpStoredEvents(cli, logGroup, logStreams, 5, base)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	since, until := base.Add(30*time.Second).Format(time.RFC3339), base.Add(150*time.Second).Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Get log events in raw format",
+			args: []string{"--log-group", logGroup, "--format", "raw"},
+			want: messages,
+		},
+		{
+			name: "Get log events in text format",
+			args: []string{"--log-group", logGroup, "--log-stream", logStreams[0], "--format", "text", "--timezone", "UTC"},
+			want: []string{
+				base.Format(time.RFC3339Nano) + " " + messages[0],
+				base.Add(time.Minute).Format(time.RFC3339Nano) + " " + messages[1],
+				base.Add(2*time.Minute).Format(time.RFC3339Nano) + " " + messages[2],
+				base.Add(3*time.Minute).Format(time.RFC3339Nano) + " " + messages[3],
+				base.Add(4*time.Minute).Format(time.RFC3339Nano) + " " + messages[4],
+			},
+		},
+		{
+			name: "Get log events in ndjson format within time range",
+			args: []string{"--log-group", logGroup, "--log-stream", logStreams[0], "--since", since, "--until", until},
+			want: []string{
+				fmt.Sprintf(`{"timestamp":%d,"message":"%s","logStream":"%s"`, toMillis(base.Add(time.Minute)), messages[1], logStreams[0]),
+				fmt.Sprintf(`{"timestamp":%d,"message":"%s","logStream":"%s"`, toMillis(base.Add(2*time.Minute)), messages[2], logStreams[0]),
+			},
+		},
+		{name: "Get log events without log group", args: []string{"--format", "raw"}, wantErr: true},
+		{name: "Get log events with invalid format", args: []string{"--log-group", logGroup, "--format", "yaml"}, wantErr: true},
+		{name: "Get log events with invalid time range", args: []string{"--log-group", logGroup, "--since", "yesterday"}, wantErr: true},
+		{name: "Get log events in unknown log stream", args: []string{"--log-group", logGroup, "--log-stream", "unknown"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "events.log")
+			args := append([]string{"get", "--endpoint-url", server.URL, "--region", "us-east-1", "--output-file", outputFile}, tt.args...)
+			if err := runGet(args); (err != nil) != tt.wantErr {
+				t.Errorf("runGet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			b, err := ioutil.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("runGet() wrote %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				// The ingestion time of the ndjson format is not compared
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("runGet() wrote %v, want %v", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
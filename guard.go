@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// uploadGuard protects against uploading too many log events by mistake. The zero limit means no limit.
+type uploadGuard struct {
+	maxEvents int
+	maxBytes  int
+}
+
+// check returns the error with the projected size if log events exceed the limits.
+func (g uploadGuard) check(events []types.InputLogEvent) error {
+	size := 0
+	for _, event := range events {
+		size += eventSize(event)
+	}
+
+	exceeded := []string{}
+	if g.maxEvents > 0 && len(events) > g.maxEvents {
+		exceeded = append(exceeded, fmt.Sprintf("--max-events %d", g.maxEvents))
+	}
+	if g.maxBytes > 0 && size > g.maxBytes {
+		exceeded = append(exceeded, fmt.Sprintf("--max-bytes %d", g.maxBytes))
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("guard error: %d log events (%d bytes) exceed %s", len(events), size, strings.Join(exceeded, " and "))
+	}
+	return nil
+}
+
+// defaultProtectedGroups matches names of log groups which look like production, such as /prod/api or
+// production-app, but not /product/api.
+const defaultProtectedGroups = `(?i)(^|[^a-z])prod(uction)?([^a-z]|$)`
+
+// protectedLogGroups returns the log groups of destinations matching the pattern.
+func protectedLogGroups(pattern *regexp.Regexp, dsts []destination) []string {
+	groups := []string{}
+	if pattern == nil {
+		return groups
+	}
+	seen := map[string]bool{}
+	for _, dst := range dsts {
+		if pattern.MatchString(dst.logGroup) && !seen[dst.logGroup] {
+			seen[dst.logGroup] = true
+			groups = append(groups, dst.logGroup)
+		}
+	}
+	return groups
+}
+
+// uploadWarnings returns the reasons to confirm uploading log events to the destinations.
+func uploadWarnings(g uploadGuard, protected *regexp.Regexp, dsts []destination, events []types.InputLogEvent) []string {
+	warnings := []string{}
+	if err := g.check(events); err != nil {
+		warnings = append(warnings, strings.TrimPrefix(err.Error(), "guard error: "))
+	}
+	if groups := protectedLogGroups(protected, dsts); len(groups) > 0 {
+		warnings = append(warnings, fmt.Sprintf("log groups %s match --protected-groups", strings.Join(groups, ", ")))
+	}
+	return warnings
+}
+
+// confirmUpload asks whether log events are uploaded on the terminal if they exceed the guard, or the destinations
+// match the pattern of protected log groups. Without the terminal, they are not uploaded, since nobody confirms them.
+// It is not called with --yes.
+func confirmUpload(g uploadGuard, protected *regexp.Regexp, dsts []destination, events []types.InputLogEvent) error {
+	warnings := uploadWarnings(g, protected, dsts, events)
+	if len(warnings) == 0 {
+		return nil
+	}
+	if !isInteractive() {
+		return fmt.Errorf("guard error: %s. Use --yes to upload them without the terminal", strings.Join(warnings, ", and "))
+	}
+	ok, err := confirm(bufio.NewReader(os.Stdin), os.Stdout, fmt.Sprintf("%s. Upload %d log events?", strings.Join(warnings, ", and "), len(events)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("canceled")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_uploadGuard_check(t *testing.T) {
+	events := newTestEvents(3, "[INFO] Start Server", time.Second)
+	size := 3 * (eventOverheadBytes + len("[INFO] Start Server"))
+	tests := []struct {
+		name    string
+		guard   uploadGuard
+		wantErr bool
+	}{
+		{
+			name:    "Check log events without limits",
+			guard:   uploadGuard{},
+			wantErr: false,
+		},
+		{
+			name:    "Check log events within limits",
+			guard:   uploadGuard{maxEvents: 3, maxBytes: size},
+			wantErr: false,
+		},
+		{
+			name:    "Check log events exceeding --max-events",
+			guard:   uploadGuard{maxEvents: 2},
+			wantErr: true,
+		},
+		{
+			name:    "Check log events exceeding --max-bytes",
+			guard:   uploadGuard{maxBytes: size - 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.guard.check(events)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("check() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			// The error tells the projected size
+			if err != nil && !strings.Contains(err.Error(), "3 log events") {
+				t.Errorf("check() error = %v, want the projected size", err)
+			}
+		})
+	}
+}
+
+func Test_protectedLogGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern *regexp.Regexp
+		dsts    []destination
+		want    []string
+	}{
+		{
+			name:    "Match production log groups",
+			pattern: regexp.MustCompile(defaultProtectedGroups),
+			dsts: []destination{
+				{logGroup: "/prod/api", logStream: "api-1"},
+				{logGroup: "/prod/api", logStream: "api-2"},
+				{logGroup: "production-worker"},
+				{logGroup: "/app/PROD"},
+				{logGroup: "/product/api"},
+				{logGroup: "/dev/api"},
+			},
+			want: []string{"/prod/api", "production-worker", "/app/PROD"},
+		},
+		{
+			name:    "Match nothing without pattern",
+			pattern: nil,
+			dsts:    []destination{{logGroup: "/prod/api"}},
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protectedLogGroups(tt.pattern, tt.dsts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("protectedLogGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_uploadWarnings(t *testing.T) {
+	events := newTestEvents(3, "[INFO] Start Server", time.Second)
+	pattern := regexp.MustCompile(defaultProtectedGroups)
+	tests := []struct {
+		name  string
+		guard uploadGuard
+		dsts  []destination
+		want  int
+	}{
+		{name: "Warn nothing", dsts: []destination{{logGroup: "/dev/api"}}, want: 0},
+		{name: "Warn production log group", dsts: []destination{{logGroup: "/prod/api"}}, want: 1},
+		{name: "Warn exceeding guard and production log group", guard: uploadGuard{maxEvents: 2}, dsts: []destination{{logGroup: "/prod/api"}}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uploadWarnings(tt.guard, pattern, tt.dsts, events); len(got) != tt.want {
+				t.Errorf("uploadWarnings() = %v, want %d warnings", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_confirmUpload(t *testing.T) {
+	events := newTestEvents(3, "[INFO] Start Server", time.Second)
+	pattern := regexp.MustCompile(defaultProtectedGroups)
+	tests := []struct {
+		name        string
+		guard       uploadGuard
+		dsts        []destination
+		interactive bool
+		answer      string
+		wantErr     bool
+	}{
+		{name: "Upload without warnings on the terminal", dsts: []destination{{logGroup: "/dev/api"}}, interactive: true},
+		{name: "Upload without warnings in scripts", dsts: []destination{{logGroup: "/dev/api"}}},
+		{name: "Upload to production log group confirmed on the terminal", dsts: []destination{{logGroup: "/prod/api"}}, interactive: true, answer: "y\n"},
+		{name: "Cancel uploading to production log group on the terminal", dsts: []destination{{logGroup: "/prod/api"}}, interactive: true, answer: "n\n", wantErr: true},
+		{name: "Fail to upload to production log group in scripts", dsts: []destination{{logGroup: "/prod/api"}}, wantErr: true},
+		{name: "Fail to upload log events exceeding guard in scripts", guard: uploadGuard{maxEvents: 2}, dsts: []destination{{logGroup: "/dev/api"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUpAnswer(t, tt.interactive, tt.answer)
+			if err := confirmUpload(tt.guard, pattern, tt.dsts, events); (err != nil) != tt.wantErr {
+				t.Errorf("confirmUpload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// newHTTPClient creates the HTTP client for the SDK with the TLS and proxy options. It returns nil if no options are given.
+// Without --proxy-url, the SDK uses the proxy in HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func newHTTPClient(params parameters) (*awshttp.BuildableClient, error) {
+	if params.caBundle == "" && !params.insecureSkipVerify && params.proxyURL == "" {
+		return nil, nil
+	}
+
+	var proxy func(*http.Request) (*url.URL, error)
+	if params.proxyURL != "" {
+		u, err := url.Parse(params.proxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("argument error: --proxy-url must be a URL such as http://proxy.example.com:8080, but got %s", params.proxyURL)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: params.insecureSkipVerify,
+	}
+	if params.caBundle != "" {
+		pem, err := ioutil.ReadFile(params.caBundle)
+		if err != nil {
+			return nil, err
+		}
+		// Trust the CA bundle in addition to the system CAs
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("argument error: --ca-bundle %s has no PEM certificates", params.caBundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.TLSClientConfig = tlsConfig
+		if proxy != nil {
+			tr.Proxy = proxy
+		}
+	}), nil
+}
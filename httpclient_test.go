@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func Test_newHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The proxy tunnels CONNECT requests to the server, and counts them
+	proxied := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT is expected", http.StatusMethodNotAllowed)
+			return
+		}
+		proxied++
+		dst, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		src, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			dst.Close()
+			return
+		}
+		go func() {
+			io.Copy(dst, src)
+			dst.Close()
+		}()
+		io.Copy(src, dst)
+		src.Close()
+	}))
+	defer proxy.Close()
+
+	dir := t.TempDir()
+	caBundle := filepath.Join(dir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caBundle, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		params  parameters
+		wantNil bool
+		wantErr bool
+	}{
+		{
+			name:    "Create no client without options",
+			params:  parameters{},
+			wantNil: true,
+		},
+		{
+			name:   "Create client trusting the CA bundle",
+			params: parameters{caBundle: caBundle},
+		},
+		{
+			name:   "Create client skipping verification",
+			params: parameters{insecureSkipVerify: true},
+		},
+		{
+			name:   "Create client through the proxy",
+			params: parameters{proxyURL: proxy.URL, insecureSkipVerify: true},
+		},
+		{
+			name:    "Create client with invalid proxy URL",
+			params:  parameters{proxyURL: "proxy:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "Create client with invalid CA bundle",
+			params:  parameters{caBundle: invalid},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newHTTPClient(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newHTTPClient() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Errorf("newHTTPClient() = %v, wantNil %v", got, tt.wantNil)
+				return
+			}
+			if got == nil {
+				return
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			res, err := got.Do(req)
+			if err != nil {
+				t.Errorf("newHTTPClient() client failed to request the TLS server: %v", err)
+				return
+			}
+			res.Body.Close()
+		})
+	}
+	if proxied != 1 {
+		t.Errorf("newHTTPClient() client requested through the proxy %d times, want %d", proxied, 1)
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// filesFlag stores file paths each time the flag is given.
+type filesFlag []string
+
+func (f *filesFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *filesFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// The units of epoch timestamps
+const (
+	timestampUnitAuto         = "auto"
+	timestampUnitSeconds      = "s"
+	timestampUnitMilliseconds = "ms"
+	timestampUnitMicroseconds = "us"
+	timestampUnitNanoseconds  = "ns"
+)
+
+func validateTimestampUnit(unit string) error {
+	switch unit {
+	case timestampUnitAuto, timestampUnitSeconds, timestampUnitMilliseconds, timestampUnitMicroseconds, timestampUnitNanoseconds:
+		return nil
+	}
+	return fmt.Errorf("argument error: --timestamp-unit must be %s, %s, %s, %s or %s, but got %s", timestampUnitAuto, timestampUnitSeconds, timestampUnitMilliseconds, timestampUnitMicroseconds, timestampUnitNanoseconds, unit)
+}
+
+// detectTimestampUnit guesses the unit of the epoch timestamp by the magnitude.
+// Each unit covers the years from 1973 to 5138.
+func detectTimestampUnit(epoch float64) string {
+	switch epoch = math.Abs(epoch); {
+	case epoch < 1e11:
+		return timestampUnitSeconds
+	case epoch < 1e14:
+		return timestampUnitMilliseconds
+	case epoch < 1e17:
+		return timestampUnitMicroseconds
+	}
+	return timestampUnitNanoseconds
+}
+
+// maxEpochSeconds is the largest distance from the epoch in seconds whose time is stored in milliseconds of int64 by CloudWatch Logs.
+const maxEpochSeconds = math.MaxInt64 / 1000
+
+// epochToTime converts the epoch timestamp in the unit to the time. The seconds and the rest are converted separately,
+// so that timestamps far from the epoch do not overflow nanoseconds in int64.
+// It returns false if the time is farther than about 292 million years from the epoch, which milliseconds of int64 can not represent.
+func epochToTime(epoch float64, unit string) (time.Time, bool) {
+	if unit == timestampUnitAuto {
+		unit = detectTimestampUnit(epoch)
+	}
+	perSecond := map[string]float64{
+		timestampUnitSeconds:      1,
+		timestampUnitMilliseconds: 1e3,
+		timestampUnitMicroseconds: 1e6,
+		timestampUnitNanoseconds:  1e9,
+	}[unit]
+	sec := math.Floor(epoch / perSecond)
+	if !(math.Abs(sec) <= maxEpochSeconds) {
+		return time.Time{}, false
+	}
+	// The rest is exact for integer timestamps in the unit, unlike the fraction of the seconds
+	nsec := math.Round((epoch - sec*perSecond) * 1e9 / perSecond)
+	return time.Unix(int64(sec), int64(nsec)).UTC(), true
+}
+
+// timestampOptions tells where log events have their time.
+type timestampOptions struct {
+	field string
+	// unit is the unit of epoch timestamps in the field
+	unit string
+}
+
+// parse returns the time in the field of the JSON log event. The field has RFC3339 time or an epoch timestamp.
+// It returns false if the log event is not JSON or the field is not found or invalid, such as an epoch timestamp out of range.
+func (o timestampOptions) parse(message string) (time.Time, bool) {
+	if o.field == "" {
+		return time.Time{}, false
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(message), &fields); err != nil {
+		return time.Time{}, false
+	}
+	switch v := fields[o.field].(type) {
+	case string:
+		if epoch, err := strconv.ParseFloat(v, 64); err == nil {
+			return epochToTime(epoch, o.unit)
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case float64:
+		return epochToTime(v, o.unit)
+	}
+	return time.Time{}, false
+}
+
+// newTimestampedLogEvent creates the log event with the time in the timestamp field. The log event without it happened at now.
+func newTimestampedLogEvent(log string, timestamps timestampOptions, now time.Time) types.InputLogEvent {
+	t, ok := timestamps.parse(log)
+	if !ok {
+		t = now
+	}
+	return types.InputLogEvent{
+		Message:   aws.String(log),
+		Timestamp: aws.Int64(toMillis(t)),
+	}
+}
+
+// newTimestampedLogEvents creates log events with the time in the timestamp field. Log events without it happened at now.
+// Log events are sorted by the timestamp keeping the original order of log events at the same time.
+func newTimestampedLogEvents(logs []string, timestamps timestampOptions, now time.Time) []types.InputLogEvent {
+	events := make([]types.InputLogEvent, len(logs))
+	for i, log := range logs {
+		events[i] = newTimestampedLogEvent(log, timestamps, now)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+	})
+	return events
+}
+
+// inputOptions are the options to read log events from files.
+type inputOptions struct {
+	format string
+	// encoding is the character encoding of files transcoded into UTF-8
+	encoding string
+	// plugin parses files instead of format if it is not nil
+	plugin *parserPlugin
+	// archives chooses member files of archives
+	archives archiveFilter
+	// lines limits the length of lines of text formats
+	lines *lineLimiter
+}
+
+// readLogEventsFiles reads log events from the files. Archives are read as their member files.
+// If merge is true, log events of all files are interleaved in order of the timestamp, otherwise they are read file by file.
+func readLogEventsFiles(fileNames []string, input inputOptions, timestamps timestampOptions, merge bool, now time.Time) ([]types.InputLogEvent, error) {
+	if merge {
+		return mergeLogEventsFiles(fileNames, input, timestamps, now)
+	}
+	events := []types.InputLogEvent{}
+	for _, fileName := range fileNames {
+		files, err := readInputFiles(fileName, input.archives)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			parsed, err := parseInputFile(file, input, timestamps, now)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, parsed...)
+		}
+	}
+	return events, nil
+}
+
+// parseInputFile parses log events of the file read into memory, sorted by the timestamp.
+func parseInputFile(file inputFile, input inputOptions, timestamps timestampOptions, now time.Time) ([]types.InputLogEvent, error) {
+	if input.plugin != nil {
+		return input.plugin.parse(file, timestamps, now)
+	}
+	data, err := decodeInput(file.data, input.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.name, err)
+	}
+	logs, err := parseLogs(data, input.format, input.lines)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.name, err)
+	}
+	return newTimestampedLogEvents(logs, timestamps, now), nil
+}
+
+// mergeLogEventsFiles reads all files at the same time, and interleaves their log events in order of the timestamp.
+func mergeLogEventsFiles(fileNames []string, input inputOptions, timestamps timestampOptions, now time.Time) ([]types.InputLogEvent, error) {
+	readers := []*logEventReader{}
+	for _, fileName := range fileNames {
+		opened, err := openLogEventReaders(fileName, input, timestamps, now)
+		if err != nil {
+			for _, r := range readers {
+				r.close()
+			}
+			return nil, err
+		}
+		readers = append(readers, opened...)
+	}
+
+	events := []types.InputLogEvent{}
+	err := mergeLogEvents(readers, func(event types.InputLogEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// mergeChunkBytes is the size of the head of files read to detect the format before the files are merged.
+const mergeChunkBytes = 64 * 1024
+
+// openLogEventReaders opens the readers of log events in the file, or in member files of the archive.
+// Plain files are read line by line while they are merged, but archives and files parsed by the plugin are read into memory.
+func openLogEventReaders(fileName string, input inputOptions, timestamps timestampOptions, now time.Time) ([]*logEventReader, error) {
+	if input.plugin == nil && !isArchive(fileName) {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		r := newLogEventReader(func(send func(types.InputLogEvent) error) error {
+			defer f.Close()
+			if err := scanInputLogEvents(f, input, timestamps, now, send); err != nil && !errors.Is(err, errReaderClosed) {
+				return fmt.Errorf("%s: %w", fileName, err)
+			}
+			return nil
+		})
+		return []*logEventReader{r}, nil
+	}
+
+	files, err := readInputFiles(fileName, input.archives)
+	if err != nil {
+		return nil, err
+	}
+	readers := []*logEventReader{}
+	for _, file := range files {
+		events, err := parseInputFile(file, input, timestamps, now)
+		if err != nil {
+			for _, r := range readers {
+				r.close()
+			}
+			return nil, err
+		}
+		readers = append(readers, newSliceLogEventReader(events))
+	}
+	return readers, nil
+}
+
+// scanInputLogEvents passes log events of r to send as soon as each line is read.
+// Log events are expected to be in order of the timestamp, since they are not sorted unlike files read into memory.
+// JSON arrays can not be read line by line, so they are read into memory and sorted.
+func scanInputLogEvents(r io.Reader, input inputOptions, timestamps timestampOptions, now time.Time, send func(types.InputLogEvent) error) error {
+	br := bufio.NewReaderSize(newDecodingReader(r, input.encoding), mergeChunkBytes)
+	format := input.format
+	if format == inputFormatAuto {
+		head, err := br.Peek(mergeChunkBytes)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		trimmed := bytes.TrimSpace(head)
+		// The head of a large JSON array is not valid JSON by itself, so it is validated after it is read
+		if err == nil && bytes.HasPrefix(trimmed, []byte("[")) && looksLikeJSON(trimmed) {
+			format = inputFormatJSON
+		} else if format, err = detectInputFormat(head); err != nil {
+			return err
+		}
+	}
+
+	if format == inputFormatJSON {
+		data, err := ioutil.ReadAll(br)
+		if err != nil {
+			return err
+		}
+		logs, err := parseLogEvents(data)
+		if err != nil {
+			return err
+		}
+		for _, event := range newTimestampedLogEvents(logs, timestamps, now) {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return parseLogLines(br, format, input.lines, func(log string) error {
+		return send(newTimestampedLogEvent(log, timestamps, now))
+	})
+}
+
+// errReaderClosed is the error returned to the goroutine of logEventReader after the reader is closed.
+var errReaderClosed = errors.New("the reader of log events is closed")
+
+// logEventReader reads log events of an input in a goroutine and passes them one by one,
+// so that only the next log event of each input is kept while inputs are merged.
+type logEventReader struct {
+	events chan types.InputLogEvent
+	// err is the error which stopped reading. It is set before events is closed.
+	err  error
+	done chan struct{}
+}
+
+// newLogEventReader starts read in a goroutine. read passes each log event to send, which fails after the reader is closed.
+func newLogEventReader(read func(send func(types.InputLogEvent) error) error) *logEventReader {
+	r := &logEventReader{events: make(chan types.InputLogEvent), done: make(chan struct{})}
+	go func() {
+		defer close(r.events)
+		r.err = read(func(event types.InputLogEvent) error {
+			select {
+			case r.events <- event:
+				return nil
+			case <-r.done:
+				return errReaderClosed
+			}
+		})
+	}()
+	return r
+}
+
+// newSliceLogEventReader returns the reader of log events already in memory.
+func newSliceLogEventReader(events []types.InputLogEvent) *logEventReader {
+	return newLogEventReader(func(send func(types.InputLogEvent) error) error {
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// next returns the next log event. It returns false at the end of the input or with the error which stopped reading.
+func (r *logEventReader) next() (types.InputLogEvent, bool, error) {
+	event, ok := <-r.events
+	if !ok {
+		return types.InputLogEvent{}, false, r.err
+	}
+	return event, true, nil
+}
+
+// close stops reading the input. It must be called once.
+func (r *logEventReader) close() {
+	close(r.done)
+}
+
+// mergeLogEvents passes log events of the readers to emit in order of the timestamp as soon as they are merged.
+// Each reader must return log events sorted by the timestamp. Log events at the same time are ordered by the order of readers.
+// The readers are closed when it returns.
+func mergeLogEvents(readers []*logEventReader, emit func(types.InputLogEvent) error) error {
+	defer func() {
+		for _, r := range readers {
+			r.close()
+		}
+	}()
+
+	h := &eventHeap{}
+	for i, r := range readers {
+		if err := h.pushNext(i, r); err != nil {
+			return err
+		}
+	}
+	for h.Len() > 0 {
+		c := heap.Pop(h).(eventCursor)
+		if err := emit(c.event); err != nil {
+			return err
+		}
+		if err := h.pushNext(c.input, c.reader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventCursor has the next log event of an input.
+type eventCursor struct {
+	input  int
+	reader *logEventReader
+	event  types.InputLogEvent
+}
+
+// eventHeap is a min-heap of the next log events of inputs.
+type eventHeap []eventCursor
+
+// pushNext pushes the next log event of the reader if the reader has one.
+func (h *eventHeap) pushNext(input int, r *logEventReader) error {
+	event, ok, err := r.next()
+	if ok {
+		heap.Push(h, eventCursor{input: input, reader: r, event: event})
+	}
+	return err
+}
+
+func (h eventHeap) Len() int { return len(h) }
+
+func (h eventHeap) Less(i, j int) bool {
+	ti, tj := aws.ToInt64(h[i].event.Timestamp), aws.ToInt64(h[j].event.Timestamp)
+	if ti != tj {
+		return ti < tj
+	}
+	return h[i].input < h[j].input
+}
+
+func (h eventHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *eventHeap) Push(x interface{}) { *h = append(*h, x.(eventCursor)) }
+
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_timestampOptions_parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		unit    string
+		want    time.Time
+		wantOK  bool
+	}{
+		{
+			name:    "Get RFC3339 time",
+			message: `{"time":"2021-03-01T09:00:00+09:00","message":"Start Server"}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch milliseconds",
+			message: `{"time":1614556800000,"message":"Start Server"}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch seconds",
+			message: `{"time":1614556800.5}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 500000000, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch microseconds",
+			message: `{"time":1614556800000000}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch nanoseconds in a string",
+			message: `{"time":"1614556800000000000"}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch timestamp in the given unit",
+			message: `{"time":1614556800}`,
+			unit:    timestampUnitMilliseconds,
+			want:    time.Date(1970, 1, 19, 16, 29, 16, 800000000, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch milliseconds with the fraction",
+			message: `{"time":1614556800123}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(2021, 3, 1, 0, 0, 0, 123000000, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch seconds before the epoch",
+			message: `{"time":-1.5}`,
+			unit:    timestampUnitSeconds,
+			want:    time.Date(1969, 12, 31, 23, 59, 58, 500000000, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get epoch seconds after 2262 which overflow nanoseconds",
+			message: `{"time":95617584000}`,
+			unit:    timestampUnitAuto,
+			want:    time.Date(5000, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get large epoch microseconds which overflow nanoseconds",
+			message: `{"time":95617584000000000}`,
+			unit:    timestampUnitMicroseconds,
+			want:    time.Date(5000, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOK:  true,
+		},
+		{
+			name:    "Get no time from an epoch timestamp out of range",
+			message: `{"time":1e300}`,
+			unit:    timestampUnitSeconds,
+			wantOK:  false,
+		},
+		{
+			name:    "Get no time from an infinite epoch timestamp",
+			message: `{"time":"Inf"}`,
+			unit:    timestampUnitAuto,
+			wantOK:  false,
+		},
+		{
+			name:    "Get no time without the field",
+			message: `{"message":"Start Server"}`,
+			unit:    timestampUnitAuto,
+			wantOK:  false,
+		},
+		{
+			name:    "Get no time from a string log",
+			message: "[INFO] Start Server",
+			unit:    timestampUnitAuto,
+			wantOK:  false,
+		},
+		{
+			name:    "Get no time from an invalid time",
+			message: `{"time":"yesterday"}`,
+			unit:    timestampUnitAuto,
+			wantOK:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := timestampOptions{field: "time", unit: tt.unit}.parse(tt.message)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parse() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_mergeLogEvents(t *testing.T) {
+	event := func(message string, timestamp int64) types.InputLogEvent {
+		return types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(timestamp)}
+	}
+	tests := []struct {
+		name   string
+		inputs [][]types.InputLogEvent
+		want   []types.InputLogEvent
+	}{
+		{
+			name:   "Merge no inputs",
+			inputs: [][]types.InputLogEvent{},
+			want:   []types.InputLogEvent{},
+		},
+		{
+			name: "Merge inputs in order of the timestamp",
+			inputs: [][]types.InputLogEvent{
+				{event("host1 start", 1000), event("host1 stop", 4000)},
+				{},
+				{event("host2 start", 2000), event("host2 retry", 3000), event("host2 stop", 5000)},
+			},
+			want: []types.InputLogEvent{
+				event("host1 start", 1000), event("host2 start", 2000), event("host2 retry", 3000), event("host1 stop", 4000), event("host2 stop", 5000),
+			},
+		},
+		{
+			name: "Merge log events at the same time in order of inputs",
+			inputs: [][]types.InputLogEvent{
+				{event("host1 start", 1000)},
+				{event("host2 start", 1000)},
+			},
+			want: []types.InputLogEvent{event("host1 start", 1000), event("host2 start", 1000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readers := make([]*logEventReader, len(tt.inputs))
+			for i, input := range tt.inputs {
+				readers[i] = newSliceLogEventReader(input)
+			}
+			got := []types.InputLogEvent{}
+			err := mergeLogEvents(readers, func(event types.InputLogEvent) error {
+				got = append(got, event)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("mergeLogEvents() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Merge log events before inputs are read to the end", func(t *testing.T) {
+		// The first input does not read the second log event until the first one is merged
+		merged := make(chan struct{})
+		slow := newLogEventReader(func(send func(types.InputLogEvent) error) error {
+			if err := send(event("host1 start", 1000)); err != nil {
+				return err
+			}
+			select {
+			case <-merged:
+			case <-time.After(5 * time.Second):
+				return errors.New("the first log event is not merged before the input is read to the end")
+			}
+			return send(event("host1 stop", 3000))
+		})
+		got := []types.InputLogEvent{}
+		err := mergeLogEvents([]*logEventReader{slow, newSliceLogEventReader([]types.InputLogEvent{event("host2 start", 2000)})}, func(e types.InputLogEvent) error {
+			if len(got) == 0 {
+				close(merged)
+			}
+			got = append(got, e)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("mergeLogEvents() error = %v", err)
+		}
+		if want := []types.InputLogEvent{event("host1 start", 1000), event("host2 start", 2000), event("host1 stop", 3000)}; !reflect.DeepEqual(got, want) {
+			t.Errorf("mergeLogEvents() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Merge inputs failing to be read", func(t *testing.T) {
+		failing := newLogEventReader(func(send func(types.InputLogEvent) error) error {
+			if err := send(event("host1 start", 1000)); err != nil {
+				return err
+			}
+			return errors.New("broken file")
+		})
+		err := mergeLogEvents([]*logEventReader{failing, newSliceLogEventReader([]types.InputLogEvent{event("host2 start", 2000)})}, func(types.InputLogEvent) error {
+			return nil
+		})
+		if err == nil {
+			t.Error("mergeLogEvents() error = nil, want error")
+		}
+	})
+}
+
+func Test_mergeLogEventsFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+		return path
+	}
+	ndjson := func(host string, times ...int) string {
+		b := &strings.Builder{}
+		for _, t := range times {
+			fmt.Fprintf(b, `{"time":%d,"host":"%s"}`+"\n", t, host)
+		}
+		return b.String()
+	}
+	// The large file is longer than the head read to detect the format
+	large := make([]int, 5000)
+	for i := range large {
+		large[i] = 1000 + i*2
+	}
+	host1 := writeFile("host1.json", ndjson("host1", 1000, 3000))
+	host2 := writeFile("host2.json", ndjson("host2", 2000, 4000))
+	host3 := writeFile("host3.json", ndjson("host3", large...))
+	array := writeFile("array.json", `[{"time":4000,"host":"array"},{"time":1500,"host":"array"}]`)
+	logfmt := writeFile("host4.log", "time=2500 host=host4\n")
+	broken := writeFile("broken.json", `{"time":1000,"host":"broken"}`+"\n"+`{"time":`)
+
+	timestamps := timestampOptions{field: "time", unit: timestampUnitMilliseconds}
+	input := inputOptions{format: inputFormatAuto, encoding: inputEncodingUTF8}
+	tests := []struct {
+		name      string
+		fileNames []string
+		want      []string
+		wantN     int
+		wantErr   bool
+	}{
+		{
+			name:      "Merge files",
+			fileNames: []string{host1, host2},
+			want:      []string{`{"host":"host1","time":1000}`, `{"host":"host2","time":2000}`, `{"host":"host1","time":3000}`, `{"host":"host2","time":4000}`},
+			wantN:     4,
+		},
+		{
+			name:      "Merge JSON array and logfmt files",
+			fileNames: []string{array, logfmt},
+			want:      []string{`{"host":"array","time":1500}`, `{"host":"host4","time":"2500"}`, `{"host":"array","time":4000}`},
+			wantN:     3,
+		},
+		{
+			name:      "Merge large file",
+			fileNames: []string{host3, host1},
+			want:      []string{`{"host":"host3","time":1000}`, `{"host":"host1","time":1000}`, `{"host":"host3","time":1002}`},
+			wantN:     5002,
+		},
+		{name: "Merge malformed file", fileNames: []string{host1, broken}, wantErr: true},
+		{name: "Merge missing file", fileNames: []string{host1, filepath.Join(dir, "missing.json")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeLogEventsFiles(tt.fileNames, input, timestamps, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mergeLogEventsFiles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != tt.wantN {
+				t.Fatalf("mergeLogEventsFiles() returned %d log events, want %d", len(got), tt.wantN)
+			}
+			for i, want := range tt.want {
+				if message := aws.ToString(got[i].Message); message != want {
+					t.Errorf("mergeLogEventsFiles()[%d] = %v, want %v", i, message, want)
+				}
+			}
+			for i := 1; i < len(got); i++ {
+				if aws.ToInt64(got[i].Timestamp) < aws.ToInt64(got[i-1].Timestamp) {
+					t.Fatalf("mergeLogEventsFiles() is not in order of the timestamp at %d", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// queryPollInterval is the interval to check if the Insights query is completed.
+var queryPollInterval = time.Second
+
+// insightsPointerField is the field which Insights adds to each result to get the log event. It is not shown.
+const insightsPointerField = "@ptr"
+
+// insightsResults is the results of the Insights query. Each row is the map from the field name to the value,
+// and fields are the names of the fields in order of appearance.
+type insightsResults struct {
+	fields []string
+	rows   []map[string]string
+}
+
+// runInsightsQuery runs the CloudWatch Logs Insights query and waits for the results.
+// Each result is the map from the field name to the value.
+func runInsightsQuery(client *cloudwatchlogs.Client, logGroups []string, query string, start, end time.Time) ([]map[string]string, error) {
+	r, err := queryInsights(client, logGroups, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return r.rows, nil
+}
+
+// queryInsights runs the CloudWatch Logs Insights query and waits for the results.
+func queryInsights(client *cloudwatchlogs.Client, logGroups []string, query string, start, end time.Time) (insightsResults, error) {
+	in := &cloudwatchlogs.StartQueryInput{
+		LogGroupNames: logGroups,
+		QueryString:   aws.String(query),
+		StartTime:     aws.Int64(start.Unix()),
+		EndTime:       aws.Int64(end.Unix()),
+	}
+	out, err := client.StartQuery(context.Background(), in)
+	if err != nil {
+		return insightsResults{}, err
+	}
+
+	for {
+		res, err := client.GetQueryResults(context.Background(), &cloudwatchlogs.GetQueryResultsInput{
+			QueryId: out.QueryId,
+		})
+		if err != nil {
+			return insightsResults{}, err
+		}
+
+		switch res.Status {
+		case types.QueryStatusComplete:
+			return newInsightsResults(res.Results), nil
+		case types.QueryStatusFailed, types.QueryStatusCancelled:
+			return insightsResults{}, fmt.Errorf("query error: the query %s is %s", aws.ToString(out.QueryId), res.Status)
+		}
+
+		time.Sleep(queryPollInterval)
+	}
+}
+
+func newInsightsResults(results [][]types.ResultField) insightsResults {
+	r := insightsResults{rows: make([]map[string]string, len(results))}
+	known := map[string]bool{}
+	for i, fields := range results {
+		r.rows[i] = map[string]string{}
+		for _, field := range fields {
+			name := aws.ToString(field.Field)
+			r.rows[i][name] = aws.ToString(field.Value)
+			if !known[name] && name != insightsPointerField {
+				known[name] = true
+				r.fields = append(r.fields, name)
+			}
+		}
+	}
+	return r
+}
+
+// maxInsightsResults is the maximum number of results which an Insights query returns.
+const maxInsightsResults = 10000
+
+// insightsStatsPattern matches queries aggregating log events with the stats command.
+var insightsStatsPattern = regexp.MustCompile(`(?i)(^|\|)\s*stats\s`)
+
+// append appends the rows of other, adding fields which r does not have yet.
+func (r *insightsResults) append(other insightsResults) {
+	known := map[string]bool{}
+	for _, field := range r.fields {
+		known[field] = true
+	}
+	for _, field := range other.fields {
+		if !known[field] {
+			known[field] = true
+			r.fields = append(r.fields, field)
+		}
+	}
+	r.rows = append(r.rows, other.rows...)
+}
+
+// paginateInsights runs the query by run, and if the results reach maxInsightsResults, splits the time range in halves
+// and runs the query on each of them, so that large result sets are not truncated. The results of the newer half come
+// first, as Insights sorts results by @timestamp in descending order by default. Queries with stats are not split,
+// since the results of halves can not be merged.
+func paginateInsights(query string, start, end time.Time, run func(start, end time.Time) (insightsResults, error)) (insightsResults, error) {
+	r, err := run(start, end)
+	if err != nil || len(r.rows) < maxInsightsResults || insightsStatsPattern.MatchString(query) {
+		return r, err
+	}
+	// StartQuery takes times in seconds, so the range of a second can not be split
+	if end.Sub(start) < 2*time.Second {
+		return r, nil
+	}
+
+	mid := start.Add(end.Sub(start) / 2).Truncate(time.Second)
+	newer, err := paginateInsights(query, mid.Add(time.Second), end, run)
+	if err != nil {
+		return insightsResults{}, err
+	}
+	older, err := paginateInsights(query, start, mid, run)
+	if err != nil {
+		return insightsResults{}, err
+	}
+	newer.append(older)
+	return newer, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// lambdaExtensionName is the name of the extension. It must be the file name of the executable in /opt/extensions.
+const lambdaExtensionName = "awsputlogs"
+
+// lambdaTelemetryAddr is the address to receive function logs from the Telemetry API.
+// See https://docs.aws.amazon.com/lambda/latest/dg/telemetry-api.html
+const lambdaTelemetryAddr = "sandbox.localdomain:4243"
+
+// lambdaTelemetryBuffering is how long the Telemetry API buffers function logs before sending them.
+const lambdaTelemetryBuffering = 100 * time.Millisecond
+
+// lambdaShutdownMargin is the time left before the deadline of the SHUTDOWN event to upload the last function logs.
+const lambdaShutdownMargin = 500 * time.Millisecond
+
+// lambdaExtension is the Lambda external extension which forwards function logs received from the Telemetry API.
+type lambdaExtension struct {
+	runtimeAPI string
+	listenAddr string
+	// flush uploads the emitted function logs. It is called after each invocation, before the sandbox is frozen.
+	flush func() error
+}
+
+func newLambdaExtension() (*lambdaExtension, error) {
+	runtimeAPI := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if runtimeAPI == "" {
+		return nil, errors.New("lambda error: AWS_LAMBDA_RUNTIME_API is not set. --lambda-extension works only as an extension of Lambda functions")
+	}
+	return &lambdaExtension{runtimeAPI: runtimeAPI, listenAddr: lambdaTelemetryAddr}, nil
+}
+
+// call calls the API of the Lambda runtime with the extension ID, or with the extension name if id is empty. It decodes the response into v if it is not nil.
+func (x *lambdaExtension) call(ctx context.Context, method, path, id string, body, v interface{}) (http.Header, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+x.runtimeAPI+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id == "" {
+		req.Header.Set("Lambda-Extension-Name", lambdaExtensionName)
+	} else {
+		req.Header.Set("Lambda-Extension-Identifier", id)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lambda error: %s %s returned %s", method, path, resp.Status)
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
+
+// register registers the extension to receive the INVOKE and SHUTDOWN events, and returns the extension ID.
+func (x *lambdaExtension) register(ctx context.Context) (string, error) {
+	header, err := x.call(ctx, http.MethodPost, "/2020-01-01/extension/register", "", map[string]interface{}{"events": []string{"INVOKE", "SHUTDOWN"}}, nil)
+	if err != nil {
+		return "", err
+	}
+	id := header.Get("Lambda-Extension-Identifier")
+	if id == "" {
+		return "", errors.New("lambda error: no extension ID is returned by registering the extension")
+	}
+	return id, nil
+}
+
+// subscribe subscribes to function logs and platform events, which are sent to uri.
+// The platform.runtimeDone event tells that all function logs of the invocation are sent.
+func (x *lambdaExtension) subscribe(ctx context.Context, id, uri string) error {
+	body := map[string]interface{}{
+		"schemaVersion": "2022-12-13",
+		"types":         []string{"platform", "function"},
+		"buffering":     map[string]int{"maxItems": 1000, "maxBytes": 256 * 1024, "timeoutMs": int(lambdaTelemetryBuffering / time.Millisecond)},
+		"destination":   map[string]string{"protocol": "HTTP", "URI": uri},
+	}
+	_, err := x.call(ctx, http.MethodPut, "/2022-07-01/telemetry", id, body, nil)
+	return err
+}
+
+// lambdaEvent is an event of the Lambda lifecycle. The request ID is set only for the INVOKE event.
+type lambdaEvent struct {
+	EventType  string `json:"eventType"`
+	RequestID  string `json:"requestId"`
+	DeadlineMs int64  `json:"deadlineMs"`
+}
+
+// next waits for the next event of the Lambda lifecycle.
+func (x *lambdaExtension) next(ctx context.Context, id string) (lambdaEvent, error) {
+	var event lambdaEvent
+	if _, err := x.call(ctx, http.MethodGet, "/2020-01-01/extension/event/next", id, nil, &event); err != nil {
+		return lambdaEvent{}, err
+	}
+	return event, nil
+}
+
+// telemetryEvent is an event sent by the Telemetry API. The record of function logs is a string in the text log format,
+// or a JSON object in the JSON log format.
+type telemetryEvent struct {
+	Time   string          `json:"time"`
+	Type   string          `json:"type"`
+	Record json.RawMessage `json:"record"`
+}
+
+// logEvent converts the function log into the log event. It has the time when the function logged it.
+func (e telemetryEvent) logEvent() types.InputLogEvent {
+	message := string(e.Record)
+	var text string
+	if json.Unmarshal(e.Record, &text) == nil {
+		message = strings.TrimRight(text, "\n")
+	}
+	t, err := time.Parse(time.RFC3339Nano, e.Time)
+	if err != nil {
+		t = time.Now()
+	}
+	return types.InputLogEvent{
+		Message:   aws.String(message),
+		Timestamp: aws.Int64(toMillis(t)),
+	}
+}
+
+// run registers the extension and subscribes to function logs, and emits them until the function is shut down.
+// After each invocation, it waits for the function logs of it and flushes them before waiting for the next event.
+func (x *lambdaExtension) run(emit func(types.InputLogEvent) error) error {
+	ctx := context.Background()
+	id, err := x.register(ctx)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", x.listenAddr)
+	if err != nil {
+		return err
+	}
+	// The Telemetry API may send function logs concurrently, but log events are emitted one by one
+	var mu sync.Mutex
+	var emitErr error
+	// doneRequestID is the request ID of the last platform.runtimeDone event, and runtimeDone is notified when it changes
+	var doneRequestID string
+	runtimeDone := make(chan struct{}, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := []telemetryEvent{}
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, event := range events {
+			switch event.Type {
+			case "function":
+				if emitErr == nil {
+					emitErr = emit(event.logEvent())
+				}
+			case "platform.runtimeDone":
+				var record struct {
+					RequestID string `json:"requestId"`
+				}
+				if json.Unmarshal(event.Record, &record) == nil {
+					doneRequestID = record.RequestID
+					select {
+					case runtimeDone <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	})}
+	go server.Serve(ln)
+	defer server.Close()
+
+	// waitRuntimeDone waits until the function logs of the invocation are sent, or until the deadline
+	waitRuntimeDone := func(requestID string, deadline time.Time) {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		for {
+			mu.Lock()
+			done := doneRequestID == requestID
+			mu.Unlock()
+			if done {
+				return
+			}
+			select {
+			case <-runtimeDone:
+			case <-timer.C:
+				return
+			}
+		}
+	}
+
+	if err := x.subscribe(ctx, id, "http://"+ln.Addr().String()); err != nil {
+		return err
+	}
+	// requestID is the request ID of the invocation whose function logs may not be sent yet
+	var requestID string
+	for {
+		event, err := x.next(ctx, id)
+		if err != nil {
+			return err
+		}
+		if event.EventType == "SHUTDOWN" {
+			// Wait for the function logs of the invocation which is timed out or failed.
+			// The last function logs are uploaded after run returns, so some time is left before the deadline.
+			if requestID != "" {
+				waitRuntimeDone(requestID, fromMillis(event.DeadlineMs).Add(-lambdaShutdownMargin))
+			}
+			shutdownCtx, cancel := context.WithTimeout(ctx, lambdaTelemetryBuffering)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+			mu.Lock()
+			defer mu.Unlock()
+			return emitErr
+		}
+
+		requestID = event.RequestID
+		waitRuntimeDone(requestID, fromMillis(event.DeadlineMs))
+		mu.Lock()
+		if doneRequestID == requestID {
+			requestID = ""
+		}
+		err = emitErr
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if x.flush != nil {
+			if err := x.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_telemetryEvent_logEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event telemetryEvent
+		want  types.InputLogEvent
+	}{
+		{
+			name:  "Convert text function log",
+			event: telemetryEvent{Time: "2024-01-01T00:00:00.123Z", Type: "function", Record: json.RawMessage(`"[INFO] Start\n"`)},
+			want:  types.InputLogEvent{Message: aws.String("[INFO] Start"), Timestamp: aws.Int64(1704067200123)},
+		},
+		{
+			name:  "Convert JSON function log",
+			event: telemetryEvent{Time: "2024-01-01T00:00:00Z", Type: "function", Record: json.RawMessage(`{"level":"INFO","message":"Start"}`)},
+			want:  types.InputLogEvent{Message: aws.String(`{"level":"INFO","message":"Start"}`), Timestamp: aws.Int64(1704067200000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.logEvent(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("telemetryEvent.logEvent() = %s %d, want %s %d", aws.ToString(got.Message), aws.ToInt64(got.Timestamp), aws.ToString(tt.want.Message), aws.ToInt64(tt.want.Timestamp))
+			}
+		})
+	}
+}
+
+// newLambdaRuntimeServer returns the fake Lambda runtime API. It sends the telemetry events to the subscribed URI,
+// and then the lifecycle events one by one. Their deadlines are relative to the time when they are sent.
+func newLambdaRuntimeServer(t *testing.T, telemetry string, events []lambdaEvent) *httptest.Server {
+	uri := make(chan string, 1)
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2020-01-01/extension/register":
+			if r.Header.Get("Lambda-Extension-Name") != lambdaExtensionName {
+				http.Error(w, "invalid extension name", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Lambda-Extension-Identifier", "id")
+			w.Write([]byte(`{"functionName":"app"}`))
+		case "/2022-07-01/telemetry":
+			var subscription struct {
+				Destination struct{ URI string }
+			}
+			json.NewDecoder(r.Body).Decode(&subscription)
+			uri <- subscription.Destination.URI
+			w.Write([]byte("OK"))
+		case "/2020-01-01/extension/event/next":
+			select {
+			case u := <-uri:
+				resp, err := http.Post(u, "application/json", strings.NewReader(telemetry))
+				if err != nil {
+					t.Errorf("failed to send telemetry: %v", err)
+				} else {
+					resp.Body.Close()
+				}
+			default:
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) == 0 {
+				t.Error("event/next is called after SHUTDOWN")
+				http.Error(w, "no more events", http.StatusInternalServerError)
+				return
+			}
+			event := events[0]
+			events = events[1:]
+			event.DeadlineMs += toMillis(time.Now())
+			json.NewEncoder(w).Encode(event)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_lambdaExtension_run(t *testing.T) {
+	tests := []struct {
+		name      string
+		telemetry string
+		events    []lambdaEvent
+		// wantFlushed is the function logs emitted before each flush
+		wantFlushed []int
+		want        []string
+	}{
+		{
+			name: "Flush function logs after the invocation",
+			telemetry: `[
+				{"time":"2024-01-01T00:00:00Z","type":"platform.start","record":{"requestId":"1"}},
+				{"time":"2024-01-01T00:00:00Z","type":"function","record":"[INFO] Start\n"},
+				{"time":"2024-01-01T00:00:01Z","type":"function","record":{"level":"ERROR"}},
+				{"time":"2024-01-01T00:00:01Z","type":"platform.runtimeDone","record":{"requestId":"1","status":"success"}}
+			]`,
+			events: []lambdaEvent{
+				{EventType: "INVOKE", RequestID: "1", DeadlineMs: 3000},
+				{EventType: "SHUTDOWN", DeadlineMs: 2000},
+			},
+			wantFlushed: []int{2},
+			want:        []string{"[INFO] Start", `{"level":"ERROR"}`},
+		},
+		{
+			name: "Flush function logs at the deadline of the invocation without platform.runtimeDone",
+			telemetry: `[
+				{"time":"2024-01-01T00:00:00Z","type":"function","record":"[INFO] Start\n"}
+			]`,
+			events: []lambdaEvent{
+				{EventType: "INVOKE", RequestID: "1", DeadlineMs: 100},
+				{EventType: "SHUTDOWN", DeadlineMs: 600},
+			},
+			wantFlushed: []int{1},
+			want:        []string{"[INFO] Start"},
+		},
+		{
+			name:      "Shut down without invocations",
+			telemetry: `[]`,
+			events: []lambdaEvent{
+				{EventType: "SHUTDOWN", DeadlineMs: 2000},
+			},
+			wantFlushed: []int{},
+			want:        []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLambdaRuntimeServer(t, tt.telemetry, tt.events)
+			var mu sync.Mutex
+			got := []string{}
+			flushed := []int{}
+			x := &lambdaExtension{
+				runtimeAPI: strings.TrimPrefix(server.URL, "http://"),
+				listenAddr: "127.0.0.1:0",
+				flush: func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					flushed = append(flushed, len(got))
+					return nil
+				},
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				done <- x.run(func(event types.InputLogEvent) error {
+					mu.Lock()
+					defer mu.Unlock()
+					got = append(got, aws.ToString(event.Message))
+					return nil
+				})
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("lambdaExtension.run() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("lambdaExtension.run() does not return after SHUTDOWN")
+			}
+
+			if !reflect.DeepEqual(flushed, tt.wantFlushed) {
+				t.Errorf("lambdaExtension.run() flushed after %v function logs, want %v", flushed, tt.wantFlushed)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lambdaExtension.run() emitted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
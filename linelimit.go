@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// The policies for lines longer than --max-line-bytes
+const (
+	longLineTruncate = "truncate"
+	longLineSplit    = "split"
+	longLineDrop     = "drop"
+)
+
+func validateLongLinePolicy(policy string) error {
+	switch policy {
+	case longLineTruncate, longLineSplit, longLineDrop:
+		return nil
+	}
+	return fmt.Errorf("argument error: --on-long-line must be %s, %s or %s, but got %s", longLineTruncate, longLineSplit, longLineDrop, policy)
+}
+
+// lineLimiter limits the length of lines of text input. Long lines are truncated, split or dropped
+// without reading more than the limit into memory. The nil lineLimiter fails on lines longer than the limit of a batch.
+type lineLimiter struct {
+	maxBytes int
+	policy   string
+	// mu guards affected because lines may be read concurrently
+	mu       sync.Mutex
+	affected int
+}
+
+func newLineLimiter(maxBytes int, policy string) (*lineLimiter, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("argument error: --max-line-bytes must be positive, but got %d", maxBytes)
+	}
+	if err := validateLongLinePolicy(policy); err != nil {
+		return nil, err
+	}
+	return &lineLimiter{maxBytes: maxBytes, policy: policy}, nil
+}
+
+// runeBoundary returns the largest length of b up to n which does not cut a UTF-8 character.
+func runeBoundary(b []byte, n int) int {
+	for i := n; i > 0 && n-i < utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			return i
+		}
+	}
+	return n
+}
+
+// scan calls f with each line of r without the line break.
+func (l *lineLimiter) scan(r io.Reader, f func(line string) error) error {
+	maxBytes, policy := maxBatchBytes, ""
+	if l != nil {
+		maxBytes, policy = l.maxBytes, l.policy
+	}
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	line := []byte{}
+	long := false
+	for {
+		fragment, err := br.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			return err
+		}
+		end := err != bufio.ErrBufferFull
+		if end {
+			fragment = bytes.TrimSuffix(bytes.TrimSuffix(fragment, []byte("\n")), []byte("\r"))
+		}
+		// Fragments over the limit are discarded unless the line is split
+		if !long || policy == longLineSplit {
+			line = append(line, fragment...)
+		}
+		for len(line) > maxBytes {
+			if policy == "" {
+				return fmt.Errorf("input error: a line is longer than %d bytes. use --max-line-bytes to truncate, split or drop it", maxBytes)
+			}
+			long = true
+			n := runeBoundary(line, maxBytes)
+			if policy == longLineSplit {
+				if err := f(string(line[:n])); err != nil {
+					return err
+				}
+				line = append(line[:0], line[n:]...)
+				continue
+			}
+			line = line[:n]
+		}
+		if !end {
+			continue
+		}
+
+		if long {
+			l.mu.Lock()
+			l.affected++
+			l.mu.Unlock()
+		}
+		if !(long && policy == longLineDrop) && !(err == io.EOF && len(line) == 0) && !(long && len(line) == 0) {
+			if err := f(string(line)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		line = line[:0]
+		long = false
+	}
+}
+
+// count returns the number of lines affected by the limit.
+func (l *lineLimiter) count() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.affected
+}
+
+// report writes the number of lines affected by the limit.
+func (l *lineLimiter) report(w io.Writer) {
+	n := l.count()
+	if n == 0 {
+		return
+	}
+	verb := map[string]string{longLineTruncate: "Truncated", longLineSplit: "Split", longLineDrop: "Dropped"}[l.policy]
+	fmt.Fprintf(w, "%s %d lines longer than %d bytes\n", verb, n, l.maxBytes)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_lineLimiter_scan(t *testing.T) {
+	tests := []struct {
+		name         string
+		limiter      *lineLimiter
+		data         string
+		want         []string
+		wantAffected int
+		wantErr      bool
+	}{
+		{
+			name: "Read lines without limit",
+			data: "[INFO] Start API\r\n\n[INFO] Stop API",
+			want: []string{"[INFO] Start API", "", "[INFO] Stop API"},
+		},
+		{
+			name:    "Fail on a line longer than the limit of a batch",
+			data:    strings.Repeat("a", maxBatchBytes+1),
+			wantErr: true,
+		},
+		{
+			name:         "Truncate long lines",
+			limiter:      &lineLimiter{maxBytes: 8, policy: longLineTruncate},
+			data:         "[INFO] Start API\n[INFO]\n",
+			want:         []string{"[INFO] S", "[INFO]"},
+			wantAffected: 1,
+		},
+		{
+			name:         "Split long lines",
+			limiter:      &lineLimiter{maxBytes: 8, policy: longLineSplit},
+			data:         "[INFO] Start API\n[INFO] Stop API",
+			want:         []string{"[INFO] S", "tart API", "[INFO] S", "top API"},
+			wantAffected: 2,
+		},
+		{
+			name:         "Drop long lines",
+			limiter:      &lineLimiter{maxBytes: 8, policy: longLineDrop},
+			data:         "[INFO] Start API\n[INFO]\n[INFO] Stop API",
+			want:         []string{"[INFO]"},
+			wantAffected: 2,
+		},
+		{
+			name:         "Truncate a line without cutting a character",
+			limiter:      &lineLimiter{maxBytes: 4, policy: longLineTruncate},
+			data:         "ログ",
+			want:         []string{"ロ"},
+			wantAffected: 1,
+		},
+		{
+			name:         "Truncate a line longer than the buffer",
+			limiter:      &lineLimiter{maxBytes: 8, policy: longLineTruncate},
+			data:         strings.Repeat("a", 200*1024) + "\n[INFO]",
+			want:         []string{"aaaaaaaa", "[INFO]"},
+			wantAffected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			err := tt.limiter.scan(strings.NewReader(tt.data), func(line string) error {
+				got = append(got, line)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("lineLimiter.scan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineLimiter.scan() = %q, want %q", got, tt.want)
+			}
+			if tt.limiter != nil && tt.limiter.affected != tt.wantAffected {
+				t.Errorf("lineLimiter.affected = %d, want %d", tt.limiter.affected, tt.wantAffected)
+			}
+		})
+	}
+}
+
+func Test_newLineLimiter(t *testing.T) {
+	if _, err := newLineLimiter(0, longLineTruncate); err == nil {
+		t.Error("newLineLimiter() with 0 bytes error = nil, want error")
+	}
+	if _, err := newLineLimiter(1024, "wrap"); err == nil {
+		t.Error("newLineLimiter() with unknown policy error = nil, want error")
+	}
+}
+
+func Test_lineLimiter_report(t *testing.T) {
+	var buf bytes.Buffer
+	(&lineLimiter{maxBytes: 1024, policy: longLineSplit, affected: 3}).report(&buf)
+	(&lineLimiter{maxBytes: 1024, policy: longLineDrop}).report(&buf)
+	(*lineLimiter)(nil).report(&buf)
+	if want := "Split 3 lines longer than 1024 bytes\n"; buf.String() != want {
+		t.Errorf("lineLimiter.report() = %q, want %q", buf.String(), want)
+	}
+}
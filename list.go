@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type logGroupInfo struct {
+	Name            string    `json:"name"`
+	ARN             string    `json:"arn"`
+	CreationTime    time.Time `json:"creationTime"`
+	StoredBytes     int64     `json:"storedBytes"`
+	RetentionInDays *int32    `json:"retentionInDays,omitempty"`
+}
+
+type logStreamInfo struct {
+	Name           string     `json:"name"`
+	CreationTime   time.Time  `json:"creationTime"`
+	StoredBytes    int64      `json:"storedBytes"`
+	LastEventTime  *time.Time `json:"lastEventTime,omitempty"`
+	FirstEventTime *time.Time `json:"firstEventTime,omitempty"`
+}
+
+func listLogGroups(client *cloudwatchlogs.Client, prefix string) ([]logGroupInfo, error) {
+	param := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if prefix != "" {
+		param.LogGroupNamePrefix = aws.String(prefix)
+	}
+
+	groups := []logGroupInfo{}
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range res.LogGroups {
+			groups = append(groups, logGroupInfo{
+				Name:            aws.ToString(group.LogGroupName),
+				ARN:             aws.ToString(group.LogGroupArn),
+				CreationTime:    fromMillis(aws.ToInt64(group.CreationTime)),
+				StoredBytes:     aws.ToInt64(group.StoredBytes),
+				RetentionInDays: group.RetentionInDays,
+			})
+		}
+	}
+	return groups, nil
+}
+
+func findLogGroup(client *cloudwatchlogs.Client, logGroup string) (logGroupInfo, error) {
+	groups, err := listLogGroups(client, logGroup)
+	if err != nil {
+		return logGroupInfo{}, err
+	}
+	for _, group := range groups {
+		if group.Name == logGroup {
+			return group, nil
+		}
+	}
+	return logGroupInfo{}, fmt.Errorf("no log group error: %s is not found", logGroup)
+}
+
+func listLogStreams(client *cloudwatchlogs.Client, logGroup string, orderBy types.OrderBy) ([]logStreamInfo, error) {
+	param := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup),
+		OrderBy:      orderBy,
+	}
+	if orderBy == types.OrderByLastEventTime {
+		param.Descending = aws.Bool(true)
+	}
+
+	streams := []logStreamInfo{}
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, stream := range res.LogStreams {
+			info := logStreamInfo{
+				Name:         aws.ToString(stream.LogStreamName),
+				CreationTime: fromMillis(aws.ToInt64(stream.CreationTime)),
+				StoredBytes:  aws.ToInt64(stream.StoredBytes),
+			}
+			if stream.FirstEventTimestamp != nil {
+				t := fromMillis(*stream.FirstEventTimestamp)
+				info.FirstEventTime = &t
+			}
+			if stream.LastEventTimestamp != nil {
+				t := fromMillis(*stream.LastEventTimestamp)
+				info.LastEventTime = &t
+			}
+			streams = append(streams, info)
+		}
+	}
+	return streams, nil
+}
+
+func runGroups(args []string) error {
+	params := parameters{}
+	prefix := ""
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "List log groups.", "[options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&prefix, "prefix", "", "The prefix of the log group names to list.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	groups, err := listLogGroups(client, prefix)
+	if err != nil {
+		return err
+	}
+
+	return writeLogGroups(os.Stdout, groups, output)
+}
+
+// writeLogGroups writes the log groups in the output format.
+func writeLogGroups(w io.Writer, groups []logGroupInfo, output string) error {
+	if output == outputJSON {
+		return writeJSON(w, groups)
+	}
+
+	rows := make([][]string, len(groups))
+	for i, group := range groups {
+		retention := "-"
+		if group.RetentionInDays != nil {
+			retention = strconv.Itoa(int(*group.RetentionInDays))
+		}
+		rows[i] = []string{
+			group.Name,
+			group.CreationTime.Format(time.RFC3339),
+			strconv.FormatInt(group.StoredBytes, 10),
+			retention,
+		}
+	}
+	return writeTable(w, []string{"NAME", "CREATED", "STORED BYTES", "RETENTION DAYS"}, rows)
+}
+
+func runStreams(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	orderBy := "name"
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "List log streams in the log group.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group whose log streams are listed. It is required.")
+	flags.StringVar(&orderBy, "order-by", "name", "The order of log streams. name or last-event. last-event lists the latest log stream first.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	var order types.OrderBy
+	switch orderBy {
+	case "name":
+		order = types.OrderByLogStreamName
+	case "last-event":
+		order = types.OrderByLastEventTime
+	default:
+		return fmt.Errorf("argument error: --order-by must be name or last-event, but got %s", orderBy)
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	streams, err := listLogStreams(client, logGroup, order)
+	if err != nil {
+		return err
+	}
+
+	return writeLogStreams(os.Stdout, streams, output)
+}
+
+// writeLogStreams writes the log streams in the output format.
+func writeLogStreams(w io.Writer, streams []logStreamInfo, output string) error {
+	if output == outputJSON {
+		return writeJSON(w, streams)
+	}
+
+	rows := make([][]string, len(streams))
+	for i, stream := range streams {
+		lastEvent := "-"
+		if stream.LastEventTime != nil {
+			lastEvent = stream.LastEventTime.Format(time.RFC3339)
+		}
+		rows[i] = []string{
+			stream.Name,
+			stream.CreationTime.Format(time.RFC3339),
+			strconv.FormatInt(stream.StoredBytes, 10),
+			lastEvent,
+		}
+	}
+	return writeTable(w, []string{"NAME", "CREATED", "STORED BYTES", "LAST EVENT"}, rows)
+}
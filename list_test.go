@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+// newListServer starts the fake CloudWatch Logs returning two items a page and creating everything at now.
+func newListServer(t *testing.T, now time.Time) *cloudwatchlogs.Client {
+	fake := fakelogs.New()
+	fake.PageSize = 2
+	fake.Now = func() time.Time { return now }
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	return cli
+}
+
+func Test_listLogGroups(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cli := newListServer(t, now)
+	for _, name := range []string{"/app/api", "/app/web", "/app/worker", "/batch/report"} {
+		if _, err := cli.CreateLogGroup(context.Background(), &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(name)}); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+	}
+	if _, err := cli.PutRetentionPolicy(context.Background(), &cloudwatchlogs.PutRetentionPolicyInput{LogGroupName: aws.String("/app/api"), RetentionInDays: aws.Int32(7)}); err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	created := now.Format(time.RFC3339)
+
+	tests := []struct {
+		name   string
+		prefix string
+		output string
+		want   string
+	}{
+		{
+			name:   "List all log groups over pages",
+			output: outputTable,
+			want: "NAME           CREATED" + strings.Repeat(" ", len(created)-5) + "STORED BYTES  RETENTION DAYS\n" +
+				"/app/api       " + created + "  0             7\n" +
+				"/app/web       " + created + "  0             -\n" +
+				"/app/worker    " + created + "  0             -\n" +
+				"/batch/report  " + created + "  0             -\n",
+		},
+		{
+			name:   "List log groups by prefix",
+			prefix: "/app/w",
+			output: outputTable,
+			want: "NAME         CREATED" + strings.Repeat(" ", len(created)-5) + "STORED BYTES  RETENTION DAYS\n" +
+				"/app/web     " + created + "  0             -\n" +
+				"/app/worker  " + created + "  0             -\n",
+		},
+		{
+			name:   "List log groups by prefix in json",
+			prefix: "/batch/",
+			output: outputJSON,
+			want: fmt.Sprintf(`[
+  {
+    "name": "/batch/report",
+    "arn": "arn:aws:logs:us-east-1:000000000000:log-group:/batch/report",
+    "creationTime": "%s",
+    "storedBytes": 0
+  }
+]
+`, now.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "List log groups by unknown prefix in json",
+			prefix: "/unknown/",
+			output: outputJSON,
+			want:   "[]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := listLogGroups(cli, tt.prefix)
+			if err != nil {
+				t.Fatalf("listLogGroups() error = %v", err)
+			}
+			b := &bytes.Buffer{}
+			if err := writeLogGroups(b, groups, tt.output); err != nil {
+				t.Fatalf("writeLogGroups() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeLogGroups() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_listLogStreams(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cli := newListServer(t, now)
+	logGroup, _, err := setUpLogGroupAndStreams(cli, 0)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	lastEvents := map[string]time.Duration{"api-1": 3 * time.Minute, "api-2": time.Minute, "api-3": 0, "api-4": 2 * time.Minute}
+	for _, name := range []string{"api-1", "api-2", "api-3", "api-4"} {
+		if err := createLogStream(cli, logGroup, name); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+		if lastEvents[name] == 0 {
+			continue
+		}
+		in := &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(logGroup),
+			LogStreamName: aws.String(name),
+			LogEvents:     []types.InputLogEvent{{Timestamp: aws.Int64(toMillis(now.Add(-lastEvents[name]))), Message: aws.String("[INFO] Start API")}},
+		}
+		if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+	}
+	created := now.Format(time.RFC3339)
+	last := func(name string) string {
+		return now.Add(-lastEvents[name]).Format(time.RFC3339)
+	}
+
+	tests := []struct {
+		name    string
+		orderBy types.OrderBy
+		output  string
+		want    string
+	}{
+		{
+			name:    "List log streams by name over pages",
+			orderBy: types.OrderByLogStreamName,
+			output:  outputTable,
+			want: "NAME   CREATED" + strings.Repeat(" ", len(created)-5) + "STORED BYTES  LAST EVENT\n" +
+				"api-1  " + created + "  42            " + last("api-1") + "\n" +
+				"api-2  " + created + "  42            " + last("api-2") + "\n" +
+				"api-3  " + created + "  0             -\n" +
+				"api-4  " + created + "  42            " + last("api-4") + "\n",
+		},
+		{
+			name:    "List log streams by last event over pages",
+			orderBy: types.OrderByLastEventTime,
+			output:  outputTable,
+			want: "NAME   CREATED" + strings.Repeat(" ", len(created)-5) + "STORED BYTES  LAST EVENT\n" +
+				"api-2  " + created + "  42            " + last("api-2") + "\n" +
+				"api-4  " + created + "  42            " + last("api-4") + "\n" +
+				"api-1  " + created + "  42            " + last("api-1") + "\n" +
+				"api-3  " + created + "  0             -\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, err := listLogStreams(cli, logGroup, tt.orderBy)
+			if err != nil {
+				t.Fatalf("listLogStreams() error = %v", err)
+			}
+			b := &bytes.Buffer{}
+			if err := writeLogStreams(b, streams, tt.output); err != nil {
+				t.Fatalf("writeLogStreams() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeLogStreams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("List log streams in json", func(t *testing.T) {
+		streams, err := listLogStreams(cli, logGroup, types.OrderByLogStreamName)
+		if err != nil {
+			t.Fatalf("listLogStreams() error = %v", err)
+		}
+		b := &bytes.Buffer{}
+		if err := writeLogStreams(b, streams[2:4], outputJSON); err != nil {
+			t.Fatalf("writeLogStreams() error = %v", err)
+		}
+		lastEvent := now.Add(-lastEvents["api-4"]).Format(time.RFC3339Nano)
+		want := fmt.Sprintf(`[
+  {
+    "name": "api-3",
+    "creationTime": "%[1]s",
+    "storedBytes": 0
+  },
+  {
+    "name": "api-4",
+    "creationTime": "%[1]s",
+    "storedBytes": 42,
+    "lastEventTime": "%[2]s",
+    "firstEventTime": "%[2]s"
+  }
+]
+`, now.Format(time.RFC3339Nano), lastEvent)
+		if got := b.String(); got != want {
+			t.Errorf("writeLogStreams() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("List log streams of unknown log group", func(t *testing.T) {
+		if _, err := listLogStreams(cli, "unknown", types.OrderByLogStreamName); err == nil {
+			t.Error("listLogStreams() error = nil, want error")
+		}
+	})
+}
+
+func Test_runGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "List log groups with invalid output", args: []string{"groups", "--output", "yaml"}},
+		{name: "List log streams without log group", args: []string{"streams"}},
+		{name: "List log streams with invalid order", args: []string{"streams", "--log-group", "/app/api", "--order-by", "size"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := runGroups
+			if tt.args[0] == "streams" {
+				run = runStreams
+			}
+			if err := run(tt.args); err == nil {
+				t.Errorf("%s error = nil, want error", tt.args[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The schemes of --source which stream log events written by local processes.
+const (
+	sourceSchemeUnix = "unix://"
+	sourceSchemeFIFO = "fifo://"
+)
+
+// localSource streams log events written line by line to a Unix domain socket or a named pipe by local processes.
+type localSource struct {
+	scheme string
+	path   string
+	// encoding is the character encoding of log events transcoded into UTF-8
+	encoding   string
+	timestamps timestampOptions
+	lines      *lineLimiter
+	listener   net.Listener
+}
+
+// parseLocalSource parses --source like unix:///var/run/app-logs.sock or fifo:///var/run/app-logs.fifo.
+// It returns nil if the source is not a local source.
+func parseLocalSource(source string) (*localSource, error) {
+	for _, scheme := range []string{sourceSchemeUnix, sourceSchemeFIFO} {
+		if !strings.HasPrefix(source, scheme) {
+			continue
+		}
+		path := strings.TrimPrefix(source, scheme)
+		if path == "" {
+			return nil, fmt.Errorf("argument error: --source %s must have the path, such as %s/var/run/app-logs", source, scheme)
+		}
+		return &localSource{scheme: scheme, path: path}, nil
+	}
+	return nil, nil
+}
+
+func (s *localSource) String() string {
+	return s.scheme + s.path
+}
+
+// listen listens on the Unix domain socket, or checks the named pipe exists.
+// A socket file left by the previous run is removed, but other files are not overwritten.
+func (s *localSource) listen() error {
+	info, err := os.Stat(s.path)
+	if s.scheme == sourceSchemeFIFO {
+		if err != nil {
+			return fmt.Errorf("source error: %w. create the named pipe with mkfifo", err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return fmt.Errorf("source error: %s is not a named pipe", s.path)
+		}
+		return nil
+	}
+
+	if err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("source error: %s already exists and is not a socket", s.path)
+		}
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+	}
+	if s.listener, err = net.Listen("unix", s.path); err != nil {
+		return fmt.Errorf("source error: failed to listen on %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// close stops listening and removes the socket file. The nil localSource can be closed.
+func (s *localSource) close() error {
+	if s == nil || s.listener == nil {
+		return nil
+	}
+	// Closing the Unix listener also removes the socket file
+	return s.listener.Close()
+}
+
+// run emits log events until reading fails. Log events of each connection of the socket are read concurrently,
+// and the named pipe is reopened each time all writers close it.
+func (s *localSource) run(emit func(types.InputLogEvent) error) error {
+	// emit is not safe for concurrent use, so log events are emitted one by one
+	var mu sync.Mutex
+	emitOne := func(event types.InputLogEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return emit(event)
+	}
+
+	if s.scheme == sourceSchemeFIFO {
+		for {
+			// Opening the named pipe waits for a writer
+			f, err := os.Open(s.path)
+			if err != nil {
+				return fmt.Errorf("source error: %w", err)
+			}
+			err = scanLogEvents(newDecodingReader(f, s.encoding), s.lines, s.timestamps, emitOne)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					errs <- fmt.Errorf("source error: %w", err)
+				}
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var emitErr error
+				err := scanLogEvents(newDecodingReader(conn, s.encoding), s.lines, s.timestamps, func(event types.InputLogEvent) error {
+					emitErr = emitOne(event)
+					return emitErr
+				})
+				if emitErr != nil {
+					select {
+					case errs <- emitErr:
+					default:
+					}
+					return
+				}
+				// A broken connection only loses its own log events
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "source error: failed to read a connection of %s: %v\n", s, err)
+				}
+			}()
+		}
+	}()
+	return <-errs
+}
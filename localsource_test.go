@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseLocalSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    *localSource
+		wantErr bool
+	}{
+		{name: "Parse Unix domain socket", source: "unix:///var/run/app-logs.sock", want: &localSource{scheme: sourceSchemeUnix, path: "/var/run/app-logs.sock"}},
+		{name: "Parse named pipe", source: "fifo://app-logs.fifo", want: &localSource{scheme: sourceSchemeFIFO, path: "app-logs.fifo"}},
+		{name: "Parse other source", source: sourceWinEventLog},
+		{name: "Parse socket without path", source: "unix://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLocalSource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLocalSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLocalSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// receiveLogEvents runs the source, and returns n messages of log events emitted by it.
+func receiveLogEvents(t *testing.T, s *localSource, n int, write func()) []string {
+	events := make(chan types.InputLogEvent, n)
+	go s.run(func(event types.InputLogEvent) error {
+		events <- event
+		return nil
+	})
+	write()
+
+	messages := []string{}
+	for len(messages) < n {
+		select {
+		case event := <-events:
+			messages = append(messages, aws.ToString(event.Message))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("localSource.run() emitted %v, want %d log events", messages, n)
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}
+
+func Test_localSource_run_unix(t *testing.T) {
+	s := &localSource{scheme: sourceSchemeUnix, path: filepath.Join(t.TempDir(), "app.sock"), encoding: inputEncodingUTF8}
+	if err := s.listen(); err != nil {
+		t.Fatalf("localSource.listen() error = %v", err)
+	}
+	defer s.close()
+
+	got := receiveLogEvents(t, s, 3, func() {
+		for i, lines := range []string{"[INFO] Start API\n[INFO] Stop API\n", "[INFO] Start Worker"} {
+			conn, err := net.Dial("unix", s.path)
+			if err != nil {
+				t.Fatalf("failed to connect %d: %v", i, err)
+			}
+			fmt.Fprint(conn, lines)
+			conn.Close()
+		}
+	})
+	want := []string{"[INFO] Start API", "[INFO] Start Worker", "[INFO] Stop API"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("localSource.run() = %v, want %v", got, want)
+	}
+}
+
+func Test_localSource_listen(t *testing.T) {
+	file := writeFile(t, "app.log", []byte("[INFO] Start API\n"))
+	for _, scheme := range []string{sourceSchemeUnix, sourceSchemeFIFO} {
+		s := &localSource{scheme: scheme, path: file}
+		if err := s.listen(); err == nil {
+			s.close()
+			t.Errorf("localSource.listen() %s on a regular file error = nil, want error", scheme)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func Test_localSource_run_fifo(t *testing.T) {
+	s := &localSource{scheme: sourceSchemeFIFO, path: filepath.Join(t.TempDir(), "app.fifo"), encoding: inputEncodingUTF8}
+	if err := syscall.Mkfifo(s.path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.listen(); err != nil {
+		t.Fatalf("localSource.listen() error = %v", err)
+	}
+
+	// The named pipe is read again after the first writer closes it
+	got := receiveLogEvents(t, s, 2, func() {
+		for _, line := range []string{"[INFO] Start API\n", "[INFO] Stop API\n"} {
+			f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprint(f, line)
+			f.Close()
+		}
+	})
+	want := []string{"[INFO] Start API", "[INFO] Stop API"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("localSource.run() = %v, want %v", got, want)
+	}
+}
@@ -1,52 +1,595 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type parameters struct {
-	logGroup    string
-	logStream   string
-	fileName    string
-	region      string
-	endpointURL string
-	logs        []string
+	destinations    []destination
+	logStreamPrefix string
+	fileNames       []string
+	fileMappings    []fileMapping
+	archives        archiveFilter
+	inputFormat     string
+	inputEncoding   string
+	lines           *lineLimiter
+	parserPlugin    string
+	timestampField  string
+	timestampUnit   string
+	merge           bool
+	stdin           bool
+	lambdaExtension bool
+	winEventLog     *winEventLogSource
+	// since and until choose log events by their timestamps. The zero time is not bounded.
+	since            time.Time
+	until            time.Time
+	localSource      *localSource
+	batchLimits      batchLimits
+	flushInterval    time.Duration
+	drainTimeout     time.Duration
+	maxBufferBytes   int
+	onBackpressure   string
+	dedupeWindow     time.Duration
+	sampleRate       float64
+	sampleKey        string
+	guard            uploadGuard
+	protectedGroups  *regexp.Regexp
+	yes              bool
+	preflight        bool
+	dryRun           bool
+	replay           bool
+	idempotent       bool
+	manifestDir      string
+	resumeFromStream bool
+	skipExisting     bool
+	rateLimits       rateLimits
+	shardField       string
+	chunkBy          string
+	routes           []routeRule
+	shardCount       int
+	speed            float64
+	pricePerGB       float64
+	output           string
+	noColor          bool
+	// tui shows the dashboard of live counters of each destination while streaming
+	tui             bool
+	noSequenceToken bool
+	// newStream creates the log stream named by streamTemplate for the run
+	newStream            bool
+	streamTemplate       string
+	spoolDir             string
+	spoolMaxBytes        int64
+	circuitFailures      int
+	circuitCooldown      time.Duration
+	batchTimeout         time.Duration
+	deadline             time.Duration
+	deadLetterFile       string
+	schemaFile           string
+	enrich               string
+	traceHeaderEnv       string
+	wrapKey              string
+	fields               *fieldTransform
+	anonymizer           *ipAnonymizer
+	scriptFile           string
+	emf                  *emfFormat
+	onSchemaError        string
+	wait                 bool
+	waitTimeout          time.Duration
+	region               string
+	endpointURL          string
+	caBundle             string
+	insecureSkipVerify   bool
+	proxyURL             string
+	useFIPSEndpoint      bool
+	useDualStackEndpoint bool
+	profile              string
+	ssoLogin             bool
+	noCredentialCache    bool
+	mfaSerial            string
+	mfaToken             string
+	noSignRequest        bool
+	debugHTTP            bool
+	userAgentExtra       string
+	verbose              bool
+	otelEndpoint         string
+	metricsAddr          string
+	emitMetrics          bool
+	metricsNamespace     string
+	auditFile            string
+	retry                retryPolicy
+	logs                 []string
+}
+
+// addClientFlags adds the flags to configure the client to flags.
+func addClientFlags(flags *flag.FlagSet, params *parameters) {
+	flags.StringVar(&params.region, "region", "", "The name of the region. Override the region configured in config file.")
+	flags.StringVar(&params.profile, "profile", "", "The name of the profile in config file. Override AWS_PROFILE environment variable.")
+	flags.BoolVar(&params.ssoLogin, "sso-login", false, "Sign in to SSO of the profile with the browser if the cached SSO token is expired.")
+	flags.StringVar(&params.mfaSerial, "mfa-serial", "", "The ARN of the MFA device to assume the role given by --role-arn. If you do not use this parameters, mfa_serial of the profile is used.")
+	flags.StringVar(&params.mfaToken, "mfa-token", "", "The MFA token code to assume the role requiring MFA. If you do not use this parameters, AWSPUTLOGS_MFA_TOKEN environment variable is used, or it is asked on the terminal.")
+	flags.BoolVar(&params.noCredentialCache, "no-credential-cache", false, "Do not cache temporary credentials of assumed roles and SSO in ~/.aws/awsputlogs/cache. They are cached until 5 minutes before the expiration by default.")
+	flags.BoolVar(&params.debugHTTP, "debug-http", false, "Write signed requests and responses of AWS APIs with retry attempts and latencies to stderr. Credentials in them are redacted.")
+	flags.StringVar(&params.userAgentExtra, "user-agent-extra", "", "The tokens appended to the User-Agent of requests, such as the name of the pipeline, to find the API calls in CloudTrail.")
+	flags.IntVar(&params.retry.maxAttempts, "max-attempts", 0, "The maximum number of attempts of each AWS API call including retries. If you do not use this parameters, it is 3.")
+	flags.StringVar(&params.retry.mode, "retry-mode", retryModeStandard, "The retry mode of AWS API calls. standard or adaptive. adaptive also limits the rate of calls while they are throttled.")
+	flags.BoolVar(&params.noSignRequest, "no-sign-request", false, "Do not sign requests, for emulators which do not check credentials.")
+	flags.StringVar(&params.endpointURL, "endpoint-url", "", "The url of endpoint. Override default endpoint and AWS_ENDPOINT_URL environment variables with the given URL.")
+	flags.StringVar(&params.caBundle, "ca-bundle", "", "The path of PEM file that includes CA certificates trusted in addition to the system ones, for private endpoints.")
+	flags.StringVar(&params.proxyURL, "proxy-url", "", "The URL of the HTTP proxy to access AWS APIs. If you do not use this parameters, it uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY.")
+	flags.BoolVar(&params.insecureSkipVerify, "insecure-skip-verify", false, "Skip verifying TLS certificates of endpoints. Use it only for testing.")
+	flags.BoolVar(&params.useFIPSEndpoint, "use-fips-endpoint", false, "Use the FIPS endpoint of the region, such as for GovCloud.")
+	flags.BoolVar(&params.useDualStackEndpoint, "use-dualstack-endpoint", false, "Use the dual-stack endpoint of the region, which supports IPv4 and IPv6.")
+}
+
+// streams reports whether log events are uploaded continuously from stdin, the Lambda Telemetry API, or a socket or a named pipe.
+func (params parameters) streams() bool {
+	return params.stdin || params.lambdaExtension || params.localSource != nil
 }
 
 func parseOption(args []string) (parameters, error) {
 	params := parameters{}
+	logGroupARNRegions := []string{}
+	maxBatchBytesSize := ""
+	spoolMaxBytesSize := ""
+	retryOn, retryConfigFile := "", ""
+	maxBufferBytesSize := ""
+	guardMaxBytesSize, protectedGroups := "", ""
+	speed := ""
+	maxBytesPerSecond, burstBytes := "", ""
+	wrapJSON, messageKey := false, ""
+	anonymizeIP, ipFields := false, ""
+	renames, keepFields, dropFields, flatten, flattenDepth := []string{}, []string{}, []string{}, false, 0
+	useEMF, emfNamespace, emfDimensions, emfMetrics := false, "", "", []string{}
+	source, channel, since, until := "", "", "", ""
+	maxLineBytesSize, onLongLine := "", ""
 
 	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
-	flags.StringVar(&params.logGroup, "log-group", "", "The name of the log group where you want to put logs. It is required.")
-	flags.StringVar(&params.logStream, "log-stream", "", "The name of the log stream where you want to put logs. If you do not use this parameters, it uploads logs to latest log stream.")
-	flags.StringVar(&params.region, "region", "", "The name of the region. Override the region configured in config file.")
-	flags.StringVar(&params.endpointURL, "endpoint-url", "", "The url of endpoint. Override default endpoint with the given URL.")
-	flags.StringVar(&params.fileName, "logs-file", "", "The path of file that includes log events. See https://github.com/x-color/awsputlogs")
+	flags.Var(logGroupFlag{&params.destinations, &logGroupARNRegions}, "log-group", "The name or the ARN of the log group where you want to put logs. It is required. Repeat it to put the same logs to several log groups.")
+	flags.Var(logStreamFlag{&params.destinations}, "log-stream", "The name of the log stream where you want to put logs. It applies to the preceding --log-group. If you do not use this parameters, it uploads logs to latest log stream.")
+	flags.BoolVar(&params.newStream, "new-stream", false, "Create a new log stream named by --stream-template in each log group, and upload logs to it. Each run uploads logs to its own log stream, so it never conflicts with other writers.")
+	flags.StringVar(&params.streamTemplate, "stream-template", "", "The name of the log stream created by --new-stream, where {date} is replaced with the current date and {uuid} with a random UUID. If you do not use this parameters, it is "+defaultStreamTemplate+".")
+	flags.StringVar(&params.logStreamPrefix, "log-stream-prefix", "", "The prefix of the log stream names. If you do not use --log-stream, it uploads logs to latest log stream whose name starts with the prefix.")
+	flags.Var(destFlag{&params.destinations}, "dest", "The ARN of the log group where you want to put logs. It is used instead of --log-group to put logs to a log group in another region or account.")
+	flags.Var(roleARNFlag{&params.destinations}, "role-arn", "The ARN of the IAM role assumed to put logs. It applies to the preceding --log-group or --dest.")
+	addClientFlags(flags, &params)
+	flags.Var((*filesFlag)(&params.fileNames), "logs-file", "The path of file that includes log events. See https://github.com/x-color/awsputlogs. Repeat it to upload several files.")
+	flags.Var((*filesFlag)(&params.archives.include), "archive-include", "The glob of member files read from .zip and .tar.gz archives given as --logs-file, such as *.log. It matches the paths or the base names of member files. Repeat it to read several kinds of files. If you do not use this parameters, all member files are read.")
+	flags.Var((*filesFlag)(&params.archives.exclude), "archive-exclude", "The glob of member files skipped in .zip and .tar.gz archives given as --logs-file, such as *.gz. Repeat it to skip several kinds of files.")
+	flags.Var(mapFlag{&params.fileMappings}, "map", "The mapping of --logs-file to the destination, such as 'api-*.log=>/app/api:api-{date}'. Files whose paths or base names match the pattern are uploaded to the log group and the log stream, where {date} is replaced with the current date and {file} with the base name of the file. Repeat it to map files to several destinations. Log streams are created if they do not exist.")
+	flags.StringVar(&params.inputFormat, "input-format", inputFormatAuto, "The format of --logs-file. auto, json, ndjson, logfmt or text. auto detects it from the content. logfmt lines are converted into JSON log events.")
+	flags.StringVar(&params.inputEncoding, "input-encoding", inputEncodingUTF8, "The character encoding of --logs-file and stdin transcoded into UTF-8. utf-8, shift-jis, euc-jp, latin-1, windows-1252, utf-16, utf-16le or utf-16be. A leading BOM is stripped whatever it is.")
+	flags.StringVar(&maxLineBytesSize, "max-line-bytes", "", "The maximum size of lines of --logs-file and stdin read as ndjson, logfmt or text, such as 256k. Longer lines are handled by --on-long-line before they are parsed. If you do not use this parameters, lines longer than the limit of a batch fail the run.")
+	flags.StringVar(&onLongLine, "on-long-line", "", "How lines longer than --max-line-bytes are handled. truncate cuts them at the limit, split uploads them as several log events and drop skips them. If you do not use this parameters, they are truncated.")
+	flags.StringVar(&params.parserPlugin, "parser-plugin", "", "The path of WebAssembly (WASI) module which parses --logs-file of a custom format instead of --input-format. See https://github.com/x-color/awsputlogs.")
+	flags.BoolVar(&wrapJSON, "wrap-json", false, "Wrap log events which are not JSON objects into JSON objects with the message in --message-key, so that they are consistent with JSON log events.")
+	flags.StringVar(&messageKey, "message-key", "message", "The key of the message in log events wrapped by --wrap-json.")
+	flags.Var((*filesFlag)(&renames), "rename-field", "The field of JSON log events renamed, such as msg=message. Repeat it to rename several fields. They are renamed before --keep-fields and --drop-field, and not renamed if the new field exists.")
+	flags.Var((*filesFlag)(&keepFields), "keep-fields", "The comma-separated paths of fields kept in JSON log events, such as level,message,http.status. Other fields are dropped.")
+	flags.Var((*filesFlag)(&dropFields), "drop-field", "The path of the field dropped from JSON log events, such as context.request.headers. Repeat it to drop several fields.")
+	flags.BoolVar(&flatten, "flatten", false, "Flatten nested objects in JSON log events into fields whose keys are joined with dots, such as {\"http.status\":200} for {\"http\":{\"status\":200}}.")
+	flags.IntVar(&flattenDepth, "flatten-depth", 0, "The maximum depth of nested objects flattened by --flatten. Deeper objects are kept as they are. If you do not use this parameters, all nested objects are flattened.")
+	flags.BoolVar(&anonymizeIP, "anonymize-ip", false, "Mask the last octet of IPv4 addresses and the low 80 bits of IPv6 addresses in log events, such as 192.0.2.0 for 192.0.2.10.")
+	flags.StringVar(&ipFields, "ip-fields", "", "The comma-separated paths of fields of JSON log events whose addresses are masked by --anonymize-ip, such as client_ip,remote_addr. If you do not use this parameters, addresses in all of log events are masked.")
+	flags.StringVar(&params.scriptFile, "script", "", "The path of Starlark script which defines transform(event) to enrich, filter or rewrite each log event. See https://github.com/x-color/awsputlogs.")
+	flags.StringVar(&params.timestampField, "timestamp-field", "", "The field of JSON log events that has the time of log events in RFC3339 or an epoch timestamp. Log events without it use the current time.")
+	flags.StringVar(&params.timestampUnit, "timestamp-unit", timestampUnitAuto, "The unit of epoch timestamps in --timestamp-field. auto, s, ms, us or ns. auto detects it by the magnitude of each timestamp.")
+	flags.BoolVar(&params.merge, "merge", false, "Interleave log events of all --logs-file in order of the time in --timestamp-field. If you do not use this parameters, files are uploaded one by one.")
+	flags.BoolVar(&params.stdin, "stdin", false, "Read log events from stdin line by line, and upload them continuously until stdin is closed.")
+	flags.BoolVar(&params.lambdaExtension, "lambda-extension", false, "Run as a Lambda external extension, and upload function logs received from the Telemetry API continuously until the function is shut down.")
+	flags.StringVar(&source, "source", "", "The source of log events read instead of args or --logs-file. wineventlog reads records of --channel of the Windows Event Log as JSON log events. It is only supported on Windows. unix://PATH listens on the Unix domain socket, and fifo://PATH reads the named pipe, to upload log events written line by line by local processes continuously.")
+	flags.StringVar(&channel, "channel", "Application", "The channel of the Windows Event Log read by --source wineventlog, such as Application, System or Microsoft-Windows-PowerShell/Operational.")
+	flags.StringVar(&since, "since", "", "The time of the oldest log event uploaded. now, duration before now such as 24h or 7d, RFC3339 or 2006-01-02. Log events are chosen by the time in --timestamp-field, the timestamps returned by --parser-plugin or the time of records of --source wineventlog. If you do not use this parameters, all log events are uploaded.")
+	flags.StringVar(&until, "until", "", "The time of the newest log event uploaded. now, duration before now such as 24h or 7d, RFC3339 or 2006-01-02. Log events are chosen like --since. If you do not use this parameters, all log events are uploaded.")
+	flags.DurationVar(&params.flushInterval, "flush-interval", 5*time.Second, "The maximum time to buffer log events read from stdin before uploading them.")
+	flags.DurationVar(&params.drainTimeout, "drain-timeout", 10*time.Second, "The maximum time to upload buffered log events after SIGINT or SIGTERM.")
+	flags.IntVar(&params.batchLimits.maxEvents, "max-batch-events", maxBatchEvents, "The maximum number of log events uploaded by a request.")
+	flags.StringVar(&maxBatchBytesSize, "max-batch-bytes", "", "The maximum size of log events uploaded by a request, such as 512k. If you do not use this parameters, it is the limit of CloudWatch Logs (1m).")
+	flags.StringVar(&maxBufferBytesSize, "max-buffer-bytes", "", "The maximum size of log events read from stdin and buffered before uploading them, such as 16m. If you do not use this parameters, it is 8m.")
+	flags.StringVar(&params.onBackpressure, "on-backpressure", backpressureBlock, "The behavior when the buffer exceeds --max-buffer-bytes. block stops reading stdin, drop-oldest drops the oldest buffered log events, and drop-newest drops new log events.")
+	flags.DurationVar(&params.dedupeWindow, "dedupe-window", 0, "The window to drop consecutive identical log events. They are summarized into a \"last message repeated N times\" log event. If you do not use this parameters, no log events are dropped.")
+	flags.Float64Var(&params.sampleRate, "sample-rate", 1, "The rate of log events uploaded, such as 0.1. They are chosen randomly. If you do not use this parameters, all log events are uploaded.")
+	flags.StringVar(&params.sampleKey, "sample-key", "", "The key of JSON log events to choose log events by --sample-rate. Log events sharing the value of the key are uploaded or dropped together.")
+	flags.IntVar(&params.guard.maxEvents, "max-events", 0, "The maximum number of log events to upload. It aborts, or asks on the terminal, if logs exceed it. If you do not use this parameters, there is no limit.")
+	flags.StringVar(&guardMaxBytesSize, "max-bytes", "", "The maximum size of log events to upload, such as 100m. It aborts, or asks on the terminal, if logs exceed it. If you do not use this parameters, there is no limit.")
+	flags.StringVar(&protectedGroups, "protected-groups", defaultProtectedGroups, "The regular expression of names of log groups asked for confirmation before uploading logs, such as production ones. Uploading to them fails without the terminal unless --yes is given. Give the empty string not to ask.")
+	flags.BoolVar(&params.preflight, "preflight", false, "Check the permissions to put logs to each destination before uploading, and fail with the list of missing ones. It calls PutLogEvents and CreateLogStream in ways which fail without side effects.")
+	flags.BoolVar(&params.yes, "yes", false, "Upload logs without confirmation, even if they exceed --max-events or --max-bytes, or the log group matches --protected-groups.")
+	flags.BoolVar(&params.dryRun, "dry-run", false, "Show the number, the size and the estimated ingestion cost of log events without uploading them.")
+	flags.BoolVar(&params.replay, "replay", false, "Upload log events spaced according to their timestamps from now, like they happen again. Their timestamps are shifted to the time when they are uploaded.")
+	flags.StringVar(&speed, "speed", "1x", "The speed of --replay, such as 10x.")
+	flags.BoolVar(&params.idempotent, "idempotent", false, "Record the hashes of uploaded log events in a local manifest for each log stream, and skip log events already uploaded on re-run.")
+	flags.BoolVar(&params.resumeFromStream, "resume-from-stream", false, "Skip log events at or before the last log event in the log stream, to re-run an interrupted chronological import.")
+	flags.BoolVar(&params.skipExisting, "skip-existing", false, "Skip log events whose timestamps and messages are already in the log stream, to merge a corrected file into a log stream which has a part of log events.")
+	flags.Float64Var(&params.rateLimits.requestsPerSecond, "max-requests-per-second", 0, "The maximum number of PutLogEvents calls per second for each destination. If you do not use this parameters, there is no limit.")
+	flags.StringVar(&maxBytesPerSecond, "max-bytes-per-second", "", "The maximum size of log events uploaded per second for each destination, such as 512k. If you do not use this parameters, there is no limit.")
+	flags.IntVar(&params.rateLimits.burstRequests, "burst-requests", 0, "The number of PutLogEvents calls allowed at once over --max-requests-per-second. If you do not use this parameters, it is the calls for a second.")
+	flags.StringVar(&burstBytes, "burst-bytes", "", "The size of log events allowed at once over --max-bytes-per-second. If you do not use this parameters, it is the size for a second.")
+	flags.StringVar(&params.shardField, "shard-by-field", "", "The field of JSON log events to distribute them across --shard-count log streams by its hash. --log-stream must include {shard}, which is replaced with the shard number. Log streams are created if they do not exist.")
+	flags.IntVar(&params.shardCount, "shard-count", 8, "The number of log streams for --shard-by-field.")
+	flags.StringVar(&params.chunkBy, "chunk-by", "", "Put log events into a log stream for each day or hour of their timestamps in UTC. day or hour. --log-stream must include {chunk}, which is replaced with the day such as 2024-01-02 or the hour such as 2024-01-02-15. Log streams are created if they do not exist.")
+	flags.Var(routeFlag{&params.routes}, "route", "The route of log events matching the condition to the destination, such as 'level==\"error\" => /app/errors:errors-{date}'. The condition is level==\"error\", level!=\"debug\" or a filter pattern of CloudWatch Logs, and {date} is replaced with the current date. Log events go to the destination of the first route they match, and others to --log-group or --dest. @name uses the routes named in the config file. Repeat it to add several routes. Log streams are created if they do not exist.")
+	flags.StringVar(&params.manifestDir, "manifest-dir", "", "The directory of manifests for --idempotent. If you do not use this parameters, it is in the user cache directory.")
+	flags.StringVar(&params.otelEndpoint, "otel-endpoint", "", "The URL of the OTLP/HTTP endpoint to export traces of parsing, transforming, batching and putting logs, such as http://localhost:4318.")
+	flags.StringVar(&params.output, "output", outputTable, "The format of the summary written after uploading. table or json. The summary has the number, the size, the estimated cost, the levels and the time range of log events of each destination.")
+	flags.BoolVar(&params.tui, "tui", false, "Show the dashboard of live counters of each destination, such as events per second, retries and the last error, on the terminal while uploading logs with --stdin, --lambda-extension or --source of a socket or a named pipe.")
+	flags.BoolVar(&params.noColor, "no-color", false, "Do not color the summary and errors on the terminal. NO_COLOR environment variable also turns colors off.")
+	flags.BoolVar(&params.verbose, "verbose", false, "Show the number of calls and the latencies of each AWS API in the summary.")
+	flags.Float64Var(&params.pricePerGB, "price-per-gb", 0, "The price in USD to ingest 1 GB of logs, used to estimate the ingestion cost. If you do not use this parameters, it is the price of the Standard log class in the region.")
+	flags.StringVar(&params.spoolDir, "spool-dir", "", "The directory where log events are persisted when they fail to be uploaded with --stdin. They are uploaded when the destination is available again, even after restarting.")
+	flags.IntVar(&params.circuitFailures, "circuit-breaker-failures", 0, "The number of consecutive failures to put log events read from stdin which stops calling the API for a destination. Log events are spooled in --spool-dir or dropped until a probe succeeds. If you do not use this parameters, the circuit breaker is disabled.")
+	flags.DurationVar(&params.circuitCooldown, "circuit-breaker-cooldown", 30*time.Second, "The time to wait before the first probe after the circuit breaker opens. It doubles after each failed probe up to 10m.")
+	flags.BoolVar(&params.noSequenceToken, "no-sequence-token", false, "Put log events without the sequence token. CloudWatch Logs does not require it now, so several writers can put log events to the same log stream at the same time. If you do not use this parameters, the token is sent, and batches conflicting with other writers are put again with the new token.")
+	flags.DurationVar(&params.batchTimeout, "batch-timeout", 0, "The maximum time of each PutLogEvents call including its retries, such as 30s. If you do not use this parameters, calls are not timed out.")
+	flags.DurationVar(&params.deadline, "deadline", 0, "The maximum time to upload log events, such as 15m. When it is exceeded, buffered log events are flushed within --drain-timeout with --stdin, and it exits with the code 124.")
+	flags.StringVar(&params.deadLetterFile, "dead-letter-file", "", "The path of the file to append NDJSON records of log events which were not put by --deadline, or which do not match --schema with --on-schema-error dead-letter.")
+	flags.StringVar(&params.enrich, "enrich", "", "The source of metadata injected into every log event. ec2 adds the instance ID, the availability zone, the instance type and the tags of the EC2 instance got with IMDSv2. ecs adds the cluster, the task ARN, the container name and the image got from the ECS container metadata endpoint. trace adds the trace ID of the W3C traceparent in each log event or in --trace-header-env as the trace_id field.")
+	flags.StringVar(&params.traceHeaderEnv, "trace-header-env", defaultTraceHeaderEnv, "The environment variable which has the X-Ray trace header or the W3C traceparent injected by --enrich trace.")
+	flags.BoolVar(&useEMF, "emf", false, "Wrap JSON log events into the CloudWatch Embedded Metric Format, so that uploading them also produces metrics of --emf-metric.")
+	flags.StringVar(&emfNamespace, "emf-namespace", "", "The namespace of metrics produced by --emf. It is required with --emf.")
+	flags.StringVar(&emfDimensions, "emf-dimensions", "", "The comma-separated fields of JSON log events used as the dimensions of metrics produced by --emf, such as service,env.")
+	flags.Var((*filesFlag)(&emfMetrics), "emf-metric", "The field of JSON log events and the unit of the metric produced by --emf, such as latency_ms:Milliseconds. Repeat it or separate them with commas to produce several metrics.")
+	flags.StringVar(&params.schemaFile, "schema", "", "The path of JSON Schema file to validate each JSON log event against before uploading it.")
+	flags.StringVar(&params.onSchemaError, "on-schema-error", schemaErrorFail, "The behavior when a log event does not match --schema. It is drop, fail or dead-letter, which writes the log event to --dead-letter-file.")
+	flags.BoolVar(&params.wait, "wait", false, "Wait until the uploaded log events are visible with FilterLogEvents after uploading them, so that they can be queried.")
+	flags.DurationVar(&params.waitTimeout, "wait-timeout", 5*time.Minute, "The maximum time to wait for uploaded log events to be visible with --wait.")
+	flags.DurationVar(&params.retry.maxElapsedTime, "max-elapsed-time", 0, "The maximum time to keep retrying a batch of log events which failed to be put after AWS API call retries, such as 10m. If you do not use this parameters, the batch is not retried unless --retry-on is used.")
+	flags.StringVar(&retryOn, "retry-on", "", "The maximum number of attempts to put a batch of log events for each error class, such as throttling=10,server=5. The classes are throttling, server, network and client.")
+	flags.StringVar(&retryConfigFile, "retry-config", "", "The path of JSON file that includes the retry policy with maxAttempts, mode, maxElapsedTime, retryOn and delivery. The parameters given by flags override them.")
+	flags.StringVar(&params.retry.delivery, "delivery", deliveryAtLeastOnce, "What to do when it is unknown whether a batch of log events was stored, such as when PutLogEvents timed out after sending it. at-least-once retries it and may duplicate log events. at-most-once abandons it and may lose log events.")
+	flags.StringVar(&params.auditFile, "audit-file", "", "The path of the file to append NDJSON records of every batch and run, with who put how many log events where and the result.")
+	flags.BoolVar(&params.emitMetrics, "emit-metrics", false, "Publish the number of uploaded, rejected and failed log events of the run to CloudWatch metrics.")
+	flags.StringVar(&params.metricsNamespace, "metrics-namespace", defaultMetricsNamespace, "The namespace of CloudWatch metrics published with --emit-metrics.")
+	flags.StringVar(&params.metricsAddr, "metrics-addr", "", "The address to serve Prometheus metrics on /metrics and the health check on /healthz with --stdin, such as :9090.")
+	flags.StringVar(&spoolMaxBytesSize, "spool-max-bytes", "", "The maximum size of log events persisted in --spool-dir for each destination, such as 512m. The oldest log events are dropped if it is exceeded. If you do not use this parameters, it is 100m.")
 	flags.Usage = func() {
 		fmt.Fprintf(os.Stdout, "awsputlogs is tool to upload JSON and string logs to the AWS CloudWatch Logs easily.\n\n")
 		fmt.Fprintf(os.Stdout, "Usage: \n")
+		fmt.Fprintf(os.Stdout, "  awsputlogs [options] [logs...]\n")
+		fmt.Fprintf(os.Stdout, "  awsputlogs <command> [options]\n\n")
+		fmt.Fprintf(os.Stdout, "Commands: \n")
+		printSubcommands(os.Stdout)
+		fmt.Fprintf(os.Stdout, "\nOptions: \n")
 		flags.PrintDefaults()
 	}
 
 	flags.Parse(args[1:])
 
-	if params.logGroup == "" {
-		return parameters{}, errors.New("argument error: --log-group is required")
+	// The destination is chosen interactively later if it is not given on the terminal
+	if len(params.destinations) == 0 && len(params.fileMappings) == 0 && len(params.routes) == 0 && !isInteractive() {
+		return parameters{}, errors.New("argument error: --log-group or --dest is required")
+	}
+	for _, dst := range params.destinations {
+		if dst.logGroup == "" {
+			return parameters{}, fmt.Errorf("argument error: --log-group is required for --log-stream %s", dst.logStream)
+		}
+	}
+	for _, region := range logGroupARNRegions {
+		if params.region != "" && params.region != region {
+			return parameters{}, fmt.Errorf("argument error: --region %s conflicts with the region %s in the ARN given to --log-group", params.region, region)
+		}
+	}
+	params.batchLimits.maxBytes = maxBatchBytes
+	if maxBatchBytesSize != "" {
+		size, err := parseByteSize(maxBatchBytesSize)
+		if err != nil {
+			return parameters{}, fmt.Errorf("argument error: --max-batch-bytes: %w", err)
+		}
+		params.batchLimits.maxBytes = size
+	}
+	if err := params.batchLimits.validate(); err != nil {
+		return parameters{}, err
+	}
+	if params.flushInterval <= 0 {
+		return parameters{}, fmt.Errorf("argument error: --flush-interval must be positive, but got %s", params.flushInterval)
+	}
+	if params.drainTimeout <= 0 {
+		return parameters{}, fmt.Errorf("argument error: --drain-timeout must be positive, but got %s", params.drainTimeout)
+	}
+	params.maxBufferBytes = defaultMaxBufferBytes
+	if maxBufferBytesSize != "" {
+		size, err := parseByteSize(maxBufferBytesSize)
+		if err != nil {
+			return parameters{}, fmt.Errorf("argument error: --max-buffer-bytes: %w", err)
+		}
+		params.maxBufferBytes = size
+	}
+	if params.maxBufferBytes < params.batchLimits.maxBytes {
+		return parameters{}, fmt.Errorf("argument error: --max-buffer-bytes must be --max-batch-bytes (%d) or more, but got %d", params.batchLimits.maxBytes, params.maxBufferBytes)
+	}
+	if err := validateBackpressure(params.onBackpressure); err != nil {
+		return parameters{}, err
+	}
+	if params.sampleRate <= 0 || 1 < params.sampleRate {
+		return parameters{}, fmt.Errorf("argument error: --sample-rate must be more than 0 and 1 or less, but got %v", params.sampleRate)
+	}
+	if guardMaxBytesSize != "" {
+		size, err := parseByteSize(guardMaxBytesSize)
+		if err != nil {
+			return parameters{}, fmt.Errorf("argument error: --max-bytes: %w", err)
+		}
+		params.guard.maxBytes = size
+	}
+	if params.stdin && params.guard != (uploadGuard{}) {
+		return parameters{}, errors.New("argument error: --max-events and --max-bytes can not be used with --stdin")
+	}
+	var err error
+	if params.speed, err = parseSpeed(speed); err != nil {
+		return parameters{}, err
+	}
+	if protectedGroups != "" {
+		if params.protectedGroups, err = regexp.Compile(protectedGroups); err != nil {
+			return parameters{}, fmt.Errorf("argument error: --protected-groups: %w", err)
+		}
+	}
+	if params.stdin && params.replay {
+		return parameters{}, errors.New("argument error: --replay can not be used with --stdin")
+	}
+	if params.idempotent && (params.stdin || params.replay) {
+		return parameters{}, errors.New("argument error: --idempotent can not be used with --stdin or --replay")
+	}
+	if params.resumeFromStream && (params.stdin || params.replay || params.idempotent) {
+		return parameters{}, errors.New("argument error: --resume-from-stream can not be used with --stdin, --replay or --idempotent")
+	}
+	if maxBytesPerSecond != "" {
+		if params.rateLimits.bytesPerSecond, err = parseByteSize(maxBytesPerSecond); err != nil {
+			return parameters{}, fmt.Errorf("argument error: --max-bytes-per-second: %w", err)
+		}
+	}
+	if burstBytes != "" {
+		if params.rateLimits.burstBytes, err = parseByteSize(burstBytes); err != nil {
+			return parameters{}, fmt.Errorf("argument error: --burst-bytes: %w", err)
+		}
+	}
+	if params.shardField != "" && (params.stdin || params.replay || params.idempotent || params.resumeFromStream) {
+		return parameters{}, errors.New("argument error: --shard-by-field can not be used with --stdin, --replay, --idempotent or --resume-from-stream")
+	}
+	if len(params.fileMappings) > 0 && len(params.fileNames) == 0 {
+		return parameters{}, errors.New("argument error: --map requires --logs-file")
+	}
+	if len(params.fileMappings) > 0 && len(params.destinations) > 0 {
+		return parameters{}, errors.New("argument error: --map can not be used with --log-group or --dest")
+	}
+	if len(params.fileMappings) > 0 && (params.replay || params.idempotent || params.resumeFromStream || params.shardField != "") {
+		return parameters{}, errors.New("argument error: --map can not be used with --replay, --idempotent, --resume-from-stream or --shard-by-field")
+	}
+	if params.streamTemplate != "" && !params.newStream {
+		return parameters{}, errors.New("argument error: --stream-template requires --new-stream")
+	}
+	if params.newStream {
+		for _, dst := range params.destinations {
+			if dst.logStream != "" {
+				return parameters{}, fmt.Errorf("argument error: --new-stream can not be used with --log-stream %s", dst.logStream)
+			}
+		}
+		if params.logStreamPrefix != "" || len(params.fileMappings) > 0 || params.idempotent || params.resumeFromStream {
+			return parameters{}, errors.New("argument error: --new-stream can not be used with --log-stream-prefix, --map, --idempotent or --resume-from-stream")
+		}
+		if params.streamTemplate == "" {
+			params.streamTemplate = defaultStreamTemplate
+		}
+		if err := validateStreamTemplate(params.streamTemplate); err != nil {
+			return parameters{}, err
+		}
+	}
+	if params.shardCount <= 0 {
+		return parameters{}, fmt.Errorf("argument error: --shard-count must be positive, but got %d", params.shardCount)
+	}
+	if params.stdin && params.dryRun {
+		return parameters{}, errors.New("argument error: --dry-run can not be used with --stdin")
+	}
+	params.spoolMaxBytes = defaultSpoolMaxBytes
+	if spoolMaxBytesSize != "" {
+		size, err := parseByteSize(spoolMaxBytesSize)
+		if err != nil {
+			return parameters{}, fmt.Errorf("argument error: --spool-max-bytes: %w", err)
+		}
+		params.spoolMaxBytes = int64(size)
+	}
+	if err := validateInputFormat(params.inputFormat); err != nil {
+		return parameters{}, err
+	}
+	if err := validateTimestampUnit(params.timestampUnit); err != nil {
+		return parameters{}, err
+	}
+	if err := params.archives.validate(); err != nil {
+		return parameters{}, err
+	}
+	if err := validateInputEncoding(params.inputEncoding); err != nil {
+		return parameters{}, err
+	}
+	if err := validateOutputFormat(params.output); err != nil {
+		return parameters{}, err
+	}
+	if params.parserPlugin != "" && params.inputEncoding != inputEncodingUTF8 {
+		return parameters{}, errors.New("argument error: --parser-plugin can not be used with --input-encoding")
+	}
+	if onLongLine != "" && maxLineBytesSize == "" {
+		return parameters{}, errors.New("argument error: --on-long-line requires --max-line-bytes")
+	}
+	if maxLineBytesSize != "" {
+		size, err := parseByteSize(maxLineBytesSize)
+		if err != nil {
+			return parameters{}, fmt.Errorf("argument error: --max-line-bytes: %w", err)
+		}
+		if onLongLine == "" {
+			onLongLine = longLineTruncate
+		}
+		if params.lines, err = newLineLimiter(size, onLongLine); err != nil {
+			return parameters{}, err
+		}
+	}
+	if params.since, params.until, err = parseTimeRange(since, until); err != nil {
+		return parameters{}, err
+	}
+	if (since != "" || until != "") && source == "" {
+		if params.streams() {
+			return parameters{}, errors.New("argument error: --since and --until can not be used with --stdin or --lambda-extension")
+		}
+		if params.timestampField == "" && params.parserPlugin == "" {
+			return parameters{}, errors.New("argument error: --since and --until require --timestamp-field or --parser-plugin to parse the time of log events")
+		}
+	}
+	switch source {
+	case "":
+	case sourceWinEventLog:
+		if len(flags.Args()) > 0 || len(params.fileNames) > 0 || params.streams() {
+			return parameters{}, errors.New("argument error: --source can not be used with logs in args, --logs-file, --stdin or --lambda-extension")
+		}
+		params.winEventLog = &winEventLogSource{channel: channel, since: params.since}
+	default:
+		if params.localSource, err = parseLocalSource(source); err != nil {
+			return parameters{}, err
+		}
+		if params.localSource == nil {
+			return parameters{}, fmt.Errorf("argument error: --source must be %s, %sPATH or %sPATH, but got %s", sourceWinEventLog, sourceSchemeUnix, sourceSchemeFIFO, source)
+		}
+		if len(flags.Args()) > 0 || len(params.fileNames) > 0 || params.stdin || params.lambdaExtension {
+			return parameters{}, errors.New("argument error: --source can not be used with logs in args, --logs-file, --stdin or --lambda-extension")
+		}
+		if params.replay || params.idempotent || params.resumeFromStream || params.shardField != "" || params.dryRun || params.wait || params.guard != (uploadGuard{}) || since != "" || until != "" {
+			return parameters{}, fmt.Errorf("argument error: --source %s can not be used with --replay, --idempotent, --resume-from-stream, --shard-by-field, --dry-run, --wait, --max-events, --max-bytes, --since or --until", source)
+		}
+		params.localSource.encoding = params.inputEncoding
+		params.localSource.lines = params.lines
+		params.localSource.timestamps = timestampOptions{field: params.timestampField, unit: params.timestampUnit}
+	}
+	if params.parserPlugin != "" && len(params.fileNames) == 0 {
+		return parameters{}, errors.New("argument error: --parser-plugin requires --logs-file")
+	}
+	if params.parserPlugin != "" && params.inputFormat != inputFormatAuto {
+		return parameters{}, errors.New("argument error: --parser-plugin can not be used with --input-format")
+	}
+	if messageKey == "" {
+		return parameters{}, errors.New("argument error: --message-key must not be empty")
+	}
+	if wrapJSON {
+		params.wrapKey = messageKey
+	} else if messageKey != "message" {
+		return parameters{}, errors.New("argument error: --message-key requires --wrap-json")
+	}
+	if params.fields, err = newFieldTransform(renames, keepFields, dropFields, flatten, flattenDepth); err != nil {
+		return parameters{}, err
+	}
+	if anonymizeIP {
+		if params.anonymizer, err = newIPAnonymizer(ipFields); err != nil {
+			return parameters{}, err
+		}
+	} else if ipFields != "" {
+		return parameters{}, errors.New("argument error: --ip-fields requires --anonymize-ip")
+	}
+	if params.merge && params.timestampField == "" {
+		return parameters{}, errors.New("argument error: --merge requires --timestamp-field")
+	}
+	if params.spoolDir != "" && !params.streams() {
+		return parameters{}, errors.New("argument error: --spool-dir can only be used with --stdin, --lambda-extension or --source of a socket or a named pipe")
+	}
+	if params.circuitFailures < 0 {
+		return parameters{}, fmt.Errorf("argument error: --circuit-breaker-failures must be positive, but got %d", params.circuitFailures)
+	}
+	if params.circuitCooldown <= 0 {
+		return parameters{}, fmt.Errorf("argument error: --circuit-breaker-cooldown must be positive, but got %s", params.circuitCooldown)
+	}
+	if params.circuitFailures > 0 && !params.streams() {
+		return parameters{}, errors.New("argument error: --circuit-breaker-failures can only be used with --stdin, --lambda-extension or --source of a socket or a named pipe")
+	}
+	if params.batchTimeout < 0 {
+		return parameters{}, fmt.Errorf("argument error: --batch-timeout must be positive, but got %s", params.batchTimeout)
+	}
+	if params.deadline < 0 {
+		return parameters{}, fmt.Errorf("argument error: --deadline must be positive, but got %s", params.deadline)
+	}
+	if err := validateOnSchemaError(params.onSchemaError); err != nil {
+		return parameters{}, err
+	}
+	if err := validateEnrich(params.enrich); err != nil {
+		return parameters{}, err
+	}
+	if useEMF {
+		if params.emf, err = newEMFFormat(emfNamespace, emfDimensions, emfMetrics); err != nil {
+			return parameters{}, err
+		}
+	} else if emfNamespace != "" || emfDimensions != "" || len(emfMetrics) > 0 {
+		return parameters{}, errors.New("argument error: --emf-namespace, --emf-dimensions and --emf-metric require --emf")
+	}
+	schemaDeadLetter := params.schemaFile != "" && params.onSchemaError == schemaErrorDeadLetter
+	if schemaDeadLetter && params.deadLetterFile == "" {
+		return parameters{}, errors.New("argument error: --on-schema-error dead-letter requires --dead-letter-file")
+	}
+	if params.deadLetterFile != "" && params.deadline == 0 && !schemaDeadLetter {
+		return parameters{}, errors.New("argument error: --dead-letter-file requires --deadline or --on-schema-error dead-letter")
+	}
+	if params.deadLetterFile != "" && params.spoolDir != "" {
+		return parameters{}, errors.New("argument error: --dead-letter-file can not be used with --spool-dir")
+	}
+	if params.waitTimeout <= 0 {
+		return parameters{}, fmt.Errorf("argument error: --wait-timeout must be positive, but got %s", params.waitTimeout)
+	}
+	if params.wait && params.stdin {
+		return parameters{}, errors.New("argument error: --wait can not be used with --stdin")
+	}
+	if params.retry.batchAttempts, err = parseRetryOn(retryOn); err != nil {
+		return parameters{}, err
+	}
+	if retryConfigFile != "" {
+		setFlags := map[string]bool{}
+		flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+		if params.retry, err = loadRetryConfig(retryConfigFile, params.retry, setFlags); err != nil {
+			return parameters{}, err
+		}
+	}
+	if err := params.retry.validate(); err != nil {
+		return parameters{}, err
+	}
+	if params.retry.delivery == deliveryAtMostOnce && params.spoolDir != "" {
+		return parameters{}, errors.New("argument error: --spool-dir puts failed log events again, so it can not be used with --delivery at-most-once")
+	}
+	if params.tui && !params.streams() {
+		return parameters{}, errors.New("argument error: --tui can only be used with --stdin, --lambda-extension or --source of a socket or a named pipe")
+	}
+	if params.metricsAddr != "" && !params.streams() {
+		return parameters{}, errors.New("argument error: --metrics-addr can only be used with --stdin, --lambda-extension or --source of a socket or a named pipe")
+	}
+	if params.lambdaExtension && (params.stdin || params.replay || params.idempotent || params.resumeFromStream || params.shardField != "" || params.dryRun || params.wait || params.guard != (uploadGuard{})) {
+		return parameters{}, errors.New("argument error: --lambda-extension can not be used with --stdin, --replay, --idempotent, --resume-from-stream, --shard-by-field, --dry-run, --wait, --max-events or --max-bytes")
+	}
+	if params.skipExisting && (params.streams() || params.replay || params.idempotent || params.resumeFromStream || params.shardField != "" || len(params.fileMappings) > 0 || params.newStream) {
+		return parameters{}, errors.New("argument error: --skip-existing can not be used with --stdin, --source, --lambda-extension, --replay, --idempotent, --resume-from-stream, --shard-by-field, --map or --new-stream")
+	}
+	if params.chunkBy != "" {
+		if err := validateChunkBy(params.chunkBy); err != nil {
+			return parameters{}, err
+		}
+		if params.streams() || params.replay || params.idempotent || params.resumeFromStream || params.skipExisting || params.shardField != "" || len(params.fileMappings) > 0 || params.newStream {
+			return parameters{}, errors.New("argument error: --chunk-by can not be used with --stdin, --source, --lambda-extension, --replay, --idempotent, --resume-from-stream, --skip-existing, --shard-by-field, --map or --new-stream")
+		}
+	}
+	if len(params.routes) > 0 && (params.streams() || params.replay || params.idempotent || params.resumeFromStream || params.skipExisting || params.shardField != "" || len(params.fileMappings) > 0 || params.newStream || params.chunkBy != "") {
+		return parameters{}, errors.New("argument error: --route can not be used with --stdin, --source, --lambda-extension, --replay, --idempotent, --resume-from-stream, --skip-existing, --shard-by-field, --map, --new-stream or --chunk-by")
 	}
 	params.logs = flags.Args()
-
 	return params, nil
 }
 
@@ -58,23 +601,30 @@ func parseLogEvents(data []byte) ([]string, error) {
 
 	events := make([]string, len(logs))
 	for i, event := range logs {
-		// Convert the event to a string if it is JSON format
-		if _, ok := event.(map[string]interface{}); ok {
-			b, err := json.Marshal(event)
-			if err != nil {
-				return nil, err
-			}
-			events[i] = string(b)
-			continue
+		var err error
+		events[i], err = logEventString(event)
+		if err != nil {
+			return nil, err
 		}
-
-		events[i] = fmt.Sprint(event)
 	}
 
 	return events, nil
 }
 
-func getLogEventsFromFile(fileName string) ([]string, error) {
+func logEventString(event interface{}) (string, error) {
+	// Convert the event to a string if it is JSON format
+	if _, ok := event.(map[string]interface{}); ok {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	return fmt.Sprint(event), nil
+}
+
+func getLogEventsFromFile(fileName, format string) ([]string, error) {
 	f, err := os.Open(fileName)
 	if err != nil {
 		return nil, err
@@ -85,112 +635,978 @@ func getLogEventsFromFile(fileName string) ([]string, error) {
 		return nil, err
 	}
 
-	return parseLogEvents(data)
+	return parseLogs(data, format, nil)
 }
 
 func loadConfig(params parameters) (aws.Config, error) {
 	paramsFns := []func(*config.LoadOptions) error{}
 
+	if err := params.retry.validate(); err != nil {
+		return aws.Config{}, err
+	}
+	if params.retry.maxAttempts > 0 {
+		paramsFns = append(paramsFns, config.WithRetryMaxAttempts(params.retry.maxAttempts))
+	}
+	if params.retry.mode == retryModeAdaptive {
+		paramsFns = append(paramsFns, config.WithRetryMode(aws.RetryModeAdaptive))
+	}
+
+	if params.endpointURL != "" && params.useFIPSEndpoint {
+		return aws.Config{}, errors.New("argument error: --use-fips-endpoint can not be used with --endpoint-url")
+	}
+	if params.endpointURL != "" && params.useDualStackEndpoint {
+		return aws.Config{}, errors.New("argument error: --use-dualstack-endpoint can not be used with --endpoint-url")
+	}
+
 	if params.endpointURL != "" {
-		endpointResolver := aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
-			return aws.Endpoint{
-				URL:           params.endpointURL,
-				SigningRegion: params.region,
-			}, nil
-		})
-		paramsFns = append(paramsFns, config.WithEndpointResolver(endpointResolver))
+		paramsFns = append(paramsFns, config.WithBaseEndpoint(params.endpointURL))
 	}
 
 	if params.region != "" {
 		paramsFns = append(paramsFns, config.WithRegion(params.region))
 	}
 
-	return config.LoadDefaultConfig(context.Background(), paramsFns...)
+	if params.profile != "" {
+		paramsFns = append(paramsFns, config.WithSharedConfigProfile(params.profile))
+	}
+
+	apiOptions, err := userAgentOptions(params.userAgentExtra)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	apiOptions = append(apiOptions, apiCalls.addRecording)
+	paramsFns = append(paramsFns, config.WithAPIOptions(apiOptions))
+
+	if params.debugHTTP {
+		paramsFns = append(paramsFns,
+			config.WithLogger(redactingLogger{w: os.Stderr}),
+			config.WithClientLogMode(debugHTTPLogMode),
+			config.WithAPIOptions([]func(*middleware.Stack) error{addLatencyLogging}),
+		)
+	}
+
+	if params.noSignRequest {
+		paramsFns = append(paramsFns, config.WithCredentialsProvider(aws.AnonymousCredentials{}))
+	}
+	paramsFns = append(paramsFns, config.WithAssumeRoleCredentialOptions(withMFA(params)))
+
+	if usesEmulator(params) {
+		// Emulators accept any region, but the SDK fails without it
+		paramsFns = append(paramsFns, config.WithDefaultRegion(emulatorRegion), config.WithEC2RoleCredentialOptions(withQuickInstanceCredentials))
+	}
+
+	if params.useFIPSEndpoint {
+		paramsFns = append(paramsFns, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	if params.useDualStackEndpoint {
+		paramsFns = append(paramsFns, config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+
+	httpClient, err := newHTTPClient(params)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if httpClient != nil {
+		paramsFns = append(paramsFns, config.WithHTTPClient(httpClient))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), paramsFns...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if err := resolveRegion(&cfg); err != nil {
+		return aws.Config{}, err
+	}
+	if usesEmulator(params) && !params.noSignRequest {
+		// Emulators accept any credentials, but the SDK fails without them
+		cfg.Credentials = aws.NewCredentialsCache(emulatorCredentials{provider: cfg.Credentials})
+	}
+	if params.ssoLogin {
+		if err := ssoLogin(cfg); err != nil {
+			return aws.Config{}, err
+		}
+	}
+	if key, ok := profileCredentialsCacheKey(cfg, params); ok {
+		cfg.Credentials = newFileCredentialsCache(cfg.Credentials, key)
+	}
+	return cfg, nil
 }
 
-func getLatestLogStream(client *cloudwatchlogs.Client, logGroup string) (string, error) {
+// loadDestinationConfig loads the configuration for the destination.
+// The region and the IAM role of the destination override the ones given by the parameters.
+func loadDestinationConfig(params parameters, dst destination) (aws.Config, error) {
+	if dst.region != "" {
+		params.region = dst.region
+	}
+
+	cfg, err := loadConfig(params)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	if dst.roleARN != "" {
+		provider := newAssumeRoleProvider(cfg, params, dst.roleARN)
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+		if key, ok := roleCredentialsCacheKey(params, dst.roleARN); ok {
+			cfg.Credentials = newFileCredentialsCache(provider, key)
+		}
+	}
+	return cfg, nil
+}
+
+// newClient creates a client for the destination.
+// The region and the IAM role of the destination override the ones given by the parameters.
+func newClient(params parameters, dst destination) (*cloudwatchlogs.Client, error) {
+	cfg, err := loadDestinationConfig(params, dst)
+	if err != nil {
+		return nil, err
+	}
+	return newClientFromConfig(cfg, params), nil
+}
+
+// newClientFromConfig creates a client with the configuration loaded for the parameters.
+func newClientFromConfig(cfg aws.Config, params parameters) *cloudwatchlogs.Client {
+	return cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+		if endpointURL := logsEndpointURL(params); endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+}
+
+// logsEndpointURL returns the endpoint URL of CloudWatch Logs which the SDK does not resolve by itself.
+// The SDK resolves AWS_ENDPOINT_URL and AWS_ENDPOINT_URL_CLOUDWATCH_LOGS, but the latter overrides --endpoint-url.
+// AWS_ENDPOINT_URL_LOGS is accepted as the short name of AWS_ENDPOINT_URL_CLOUDWATCH_LOGS.
+func logsEndpointURL(params parameters) string {
+	if params.endpointURL != "" {
+		return params.endpointURL
+	}
+	if _, ok := os.LookupEnv("AWS_ENDPOINT_URL_CLOUDWATCH_LOGS"); ok {
+		return ""
+	}
+	return os.Getenv("AWS_ENDPOINT_URL_LOGS")
+}
+
+// getLatestLogStream returns the log stream which has the latest log event in the log group.
+// If prefix is not empty, it only looks at the log streams whose names start with prefix.
+func getLatestLogStream(client *cloudwatchlogs.Client, logGroup, prefix string) (string, error) {
+	if prefix != "" {
+		return getLatestLogStreamWithPrefix(client, logGroup, prefix)
+	}
+
 	param := &cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName: aws.String(logGroup),
 		Descending:   aws.Bool(true),
 		OrderBy:      types.OrderByLastEventTime,
 	}
-	res, err := client.DescribeLogStreams(context.Background(), param)
-	if err != nil {
-		return "", err
-	}
-	if len(res.LogStreams) == 0 {
-		return "", fmt.Errorf("no log stream error: log streams are not found in %s. you have to create log stream before running this tool", logGroup)
+	// A page can be empty even if the next page has log streams
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return "", err
+		}
+		if len(res.LogStreams) > 0 {
+			return aws.ToString(res.LogStreams[0].LogStreamName), nil
+		}
 	}
-	return *res.LogStreams[0].LogStreamName, nil
+	return "", fmt.Errorf("no log stream error: log streams are not found in %s. you have to create log stream before running this tool", logGroup)
 }
 
-func putLogEvents(client *cloudwatchlogs.Client, logGroup, logStream string, logEvents []string) error {
-	in := &cloudwatchlogs.DescribeLogStreamsInput{
+// describeLogStream returns the log stream whose name is exactly logStream, or nil if it does not exist.
+// DescribeLogStreams finds log streams only by the prefix, so it looks at all pages of log streams sharing the prefix,
+// such as api-2 and api-20 for api-2.
+func describeLogStream(ctx context.Context, client *cloudwatchlogs.Client, logGroup, logStream string) (*types.LogStream, error) {
+	param := &cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName:        aws.String(logGroup),
 		LogStreamNamePrefix: aws.String(logStream),
 	}
-	out, err := client.DescribeLogStreams(context.Background(), in)
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, stream := range res.LogStreams {
+			if aws.ToString(stream.LogStreamName) == logStream {
+				return &stream, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func getLatestLogStreamWithPrefix(client *cloudwatchlogs.Client, logGroup, prefix string) (string, error) {
+	// DescribeLogStreams can not order log streams by the last event time when the prefix is given.
+	// So it looks at all log streams matching the prefix.
+	param := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(logGroup),
+		LogStreamNamePrefix: aws.String(prefix),
+	}
+	latest := ""
+	latestTime := int64(-1)
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return "", err
+		}
+		for _, stream := range res.LogStreams {
+			t := aws.ToInt64(stream.LastEventTimestamp)
+			if t == 0 {
+				t = aws.ToInt64(stream.CreationTime)
+			}
+			if t > latestTime {
+				latest = aws.ToString(stream.LogStreamName)
+				latestTime = t
+			}
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no log stream error: log streams starting with %s are not found in %s. you have to create log stream before running this tool", prefix, logGroup)
+	}
+	return latest, nil
+}
+
+// errNoActiveDestinations means that uploading to all destinations failed.
+var errNoActiveDestinations = errors.New("upload error: no destinations to put logs")
+
+// uploader puts log events to a destination. It keeps the sequence token between PutLogEvents calls.
+type uploader struct {
+	client *cloudwatchlogs.Client
+	dst    destination
+	limits batchLimits
+	token  *string
+	// withoutToken puts log events without the sequence token, so that they do not conflict with other writers
+	withoutToken bool
+	// ingested counts log events put to the destination
+	ingested ingestion
+	// rejected counts log events rejected by PutLogEvents, and failed counts ones not put because of errors
+	rejected int
+	failed   int
+	// ambiguous counts failed log events which may have been stored, since the result of PutLogEvents is unknown
+	ambiguous int
+	// limiter limits PutLogEvents calls if it is not nil
+	limiter *uploadLimiter
+	// shard is the number of the shard uploaded with --shard-by-field
+	shard int
+	// mapping is the index of the destination which files are mapped to with --map
+	mapping int
+	// spool keeps log events which failed to be uploaded if it is not nil
+	spool *spool
+	// circuit stops putting log events after consecutive failures if it is not nil
+	circuit *circuitBreaker
+	// metrics records the results of PutLogEvents if it is not nil
+	metrics *shipperMetrics
+	// audit records batches put to the destination if it is not nil
+	audit *auditLog
+	// retry decides whether the batch which failed to be put is retried
+	retry retryPolicy
+	// batchTimeout limits each PutLogEvents call if it is not zero
+	batchTimeout time.Duration
+	// deadLetters keeps log events not put by the deadline if it is not nil
+	deadLetters *deadLetterFile
+	// progress shows the number of batches put on the terminal if it is not nil
+	progress *batchProgress
+	// dashboard shows the counters of the destination with --tui if it is not nil
+	dashboard *dashboardRow
+	// streams shares the sequence token with other uploaders of the log stream if it is not nil
+	streams *logStreamCache
+	// err is the error which stopped uploading to the destination
+	err error
+}
+
+// newUploader creates the uploader for the destination.
+// If the destination has no log stream, it uploads to the latest log stream whose name starts with logStreamPrefix.
+// Log streams are resolved through streams if it is not nil, so that uploaders of the run share them.
+func newUploader(client *cloudwatchlogs.Client, dst destination, logStreamPrefix string, limits batchLimits, streams *logStreamCache) (*uploader, error) {
+	region := client.Options().Region
+	if dst.logStream == "" {
+		var err error
+		dst.logStream, err = streams.latest(region, dst.logGroup, logStreamPrefix, func() (string, error) {
+			return getLatestLogStream(client, dst.logGroup, logStreamPrefix)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := streams.token(region, dst, func() (*string, bool, error) {
+		stream, err := describeLogStream(context.Background(), client, dst.logGroup, dst.logStream)
+		if err != nil || stream == nil {
+			return nil, false, err
+		}
+		return stream.UploadSequenceToken, true, nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if len(out.LogStreams) == 0 {
-		return fmt.Errorf("not log stream error: %s is not found in %s", logStream, logGroup)
+
+	return &uploader{
+		client:   client,
+		dst:      dst,
+		limits:   limits,
+		token:    token,
+		streams:  streams,
+		ingested: ingestion{dst: dst, region: client.Options().Region},
+	}, nil
+}
+
+// put puts log events sorted by the timestamp.
+// It splits log events into several PutLogEvents calls if they exceed the limits of a call.
+func (u *uploader) put(ctx context.Context, events []types.InputLogEvent) (err error) {
+	batches := splitBatches(events, u.limits)
+	ctx, span := tracer().Start(ctx, "batch", trace.WithAttributes(
+		attribute.String("aws.log.group.name", u.dst.logGroup),
+		attribute.String("aws.log.stream.name", u.dst.logStream),
+		attribute.Int("awsputlogs.events", len(events)),
+		attribute.Int("awsputlogs.batches", len(batches)),
+	))
+	defer func() { endSpan(span, err) }()
+
+	u.progress.add(len(batches))
+	for i, batch := range batches {
+		if err := u.putBatch(ctx, batch); err != nil {
+			deadlineExceeded := errors.Is(ctx.Err(), context.DeadlineExceeded)
+			for _, failed := range batches[i:] {
+				u.failed += len(failed)
+				if deadlineExceeded {
+					u.deadLetters.write(u.dst, failed)
+				}
+			}
+			return err
+		}
+		u.progress.advance()
 	}
+	return nil
+}
+
+// putBatch puts a batch of log events within the limits of PutLogEvents.
+func (u *uploader) putBatch(ctx context.Context, batch []types.InputLogEvent) (err error) {
+	ctx, span := tracer().Start(ctx, "put", trace.WithAttributes(
+		attribute.Int("awsputlogs.events", len(batch)),
+		attribute.Int("awsputlogs.bytes", ingestedBytes(batch)),
+	))
+	defer func() { endSpan(span, err) }()
 
 	param := &cloudwatchlogs.PutLogEventsInput{
-		LogEvents:     make([]types.InputLogEvent, len(logEvents)),
-		LogGroupName:  aws.String(logGroup),
-		LogStreamName: aws.String(logStream),
-		SequenceToken: out.LogStreams[0].UploadSequenceToken,
+		LogEvents:     batch,
+		LogGroupName:  aws.String(u.dst.logGroup),
+		LogStreamName: aws.String(u.dst.logStream),
+	}
+	if err := u.limiter.wait(ctx, ingestedBytes(batch)); err != nil {
+		return err
+	}
+	// The SDK retries the call, and the batch is retried after it fails by the retry policy
+	var res *cloudwatchlogs.PutLogEventsOutput
+	start := time.Now()
+	for batchAttempts := 1; ; batchAttempts++ {
+		res, err = u.putWithToken(ctx, param)
+		if err == nil || !u.retry.shouldRetryBatch(ctx, err, batchAttempts, time.Since(start)) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(batchBackoff(batchAttempts)):
+		}
+	}
+	u.audit.recordBatch(u, batch, err)
+	n := attempts(res, err)
+	span.SetAttributes(attribute.Int("awsputlogs.attempts", n))
+	if err != nil {
+		if isAmbiguousError(err) {
+			u.ambiguous += len(batch)
+		}
+		u.dashboard.setError(err)
+		return err
+	}
+	u.metrics.addPut(batch, res.RejectedLogEventsInfo, n)
+	u.dashboard.addPut(batch, res.RejectedLogEventsInfo, n)
+	u.rejected += rejectedLogEvents(len(batch), res.RejectedLogEventsInfo)
+	u.token = res.NextSequenceToken
+	u.streams.setToken(u.client.Options().Region, u.dst, u.token)
+	u.ingested.add(batch)
+	return nil
+}
+
+// putLogEvents calls PutLogEvents within the batch timeout.
+func (u *uploader) putLogEvents(ctx context.Context, param *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if u.batchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.batchTimeout)
+		defer cancel()
+	}
+	return u.client.PutLogEvents(ctx, param, func(o *cloudwatchlogs.Options) {
+		o.Retryer = u.retry.putRetryer(o.Retryer)
+	})
+}
+
+// upload puts log events. If the uploader has the spool, it puts spooled log events first,
+// and it spools log events instead of returning the error when it fails to put them.
+// If the uploader has the circuit breaker, log events are spooled or dropped without calling the API while it is open.
+func (u *uploader) upload(ctx context.Context, events []types.InputLogEvent) error {
+	allowed, event := u.circuit.allow()
+	u.circuitChanged(event)
+	var err error
+	if allowed {
+		err = u.putSpooled(ctx, events)
+		u.circuitChanged(u.circuit.record(err))
+		if err == nil {
+			return nil
+		}
+	}
+
+	switch {
+	case u.spool != nil:
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\nlogs are spooled in %s\n", u.dst, err, u.spool.dir)
+		}
+		return u.spool.push(events)
+	case u.circuit != nil:
+		// The destination is kept to be probed later, so only the log events are lost
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\nlogs are dropped\n", u.dst, err)
+		} else {
+			u.failed += len(events)
+		}
+		return nil
+	default:
+		return err
 	}
+}
+
+// putSpooled puts spooled log events before log events if the uploader has the spool.
+func (u *uploader) putSpooled(ctx context.Context, events []types.InputLogEvent) error {
+	if u.spool == nil {
+		return u.put(ctx, events)
+	}
+	err := u.spool.drain(func(spooled []types.InputLogEvent) error {
+		return u.put(ctx, spooled)
+	})
+	if err != nil {
+		return err
+	}
+	return u.put(ctx, events)
+}
+
+// circuitChanged reports the event of the circuit breaker if its state changes.
+func (u *uploader) circuitChanged(event string) {
+	if event == "" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "circuit breaker: %s %s\n", u.dst, event)
+	if u.circuit.isOpen() {
+		u.metrics.addCircuitOpened()
+	}
+}
 
-	for i, event := range logEvents {
-		param.LogEvents[i] = types.InputLogEvent{
-			Message:   aws.String(event),
-			Timestamp: aws.Int64(time.Now().UnixNano() / int64(time.Millisecond)),
+// putIdempotent puts log events skipping ones recorded in the manifest of each log stream.
+func putIdempotent(ctx context.Context, params parameters, uploaders []*uploader, events []types.InputLogEvent) error {
+	dir := params.manifestDir
+	if dir == "" {
+		var err error
+		if dir, err = defaultManifestDir(); err != nil {
+			return err
 		}
 	}
 
-	_, err = client.PutLogEvents(context.Background(), param)
-	return err
+	keys := eventKeys(events, params.timestampField != "")
+	for _, u := range uploaders {
+		m, err := openManifest(dir, u.dst)
+		if err != nil {
+			return err
+		}
+		skipped, err := u.putIdempotent(ctx, m, events, keys)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d log events already uploaded to %s\n", skipped, u.dst)
+		}
+		u.err = err
+	}
+	return nil
 }
 
 func exec() error {
-	params, err := parseOption(os.Args)
+	if len(os.Args) > 1 {
+		// --version is an alias of the version subcommand, which bug reports are asked to include
+		if os.Args[1] == "--version" {
+			os.Args[1] = "version"
+		}
+		if cmd, ok := findSubcommand(os.Args[1]); ok {
+			return cmd.run(os.Args[1:])
+		}
+	}
+
+	args := os.Args
+	// put names the default command explicitly, such as awsputlogs put @prod-api "message"
+	if len(args) > 1 && args[1] == "put" {
+		args = append([]string{args[0]}, args[2:]...)
+	}
+	args, err := expandAlias(args)
+	if err != nil {
+		return err
+	}
+
+	params, err := parseOption(args)
 	if err != nil {
 		return err
 	}
+	colors = newPalette(os.Stdout, params.noColor)
+
+	shutdownTracing, err := setUpTracing(params.otelEndpoint)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+	ctx, span := tracer().Start(context.Background(), "awsputlogs")
+	defer span.End()
+	if params.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, params.deadline)
+		defer cancel()
+	}
 
-	if params.fileName != "" {
-		params.logs, err = getLogEventsFromFile(params.fileName)
+	now := time.Now()
+	timestamps := timestampOptions{field: params.timestampField, unit: params.timestampUnit}
+	_, parseSpan := tracer().Start(ctx, "parse")
+	events := newTimestampedLogEvents(params.logs, timestamps, now)
+	// mapped has log events of the files mapped to each destination with --map
+	var mapped [][]types.InputLogEvent
+	if len(params.fileNames) > 0 {
+		plugin, err := newParserPlugin(params.parserPlugin)
 		if err != nil {
+			endSpan(parseSpan, err)
+			return err
+		}
+		input := inputOptions{format: params.inputFormat, encoding: params.inputEncoding, plugin: plugin, archives: params.archives, lines: params.lines}
+		if len(params.fileMappings) > 0 {
+			var files [][]string
+			if params.destinations, files, err = mapDestinations(params.fileNames, params.fileMappings, now); err == nil {
+				mapped = make([][]types.InputLogEvent, len(files))
+				for i := range files {
+					if mapped[i], err = readLogEventsFiles(files[i], input, timestamps, params.merge, now); err != nil {
+						break
+					}
+				}
+				events = joinLogEvents(mapped)
+			}
+		} else {
+			events, err = readLogEventsFiles(params.fileNames, input, timestamps, params.merge, now)
+		}
+		plugin.close()
+		if err != nil {
+			endSpan(parseSpan, err)
+			return err
+		}
+	}
+	if params.winEventLog != nil {
+		if events, err = params.winEventLog.read(); err != nil {
+			endSpan(parseSpan, err)
 			return err
 		}
 	}
+	parseSpan.SetAttributes(attribute.Int("awsputlogs.events", len(events)))
+	endSpan(parseSpan, nil)
 
-	if len(params.logs) == 0 {
+	if params.stdin && len(events) > 0 {
+		return errors.New("argument error: --stdin can not be used with logs in args or --logs-file")
+	}
+	if params.lambdaExtension && len(events) > 0 {
+		return errors.New("argument error: --lambda-extension can not be used with logs in args or --logs-file")
+	}
+	if !params.streams() && len(events) == 0 {
 		return errors.New("no logs error: logs are required. you must set the log to args or use --events-file parameters")
 	}
 
-	cfg, err := loadConfig(params)
+	var deadLetters *deadLetterFile
+	if params.deadLetterFile != "" {
+		deadLetters, err = openDeadLetterFile(params.deadLetterFile)
+		if err != nil {
+			return err
+		}
+	}
+	validator, err := newSchemaValidator(params.schemaFile, params.onSchemaError, deadLetters)
+	if err != nil {
+		return err
+	}
+	e, err := newEnricher(params.enrich, params.traceHeaderEnv)
+	if err != nil {
+		return err
+	}
+	script, err := newScriptTransform(params.scriptFile)
 	if err != nil {
 		return err
 	}
 
-	client := cloudwatchlogs.NewFromConfig(cfg)
+	s := newSampler(params.sampleRate, params.sampleKey, rand.New(rand.NewSource(now.UnixNano())))
+	transform := func(events []types.InputLogEvent) ([]types.InputLogEvent, error) {
+		events = filterTimeRange(events, params.since, params.until)
+		events, err := validator.filter(anonymizeLogEvents(wrapLogEvents(events, params.wrapKey), params.anonymizer))
+		if err != nil {
+			return nil, err
+		}
+		if events, err = scriptLogEvents(transformLogEvents(events, params.fields), script); err != nil {
+			return nil, err
+		}
+		return formatEMFLogEvents(enrichLogEvents(dedupeLogEvents(sampleLogEvents(events, s), params.dedupeWindow), e), params.emf), nil
+	}
 
-	if params.logStream == "" {
-		params.logStream, err = getLatestLogStream(client, params.logGroup)
+	_, transformSpan := tracer().Start(ctx, "transform", trace.WithAttributes(attribute.Int("awsputlogs.events.in", len(events))))
+	// Log events of each destination are transformed separately, so that they are not deduplicated across destinations
+	for i := range mapped {
+		if mapped[i], err = transform(mapped[i]); err != nil {
+			endSpan(transformSpan, err)
+			return err
+		}
+	}
+	if mapped != nil {
+		events = joinLogEvents(mapped)
+	} else if events, err = transform(events); err != nil {
+		endSpan(transformSpan, err)
+		return err
+	}
+	transformSpan.SetAttributes(attribute.Int("awsputlogs.events.out", len(events)))
+	endSpan(transformSpan, nil)
+	if len(params.destinations) == 0 && len(params.routes) == 0 {
+		client, err := newClient(params, destination{})
 		if err != nil {
 			return err
 		}
+		dst, err := pickDestination(client, bufio.NewReader(os.Stdin), os.Stdout)
+		if err != nil {
+			return err
+		}
+		params.destinations = []destination{dst}
+	}
+
+	if params.newStream {
+		name := newStreamName(params.streamTemplate, now, uuid.NewString())
+		for i := range params.destinations {
+			params.destinations[i].logStream = name
+		}
+	}
+	if params.shardField != "" {
+		params.destinations, err = shardDestinations(params.destinations, params.shardCount)
+		if err != nil {
+			return err
+		}
+	}
+	if params.chunkBy != "" {
+		// Log events of each chunk are put in the same way as files mapped to destinations with --map
+		params.destinations, mapped, err = chunkDestinations(params.destinations, events, params.chunkBy)
+		if err != nil {
+			return err
+		}
+	}
+	if len(params.routes) > 0 {
+		// Log events of each route are put in the same way as files mapped to destinations with --map
+		var dropped int
+		params.destinations, mapped, dropped = routeLogEvents(events, params.routes, params.destinations, now)
+		if dropped > 0 {
+			fmt.Fprintf(os.Stdout, "Dropped %d log events matching no routes\n", dropped)
+		}
+		if len(params.destinations) == 0 {
+			return errors.New("route error: no log events match --route")
+		}
+	}
+
+	if !params.yes {
+		protected := params.protectedGroups
+		if params.dryRun {
+			// Nothing is uploaded to protected log groups in the dry run
+			protected = nil
+		}
+		if err := confirmUpload(params.guard, protected, params.destinations, events); err != nil {
+			return err
+		}
+	}
+
+	if params.preflight {
+		clientOf := func(dst destination) (*cloudwatchlogs.Client, error) { return newClient(params, dst) }
+		createsStreams := params.newStream || params.shardField != "" || mapped != nil
+		if err := preflightPermissions(ctx, clientOf, params.destinations, createsStreams); err != nil {
+			return err
+		}
+	}
+
+	if params.dryRun {
+		ingestions := make([]ingestion, len(params.destinations))
+		shards := shardLogEvents(events, params.shardField, params.shardCount)
+		for i, dst := range params.destinations {
+			client, err := newClient(params, dst)
+			if err != nil {
+				return err
+			}
+			dstEvents := events
+			if params.shardField != "" {
+				dstEvents = shards[i%params.shardCount]
+			}
+			if mapped != nil {
+				dstEvents = mapped[i]
+			}
+			ingestions[i] = ingestion{dst: dst, region: client.Options().Region}
+			ingestions[i].add(dstEvents)
+			ingestions[i].batches = planBatches(dstEvents, params.batchLimits)
+		}
+		return writeUploadSummary(os.Stdout, params.output, true, ingestions, params.pricePerGB, params.lines, "")
+	}
+
+	var metrics *shipperMetrics
+	if params.metricsAddr != "" || params.tui {
+		metrics = &shipperMetrics{}
+	}
+	if params.metricsAddr != "" {
+		server, err := serveMetrics(params.metricsAddr, metrics)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+	}
+	var dash *dashboard
+	if params.tui {
+		if !isTerminal(os.Stderr) {
+			return errors.New("argument error: --tui requires stderr to be the terminal")
+		}
+		dash = newDashboard(os.Stderr, metrics, now)
 	}
 
-	return putLogEvents(client, params.logGroup, params.logStream, params.logs)
+	var audit *auditLog
+	if params.auditFile != "" {
+		audit, err = openAuditLog(params.auditFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Each destination is uploaded independently, so a failure for one of them does not stop the others
+	clients := map[string]*cloudwatchlogs.Client{}
+	streams := newLogStreamCache()
+	uploaders := []*uploader{}
+	errs := []string{}
+	for i, dst := range params.destinations {
+		key := dst.region + " " + dst.roleARN
+		client, ok := clients[key]
+		if !ok {
+			client, err = newClient(params, dst)
+			if err != nil {
+				return err
+			}
+			clients[key] = client
+		}
+
+		if params.newStream {
+			// The log stream of the run must be new, so an existing one is an error
+			if err := createLogStream(client, dst.logGroup, dst.logStream); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", dst, err))
+				continue
+			}
+			streams.created(client.Options().Region, dst)
+		} else if params.shardField != "" || (mapped != nil && dst.logStream != "") {
+			created, err := ensureLogStream(client, dst.logGroup, dst.logStream)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", dst, err))
+				continue
+			}
+			if created {
+				streams.created(client.Options().Region, dst)
+			}
+		}
+		u, err := newUploader(client, dst, params.logStreamPrefix, params.batchLimits, streams)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", dst, err))
+			continue
+		}
+		u.shard = i % params.shardCount
+		u.mapping = i
+		u.limiter = newUploadLimiter(params.rateLimits)
+		u.metrics = metrics
+		if dash != nil {
+			u.dashboard = dash.addRow(u.dst)
+		}
+		u.audit = audit
+		u.retry = params.retry
+		u.batchTimeout = params.batchTimeout
+		u.withoutToken = params.noSequenceToken
+		u.deadLetters = deadLetters
+		u.circuit = newCircuitBreaker(params.circuitFailures, params.circuitCooldown)
+		if !params.streams() && params.output == outputTable {
+			u.progress = newBatchProgress(u.dst)
+		}
+		audit.setPrincipal(params, dst)
+		if params.spoolDir != "" {
+			u.spool, err = newSpool(params.spoolDir, dst, params.spoolMaxBytes)
+			if err != nil {
+				return err
+			}
+		}
+		uploaders = append(uploaders, u)
+	}
+
+	put := func(ctx context.Context, events []types.InputLogEvent) error {
+		active := 0
+		for _, u := range uploaders {
+			if u.err != nil {
+				continue
+			}
+			if u.err = u.upload(ctx, events); u.err == nil && !u.circuit.isOpen() {
+				active++
+			}
+		}
+		metrics.setHealthy(active > 0)
+		if active == 0 {
+			return errNoActiveDestinations
+		}
+		return nil
+	}
+
+	if params.streams() {
+		// Stop reading log events on SIGINT or SIGTERM, and upload buffered log events before exiting
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		opts := streamOptions{
+			limits:         params.batchLimits,
+			flushInterval:  params.flushInterval,
+			drainTimeout:   params.drainTimeout,
+			maxBufferBytes: params.maxBufferBytes,
+			onBackpressure: params.onBackpressure,
+			dedupeWindow:   params.dedupeWindow,
+			sampler:        s,
+			schema:         validator,
+			enricher:       e,
+			emf:            params.emf,
+			timestamps:     timestamps,
+			lines:          params.lines,
+			wrapKey:        params.wrapKey,
+			fields:         params.fields,
+			anonymizer:     params.anonymizer,
+			script:         script,
+			metrics:        metrics,
+		}
+		// The dashboard is not stopped by SIGINT, so that it shows buffered log events being drained
+		stopDashboard := dash.start(context.Background())
+		if params.lambdaExtension {
+			var x *lambdaExtension
+			if x, err = newLambdaExtension(); err == nil {
+				// Function logs are flushed after each invocation, before the sandbox is frozen
+				opts.flushes = make(chan chan error)
+				x.flush = requestFlush(opts.flushes)
+				err = streamEvents(ctx, x.run, opts, put)
+			}
+		} else if params.localSource != nil {
+			if err = params.localSource.listen(); err == nil {
+				defer params.localSource.close()
+				err = streamEvents(ctx, params.localSource.run, opts, put)
+			}
+		} else {
+			err = streamLogEvents(ctx, newDecodingReader(os.Stdin, params.inputEncoding), opts, put)
+		}
+		// The final counters are drawn before the summary
+		stopDashboard()
+	} else if params.replay {
+		// Stop replaying on SIGINT or SIGTERM
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = replayLogEvents(ctx, events, params.speed, put)
+	} else if params.shardField != "" {
+		putSharded(ctx, uploaders, events, params.shardField, params.shardCount)
+	} else if mapped != nil {
+		putMapped(ctx, uploaders, mapped)
+	} else if params.resumeFromStream {
+		putResumed(ctx, uploaders, events)
+	} else if params.skipExisting {
+		putSkippingExisting(ctx, uploaders, events)
+	} else if params.idempotent {
+		err = putIdempotent(ctx, params, uploaders, events)
+	} else {
+		err = put(ctx, events)
+	}
+	if err != nil && !errors.Is(err, errNoActiveDestinations) {
+		return err
+	}
+
+	ingestions := make([]ingestion, len(uploaders))
+	for i, u := range uploaders {
+		u.progress.clear()
+		ingestions[i] = u.ingested
+		ingestions[i].status = statusOf(u)
+		ingestions[i].rejected = u.rejected
+		ingestions[i].failed = u.failed
+		ingestions[i].ambiguous = u.ambiguous
+	}
+	if err := writeUploadSummary(os.Stdout, params.output, false, ingestions, params.pricePerGB, params.lines, params.retry.delivery); err != nil {
+		return err
+	}
+	if params.verbose {
+		// The JSON summary is kept parsable
+		w := io.Writer(os.Stdout)
+		if params.output == outputJSON {
+			w = os.Stderr
+		}
+		apiCalls.write(w)
+	}
+	waitErrs := []string{}
+	if params.wait {
+		waitCtx, cancel := context.WithTimeout(ctx, params.waitTimeout)
+		for _, u := range uploaders {
+			if err := waitForVisibility(waitCtx, u, waitPollInterval); err != nil {
+				waitErrs = append(waitErrs, err.Error())
+			}
+		}
+		cancel()
+	}
+	for _, u := range uploaders {
+		audit.recordRun(u)
+	}
+	auditErr := audit.close()
+	deadLetterErr := deadLetters.close()
+	if params.emitMetrics {
+		if err := emitRunMetrics(params, uploaders); err != nil {
+			fmt.Fprintf(os.Stderr, "metrics error: failed to publish metrics to CloudWatch: %v\n", err)
+		}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		failed := 0
+		for _, u := range uploaders {
+			failed += u.failed
+		}
+		switch {
+		case deadLetterErr != nil:
+			return fmt.Errorf("%w %s. %v", errDeadlineExceeded, params.deadline, deadLetterErr)
+		case params.deadLetterFile != "":
+			return fmt.Errorf("%w %s. %d log events which were not put are written to %s", errDeadlineExceeded, params.deadline, failed, params.deadLetterFile)
+		default:
+			return fmt.Errorf("%w %s. %d log events were not put", errDeadlineExceeded, params.deadline, failed)
+		}
+	}
+
+	for _, u := range uploaders {
+		if u.err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", u.dst, u.err))
+			continue
+		}
+		if u.spool != nil {
+			if files, err := u.spool.files(); err == nil && len(files) > 0 {
+				errs = append(errs, fmt.Sprintf("%s: %d batches of logs are left in %s. they are uploaded next time", u.dst, len(files), u.spool.dir))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("upload error: failed to put logs to %d of %d destinations\n%s", len(errs), len(params.destinations), strings.Join(errs, "\n"))
+	}
+	if len(waitErrs) > 0 {
+		return errors.New(strings.Join(waitErrs, "\n"))
+	}
+	if deadLetterErr != nil {
+		return deadLetterErr
+	}
+
+	return auditErr
 }
 
 func main() {
 	if err := exec(); err != nil {
-		fmt.Println(err)
+		fmt.Println(formatError(colors, err))
+		if errors.Is(err, errDeadlineExceeded) {
+			os.Exit(exitDeadlineExceeded)
+		}
 	}
 }
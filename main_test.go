@@ -5,25 +5,60 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
 )
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+// defaultOption returns the parameters parsed from the arguments without options.
+func defaultOption() parameters {
+	return parameters{
+		batchLimits:      defaultBatchLimits,
+		flushInterval:    5 * time.Second,
+		drainTimeout:     10 * time.Second,
+		inputFormat:      inputFormatAuto,
+		inputEncoding:    inputEncodingUTF8,
+		output:           outputTable,
+		protectedGroups:  regexp.MustCompile(defaultProtectedGroups),
+		timestampUnit:    timestampUnitAuto,
+		maxBufferBytes:   defaultMaxBufferBytes,
+		onBackpressure:   backpressureBlock,
+		sampleRate:       1,
+		speed:            1,
+		shardCount:       8,
+		spoolMaxBytes:    defaultSpoolMaxBytes,
+		circuitCooldown:  30 * time.Second,
+		waitTimeout:      5 * time.Minute,
+		onSchemaError:    schemaErrorFail,
+		metricsNamespace: defaultMetricsNamespace,
+		traceHeaderEnv:   defaultTraceHeaderEnv,
+		retry:            retryPolicy{mode: retryModeStandard, batchAttempts: map[string]int{}, delivery: deliveryAtLeastOnce},
+		logs:             []string{},
+	}
+}
+
 func Test_parseOption(t *testing.T) {
+	// The destination is not chosen interactively while testing
+	isInteractive = func() bool { return false }
+
 	tests := []struct {
-		name    string
-		args    []string
-		want    parameters
+		name string
+		args []string
+		// want overrides the fields of defaultOption() set by the arguments
+		want    func(p *parameters)
 		wantErr bool
 	}{
 		{
@@ -36,13 +71,13 @@ func Test_parseOption(t *testing.T) {
 				"--endpoint-url", "http://localhost:4566/",
 				"--logs-file", "logs.json",
 			},
-			want: parameters{
-				endpointURL: "http://localhost:4566/",
-				fileName:    "logs.json",
-				logGroup:    "/test/group",
-				logs:        []string{},
-				logStream:   "test-stream",
-				region:      "us-east-1",
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group", logStream: "test-stream"},
+				}
+				p.endpointURL = "http://localhost:4566/"
+				p.fileNames = []string{"logs.json"}
+				p.region = "us-east-1"
 			},
 			wantErr: false,
 		},
@@ -57,15 +92,16 @@ func Test_parseOption(t *testing.T) {
 				"[INFO] Start Server",
 				"[ERROR] Failed to Start Server",
 			},
-			want: parameters{
-				endpointURL: "http://localhost:4566/",
-				logGroup:    "/test/group",
-				logs: []string{
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group", logStream: "test-stream"},
+				}
+				p.endpointURL = "http://localhost:4566/"
+				p.logs = []string{
 					"[INFO] Start Server",
 					"[ERROR] Failed to Start Server",
-				},
-				logStream: "test-stream",
-				region:    "us-east-1",
+				}
+				p.region = "us-east-1"
 			},
 			wantErr: false,
 		},
@@ -75,9 +111,155 @@ func Test_parseOption(t *testing.T) {
 				"awsputlogs",
 				"--log-group", "/test/group",
 			},
-			want: parameters{
-				logGroup: "/test/group",
-				logs:     []string{},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set several destinations",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--log-stream", "test-stream-1",
+				"--log-stream", "test-stream-2",
+				"--log-group", "/test/audit",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group", logStream: "test-stream-1"},
+					{logGroup: "/test/group", logStream: "test-stream-2"},
+					{logGroup: "/test/audit"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set log stream before log group",
+			args: []string{
+				"awsputlogs",
+				"--log-stream", "test-stream",
+				"--log-group", "/test/group",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group", logStream: "test-stream"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set several log streams to a log group",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--log-stream", "test-stream-1",
+				"--log-stream", "test-stream-2",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group", logStream: "test-stream-1"},
+					{logGroup: "/test/group", logStream: "test-stream-2"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set destinations in other regions and accounts",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--dest", "arn:aws:logs:eu-west-1:123456789012:log-group:/central",
+				"--role-arn", "arn:aws:iam::123456789012:role/central-logging",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group"},
+					{
+						logGroup: "/central",
+						region:   "eu-west-1",
+						account:  "123456789012",
+						roleARN:  "arn:aws:iam::123456789012:role/central-logging",
+					},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set ARN of log group",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "arn:aws:logs:eu-west-1:123456789012:log-group:/test/group:*",
+				"--log-stream", "test-stream",
+				"--region", "eu-west-1",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{
+						logGroup:  "/test/group",
+						logStream: "test-stream",
+						region:    "eu-west-1",
+						account:   "123456789012",
+					},
+				}
+				p.region = "eu-west-1"
+			},
+			wantErr: false,
+		},
+		{
+			name: "Set ARN of log group conflicting with region",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "arn:aws:logs:eu-west-1:123456789012:log-group:/test/group",
+				"--region", "us-east-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Set --since without timestamps of log events",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--logs-file", "logs.json",
+				"--since", "24h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Set --new-stream with log stream",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--log-stream", "test-stream",
+				"--new-stream",
+				"[INFO] Start Server",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Set invalid --protected-groups",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/prod/api",
+				"--protected-groups", "prod(",
+				"[INFO] Start Server",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Set prefix of log stream",
+			args: []string{
+				"awsputlogs",
+				"--log-group", "/test/group",
+				"--log-stream-prefix", "api-",
+			},
+			want: func(p *parameters) {
+				p.destinations = []destination{
+					{logGroup: "/test/group"},
+				}
+				p.logStreamPrefix = "api-"
 			},
 			wantErr: false,
 		},
@@ -86,7 +268,6 @@ func Test_parseOption(t *testing.T) {
 			args: []string{
 				"awsputlogs",
 			},
-			want:    parameters{},
 			wantErr: true,
 		},
 	}
@@ -97,8 +278,13 @@ func Test_parseOption(t *testing.T) {
 				t.Errorf("parseOption() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("parseOption() = %v, want %v", got, tt.want)
+			want := parameters{}
+			if tt.want != nil {
+				want = defaultOption()
+				tt.want(&want)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parseOption() = %v, want %v", got, want)
 			}
 		})
 	}
@@ -208,6 +394,222 @@ func Test_parseLogEvents(t *testing.T) {
 	}
 }
 
+// hostRecorder records the host of the request instead of sending it.
+type hostRecorder struct {
+	host string
+}
+
+func (r *hostRecorder) Do(req *http.Request) (*http.Response, error) {
+	r.host = req.URL.Host
+	return nil, errors.New("request is not sent")
+}
+
+func Test_newClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   parameters
+		env      map[string]string
+		wantHost string
+		wantErr  bool
+	}{
+		{
+			name:     "Use default endpoint",
+			params:   parameters{region: "us-east-1"},
+			wantHost: "logs.us-east-1.amazonaws.com",
+		},
+		{
+			name:     "Use endpoint URL",
+			params:   parameters{region: "us-east-1", endpointURL: "http://localhost:4566"},
+			wantHost: "localhost:4566",
+		},
+		{
+			name:     "Use AWS_ENDPOINT_URL",
+			params:   parameters{region: "us-east-1"},
+			env:      map[string]string{"AWS_ENDPOINT_URL": "http://localhost:4566"},
+			wantHost: "localhost:4566",
+		},
+		{
+			name:   "Use AWS_ENDPOINT_URL_CLOUDWATCH_LOGS",
+			params: parameters{region: "us-east-1"},
+			env: map[string]string{
+				"AWS_ENDPOINT_URL":                 "http://localhost:4566",
+				"AWS_ENDPOINT_URL_CLOUDWATCH_LOGS": "http://localhost:4567",
+			},
+			wantHost: "localhost:4567",
+		},
+		{
+			name:   "Use AWS_ENDPOINT_URL_LOGS",
+			params: parameters{region: "us-east-1"},
+			env: map[string]string{
+				"AWS_ENDPOINT_URL":      "http://localhost:4566",
+				"AWS_ENDPOINT_URL_LOGS": "http://localhost:4567",
+			},
+			wantHost: "localhost:4567",
+		},
+		{
+			name:     "Override environment variables by endpoint URL",
+			params:   parameters{region: "us-east-1", endpointURL: "http://localhost:4568"},
+			env:      map[string]string{"AWS_ENDPOINT_URL_CLOUDWATCH_LOGS": "http://localhost:4567"},
+			wantHost: "localhost:4568",
+		},
+		{
+			name:     "Use FIPS endpoint",
+			params:   parameters{region: "us-east-1", useFIPSEndpoint: true},
+			wantHost: "logs-fips.us-east-1.amazonaws.com",
+		},
+		{
+			name:     "Use dual-stack endpoint",
+			params:   parameters{region: "us-east-1", useDualStackEndpoint: true},
+			wantHost: "logs.us-east-1.api.aws",
+		},
+		{
+			name:    "Use FIPS endpoint with endpoint URL",
+			params:  parameters{region: "us-east-1", endpointURL: "http://localhost:4566", useFIPSEndpoint: true},
+			wantErr: true,
+		},
+		{
+			name:    "Use dual-stack endpoint with endpoint URL",
+			params:  parameters{region: "us-east-1", endpointURL: "http://localhost:4566", useDualStackEndpoint: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_CLOUDWATCH_LOGS", "AWS_ENDPOINT_URL_LOGS"} {
+				t.Setenv(key, tt.env[key])
+				if _, ok := tt.env[key]; !ok {
+					os.Unsetenv(key)
+				}
+			}
+
+			client, err := newClient(tt.params, destination{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newClient() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			recorder := &hostRecorder{}
+			client.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{}, func(o *cloudwatchlogs.Options) {
+				o.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+				})
+				o.HTTPClient = recorder
+				o.RetryMaxAttempts = 1
+			})
+			if recorder.host != tt.wantHost {
+				t.Errorf("newClient() endpoint = %v, want %v", recorder.host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func Test_getLatestLogStream(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	// More log streams than a page of DescribeLogStreams
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 60)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	for i, name := range []string{logStreams[3], logStreams[57]} {
+		// Log events are later than the creation of all log streams
+		timestamp := time.Now().Add(time.Duration(i+1) * time.Minute)
+		in := &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(logGroup),
+			LogStreamName: aws.String(name),
+			LogEvents:     []types.InputLogEvent{{Message: aws.String("log"), Timestamp: aws.Int64(toMillis(timestamp))}},
+		}
+		if _, err := cli.PutLogEvents(context.Background(), in); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+	}
+	emptyGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		logGroup string
+		prefix   string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Get the latest log stream", logGroup: logGroup, want: logStreams[57]},
+		{name: "Get the latest log stream with prefix", logGroup: logGroup, prefix: "log-stream-3", want: logStreams[3]},
+		{name: "Get the latest log stream of several with prefix", logGroup: logGroup, prefix: "log-stream-5", want: logStreams[57]},
+		{name: "Get no log stream", logGroup: emptyGroup, wantErr: true},
+		{name: "Get no log stream with prefix", logGroup: logGroup, prefix: "worker", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getLatestLogStream(cli, tt.logGroup, tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getLatestLogStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getLatestLogStream() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_describeLogStream(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	// api-2 is the prefix of more log streams than a page of DescribeLogStreams
+	names := []string{"api", "api-2"}
+	for i := 0; i < 60; i++ {
+		names = append(names, fmt.Sprintf("api-2%02d", i))
+	}
+	for _, name := range names {
+		if err := createLogStream(cli, logGroup, name); err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		logStream string
+		want      string
+	}{
+		{name: "Describe log stream sharing the prefix", logStream: "api", want: "api"},
+		{name: "Describe log stream sharing the prefix with many log streams", logStream: "api-2", want: "api-2"},
+		{name: "Describe missing log stream sharing the prefix", logStream: "api-25"},
+		{name: "Describe missing log stream", logStream: "worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := describeLogStream(context.Background(), cli, logGroup, tt.logStream)
+			if err != nil {
+				t.Fatalf("describeLogStream() error = %v", err)
+			}
+			name := ""
+			if got != nil {
+				name = aws.ToString(got.LogStreamName)
+			}
+			if name != tt.want {
+				t.Errorf("describeLogStream() = %v, want %v", name, tt.want)
+			}
+		})
+	}
+}
+
 func setUpClient(endpointURL, region string) (*cloudwatchlogs.Client, error) {
 	cfg, err := loadConfig(parameters{
 		endpointURL: endpointURL,
@@ -219,11 +621,6 @@ func setUpClient(endpointURL, region string) (*cloudwatchlogs.Client, error) {
 	return cloudwatchlogs.NewFromConfig(cfg), nil
 }
 
-func isEnabledEndpoint(cli *cloudwatchlogs.Client) bool {
-	_, err := cli.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{})
-	return err == nil
-}
-
 func setUpLogGroup(cli *cloudwatchlogs.Client) (string, error) {
 	for i := 0; i < 10; i++ {
 		logGroupName := fmt.Sprintf("log-group-%X", rand.Int())
@@ -243,25 +640,13 @@ func setUpLogGroup(cli *cloudwatchlogs.Client) (string, error) {
 	return "", errors.New("error: can not create log group(log-group-<RANDOM STRING>). please try again")
 }
 
-func deleteLogGroup(cli *cloudwatchlogs.Client, logGroup string) error {
-	in := &cloudwatchlogs.DeleteLogGroupInput{
-		LogGroupName: aws.String(logGroup),
-	}
-	_, err := cli.DeleteLogGroup(context.Background(), in)
-	return err
-}
-
 func setUpLogStreams(cli *cloudwatchlogs.Client, logGroup string, n int) ([]string, error) {
 	logStreams := make([]string, n)
 	for i := range logStreams {
 		logStreams[i] = fmt.Sprintf("log-stream-%d", i)
 	}
 	for _, name := range logStreams {
-		in := &cloudwatchlogs.CreateLogStreamInput{
-			LogGroupName:  aws.String(logGroup),
-			LogStreamName: aws.String(name),
-		}
-		if _, err := cli.CreateLogStream(context.Background(), in); err != nil {
+		if err := createLogStream(cli, logGroup, name); err != nil {
 			return nil, err
 		}
 	}
@@ -299,17 +684,15 @@ func checkLogs(cli *cloudwatchlogs.Client, logGroup, logStream string, logs []st
 }
 
 func Test_exec(t *testing.T) {
-	localStackEndpointURL := "http://localhost:4566/"
-	localStackRegion := "us-east-1"
-	cli, err := setUpClient(localStackEndpointURL, localStackRegion)
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	endpointURL := server.URL
+	region := "us-east-1"
+	cli, err := setUpClient(endpointURL, region)
 	if err != nil {
 		t.Fatalf("failed to set up: %v", err)
 	}
 
-	if !isEnabledEndpoint(cli) {
-		t.Fatal("failed to set up: could not find the localstack's endpoint")
-	}
-
 	t.Run("Put string logs", func(t *testing.T) {
 		logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 3)
 		if err != nil {
@@ -330,8 +713,8 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 		}
 		os.Args = append(os.Args, logs...)
 
@@ -367,8 +750,8 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 			"--logs-file", "testdata/json-log-events.json",
 		}
 
@@ -407,8 +790,8 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 			"--logs-file", "testdata/string-and-json-log-events.json",
 		}
 
@@ -431,6 +814,50 @@ func Test_exec(t *testing.T) {
 		}
 	})
 
+	t.Run("Put logs to several destinations", func(t *testing.T) {
+		logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 3)
+		if err != nil {
+			t.Errorf("failed to set up: %v", err)
+			return
+		}
+		defer func() {
+			if err := deleteLogGroup(cli, logGroup); err != nil {
+				t.Errorf("failed to clean up: %v", err)
+			}
+		}()
+
+		logs := []string{
+			"[INFO] Start Server",
+			"[ERROR] Failed to Start Server",
+		}
+		os.Args = []string{
+			"awsputlogs",
+			"--log-group", logGroup,
+			"--log-stream", logStreams[0],
+			"--log-stream", logStreams[1],
+			"--region", region,
+			"--endpoint-url", endpointURL,
+		}
+		os.Args = append(os.Args, logs...)
+
+		if err := exec(); err != nil {
+			t.Errorf("exec() error = %v, wantErr %v", err, false)
+			return
+		}
+
+		for _, logStream := range logStreams[:2] {
+			ok, err := checkLogs(cli, logGroup, logStream, logs)
+			if err != nil {
+				t.Errorf("failed to check result: %v", err)
+				return
+			}
+			if !ok {
+				t.Errorf("failed to put logs. could not find logs in %s", logStream)
+				return
+			}
+		}
+	})
+
 	t.Run("Put logs to unspecified log stream", func(t *testing.T) {
 		logGroup, _, err := setUpLogGroupAndStreams(cli, 3)
 		if err != nil {
@@ -450,8 +877,8 @@ func Test_exec(t *testing.T) {
 		os.Args = []string{
 			"awsputlogs",
 			"--log-group", logGroup,
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 		}
 		os.Args = append(os.Args, logs...)
 
@@ -471,6 +898,47 @@ func Test_exec(t *testing.T) {
 		}
 	})
 
+	t.Run("Put logs to latest log stream matching prefix", func(t *testing.T) {
+		logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 3)
+		if err != nil {
+			t.Errorf("failed to set up: %v", err)
+			return
+		}
+		defer func() {
+			if err := deleteLogGroup(cli, logGroup); err != nil {
+				t.Errorf("failed to clean up: %v", err)
+			}
+		}()
+
+		logs := []string{
+			"[INFO] Start Server",
+			"[ERROR] Failed to Start Server",
+		}
+		os.Args = []string{
+			"awsputlogs",
+			"--log-group", logGroup,
+			"--log-stream-prefix", logStreams[1],
+			"--region", region,
+			"--endpoint-url", endpointURL,
+		}
+		os.Args = append(os.Args, logs...)
+
+		if err := exec(); err != nil {
+			t.Errorf("exec() error = %v, wantErr %v", err, false)
+			return
+		}
+
+		ok, err := checkLogs(cli, logGroup, logStreams[1], logs)
+		if err != nil {
+			t.Errorf("failed to check result: %v", err)
+			return
+		}
+		if !ok {
+			t.Error("failed to put logs. could not find logs in CloudWatch Logs")
+			return
+		}
+	})
+
 	t.Run("Invalid log group", func(t *testing.T) {
 		logs := []string{
 			"[INFO] Start Server",
@@ -479,8 +947,8 @@ func Test_exec(t *testing.T) {
 		os.Args = []string{
 			"awsputlogs",
 			"--log-group", fmt.Sprintf("uncreated-log-group-%v", rand.Int()),
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 		}
 		os.Args = append(os.Args, logs...)
 
@@ -510,8 +978,8 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", fmt.Sprintf("uncreated-log-stream-%v", rand.Int()),
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 		}
 		os.Args = append(os.Args, logs...)
 
@@ -540,8 +1008,8 @@ func Test_exec(t *testing.T) {
 		os.Args = []string{
 			"awsputlogs",
 			"--log-group", logGroup,
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 		}
 		os.Args = append(os.Args, logs...)
 
@@ -567,8 +1035,8 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 			"--logs-file", "testdata/no-file.json",
 		}
 
@@ -594,9 +1062,11 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
-			"--endpoint-url", localStackEndpointURL,
+			"--region", region,
+			"--endpoint-url", endpointURL,
 			"--logs-file", "testdata/invalid-file.json",
+			// Files which are not JSON arrays are read as text by --input-format auto
+			"--input-format", "json",
 		}
 
 		if err := exec(); err == nil {
@@ -606,8 +1076,8 @@ func Test_exec(t *testing.T) {
 	})
 
 	t.Run("Invalid region", func(t *testing.T) {
-		// The localstack does not check if specified a region is valid.
-		// It can not check this test case with the localstack.
+		// The fake CloudWatch Logs does not check if specified a region is valid.
+		// It can not check this test case with the fake CloudWatch Logs.
 		// So it always passes this test case.
 	})
 
@@ -631,7 +1101,7 @@ func Test_exec(t *testing.T) {
 			"awsputlogs",
 			"--log-group", logGroup,
 			"--log-stream", logStreams[0],
-			"--region", localStackRegion,
+			"--region", region,
 			"--endpoint-url", "https://localhost",
 		}
 		os.Args = append(os.Args, logs...)
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// eventKeys returns the content hash of each log event. Identical log events get different hashes by their occurrences.
+// The timestamp is a part of the content only if withTimestamp is true, because it is the current time without --timestamp-field.
+func eventKeys(events []types.InputLogEvent, withTimestamp bool) []string {
+	occurrences := map[string]int{}
+	keys := make([]string, len(events))
+	for i, event := range events {
+		content := aws.ToString(event.Message)
+		if withTimestamp {
+			content = strconv.FormatInt(aws.ToInt64(event.Timestamp), 10) + "\x00" + content
+		}
+		occurrences[content]++
+		sum := sha256.Sum256([]byte(content + "\x00" + strconv.Itoa(occurrences[content])))
+		keys[i] = hex.EncodeToString(sum[:])
+	}
+	return keys
+}
+
+// defaultManifestDir returns the directory of manifests in the user cache directory.
+func defaultManifestDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "awsputlogs", "manifests"), nil
+}
+
+// manifest records the hashes of log events uploaded to a log stream, so that re-runs skip them.
+type manifest struct {
+	path     string
+	uploaded map[string]bool
+}
+
+// openManifest loads the manifest of the log stream in dir.
+func openManifest(dir string, dst destination) (*manifest, error) {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %s %s:%s", dst.region, dst.account, dst.logGroup, dst.logStream)))
+	m := &manifest{
+		path:     filepath.Join(dir, hex.EncodeToString(sum[:8])),
+		uploaded: map[string]bool{},
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(m.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.uploaded[scanner.Text()] = true
+	}
+	return m, scanner.Err()
+}
+
+// filter returns log events and their hashes which are not uploaded yet.
+func (m *manifest) filter(events []types.InputLogEvent, keys []string) ([]types.InputLogEvent, []string) {
+	pendingEvents, pendingKeys := []types.InputLogEvent{}, []string{}
+	for i, key := range keys {
+		if !m.uploaded[key] {
+			pendingEvents = append(pendingEvents, events[i])
+			pendingKeys = append(pendingKeys, key)
+		}
+	}
+	return pendingEvents, pendingKeys
+}
+
+// record appends the hashes of uploaded log events to the manifest.
+func (m *manifest) record(keys []string) error {
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, key := range keys {
+		m.uploaded[key] = true
+		fmt.Fprintln(w, key)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// putIdempotent puts log events which are not recorded in the manifest, and records them batch by batch.
+// It returns the number of skipped log events.
+func (u *uploader) putIdempotent(ctx context.Context, m *manifest, events []types.InputLogEvent, keys []string) (int, error) {
+	pending, pendingKeys := m.filter(events, keys)
+	offset := 0
+	for _, batch := range splitBatches(pending, u.limits) {
+		if err := u.put(ctx, batch); err != nil {
+			return len(events) - len(pending), err
+		}
+		if err := m.record(pendingKeys[offset : offset+len(batch)]); err != nil {
+			return len(events) - len(pending), err
+		}
+		offset += len(batch)
+	}
+	return len(events) - len(pending), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_eventKeys(t *testing.T) {
+	events := []types.InputLogEvent{
+		{Message: aws.String("[INFO] Retry"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("[INFO] Retry"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("[INFO] Retry"), Timestamp: aws.Int64(2000)},
+	}
+
+	keys := eventKeys(events, true)
+	if keys[0] == keys[1] || keys[0] == keys[2] || keys[1] == keys[2] {
+		t.Errorf("eventKeys() = %v, want different keys for each log event", keys)
+	}
+	if again := eventKeys(events, true); !reflect.DeepEqual(again, keys) {
+		t.Errorf("eventKeys() = %v, want the same keys as %v", again, keys)
+	}
+
+	// Without the timestamp, the third log event is the third occurrence of the message
+	withoutTimestamp := eventKeys(events, false)
+	if withoutTimestamp[2] == keys[2] || withoutTimestamp[0] == withoutTimestamp[2] {
+		t.Errorf("eventKeys() = %v, want keys ignoring timestamps", withoutTimestamp)
+	}
+}
+
+func Test_manifest(t *testing.T) {
+	dir := t.TempDir()
+	dst := destination{logGroup: "/test/group", logStream: "test-stream"}
+	events := newTestEvents(3, "[INFO] Start Server", time.Second)
+	keys := eventKeys(events, true)
+
+	m, err := openManifest(dir, dst)
+	if err != nil {
+		t.Fatalf("openManifest() error = %v", err)
+	}
+	if err := m.record(keys[:2]); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+
+	// The manifest is loaded again on re-run
+	m, err = openManifest(dir, dst)
+	if err != nil {
+		t.Fatalf("openManifest() error = %v", err)
+	}
+	gotEvents, gotKeys := m.filter(events, keys)
+	if !reflect.DeepEqual(gotEvents, events[2:]) || !reflect.DeepEqual(gotKeys, keys[2:]) {
+		t.Errorf("filter() = %v, %v, want %v, %v", gotEvents, gotKeys, events[2:], keys[2:])
+	}
+
+	// Manifests of other log streams are independent
+	other, err := openManifest(dir, destination{logGroup: "/test/group", logStream: "other-stream"})
+	if err != nil {
+		t.Fatalf("openManifest() error = %v", err)
+	}
+	if gotEvents, _ := other.filter(events, keys); len(gotEvents) != len(events) {
+		t.Errorf("filter() returns %d log events, want %d", len(gotEvents), len(events))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The placeholders replaced in the destination of --map.
+const (
+	// datePlaceholder is replaced with the current date in UTC, such as 2024-01-02
+	datePlaceholder = "{date}"
+	// filePlaceholder is replaced with the base name of the file without the extension
+	filePlaceholder = "{file}"
+)
+
+// fileMapping maps files whose paths or base names match the pattern to the destination.
+type fileMapping struct {
+	pattern string
+	dst     destination
+}
+
+// parseFileMapping parses the mapping like PATTERN=>LOG GROUP[:LOG STREAM]. The destination can be the ARN of a log group.
+func parseFileMapping(v string) (fileMapping, error) {
+	parts := strings.SplitN(v, "=>", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return fileMapping{}, fmt.Errorf("argument error: --map must be like 'api-*.log=>/app/api:api-{date}', but got %q", v)
+	}
+	m := fileMapping{pattern: strings.TrimSpace(parts[0])}
+	if _, err := filepath.Match(m.pattern, ""); err != nil {
+		return fileMapping{}, fmt.Errorf("argument error: --map has the invalid pattern %q: %w", m.pattern, err)
+	}
+
+	dst, err := parseTarget(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fileMapping{}, err
+	}
+	if dst.logGroup == "" {
+		return fileMapping{}, fmt.Errorf("argument error: --map must have the log group, but got %q", v)
+	}
+	m.dst = dst
+	return m, nil
+}
+
+// parseTarget parses the destination of --map and --route like LOG GROUP[:LOG STREAM] or the ARN of a log group.
+func parseTarget(target string) (destination, error) {
+	if strings.HasPrefix(target, "arn:") {
+		return parseLogGroupARN(target)
+	}
+	group, stream, _ := strings.Cut(target, ":")
+	return destination{logGroup: group, logStream: stream}, nil
+}
+
+// matchPath reports whether the path or the base name of the file matches the pattern.
+func matchPath(pattern, fileName string) bool {
+	if ok, _ := filepath.Match(pattern, fileName); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(fileName))
+	return ok
+}
+
+// match reports whether the file matches the pattern of the mapping.
+func (m fileMapping) match(fileName string) bool {
+	return matchPath(m.pattern, fileName)
+}
+
+// expand returns the destination whose placeholders are replaced for the file.
+func (m fileMapping) expand(fileName string, now time.Time) destination {
+	base := filepath.Base(fileName)
+	r := strings.NewReplacer(
+		datePlaceholder, now.UTC().Format("2006-01-02"),
+		filePlaceholder, strings.TrimSuffix(base, filepath.Ext(base)),
+	)
+	dst := m.dst
+	dst.logGroup = r.Replace(dst.logGroup)
+	dst.logStream = r.Replace(dst.logStream)
+	return dst
+}
+
+// mapFlag adds a mapping each time --map is given.
+type mapFlag struct {
+	mappings *[]fileMapping
+}
+
+func (f mapFlag) String() string {
+	if f.mappings == nil {
+		return ""
+	}
+	mappings := make([]string, len(*f.mappings))
+	for i, m := range *f.mappings {
+		mappings[i] = m.pattern + "=>" + m.dst.String()
+	}
+	return strings.Join(mappings, ",")
+}
+
+func (f mapFlag) Set(v string) error {
+	m, err := parseFileMapping(v)
+	if err != nil {
+		return err
+	}
+	*f.mappings = append(*f.mappings, m)
+	return nil
+}
+
+// mapDestinations maps each file to the destination of the first mapping it matches.
+// It returns the destinations and the files mapped to each of them in order of the files.
+func mapDestinations(fileNames []string, mappings []fileMapping, now time.Time) ([]destination, [][]string, error) {
+	dsts := []destination{}
+	files := [][]string{}
+	indexes := map[destination]int{}
+	for _, fileName := range fileNames {
+		found := false
+		for _, m := range mappings {
+			if !m.match(fileName) {
+				continue
+			}
+			dst := m.expand(fileName, now)
+			i, ok := indexes[dst]
+			if !ok {
+				i = len(dsts)
+				indexes[dst] = i
+				dsts = append(dsts, dst)
+				files = append(files, nil)
+			}
+			files[i] = append(files[i], fileName)
+			found = true
+			break
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("argument error: %s does not match any --map", fileName)
+		}
+	}
+	return dsts, files, nil
+}
+
+// joinLogEvents returns log events of all destinations.
+func joinLogEvents(mapped [][]types.InputLogEvent) []types.InputLogEvent {
+	events := []types.InputLogEvent{}
+	for _, dstEvents := range mapped {
+		events = append(events, dstEvents...)
+	}
+	return events
+}
+
+// putMapped puts log events of the files mapped to each destination in parallel.
+func putMapped(ctx context.Context, uploaders []*uploader, mapped [][]types.InputLogEvent) {
+	wg := sync.WaitGroup{}
+	for _, u := range uploaders {
+		wg.Add(1)
+		go func(u *uploader) {
+			defer wg.Done()
+			u.err = u.put(ctx, mapped[u.mapping])
+		}(u)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseFileMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		want    fileMapping
+		wantErr bool
+	}{
+		{
+			name: "Parse mapping to log stream",
+			v:    "api-*.log=>/app/api:api-{date}",
+			want: fileMapping{pattern: "api-*.log", dst: destination{logGroup: "/app/api", logStream: "api-{date}"}},
+		},
+		{
+			name: "Parse mapping to log group",
+			v:    "/var/log/nginx/*.log => /app/nginx",
+			want: fileMapping{pattern: "/var/log/nginx/*.log", dst: destination{logGroup: "/app/nginx"}},
+		},
+		{
+			name: "Parse mapping to ARN",
+			v:    "*.log=>arn:aws:logs:eu-west-1:123456789012:log-group:/app/all:log-stream:{file}",
+			want: fileMapping{pattern: "*.log", dst: destination{logGroup: "/app/all", logStream: "{file}", region: "eu-west-1", account: "123456789012"}},
+		},
+		{name: "Parse mapping without destination", v: "api-*.log=>", wantErr: true},
+		{name: "Parse mapping without separator", v: "api-*.log:/app/api", wantErr: true},
+		{name: "Parse mapping with invalid pattern", v: "api-[.log=>/app/api", wantErr: true},
+		{name: "Parse mapping without log group", v: "api-*.log=>:api", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFileMapping(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFileMapping() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFileMapping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mapDestinations(t *testing.T) {
+	now := time.Date(2024, 1, 2, 23, 4, 5, 0, time.FixedZone("JST", 9*60*60))
+	mappings := []fileMapping{
+		{pattern: "api-*.log", dst: destination{logGroup: "/app/api", logStream: "api-{date}"}},
+		{pattern: "/var/log/nginx/*", dst: destination{logGroup: "/app/nginx", logStream: "{file}"}},
+		{pattern: "*", dst: destination{logGroup: "/app/others"}},
+	}
+	tests := []struct {
+		name      string
+		fileNames []string
+		mappings  []fileMapping
+		want      []destination
+		wantFiles [][]string
+		wantErr   bool
+	}{
+		{
+			name:      "Map files to destinations",
+			fileNames: []string{"/var/log/api-1.log", "/var/log/nginx/access.log", "/var/log/syslog", "/var/log/api-2.log", "/var/log/nginx/error.log"},
+			mappings:  mappings,
+			want: []destination{
+				{logGroup: "/app/api", logStream: "api-2024-01-02"},
+				{logGroup: "/app/nginx", logStream: "access"},
+				{logGroup: "/app/others"},
+				{logGroup: "/app/nginx", logStream: "error"},
+			},
+			wantFiles: [][]string{
+				{"/var/log/api-1.log", "/var/log/api-2.log"},
+				{"/var/log/nginx/access.log"},
+				{"/var/log/syslog"},
+				{"/var/log/nginx/error.log"},
+			},
+		},
+		{
+			name:      "Map file matching no mapping",
+			fileNames: []string{"/var/log/api-1.log", "/var/log/syslog"},
+			mappings:  mappings[:1],
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, files, err := mapDestinations(tt.fileNames, tt.mappings, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mapDestinations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapDestinations() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("mapDestinations() files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type metricFilterInfo struct {
+	Name         string    `json:"name"`
+	Pattern      string    `json:"pattern"`
+	MetricName   string    `json:"metricName"`
+	Namespace    string    `json:"namespace"`
+	MetricValue  string    `json:"metricValue"`
+	CreationTime time.Time `json:"creationTime"`
+}
+
+func runMetricFilter(args []string) error {
+	return runActions(args, "Manage metric filters of the log group.", []subcommand{
+		{name: "put", description: "Create or update a metric filter.", run: runMetricFilterPut},
+		{name: "list", description: "List metric filters.", run: runMetricFilterList},
+		{name: "delete", description: "Delete a metric filter.", run: runMetricFilterDelete},
+	})
+}
+
+func runMetricFilterPut(args []string) error {
+	params := parameters{}
+	logGroup, name, pattern := "", "", ""
+	metricName, namespace, metricValue, defaultValue := "", "", "1", ""
+
+	flags := newSubcommandFlagSet(args[0], "Create or update a metric filter.", "--log-group <LOG GROUP NAME> --pattern <FILTER PATTERN> --metric-name <METRIC NAME> --namespace <NAMESPACE> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&name, "name", "", "The name of the metric filter. If you do not use this parameters, it is the same as --metric-name.")
+	flags.StringVar(&pattern, "pattern", "", "The filter pattern to match log events. It is required.")
+	flags.StringVar(&metricName, "metric-name", "", "The name of the metric. It is required.")
+	flags.StringVar(&namespace, "namespace", "", "The namespace of the metric. It is required.")
+	flags.StringVar(&metricValue, "metric-value", "1", "The value published to the metric when a log event matches.")
+	flags.StringVar(&defaultValue, "default-value", "", "The value published to the metric when no log events match.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if pattern == "" {
+		return errors.New("argument error: --pattern is required")
+	}
+	if metricName == "" {
+		return errors.New("argument error: --metric-name is required")
+	}
+	if namespace == "" {
+		return errors.New("argument error: --namespace is required")
+	}
+	if name == "" {
+		name = metricName
+	}
+	transformation := types.MetricTransformation{
+		MetricName:      aws.String(metricName),
+		MetricNamespace: aws.String(namespace),
+		MetricValue:     aws.String(metricValue),
+	}
+	if defaultValue != "" {
+		v, err := strconv.ParseFloat(defaultValue, 64)
+		if err != nil {
+			return errors.New("argument error: --default-value must be a number")
+		}
+		transformation.DefaultValue = aws.Float64(v)
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.PutMetricFilterInput{
+		LogGroupName:          aws.String(logGroup),
+		FilterName:            aws.String(name),
+		FilterPattern:         aws.String(pattern),
+		MetricTransformations: []types.MetricTransformation{transformation},
+	}
+	if _, err := client.PutMetricFilter(context.Background(), in); err != nil {
+		return err
+	}
+	fmt.Printf("Put metric filter %s to %s\n", name, logGroup)
+	return nil
+}
+
+func listMetricFilters(client *cloudwatchlogs.Client, logGroup string) ([]metricFilterInfo, error) {
+	param := &cloudwatchlogs.DescribeMetricFiltersInput{
+		LogGroupName: aws.String(logGroup),
+	}
+	filters := []metricFilterInfo{}
+	paginator := cloudwatchlogs.NewDescribeMetricFiltersPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, filter := range res.MetricFilters {
+			info := metricFilterInfo{
+				Name:         aws.ToString(filter.FilterName),
+				Pattern:      aws.ToString(filter.FilterPattern),
+				CreationTime: fromMillis(aws.ToInt64(filter.CreationTime)),
+			}
+			if len(filter.MetricTransformations) > 0 {
+				info.MetricName = aws.ToString(filter.MetricTransformations[0].MetricName)
+				info.Namespace = aws.ToString(filter.MetricTransformations[0].MetricNamespace)
+				info.MetricValue = aws.ToString(filter.MetricTransformations[0].MetricValue)
+			}
+			filters = append(filters, info)
+		}
+	}
+	return filters, nil
+}
+
+func runMetricFilterList(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "List metric filters.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	filters, err := listMetricFilters(client, logGroup)
+	if err != nil {
+		return err
+	}
+
+	if output == outputJSON {
+		return writeJSON(os.Stdout, filters)
+	}
+
+	rows := make([][]string, len(filters))
+	for i, filter := range filters {
+		rows[i] = []string{filter.Name, filter.Pattern, filter.Namespace + "/" + filter.MetricName, filter.MetricValue}
+	}
+	return writeTable(os.Stdout, []string{"NAME", "PATTERN", "METRIC", "VALUE"}, rows)
+}
+
+func runMetricFilterDelete(args []string) error {
+	params := parameters{}
+	logGroup, name := "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Delete a metric filter.", "--log-group <LOG GROUP NAME> --name <FILTER NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&name, "name", "", "The name of the metric filter. It is required.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if name == "" {
+		return errors.New("argument error: --name is required")
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	in := &cloudwatchlogs.DeleteMetricFilterInput{
+		LogGroupName: aws.String(logGroup),
+		FilterName:   aws.String(name),
+	}
+	if _, err := client.DeleteMetricFilter(context.Background(), in); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted metric filter %s in %s\n", name, logGroup)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_runMetricFilter(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	client := []string{"--endpoint-url", server.URL, "--region", "us-east-1"}
+	required := []string{"--log-group", logGroup, "--pattern", "ERROR", "--metric-name", "Errors", "--namespace", "App"}
+
+	// defaultValues returns the default values of metric filters by the names, where -1 means no default value
+	defaultValues := func() map[string]float64 {
+		out, err := cli.DescribeMetricFilters(context.Background(), &cloudwatchlogs.DescribeMetricFiltersInput{LogGroupName: aws.String(logGroup)})
+		if err != nil {
+			t.Fatalf("failed to check result: %v", err)
+		}
+		values := map[string]float64{}
+		for _, filter := range out.MetricFilters {
+			v := filter.MetricTransformations[0].DefaultValue
+			if v == nil {
+				values[aws.ToString(filter.FilterName)] = -1
+				continue
+			}
+			values[aws.ToString(filter.FilterName)] = *v
+		}
+		return values
+	}
+
+	// The steps share the log group, so they run in order
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		want    map[string]float64
+	}{
+		{name: "Put metric filter without log group", args: []string{"put", "--pattern", "ERROR", "--metric-name", "Errors", "--namespace", "App"}, wantErr: true, want: map[string]float64{}},
+		{name: "Put metric filter without pattern", args: []string{"put", "--log-group", logGroup, "--metric-name", "Errors", "--namespace", "App"}, wantErr: true, want: map[string]float64{}},
+		{name: "Put metric filter without metric name", args: []string{"put", "--log-group", logGroup, "--pattern", "ERROR", "--namespace", "App"}, wantErr: true, want: map[string]float64{}},
+		{name: "Put metric filter without namespace", args: []string{"put", "--log-group", logGroup, "--pattern", "ERROR", "--metric-name", "Errors"}, wantErr: true, want: map[string]float64{}},
+		{name: "Put metric filter with invalid default value", args: append([]string{"put", "--default-value", "zero"}, required...), wantErr: true, want: map[string]float64{}},
+		{name: "Put metric filter with default value", args: append([]string{"put", "--default-value", "0.5"}, required...), want: map[string]float64{"Errors": 0.5}},
+		{name: "Put metric filter without default value", args: append([]string{"put", "--name", "api-errors"}, required...), want: map[string]float64{"Errors": 0.5, "api-errors": -1}},
+		{name: "List metric filters", args: []string{"list", "--log-group", logGroup, "--output", "json"}, want: map[string]float64{"Errors": 0.5, "api-errors": -1}},
+		{name: "Delete metric filter without name", args: []string{"delete", "--log-group", logGroup}, wantErr: true, want: map[string]float64{"Errors": 0.5, "api-errors": -1}},
+		{name: "Delete metric filter", args: []string{"delete", "--log-group", logGroup, "--name", "Errors"}, want: map[string]float64{"api-errors": -1}},
+		{name: "Delete metric filter after it is deleted", args: []string{"delete", "--log-group", logGroup, "--name", "Errors"}, wantErr: true, want: map[string]float64{"api-errors": -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"metric-filter", tt.args[0]}, append(client, tt.args[1:]...)...)
+			if err := runMetricFilter(args); (err != nil) != tt.wantErr {
+				t.Errorf("runMetricFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := defaultValues(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runMetricFilter() metric filters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
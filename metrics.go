@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// shipperMetrics are the metrics of uploading log events read from stdin, exposed in the Prometheus text format.
+// The methods do nothing on nil, so the metrics are optional.
+type shipperMetrics struct {
+	received   atomic.Int64
+	uploaded   atomic.Int64
+	rejected   atomic.Int64
+	dropped    atomic.Int64
+	retried    atomic.Int64
+	bytesSent  atomic.Int64
+	queueDepth atomic.Int64
+	// circuitOpened counts the times circuit breakers opened
+	circuitOpened atomic.Int64
+	// unhealthy is true when log events can not be uploaded to any destination
+	unhealthy atomic.Bool
+}
+
+func (m *shipperMetrics) addReceived(n int) {
+	if m != nil {
+		m.received.Add(int64(n))
+	}
+}
+
+func (m *shipperMetrics) addDropped(n int) {
+	if m != nil {
+		m.dropped.Add(int64(n))
+	}
+}
+
+func (m *shipperMetrics) setQueueDepth(n int) {
+	if m != nil {
+		m.queueDepth.Store(int64(n))
+	}
+}
+
+func (m *shipperMetrics) addCircuitOpened() {
+	if m != nil {
+		m.circuitOpened.Add(1)
+	}
+}
+
+func (m *shipperMetrics) setHealthy(healthy bool) {
+	if m != nil {
+		m.unhealthy.Store(!healthy)
+	}
+}
+
+// addPut records the result of PutLogEvents for the batch.
+func (m *shipperMetrics) addPut(batch []types.InputLogEvent, res *types.RejectedLogEventsInfo, attempts int) {
+	if m == nil {
+		return
+	}
+	rejected := rejectedLogEvents(len(batch), res)
+	m.uploaded.Add(int64(len(batch) - rejected))
+	m.rejected.Add(int64(rejected))
+	m.retried.Add(int64(attempts - 1))
+	m.bytesSent.Add(int64(ingestedBytes(batch)))
+}
+
+// rejectedLogEvents returns the number of log events in the batch of n log events rejected by PutLogEvents.
+func rejectedLogEvents(n int, info *types.RejectedLogEventsInfo) int {
+	if info == nil {
+		return 0
+	}
+	// Log events before the end indexes are too old or expired, and ones after the start index are too new
+	oldest := 0
+	if info.TooOldLogEventEndIndex != nil && int(*info.TooOldLogEventEndIndex)+1 > oldest {
+		oldest = int(*info.TooOldLogEventEndIndex) + 1
+	}
+	if info.ExpiredLogEventEndIndex != nil && int(*info.ExpiredLogEventEndIndex)+1 > oldest {
+		oldest = int(*info.ExpiredLogEventEndIndex) + 1
+	}
+	newest := n
+	if info.TooNewLogEventStartIndex != nil && int(*info.TooNewLogEventStartIndex) < newest {
+		newest = int(*info.TooNewLogEventStartIndex)
+	}
+	if oldest >= newest {
+		return n
+	}
+	return n - (newest - oldest)
+}
+
+func (m *shipperMetrics) write(w io.Writer) {
+	counters := []struct {
+		name, help string
+		value      int64
+	}{
+		{"awsputlogs_events_received_total", "The number of log events read from stdin.", m.received.Load()},
+		{"awsputlogs_events_uploaded_total", "The number of log events accepted by PutLogEvents.", m.uploaded.Load()},
+		{"awsputlogs_events_rejected_total", "The number of log events rejected by PutLogEvents because they are too old, too new or expired.", m.rejected.Load()},
+		{"awsputlogs_events_dropped_total", "The number of log events dropped because the buffer is full.", m.dropped.Load()},
+		{"awsputlogs_put_retries_total", "The number of retries of PutLogEvents.", m.retried.Load()},
+		{"awsputlogs_bytes_sent_total", "The number of bytes of log events sent by PutLogEvents.", m.bytesSent.Load()},
+		{"awsputlogs_circuit_opened_total", "The number of times circuit breakers opened after consecutive failures.", m.circuitOpened.Load()},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value)
+	}
+	fmt.Fprintf(w, "# HELP awsputlogs_queue_depth The number of log events buffered to be uploaded.\n# TYPE awsputlogs_queue_depth gauge\nawsputlogs_queue_depth %d\n", m.queueDepth.Load())
+}
+
+func (m *shipperMetrics) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.write(w)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if m.unhealthy.Load() {
+			http.Error(w, "no active destinations", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// serveMetrics serves /metrics and /healthz on addr in the background. The returned server is closed by the caller.
+func serveMetrics(addr string, m *shipperMetrics) (*http.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics error: %w", err)
+	}
+	server := &http.Server{Handler: m.handler()}
+	go server.Serve(l)
+	return server, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_rejectedLogEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		info *types.RejectedLogEventsInfo
+		want int
+	}{
+		{name: "No rejected log events", n: 10, info: nil, want: 0},
+		{name: "Too old log events", n: 10, info: &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(2)}, want: 3},
+		{name: "Too new log events", n: 10, info: &types.RejectedLogEventsInfo{TooNewLogEventStartIndex: aws.Int32(8)}, want: 2},
+		{
+			name: "Expired and too old log events",
+			n:    10,
+			info: &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(2), ExpiredLogEventEndIndex: aws.Int32(4)},
+			want: 5,
+		},
+		{
+			name: "All log events",
+			n:    10,
+			info: &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(5), TooNewLogEventStartIndex: aws.Int32(3)},
+			want: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rejectedLogEvents(tt.n, tt.info); got != tt.want {
+				t.Errorf("rejectedLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_shipperMetrics_handler(t *testing.T) {
+	m := &shipperMetrics{}
+	m.addReceived(3)
+	m.addPut([]types.InputLogEvent{
+		{Message: aws.String("log1"), Timestamp: aws.Int64(1)},
+		{Message: aws.String("log2"), Timestamp: aws.Int64(2)},
+	}, &types.RejectedLogEventsInfo{TooOldLogEventEndIndex: aws.Int32(0)}, 3)
+	m.setQueueDepth(1)
+
+	server := httptest.NewServer(m.handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	for _, want := range []string{
+		"awsputlogs_events_received_total 3\n",
+		"awsputlogs_events_uploaded_total 1\n",
+		"awsputlogs_events_rejected_total 1\n",
+		"awsputlogs_put_retries_total 2\n",
+		"awsputlogs_bytes_sent_total 60\n",
+		"awsputlogs_queue_depth 1\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics = %v, want to include %v", string(body), want)
+		}
+	}
+
+	for _, tt := range []struct {
+		healthy bool
+		want    int
+	}{{true, http.StatusOK}, {false, http.StatusServiceUnavailable}} {
+		m.setHealthy(tt.healthy)
+		res, err := http.Get(server.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("/healthz status = %v, want %v", res.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// mfaTokenEnv is the environment variable to give the MFA token code without prompting, such as in scripts.
+const mfaTokenEnv = "AWSPUTLOGS_MFA_TOKEN"
+
+// mfaTokenProvider asks the MFA token code to assume roles requiring MFA on the terminal once per run,
+// unless the code is given by --mfa-token or AWSPUTLOGS_MFA_TOKEN.
+type mfaTokenProvider struct {
+	// in is the terminal to ask the code. If it is nil, stdin is used only if it is the terminal,
+	// since log events may be piped to stdin.
+	in    io.Reader
+	out   io.Writer
+	mu    sync.Mutex
+	token string
+}
+
+// mfaTokens asks the MFA token code for all roles assumed in the run.
+var mfaTokens = &mfaTokenProvider{out: os.Stderr}
+
+// tokenFor returns the function to get the code of the MFA device, which is set as TokenProvider of AssumeRoleOptions.
+// token is the code given by --mfa-token.
+func (p *mfaTokenProvider) tokenFor(serial, token string) func() (string, error) {
+	return func() (string, error) {
+		if token != "" {
+			return token, nil
+		}
+		if token := os.Getenv(mfaTokenEnv); token != "" {
+			return token, nil
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.token != "" {
+			return p.token, nil
+		}
+		in := p.in
+		if in == nil && isTerminal(os.Stdin) {
+			in = os.Stdin
+		}
+		if in == nil {
+			return "", fmt.Errorf("mfa error: the role requires the MFA token code of %s. Give it by --mfa-token or %s environment variable", serial, mfaTokenEnv)
+		}
+		fmt.Fprintf(p.out, "Enter the MFA token code of %s: ", serial)
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("mfa error: failed to read the MFA token code: %w", err)
+		}
+		if p.token = strings.TrimSpace(line); p.token == "" {
+			return "", errors.New("mfa error: the MFA token code is empty")
+		}
+		return p.token, nil
+	}
+}
+
+// withMFA sets the MFA token code to assume the role of the profile with mfa_serial.
+func withMFA(params parameters) func(*stscreds.AssumeRoleOptions) {
+	return func(o *stscreds.AssumeRoleOptions) {
+		if o.SerialNumber != nil {
+			o.TokenProvider = mfaTokens.tokenFor(aws.ToString(o.SerialNumber), params.mfaToken)
+		}
+	}
+}
+
+// profileMFASerial returns mfa_serial of the profile loaded in cfg.
+func profileMFASerial(cfg aws.Config) string {
+	for _, source := range cfg.ConfigSources {
+		if shared, ok := source.(config.SharedConfig); ok {
+			return shared.MFASerial
+		}
+	}
+	return ""
+}
+
+// newAssumeRoleProvider returns the provider of credentials of the role. The role is assumed with MFA if --mfa-serial
+// or mfa_serial of the profile is given.
+func newAssumeRoleProvider(cfg aws.Config, params parameters, roleARN string) aws.CredentialsProvider {
+	serial := params.mfaSerial
+	if serial == "" {
+		serial = profileMFASerial(cfg)
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if serial != "" {
+			o.SerialNumber = aws.String(serial)
+			o.TokenProvider = mfaTokens.tokenFor(serial, params.mfaToken)
+		}
+	})
+	return mfaHintProvider{provider: provider, roleARN: roleARN, mfa: serial != ""}
+}
+
+// mfaHintProvider adds the hint to use MFA to AccessDenied errors of AssumeRole, which STS returns without the reason
+// when the role or the SCP requires MFA.
+type mfaHintProvider struct {
+	provider aws.CredentialsProvider
+	roleARN  string
+	mfa      bool
+}
+
+func (p mfaHintProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.provider.Retrieve(ctx)
+	var apiErr smithy.APIError
+	if err != nil && !p.mfa && errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied" {
+		return aws.Credentials{}, fmt.Errorf("credentials error: failed to assume %s. If the role requires MFA, give the ARN of the MFA device by --mfa-serial: %w", p.roleARN, err)
+	}
+	return creds, err
+}
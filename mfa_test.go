@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func Test_mfaTokenProvider_tokenFor(t *testing.T) {
+	serial := "arn:aws:iam::123456789012:mfa/dev"
+	tests := []struct {
+		name     string
+		token    string
+		env      string
+		input    string
+		terminal bool
+		want     string
+		wantErr  bool
+	}{
+		{name: "Use --mfa-token", token: "123456", env: "654321", want: "123456"},
+		{name: "Use the environment variable", env: "654321", want: "654321"},
+		{name: "Ask on the terminal", input: " 112233\n", terminal: true, want: "112233"},
+		{name: "Ask on the terminal with empty code", input: "\n", terminal: true, wantErr: true},
+		{name: "Fail without the terminal", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(mfaTokenEnv, tt.env)
+			out := &bytes.Buffer{}
+			p := &mfaTokenProvider{out: out}
+			if tt.terminal {
+				p.in = strings.NewReader(tt.input)
+			}
+			got, err := p.tokenFor(serial, tt.token)()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mfaTokenProvider.tokenFor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("mfaTokenProvider.tokenFor() = %v, want %v", got, tt.want)
+			}
+			if tt.terminal && !strings.Contains(out.String(), serial) {
+				t.Errorf("mfaTokenProvider.tokenFor() asked %q, want the serial of the MFA device", out.String())
+			}
+		})
+	}
+}
+
+func Test_newAssumeRoleProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        parameters
+		wantTokenCode string
+		wantHint      bool
+	}{
+		{
+			name:          "Assume role with MFA",
+			params:        parameters{mfaSerial: "arn:aws:iam::123456789012:mfa/dev", mfaToken: "123456"},
+			wantTokenCode: "123456",
+		},
+		{
+			name:     "Assume role requiring MFA without MFA",
+			params:   parameters{},
+			wantHint: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				w.Header().Set("Content-Type", "text/xml")
+				if r.Form.Get("TokenCode") == "" {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>User is not authorized to perform: sts:AssumeRole</Message></Error></ErrorResponse>`)
+					return
+				}
+				if r.Form.Get("TokenCode") != tt.wantTokenCode || r.Form.Get("SerialNumber") != tt.params.mfaSerial {
+					t.Errorf("AssumeRole got TokenCode %s and SerialNumber %s", r.Form.Get("TokenCode"), r.Form.Get("SerialNumber"))
+				}
+				fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIA1</AccessKeyId><SecretAccessKey>SECRET</SecretAccessKey><SessionToken>SESSION</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+			}))
+			defer server.Close()
+
+			cfg := aws.Config{
+				Region:       "us-east-1",
+				Credentials:  credentials.NewStaticCredentialsProvider("AKIA1", "SECRET", ""),
+				BaseEndpoint: aws.String(server.URL),
+			}
+			creds, err := newAssumeRoleProvider(cfg, tt.params, "arn:aws:iam::123456789012:role/logs").Retrieve(context.Background())
+			if tt.wantHint {
+				if err == nil || !strings.Contains(err.Error(), "--mfa-serial") {
+					t.Errorf("newAssumeRoleProvider() error = %v, want the hint to use MFA", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newAssumeRoleProvider() error = %v", err)
+			}
+			if creds.AccessKeyID != "ASIA1" {
+				t.Errorf("newAssumeRoleProvider() = %v, want ASIA1", creds.AccessKeyID)
+			}
+		})
+	}
+}
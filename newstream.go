@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// uuidPlaceholder is replaced with a random UUID in --stream-template
+const uuidPlaceholder = "{uuid}"
+
+// defaultStreamTemplate is the name of the log stream created by --new-stream if --stream-template is not given.
+const defaultStreamTemplate = "awsputlogs-" + datePlaceholder + "-" + uuidPlaceholder
+
+// validateStreamTemplate checks the template can be the name of a log stream.
+func validateStreamTemplate(template string) error {
+	if strings.ContainsAny(template, ":*") {
+		return fmt.Errorf("argument error: --stream-template must not include ':' or '*', but got %s", template)
+	}
+	return nil
+}
+
+// newStreamName returns the name of the log stream created for the run, where {date} is replaced with
+// the current date in UTC and {uuid} with id.
+func newStreamName(template string, now time.Time, id string) string {
+	r := strings.NewReplacer(
+		datePlaceholder, now.UTC().Format("2006-01-02"),
+		uuidPlaceholder, id,
+	)
+	return r.Replace(template)
+}
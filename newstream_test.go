@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_newStreamName(t *testing.T) {
+	now := time.Date(2024, 1, 2, 23, 0, 0, 0, time.FixedZone("JST", -9*60*60))
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "Name by default template", template: defaultStreamTemplate, want: "awsputlogs-2024-01-03-0f8fad5b-d9cb-469f-a165-70867728950e"},
+		{name: "Name by template", template: "import-{date}-{uuid}", want: "import-2024-01-03-0f8fad5b-d9cb-469f-a165-70867728950e"},
+		{name: "Name without placeholders", template: "import", want: "import"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newStreamName(tt.template, now, "0f8fad5b-d9cb-469f-a165-70867728950e"); got != tt.want {
+				t.Errorf("newStreamName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateStreamTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "Validate template", template: "import-{date}-{uuid}", wantErr: false},
+		{name: "Validate template with colon", template: "import:{uuid}", wantErr: true},
+		{name: "Validate template with asterisk", template: "import-*", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStreamTemplate(tt.template); (err != nil) != tt.wantErr {
+				t.Errorf("validateStreamTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON:
+		return nil
+	}
+	return fmt.Errorf("argument error: --output must be %s or %s, but got %s", outputTable, outputJSON, format)
+}
+
+// writeTable writes the rows aligned by columns.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// fromMillis converts the milliseconds since the epoch used by CloudWatch Logs to the time.
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms)
+}
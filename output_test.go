@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_writeTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		rows   [][]string
+		want   string
+	}{
+		{
+			name:   "Write rows aligned by columns",
+			header: []string{"NAME", "STORED BYTES"},
+			rows: [][]string{
+				{"/app/api", "1024"},
+				{"/aws/lambda/payments", "0"},
+			},
+			want: "NAME                  STORED BYTES\n" +
+				"/app/api              1024\n" +
+				"/aws/lambda/payments  0\n",
+		},
+		{
+			name:   "Write no rows",
+			header: []string{"NAME", "STORED BYTES"},
+			rows:   [][]string{},
+			want:   "NAME  STORED BYTES\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := writeTable(w, tt.header, tt.rows); err != nil {
+				t.Errorf("writeTable() error = %v", err)
+				return
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("writeTable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// The results of checking permissions
+const (
+	permissionAllowed = "allowed"
+	permissionDenied  = "denied"
+	// permissionUnknown means the permission could not be checked, such as when the log group does not exist.
+	permissionUnknown = "unknown"
+)
+
+// permissionCheck is the result of checking the IAM action on the log group.
+type permissionCheck struct {
+	Action string `json:"action"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// isAccessDenied reports whether the error is returned because the IAM action is not allowed.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "AccessDeniedException", "AccessDenied", "UnauthorizedOperation":
+		return true
+	}
+	return false
+}
+
+// probeResult classifies the error of the probe call. expected is the error which the call returns when the action is allowed.
+func probeResult(action string, err error, expected func(error) bool) permissionCheck {
+	switch {
+	case err == nil || expected(err):
+		return permissionCheck{Action: action, Result: permissionAllowed}
+	case isAccessDenied(err):
+		return permissionCheck{Action: action, Result: permissionDenied, Detail: err.Error()}
+	}
+	return permissionCheck{Action: action, Result: permissionUnknown, Detail: err.Error()}
+}
+
+// probePutPermissions checks the actions needed to put logs to the log group by calls without side effects.
+// PutLogEvents is called for a log stream which does not exist, and CreateLogStream for one which already exists,
+// so that they fail after the authorization.
+func probePutPermissions(ctx context.Context, client *cloudwatchlogs.Client, logGroup string) []permissionCheck {
+	checks := []permissionCheck{}
+
+	res, err := client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup),
+		Limit:        aws.Int32(1),
+	})
+	checks = append(checks, probeResult("logs:DescribeLogStreams", err, func(error) bool { return false }))
+
+	missing := "awsputlogs-probe-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err = client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(missing),
+		LogEvents:     []types.InputLogEvent{{Message: aws.String("probe"), Timestamp: aws.Int64(toMillis(time.Now()))}},
+	})
+	checks = append(checks, probeResult("logs:PutLogEvents", err, func(err error) bool {
+		var notFound *types.ResourceNotFoundException
+		return errors.As(err, &notFound)
+	}))
+
+	if res == nil || len(res.LogStreams) == 0 {
+		return append(checks, permissionCheck{
+			Action: "logs:CreateLogStream",
+			Result: permissionUnknown,
+			Detail: "it is checked only with an existing log stream, since the probe must not create one",
+		})
+	}
+	_, err = client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: res.LogStreams[0].LogStreamName,
+	})
+	return append(checks, probeResult("logs:CreateLogStream", err, func(err error) bool {
+		var exists *types.ResourceAlreadyExistsException
+		return errors.As(err, &exists)
+	}))
+}
+
+// preflightPermissions checks the permissions to put logs to each destination before uploading, and returns the error
+// listing the denied actions of all destinations. CreateLogStream is required only if createsStreams is true.
+func preflightPermissions(ctx context.Context, clientOf func(destination) (*cloudwatchlogs.Client, error), dsts []destination, createsStreams bool) error {
+	checked := map[string]bool{}
+	lines := []string{}
+	for _, dst := range dsts {
+		// Log streams of the same log group need the same permissions
+		key := dst.region + " " + dst.roleARN + " " + dst.logGroup
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+
+		client, err := clientOf(dst)
+		if err != nil {
+			return err
+		}
+		denied := []string{}
+		for _, check := range probePutPermissions(ctx, client, dst.logGroup) {
+			if check.Result == permissionDenied && (createsStreams || check.Action != "logs:CreateLogStream") {
+				denied = append(denied, check.Action)
+			}
+		}
+		if len(denied) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", dst.logGroup, strings.Join(denied, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return fmt.Errorf("permission error: missing permissions to put logs to %d log groups\n%s", len(lines), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_preflightPermissions(t *testing.T) {
+	tests := []struct {
+		name           string
+		deny           []string
+		createsStreams bool
+		want           string
+	}{
+		{name: "All actions are allowed", createsStreams: true},
+		{
+			name: "PutLogEvents is denied",
+			deny: []string{"PutLogEvents"},
+			want: "permission error: missing permissions to put logs to 2 log groups\n/app/api: logs:PutLogEvents\n/app/worker: logs:PutLogEvents",
+		},
+		{
+			name:           "CreateLogStream is denied and needed",
+			deny:           []string{"CreateLogStream", "DescribeLogStreams"},
+			createsStreams: true,
+			want:           "permission error: missing permissions to put logs to 2 log groups\n/app/api: logs:DescribeLogStreams\n/app/worker: logs:DescribeLogStreams",
+		},
+		{
+			name: "CreateLogStream is denied but not needed",
+			deny: []string{"CreateLogStream"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := fakelogs.New()
+			server := httptest.NewServer(denyActions(fake, tt.deny...))
+			defer server.Close()
+			setUpServer := httptest.NewServer(fake)
+			defer setUpServer.Close()
+			cli, err := setUpClient(setUpServer.URL, "us-east-1")
+			if err != nil {
+				t.Fatalf("failed to set up: %v", err)
+			}
+			for _, logGroup := range []string{"/app/api", "/app/worker"} {
+				if _, err := cli.CreateLogGroup(context.Background(), &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(logGroup)}); err != nil {
+					t.Fatalf("failed to set up: %v", err)
+				}
+				if _, err := cli.CreateLogStream(context.Background(), &cloudwatchlogs.CreateLogStreamInput{LogGroupName: aws.String(logGroup), LogStreamName: aws.String("app")}); err != nil {
+					t.Fatalf("failed to set up: %v", err)
+				}
+			}
+
+			params := parameters{endpointURL: server.URL, region: "us-east-1"}
+			clientOf := func(dst destination) (*cloudwatchlogs.Client, error) { return newClient(params, dst) }
+			dsts := []destination{{logGroup: "/app/api", logStream: "a"}, {logGroup: "/app/api", logStream: "b"}, {logGroup: "/app/worker"}}
+			err = preflightPermissions(context.Background(), clientOf, dsts, tt.createsStreams)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("preflightPermissions() error = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
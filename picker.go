@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// maxPickerItems is the number of candidates shown by the picker at once.
+const maxPickerItems = 20
+
+// isInteractive reports whether the tool can ask the user through the terminal.
+var isInteractive = func() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// fuzzyScore reports whether all characters of the query appear in the item in order.
+// The score is the number of characters skipped between the matched characters, so the lower is the better.
+func fuzzyScore(query, item string) (int, bool) {
+	query = strings.ToLower(query)
+	item = strings.ToLower(item)
+
+	if strings.Contains(item, query) {
+		return 0, true
+	}
+
+	score := 0
+	last := -1
+	for _, r := range query {
+		i := strings.IndexRune(item[last+1:], r)
+		if i < 0 {
+			return 0, false
+		}
+		if last >= 0 {
+			score += i
+		}
+		last += i + len(string(r))
+	}
+	return score, true
+}
+
+// fuzzyFilter returns the items matching the query, ordered from the best match.
+func fuzzyFilter(query string, items []string) []string {
+	if query == "" {
+		return items
+	}
+
+	type match struct {
+		item  string
+		score int
+	}
+	matches := []match{}
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item); ok {
+			matches = append(matches, match{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return len(matches[i].item) < len(matches[j].item)
+	})
+
+	filtered := make([]string, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.item
+	}
+	return filtered
+}
+
+// pick asks the user to choose one of the items.
+// The user searches items by typing a part of the name and selects one by typing its number.
+func pick(in *bufio.Reader, out io.Writer, title string, items []string) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("picker error: %s are not found", title)
+	}
+
+	candidates := items
+	for {
+		for i, item := range candidates {
+			if i == maxPickerItems {
+				fmt.Fprintf(out, "  ... and %d more\n", len(candidates)-maxPickerItems)
+				break
+			}
+			fmt.Fprintf(out, "  %2d) %s\n", i+1, item)
+		}
+		fmt.Fprintf(out, "Select %s by number, or type to search: ", title)
+
+		line, err := in.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return "", fmt.Errorf("picker error: %s is not selected: %w", title, err)
+		}
+		line = strings.TrimSpace(line)
+
+		if n, err := strconv.Atoi(line); err == nil && 0 < n && n <= len(candidates) && n <= maxPickerItems {
+			return candidates[n-1], nil
+		}
+
+		filtered := fuzzyFilter(line, items)
+		if len(filtered) == 0 {
+			fmt.Fprintf(out, "No %s match %q\n", title, line)
+			continue
+		}
+		candidates = filtered
+	}
+}
+
+// confirm asks the user to answer yes or no to the message. It returns true only if the user answers yes.
+func confirm(in *bufio.Reader, out io.Writer, message string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", message)
+	line, err := in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return false, fmt.Errorf("confirmation error: no answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func listLogGroupNames(client *cloudwatchlogs.Client) ([]string, error) {
+	names := []string{}
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{})
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range res.LogGroups {
+			names = append(names, aws.ToString(group.LogGroupName))
+		}
+	}
+	return names, nil
+}
+
+func listLogStreamNames(client *cloudwatchlogs.Client, logGroup string) ([]string, error) {
+	param := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup),
+		Descending:   aws.Bool(true),
+		OrderBy:      types.OrderByLastEventTime,
+	}
+	names := []string{}
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, stream := range res.LogStreams {
+			names = append(names, aws.ToString(stream.LogStreamName))
+		}
+	}
+	return names, nil
+}
+
+// pickDestination asks the user to choose the log group and the log stream where logs are put.
+func pickDestination(client *cloudwatchlogs.Client, in *bufio.Reader, out io.Writer) (destination, error) {
+	groups, err := listLogGroupNames(client)
+	if err != nil {
+		return destination{}, err
+	}
+	logGroup, err := pick(in, out, "log groups", groups)
+	if err != nil {
+		return destination{}, err
+	}
+
+	streams, err := listLogStreamNames(client, logGroup)
+	if err != nil {
+		return destination{}, err
+	}
+	logStream, err := pick(in, out, "log streams", streams)
+	if err != nil {
+		return destination{}, err
+	}
+
+	return destination{logGroup: logGroup, logStream: logStream}, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fuzzyFilter(t *testing.T) {
+	items := []string{
+		"/aws/lambda/payments-api",
+		"/aws/lambda/orders-api",
+		"/app/payments/worker",
+		"/app/api",
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "Filter by contiguous characters",
+			query: "payments",
+			want: []string{
+				"/app/payments/worker",
+				"/aws/lambda/payments-api",
+			},
+		},
+		{
+			name:  "Filter by characters in order",
+			query: "lmbdapi",
+			want: []string{
+				"/aws/lambda/orders-api",
+				"/aws/lambda/payments-api",
+			},
+		},
+		{
+			name:  "Filter case-insensitively",
+			query: "APP/API",
+			want: []string{
+				"/app/api",
+			},
+		},
+		{
+			name:  "Filter by empty query",
+			query: "",
+			want:  items,
+		},
+		{
+			name:  "Filter by unmatched query",
+			query: "billing",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fuzzyFilter(tt.query, items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fuzzyFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pick(t *testing.T) {
+	items := []string{
+		"/aws/lambda/payments-api",
+		"/aws/lambda/orders-api",
+		"/app/api",
+	}
+	tests := []struct {
+		name    string
+		input   string
+		items   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Select by number",
+			input:   "2\n",
+			items:   items,
+			want:    "/aws/lambda/orders-api",
+			wantErr: false,
+		},
+		{
+			name:    "Select after searching",
+			input:   "orders\n1\n",
+			items:   items,
+			want:    "/aws/lambda/orders-api",
+			wantErr: false,
+		},
+		{
+			name:    "Search again after no match",
+			input:   "billing\napp/api\n1",
+			items:   items,
+			want:    "/app/api",
+			wantErr: false,
+		},
+		{
+			name:    "Select nothing",
+			input:   "orders\n",
+			items:   items,
+			wantErr: true,
+		},
+		{
+			name:    "No items",
+			input:   "1\n",
+			items:   []string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := bufio.NewReader(strings.NewReader(tt.input))
+			got, err := pick(in, ioutil.Discard, "log groups", tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("pick() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("pick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
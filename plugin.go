@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// parserPlugin parses files of custom formats with the WebAssembly module.
+//
+// The plugin is a WASI command (wasm32-wasi or wasip1), which is run once for each file. It gets the content of the file
+// from stdin and the base name of the file as the first argument, and writes log events to stdout as NDJSON, one
+// {"message": "...", "timestamp": <epoch milliseconds>} object per line. The timestamp is optional, and log events without it use
+// the time in --timestamp-field or the current time. The plugin fails by exiting with a non-zero code, and stderr is
+// shown in the error. It can not access files, the network or environment variables, and it is stopped when it runs over
+// a minute for a file or grows its memory over 1 GiB.
+type parserPlugin struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// pluginTimeout is how long the plugin can run for each file. It is a variable to be replaced in tests.
+var pluginTimeout = time.Minute
+
+// pluginMemoryLimitPages is the maximum memory of the plugin in 64 KiB pages, which is 1 GiB.
+const pluginMemoryLimitPages = 16384
+
+// pluginEvent is a log event written by the plugin.
+type pluginEvent struct {
+	Message   *string `json:"message"`
+	Timestamp *int64  `json:"timestamp"`
+}
+
+// newParserPlugin compiles the WebAssembly module. If name is empty, it returns nil.
+func newParserPlugin(name string) (*parserPlugin, error) {
+	if name == "" {
+		return nil, nil
+	}
+	wasm, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	// The plugin is stopped when it runs over the timeout, and can not grow its memory over the limit
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithMemoryLimitPages(pluginMemoryLimitPages))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("plugin error: %s is not a valid WebAssembly module: %w", name, err)
+	}
+	if _, ok := compiled.ExportedFunctions()["_start"]; !ok {
+		r.Close(ctx)
+		return nil, fmt.Errorf("plugin error: %s must be a WASI command which exports _start", name)
+	}
+	return &parserPlugin{name: name, runtime: r, compiled: compiled}, nil
+}
+
+// run runs the plugin with the content of the file, and returns its stdout.
+func (p *parserPlugin) run(fileName string, data []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(filepath.Base(p.name), filepath.Base(fileName)).
+		WithStdin(bytes.NewReader(data)).
+		WithStdout(&stdout).
+		WithStderr(&stderr)
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	m, err := p.runtime.InstantiateModule(ctx, p.compiled, config)
+	if m != nil {
+		m.Close(context.Background())
+	}
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded {
+		return nil, fmt.Errorf("plugin error: %s did not finish parsing %s within %s", p.name, fileName, pluginTimeout)
+	}
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 0) {
+		return nil, fmt.Errorf("plugin error: %s failed to parse %s: %v: %s", p.name, fileName, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// parse parses the file with the plugin. Log events are sorted by the timestamp in the same way as the built-in formats.
+func (p *parserPlugin) parse(file inputFile, timestamps timestampOptions, now time.Time) ([]types.InputLogEvent, error) {
+	out, err := p.run(file.name, file.data)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []types.InputLogEvent{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), maxBatchBytes)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e pluginEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Message == nil {
+			return nil, fmt.Errorf("plugin error: line %d written by %s must be a JSON object with message: %s", n, p.name, truncate(scanner.Text(), 100))
+		}
+		t, ok := timestamps.parse(*e.Message)
+		if !ok {
+			t = now
+		}
+		if e.Timestamp != nil {
+			t = fromMillis(*e.Timestamp)
+		}
+		events = append(events, types.InputLogEvent{
+			Message:   e.Message,
+			Timestamp: aws.Int64(toMillis(t)),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("plugin error: %s wrote an invalid output: %w", p.name, err)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// close releases the runtime. The nil parserPlugin can be closed.
+func (p *parserPlugin) close() error {
+	if p == nil {
+		return nil
+	}
+	return p.runtime.Close(context.Background())
+}
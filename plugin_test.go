@@ -0,0 +1,173 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// wasmString encodes the string as a name of the WebAssembly binary format.
+func wasmString(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// wasmSection encodes the section whose content is shorter than 128 bytes.
+func wasmSection(id byte, content ...[]byte) []byte {
+	var b []byte
+	for _, c := range content {
+		b = append(b, c...)
+	}
+	return append([]byte{id, byte(len(b))}, b...)
+}
+
+// wasmCommand assembles the WASI command which exports the function with the body as start.
+func wasmCommand(start string, body []byte) []byte {
+	wasi := wasmString("wasi_snapshot_preview1")
+	return append([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		append(append(append(append(append(
+			// (i32, i32, i32, i32) -> i32 for fd_read and fd_write, and () -> () for start
+			wasmSection(0x01, []byte{0x02, 0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f, 0x60, 0x00, 0x00}),
+			wasmSection(0x02, []byte{0x02}, wasi, wasmString("fd_read"), []byte{0x00, 0x00}, wasi, wasmString("fd_write"), []byte{0x00, 0x00})...),
+			wasmSection(0x03, []byte{0x01, 0x01})...),
+			wasmSection(0x05, []byte{0x01, 0x00, 0x01})...),
+			wasmSection(0x07, []byte{0x02}, wasmString("memory"), []byte{0x02, 0x00}, wasmString(start), []byte{0x00, 0x02})...),
+			wasmSection(0x0a, []byte{0x01, byte(len(body))}, body)...)...)
+}
+
+// wasmEcho is the body of the function which copies stdin to stdout.
+var wasmEcho = []byte{
+	0x01, 0x01, 0x7f, // local i32 (the number of bytes read)
+	0x02, 0x40, // block
+	0x03, 0x40, // loop
+	0x41, 0x00, 0x41, 0x10, 0x36, 0x02, 0x00, // iovec.buf = 16
+	0x41, 0x04, 0x41, 0x80, 0x20, 0x36, 0x02, 0x00, // iovec.len = 4096
+	0x41, 0x00, 0x41, 0x00, 0x41, 0x01, 0x41, 0x08, 0x10, 0x00, 0x1a, // fd_read(stdin, iovec, 1, 8)
+	0x41, 0x08, 0x28, 0x02, 0x00, 0x22, 0x00, 0x45, 0x0d, 0x01, // break if nothing is read
+	0x41, 0x04, 0x20, 0x00, 0x36, 0x02, 0x00, // iovec.len = the number of bytes read
+	0x41, 0x01, 0x41, 0x00, 0x41, 0x01, 0x41, 0x0c, 0x10, 0x01, 0x1a, // fd_write(stdout, iovec, 1, 12)
+	0x0c, 0x00, // continue
+	0x0b, 0x0b, 0x0b,
+}
+
+// wasmTrap is the body of the function which traps.
+var wasmTrap = []byte{0x00, 0x00, 0x0b}
+
+// wasmLoop is the body of the function which never returns.
+var wasmLoop = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	name = filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func Test_newParserPlugin(t *testing.T) {
+	tests := []struct {
+		name    string
+		wasm    []byte
+		wantErr bool
+	}{
+		{name: "Load WASI command", wasm: wasmCommand("_start", wasmEcho)},
+		{name: "Load module without _start", wasm: wasmCommand("parse", wasmEcho), wantErr: true},
+		{name: "Load invalid module", wasm: []byte("not wasm"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newParserPlugin(writeFile(t, "parser.wasm", tt.wasm))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newParserPlugin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			p.close()
+		})
+	}
+}
+
+func Test_parserPlugin_parse(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name       string
+		body       []byte
+		input      string
+		timestamps timestampOptions
+		want       []string
+		wantTimes  []int64
+		wantErr    bool
+	}{
+		{
+			name:      "Parse log events sorted by timestamp",
+			body:      wasmEcho,
+			input:     "{\"message\":\"second\",\"timestamp\":2000}\n\n{\"message\":\"first\",\"timestamp\":1000}\n",
+			want:      []string{"first", "second"},
+			wantTimes: []int64{1000, 2000},
+		},
+		{
+			name:       "Parse log events without timestamp",
+			body:       wasmEcho,
+			input:      "{\"message\":\"{\\\"time\\\":\\\"2024-01-01T00:00:00Z\\\"}\"}\n{\"message\":\"no time\"}\n",
+			timestamps: timestampOptions{field: "time", unit: timestampUnitAuto},
+			want:       []string{`{"time":"2024-01-01T00:00:00Z"}`, "no time"},
+			wantTimes:  []int64{1704067200000, toMillis(now)},
+		},
+		{
+			name:    "Parse invalid output",
+			body:    wasmEcho,
+			input:   "{\"message\":\"ok\"}\nnot json\n",
+			wantErr: true,
+		},
+		{
+			name:    "Parse output without message",
+			body:    wasmEcho,
+			input:   "{\"timestamp\":1000}\n",
+			wantErr: true,
+		},
+		{
+			name:    "Fail in plugin",
+			body:    wasmTrap,
+			input:   "{\"message\":\"ok\"}\n",
+			wantErr: true,
+		},
+		{
+			name:    "Stop plugin over the timeout",
+			body:    wasmLoop,
+			input:   "{\"message\":\"ok\"}\n",
+			wantErr: true,
+		},
+	}
+	timeout := pluginTimeout
+	defer func() { pluginTimeout = timeout }()
+	pluginTimeout = 200 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newParserPlugin(writeFile(t, "parser.wasm", wasmCommand("_start", tt.body)))
+			if err != nil {
+				t.Fatalf("newParserPlugin() error = %v", err)
+			}
+			defer p.close()
+			got, err := p.parse(inputFile{name: "app.log", data: []byte(tt.input)}, tt.timestamps, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parserPlugin.parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			messages := make([]string, len(got))
+			times := make([]int64, len(got))
+			for i, event := range got {
+				messages[i] = aws.ToString(event.Message)
+				times[i] = aws.ToInt64(event.Timestamp)
+			}
+			if !reflect.DeepEqual(messages, tt.want) {
+				t.Errorf("parserPlugin.parse() = %q, want %q", messages, tt.want)
+			}
+			if !reflect.DeepEqual(times, tt.wantTimes) {
+				t.Errorf("parserPlugin.parse() timestamps = %v, want %v", times, tt.wantTimes)
+			}
+		})
+	}
+}
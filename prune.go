@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// selectStreamsToPrune returns the names of log streams whose last log event (or creation if no log events) is before the cutoff.
+// If emptyOnly is true, it only returns log streams without log events.
+func selectStreamsToPrune(streams []logStreamInfo, cutoff time.Time, emptyOnly bool) []string {
+	names := []string{}
+	for _, stream := range streams {
+		empty := stream.LastEventTime == nil
+		if emptyOnly && !empty {
+			continue
+		}
+		last := stream.CreationTime
+		if !empty {
+			last = *stream.LastEventTime
+		}
+		if last.Before(cutoff) {
+			names = append(names, stream.Name)
+		}
+	}
+	return names
+}
+
+// pruneSummary returns the last line of the prune-streams output for the number of log streams to delete out of total.
+func pruneSummary(n, total int, dryRun bool) string {
+	switch {
+	case dryRun:
+		return fmt.Sprintf("%d of %d log streams would be deleted", n, total)
+	case n == 0:
+		return fmt.Sprintf("No log streams to delete in %d log streams", total)
+	default:
+		return fmt.Sprintf("Deleted %d log streams", n)
+	}
+}
+
+func runPruneStreams(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	olderThan := ""
+	emptyOnly := false
+	dryRun := false
+	yes := false
+	rate := 5
+
+	flags := newSubcommandFlagSet(args[0], "Delete idle or empty log streams in the log group.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&olderThan, "older-than", "", "Delete log streams without log events for the duration such as 30d. If you do not use this parameters, it deletes log streams regardless of the last log event.")
+	flags.BoolVar(&emptyOnly, "empty-only", false, "Delete only log streams without log events.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Show log streams to delete without deleting them.")
+	flags.BoolVar(&yes, "yes", false, "Delete without confirmation.")
+	flags.IntVar(&rate, "rate", 5, "The maximum number of log streams deleted per second.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if olderThan == "" && !emptyOnly {
+		return errors.New("argument error: --older-than or --empty-only is required")
+	}
+	if rate <= 0 {
+		return fmt.Errorf("argument error: --rate must be positive, but got %d", rate)
+	}
+	cutoff := time.Now()
+	if olderThan != "" {
+		d, err := parseDuration(olderThan)
+		if err != nil {
+			return fmt.Errorf("argument error: --older-than: %w", err)
+		}
+		cutoff = cutoff.Add(-d)
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	streams, err := listLogStreams(client, logGroup, types.OrderByLogStreamName)
+	if err != nil {
+		return err
+	}
+	names := selectStreamsToPrune(streams, cutoff, emptyOnly)
+
+	if dryRun {
+		for _, name := range names {
+			fmt.Printf("Would delete log stream %s\n", name)
+		}
+		fmt.Println(pruneSummary(len(names), len(streams), true))
+		return nil
+	}
+	if len(names) == 0 {
+		fmt.Println(pruneSummary(0, len(streams), false))
+		return nil
+	}
+
+	if !yes {
+		message := fmt.Sprintf("Delete %d of %d log streams in %s?", len(names), len(streams), logGroup)
+		if err := confirmDeletion(message); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	for i, name := range names {
+		if i > 0 {
+			<-ticker.C
+		}
+		if err := deleteLogStream(client, logGroup, name); err != nil {
+			return fmt.Errorf("deleted %d of %d log streams: %w", i, len(names), err)
+		}
+		fmt.Printf("Deleted log stream %s\n", name)
+	}
+	fmt.Println(pruneSummary(len(names), len(streams), false))
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_selectStreamsToPrune(t *testing.T) {
+	old := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	streams := []logStreamInfo{
+		{Name: "old-idle", CreationTime: old, LastEventTime: &old},
+		{Name: "old-active", CreationTime: old, LastEventTime: &recent},
+		{Name: "old-empty", CreationTime: old},
+		{Name: "recent-empty", CreationTime: recent},
+	}
+	tests := []struct {
+		name      string
+		cutoff    time.Time
+		emptyOnly bool
+		want      []string
+	}{
+		{
+			name:      "Select idle log streams",
+			cutoff:    cutoff,
+			emptyOnly: false,
+			want:      []string{"old-idle", "old-empty"},
+		},
+		{
+			name:      "Select idle and empty log streams",
+			cutoff:    cutoff,
+			emptyOnly: true,
+			want:      []string{"old-empty"},
+		},
+		{
+			name:      "Select all empty log streams",
+			cutoff:    time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+			emptyOnly: true,
+			want:      []string{"old-empty", "recent-empty"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectStreamsToPrune(streams, tt.cutoff, tt.emptyOnly); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectStreamsToPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pruneSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		total  int
+		dryRun bool
+		want   string
+	}{
+		{name: "Summarize dry run", n: 2, total: 5, dryRun: true, want: "2 of 5 log streams would be deleted"},
+		{name: "Summarize dry run without log streams to delete", n: 0, total: 5, dryRun: true, want: "0 of 5 log streams would be deleted"},
+		{name: "Summarize deletion", n: 2, total: 5, want: "Deleted 2 log streams"},
+		{name: "Summarize deletion without log streams to delete", n: 0, total: 5, want: "No log streams to delete in 5 log streams"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pruneSummary(tt.n, tt.total, tt.dryRun); got != tt.want {
+				t.Errorf("pruneSummary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// outputCSV is the output format of query in addition to table and json.
+const outputCSV = "csv"
+
+const (
+	// defaultQuerySince is the start of the time range of queries.
+	defaultQuerySince = "1h"
+	// defaultQueryInterval is the interval to run the query again with --follow.
+	defaultQueryInterval = time.Minute
+)
+
+// resolveQuery returns the query string. The query named like @errors-by-service is read from the config file.
+func resolveQuery(query string, c userConfig) (string, error) {
+	if !strings.HasPrefix(query, aliasPrefix) {
+		return query, nil
+	}
+	name := strings.TrimPrefix(query, aliasPrefix)
+	saved, ok := c.Queries[name]
+	if !ok {
+		return "", fmt.Errorf("argument error: query %s is not found in the queries of the config file", name)
+	}
+	return saved, nil
+}
+
+// writeQueryResults writes the results of the Insights query in the output format. Fields are written in order of appearance.
+func writeQueryResults(w io.Writer, output string, r insightsResults) error {
+	switch output {
+	case outputJSON:
+		rows := make([]map[string]string, len(r.rows))
+		for i, row := range r.rows {
+			rows[i] = map[string]string{}
+			for _, field := range r.fields {
+				if value, ok := row[field]; ok {
+					rows[i][field] = value
+				}
+			}
+		}
+		return writeJSON(w, rows)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(r.fields)
+		for _, row := range r.rows {
+			cw.Write(r.values(row))
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	rows := make([][]string, len(r.rows))
+	for i, row := range r.rows {
+		rows[i] = r.values(row)
+	}
+	return writeTable(w, r.fields, rows)
+}
+
+// values returns the values of the row in order of the fields.
+func (r insightsResults) values(row map[string]string) []string {
+	values := make([]string, len(r.fields))
+	for i, field := range r.fields {
+		values[i] = row[field]
+	}
+	return values
+}
+
+func runQuery(args []string) error {
+	params := parameters{}
+	logGroups := []string{}
+	since, until := defaultQuerySince, ""
+	output := outputTable
+	outputFile := ""
+	follow := false
+	interval := defaultQueryInterval
+
+	flags := newSubcommandFlagSet(args[0], "Run the CloudWatch Logs Insights query.", "<QUERY | @QUERY NAME> --log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.Var((*filesFlag)(&logGroups), "log-group", "The name of the log group to query. It is required. Repeat it to query several log groups.")
+	addTimeRangeFlags(flags, &since, &until)
+	flags.StringVar(&output, "output", outputTable, "The output format. table, json or csv.")
+	flags.StringVar(&outputFile, "output-file", "", "The path of file where the results are written. If you do not use this parameters, it writes the results to stdout.")
+	flags.BoolVar(&follow, "follow", false, "Run the query again every --interval until it is interrupted. The time range moves with the current time, and --output-file is overwritten with the latest results.")
+	flags.DurationVar(&interval, "interval", defaultQueryInterval, "The interval to run the query again with --follow.")
+	queries := parseInterspersed(flags, args[1:])
+
+	setFlags := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if outputFile != "" && !setFlags["output"] {
+		output = outputFormatOf(outputFile, output)
+	}
+	if len(queries) != 1 {
+		return errors.New("argument error: a query or the name of a saved query is required")
+	}
+	if len(logGroups) == 0 {
+		return errors.New("argument error: --log-group is required")
+	}
+	switch output {
+	case outputTable, outputJSON, outputCSV:
+	default:
+		return fmt.Errorf("argument error: --output must be %s, %s or %s, but got %s", outputTable, outputJSON, outputCSV, output)
+	}
+	if follow && interval <= 0 {
+		return errors.New("argument error: --interval must be positive")
+	}
+	if _, _, err := parseTimeRange(since, until); err != nil {
+		return err
+	}
+
+	query := queries[0]
+	if strings.HasPrefix(query, aliasPrefix) {
+		path, err := defaultConfigPath()
+		if err != nil {
+			return err
+		}
+		c, err := loadUserConfig(path)
+		if err != nil {
+			return err
+		}
+		if query, err = resolveQuery(query, c); err != nil {
+			return err
+		}
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	// Stop following on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Durations such as 1h are relative to now, so the time range is parsed again each time
+		start, end, err := parseTimeRange(since, until)
+		if err != nil {
+			return err
+		}
+		if end.IsZero() {
+			end = time.Now()
+		}
+		r, err := paginateInsights(query, start, end, func(start, end time.Time) (insightsResults, error) {
+			return queryInsights(client, logGroups, query, start, end)
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeQueryOutput(outputFile, output, r); err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// outputFormatOf returns the output format by the extension of the file, such as csv for results.csv.
+func outputFormatOf(path, fallback string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return outputCSV
+	case ".json":
+		return outputJSON
+	}
+	return fallback
+}
+
+// writeQueryOutput writes the results to the file, or to stdout if the file is not given.
+func writeQueryOutput(outputFile, output string, r insightsResults) error {
+	if outputFile == "" {
+		return writeQueryResults(os.Stdout, output, r)
+	}
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if err := writeQueryResults(f, output, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d results to %s at %s\n", len(r.rows), outputFile, time.Now().Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_resolveQuery(t *testing.T) {
+	c := userConfig{Queries: map[string]string{"errors-by-service": "filter level = 'ERROR' | stats count(*) by service"}}
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Resolve saved query", query: "@errors-by-service", want: "filter level = 'ERROR' | stats count(*) by service"},
+		{name: "Keep query string", query: "fields @message | limit 10", want: "fields @message | limit 10"},
+		{name: "Resolve unknown query", query: "@slow-requests", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveQuery(tt.query, c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resolveQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeQueryResults(t *testing.T) {
+	field := func(name, value string) types.ResultField {
+		return types.ResultField{Field: aws.String(name), Value: aws.String(value)}
+	}
+	r := newInsightsResults([][]types.ResultField{
+		{field("service", "api"), field("count(*)", "12"), field("@ptr", "CmAKJwoj")},
+		{field("service", "batch, nightly"), field("count(*)", "3"), field("@ptr", "CmAKJwok")},
+	})
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "Write table",
+			output: outputTable,
+			want:   "service         count(*)\napi             12\nbatch, nightly  3\n",
+		},
+		{
+			name:   "Write csv",
+			output: outputCSV,
+			want:   "service,count(*)\napi,12\n\"batch, nightly\",3\n",
+		},
+		{
+			name:   "Write json",
+			output: outputJSON,
+			want:   "[\n  {\n    \"count(*)\": \"12\",\n    \"service\": \"api\"\n  },\n  {\n    \"count(*)\": \"3\",\n    \"service\": \"batch, nightly\"\n  }\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := writeQueryResults(buf, tt.output, r); err != nil {
+				t.Fatalf("writeQueryResults() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("writeQueryResults() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_paginateInsights(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Second)
+	// Each second has maxInsightsResults/2 log events, so the range of 3 seconds or more is truncated
+	run := func(start, end time.Time) (insightsResults, error) {
+		seconds := int(end.Sub(start)/time.Second) + 1
+		r := insightsResults{fields: []string{"@timestamp"}}
+		for s := seconds - 1; s >= 0 && len(r.rows) < maxInsightsResults; s-- {
+			for i := 0; i < maxInsightsResults/2; i++ {
+				r.rows = append(r.rows, map[string]string{"@timestamp": start.Add(time.Duration(s) * time.Second).Format(time.RFC3339)})
+			}
+		}
+		return r, nil
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "Paginate large results", query: "fields @timestamp, @message", want: 5 * maxInsightsResults / 2},
+		{name: "Do not paginate stats", query: "filter level = 'ERROR' | stats count(*) by service", want: maxInsightsResults},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := paginateInsights(tt.query, start, end, run)
+			if err != nil {
+				t.Fatalf("paginateInsights() error = %v", err)
+			}
+			if len(got.rows) != tt.want {
+				t.Errorf("paginateInsights() returned %d results, want %d", len(got.rows), tt.want)
+			}
+			if got.rows[0]["@timestamp"] != end.Format(time.RFC3339) {
+				t.Errorf("paginateInsights() returned %s first, want the newest result", got.rows[0]["@timestamp"])
+			}
+		})
+	}
+}
+
+func Test_outputFormatOf(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "results.csv", want: outputCSV},
+		{path: "reports/RESULTS.JSON", want: outputJSON},
+		{path: "results.txt", want: outputTable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := outputFormatOf(tt.path, outputTable); got != tt.want {
+				t.Errorf("outputFormatOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
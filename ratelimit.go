@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits the rate of something with the burst. The nil tokenBucket means no limit.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates the token bucket which is full. If burst is not positive, it is the rate for a second.
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait takes n tokens, and waits until the bucket is refilled if the tokens run short.
+// n can be more than the burst, and then it waits until the shortage is refilled.
+func (b *tokenBucket) wait(ctx context.Context, n float64) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens -= n
+	shortage := -b.tokens
+	b.mu.Unlock()
+
+	if shortage <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(shortage / b.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimits are the limits of PutLogEvents calls for each destination. The zero rate means no limit.
+type rateLimits struct {
+	requestsPerSecond float64
+	bytesPerSecond    int
+	burstRequests     int
+	burstBytes        int
+}
+
+// uploadLimiter limits PutLogEvents calls of an uploader.
+type uploadLimiter struct {
+	requests *tokenBucket
+	bytes    *tokenBucket
+}
+
+func newUploadLimiter(l rateLimits) *uploadLimiter {
+	return &uploadLimiter{
+		requests: newTokenBucket(l.requestsPerSecond, float64(l.burstRequests)),
+		bytes:    newTokenBucket(float64(l.bytesPerSecond), float64(l.burstBytes)),
+	}
+}
+
+// wait waits until a request with the size is allowed.
+func (l *uploadLimiter) wait(ctx context.Context, size int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.requests.wait(ctx, 1); err != nil {
+		return err
+	}
+	return l.bytes.wait(ctx, float64(size))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_tokenBucket_wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   float64
+		takes   []float64
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name:    "Take tokens within the burst",
+			rate:    10,
+			burst:   3,
+			takes:   []float64{1, 1, 1},
+			wantMin: 0,
+			wantMax: 50 * time.Millisecond,
+		},
+		{
+			name:    "Wait for tokens to be refilled",
+			rate:    100,
+			burst:   1,
+			takes:   []float64{1, 1, 1},
+			wantMin: 20 * time.Millisecond,
+			wantMax: 500 * time.Millisecond,
+		},
+		{
+			name:    "Take tokens more than the burst",
+			rate:    100,
+			burst:   1,
+			takes:   []float64{6},
+			wantMin: 50 * time.Millisecond,
+			wantMax: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTokenBucket(tt.rate, tt.burst)
+			start := time.Now()
+			for _, n := range tt.takes {
+				if err := b.wait(context.Background(), n); err != nil {
+					t.Fatalf("wait() error = %v", err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantMin || tt.wantMax < elapsed {
+				t.Errorf("wait() took %v, want %v to %v", elapsed, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func Test_tokenBucket_wait_noLimit(t *testing.T) {
+	var b *tokenBucket = newTokenBucket(0, 0)
+	if b != nil {
+		t.Fatalf("newTokenBucket() = %v, want nil", b)
+	}
+	if err := b.wait(context.Background(), 1e9); err != nil {
+		t.Errorf("wait() error = %v", err)
+	}
+}
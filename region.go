@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// imdsRegionTimeout is the timeout to get the region from IMDS, which is not reachable outside EC2.
+const imdsRegionTimeout = time.Second
+
+// regionFromIMDS returns the region of the EC2 instance with IMDSv2. It returns the empty string outside EC2,
+// or if IMDS is disabled by AWS_EC2_METADATA_DISABLED. It is a variable to be replaced in tests.
+var regionFromIMDS = func() string {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), imdsRegionTimeout)
+	defer cancel()
+	client := imds.New(imds.Options{Retryer: aws.NopRetryer{}})
+	out, err := client.GetRegion(ctx, &imds.GetRegionInput{})
+	if err != nil {
+		return ""
+	}
+	return out.Region
+}
+
+// noRegionError is returned when the region is not found in any sources, which the SDK reports only by failing API calls.
+type noRegionError struct {
+	profile string
+}
+
+func (e noRegionError) Error() string {
+	profile := e.profile
+	if profile == "" {
+		profile = "default"
+	}
+	return strings.Join([]string{
+		"config error: no region is configured. Set one of the following, such as --region us-east-1",
+		"--region",
+		"the ARN of --log-group or --dest",
+		"AWS_REGION or AWS_DEFAULT_REGION environment variables",
+		fmt.Sprintf("region of the profile %s in the config file (%s)", profile, sharedConfigFile()),
+		"the instance metadata (IMDS) of the EC2 instance",
+	}, "\n")
+}
+
+// sharedConfigFile returns the path of the config file of the AWS CLI, which has regions of profiles.
+func sharedConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	return config.DefaultSharedConfigFilename()
+}
+
+// resolveRegion sets the region of the EC2 instance to cfg if it is not given by the flags, the environment variables
+// or the profile. It returns noRegionError if the region is not found anyway.
+func resolveRegion(cfg *aws.Config) error {
+	if cfg.Region != "" {
+		return nil
+	}
+	if cfg.Region = regionFromIMDS(); cfg.Region != "" {
+		return nil
+	}
+	profile := ""
+	for _, source := range cfg.ConfigSources {
+		if shared, ok := source.(config.SharedConfig); ok {
+			profile = shared.Profile
+			break
+		}
+	}
+	return noRegionError{profile: profile}
+}
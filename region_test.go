@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_loadConfig_region(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		params     parameters
+		env        map[string]string
+		imdsRegion string
+		want       string
+		wantErr    bool
+	}{
+		{name: "Use --region", config: "[default]\nregion = us-east-1\n", params: parameters{region: "eu-west-1"}, want: "eu-west-1"},
+		{name: "Use AWS_DEFAULT_REGION", env: map[string]string{"AWS_DEFAULT_REGION": "ap-south-1"}, imdsRegion: "us-west-2", want: "ap-south-1"},
+		{name: "Use region of the profile", config: "[profile dev]\nregion = us-east-2\n", params: parameters{profile: "dev"}, imdsRegion: "us-west-2", want: "us-east-2"},
+		{name: "Use region of the EC2 instance", imdsRegion: "ap-northeast-1", want: "ap-northeast-1"},
+		{name: "Fail without region", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUpAWSConfig(t, tt.config)
+			t.Setenv("AWS_REGION", "")
+			t.Setenv("AWS_DEFAULT_REGION", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			defer func(f func() string) { regionFromIMDS = f }(regionFromIMDS)
+			regionFromIMDS = func() string { return tt.imdsRegion }
+
+			cfg, err := loadConfig(tt.params)
+			if tt.wantErr {
+				if !errors.As(err, &noRegionError{}) {
+					t.Errorf("loadConfig() error = %v, want noRegionError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if cfg.Region != tt.want {
+				t.Errorf("loadConfig() region = %v, want %v", cfg.Region, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// parseSpeed parses the speed such as 10x or 0.5.
+func parseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("argument error: --speed must be a positive number such as 10x, but got %s", s)
+	}
+	return speed, nil
+}
+
+// replayLogEvents calls flush with log events spaced according to their timestamps divided by speed, starting now.
+// The timestamps of log events are shifted to the time when they are flushed.
+// Log events due at the same time are flushed together. It stops when ctx is done.
+func replayLogEvents(ctx context.Context, events []types.InputLogEvent, speed float64, flush func(context.Context, []types.InputLogEvent) error) error {
+	if len(events) == 0 {
+		return nil
+	}
+	sorted := make([]types.InputLogEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return aws.ToInt64(sorted[i].Timestamp) < aws.ToInt64(sorted[j].Timestamp)
+	})
+
+	first := fromMillis(aws.ToInt64(sorted[0].Timestamp))
+	start := time.Now()
+	due := func(event types.InputLogEvent) time.Time {
+		offset := fromMillis(aws.ToInt64(event.Timestamp)).Sub(first)
+		return start.Add(time.Duration(float64(offset) / speed))
+	}
+
+	for len(sorted) > 0 {
+		timer := time.NewTimer(time.Until(due(sorted[0])))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		n := 0
+		for n < len(sorted) && !due(sorted[n]).After(now) {
+			n++
+		}
+		batch := make([]types.InputLogEvent, n)
+		for i, event := range sorted[:n] {
+			batch[i] = types.InputLogEvent{
+				Message:   event.Message,
+				Timestamp: aws.Int64(toMillis(due(event))),
+			}
+		}
+		if err := flush(ctx, batch); err != nil {
+			return err
+		}
+		sorted = sorted[n:]
+	}
+	return nil
+}
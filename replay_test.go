@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseSpeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{name: "Parse speed with x", s: "10x", want: 10},
+		{name: "Parse speed without x", s: "0.5", want: 0.5},
+		{name: "Parse zero speed", s: "0x", wantErr: true},
+		{name: "Parse invalid speed", s: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSpeed(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSpeed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseSpeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_replayLogEvents(t *testing.T) {
+	events := []types.InputLogEvent{
+		{Message: aws.String("[INFO] Start Server"), Timestamp: aws.Int64(0)},
+		{Message: aws.String("[ERROR] Failed to Start Server"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("[INFO] Retry"), Timestamp: aws.Int64(1000)},
+	}
+
+	start := time.Now()
+	got := [][]string{}
+	flushed := []int64{}
+	err := replayLogEvents(context.Background(), events, 10, func(_ context.Context, batch []types.InputLogEvent) error {
+		got = append(got, messages(batch))
+		flushed = append(flushed, aws.ToInt64(batch[0].Timestamp))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("replayLogEvents() error = %v", err)
+	}
+
+	want := [][]string{{"[INFO] Start Server"}, {"[ERROR] Failed to Start Server", "[INFO] Retry"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayLogEvents() = %v, want %v", got, want)
+	}
+	// 1 second between log events is replayed in 100 milliseconds from now
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("replayLogEvents() took %v, want 100ms or more", elapsed)
+	}
+	if len(flushed) == 2 && flushed[1]-flushed[0] != 100 {
+		t.Errorf("replayLogEvents() shifted timestamps %v, want 100ms between them", flushed)
+	}
+}
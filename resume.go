@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// getLastEventTimestamp returns the timestamp of the last log event in the log stream. It returns false if the log stream is empty.
+func getLastEventTimestamp(client *cloudwatchlogs.Client, logGroup, logStream string) (int64, bool, error) {
+	param := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		StartFromHead: aws.Bool(false),
+		Limit:         aws.Int32(1),
+	}
+	res, err := client.GetLogEvents(context.Background(), param)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res.Events) == 0 {
+		return 0, false, nil
+	}
+	return aws.ToInt64(res.Events[len(res.Events)-1].Timestamp), true, nil
+}
+
+// eventsAfter returns log events whose timestamps are after the timestamp.
+func eventsAfter(events []types.InputLogEvent, timestamp int64) []types.InputLogEvent {
+	after := []types.InputLogEvent{}
+	for _, event := range events {
+		if aws.ToInt64(event.Timestamp) > timestamp {
+			after = append(after, event)
+		}
+	}
+	return after
+}
+
+// putResumed puts log events after the last log event in the log stream of each uploader.
+func putResumed(ctx context.Context, uploaders []*uploader, events []types.InputLogEvent) {
+	for _, u := range uploaders {
+		last, ok, err := getLastEventTimestamp(u.client, u.dst.logGroup, u.dst.logStream)
+		if err != nil {
+			u.err = err
+			continue
+		}
+		pending := events
+		if ok {
+			pending = eventsAfter(events, last)
+			fmt.Printf("Skipped %d log events at or before %s in %s\n", len(events)-len(pending), fromMillis(last).Format(time.RFC3339Nano), u.dst)
+		}
+		u.err = u.put(ctx, pending)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_eventsAfter(t *testing.T) {
+	events := newTestEvents(3, "[INFO] Start Server", time.Second)
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		timestamp int64
+		want      int
+	}{
+		{name: "Skip log events at or before the timestamp", timestamp: toMillis(start.Add(time.Second)), want: 1},
+		{name: "Skip no log events", timestamp: toMillis(start) - 1, want: 3},
+		{name: "Skip all log events", timestamp: toMillis(start.Add(time.Hour)), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := eventsAfter(events, tt.timestamp)
+			if !reflect.DeepEqual(got, events[len(events)-tt.want:]) {
+				t.Errorf("eventsAfter() = %v, want the last %d log events", got, tt.want)
+			}
+		})
+	}
+}
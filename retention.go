@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// retentionDays are the numbers of days which CloudWatch Logs accepts as the retention.
+var retentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// validateRetentionDays checks the days are accepted as the retention before calling the API.
+func validateRetentionDays(flag string, days int) error {
+	for _, d := range retentionDays {
+		if days == d {
+			return nil
+		}
+	}
+	allowed := make([]string, len(retentionDays))
+	for i, d := range retentionDays {
+		allowed[i] = strconv.Itoa(d)
+	}
+	return fmt.Errorf("argument error: %s must be one of %s, but got %d", flag, strings.Join(allowed, ", "), days)
+}
+
+func putRetentionPolicy(client *cloudwatchlogs.Client, logGroup string, days int) error {
+	in := &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    aws.String(logGroup),
+		RetentionInDays: aws.Int32(int32(days)),
+	}
+	_, err := client.PutRetentionPolicy(context.Background(), in)
+	return err
+}
+
+func deleteRetentionPolicy(client *cloudwatchlogs.Client, logGroup string) error {
+	in := &cloudwatchlogs.DeleteRetentionPolicyInput{
+		LogGroupName: aws.String(logGroup),
+	}
+	_, err := client.DeleteRetentionPolicy(context.Background(), in)
+	return err
+}
+
+func runRetention(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	days := -1
+
+	flags := newSubcommandFlagSet(args[0], "Set the retention of log events in the log group.", "--log-group <LOG GROUP NAME> --days <DAYS> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.IntVar(&days, "days", -1, "The number of days to retain log events. 0 clears the retention, so log events never expire. It is required.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if days < 0 {
+		return errors.New("argument error: --days is required")
+	}
+	if days > 0 {
+		if err := validateRetentionDays("--days", days); err != nil {
+			return err
+		}
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	if days == 0 {
+		if err := deleteRetentionPolicy(client, logGroup); err != nil {
+			return err
+		}
+		fmt.Printf("Cleared retention of %s\n", logGroup)
+		return nil
+	}
+
+	if err := putRetentionPolicy(client, logGroup, days); err != nil {
+		return err
+	}
+	fmt.Printf("Set retention of %s to %d days\n", logGroup, days)
+	return nil
+}
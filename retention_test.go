@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_validateRetentionDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    int
+		wantErr bool
+	}{
+		{name: "Validate shortest retention", days: 1},
+		{name: "Validate two weeks retention", days: 14},
+		{name: "Validate longest retention", days: 3653},
+		{name: "Validate retention which is not allowed", days: 10, wantErr: true},
+		{name: "Validate zero retention", days: 0, wantErr: true},
+		{name: "Validate negative retention", days: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRetentionDays("--days", tt.days); (err != nil) != tt.wantErr {
+				t.Errorf("validateRetentionDays() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_runRetention(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, err := setUpLogGroup(cli)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	client := []string{"--endpoint-url", server.URL, "--region", "us-east-1"}
+
+	// The steps share the log group, so they run in order
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		want    int32
+	}{
+		{name: "Set retention without log group", args: []string{"--days", "14"}, wantErr: true},
+		{name: "Set retention without days", args: []string{"--log-group", logGroup}, wantErr: true},
+		{name: "Set retention with days which are not allowed", args: []string{"--log-group", logGroup, "--days", "10"}, wantErr: true},
+		{name: "Set retention of unknown log group", args: []string{"--log-group", "unknown", "--days", "14"}, wantErr: true},
+		{name: "Set retention", args: []string{"--log-group", logGroup, "--days", "14"}, want: 14},
+		{name: "Set retention with days which are not allowed after it is set", args: []string{"--log-group", logGroup, "--days", "-5"}, wantErr: true, want: 14},
+		{name: "Clear retention", args: []string{"--log-group", logGroup, "--days", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"retention"}, append(client, tt.args...)...)
+			if err := runRetention(args); (err != nil) != tt.wantErr {
+				t.Errorf("runRetention() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			out, err := cli.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String(logGroup)})
+			if err != nil {
+				t.Fatalf("failed to check result: %v", err)
+			}
+			if got := aws.ToInt32(out.LogGroups[0].RetentionInDays); got != tt.want {
+				t.Errorf("runRetention() retention = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// The modes of the SDK retryer
+const (
+	retryModeStandard = "standard"
+	retryModeAdaptive = "adaptive"
+)
+
+// The classes of errors to retry batches of log events
+const (
+	errorClassThrottling = "throttling"
+	errorClassServer     = "server"
+	errorClassNetwork    = "network"
+	errorClassClient     = "client"
+)
+
+// The delivery semantics of log events when it is unknown whether CloudWatch Logs stored a batch,
+// such as when PutLogEvents timed out after sending it
+const (
+	// deliveryAtLeastOnce retries the batch, which may duplicate log events
+	deliveryAtLeastOnce = "at-least-once"
+	// deliveryAtMostOnce abandons the batch, which may lose log events
+	deliveryAtMostOnce = "at-most-once"
+)
+
+const maxBatchRetryBackoff = 30 * time.Second
+
+// retryPolicy is how AWS API calls and batches of log events are retried.
+// The SDK retries each API call up to maxAttempts, and the uploader retries the batch which failed after them
+// up to the attempts for the error class in batchAttempts, or for maxElapsedTime if it is not zero.
+// With deliveryAtMostOnce, neither of them retries PutLogEvents after ambiguous failures.
+type retryPolicy struct {
+	maxAttempts    int
+	mode           string
+	maxElapsedTime time.Duration
+	batchAttempts  map[string]int
+	delivery       string
+}
+
+func (p retryPolicy) validate() error {
+	if p.mode != "" && p.mode != retryModeStandard && p.mode != retryModeAdaptive {
+		return fmt.Errorf("argument error: --retry-mode must be %s or %s, but got %s", retryModeStandard, retryModeAdaptive, p.mode)
+	}
+	if p.delivery != "" && p.delivery != deliveryAtLeastOnce && p.delivery != deliveryAtMostOnce {
+		return fmt.Errorf("argument error: --delivery must be %s or %s, but got %s", deliveryAtLeastOnce, deliveryAtMostOnce, p.delivery)
+	}
+	if p.maxAttempts < 0 {
+		return fmt.Errorf("argument error: --max-attempts must be positive, but got %d", p.maxAttempts)
+	}
+	if p.maxElapsedTime < 0 {
+		return fmt.Errorf("argument error: --max-elapsed-time must be positive, but got %s", p.maxElapsedTime)
+	}
+	return nil
+}
+
+// parseRetryOn parses the attempts for each error class, such as "throttling=10,server=5".
+func parseRetryOn(s string) (map[string]int, error) {
+	attempts := map[string]int{}
+	if s == "" {
+		return attempts, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		class, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("argument error: --retry-on must be CLASS=ATTEMPTS such as throttling=10, but got %s", item)
+		}
+		switch class {
+		case errorClassThrottling, errorClassServer, errorClassNetwork, errorClassClient:
+		default:
+			return nil, fmt.Errorf("argument error: --retry-on class must be %s, %s, %s or %s, but got %s", errorClassThrottling, errorClassServer, errorClassNetwork, errorClassClient, class)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("argument error: --retry-on attempts must be a positive integer, but got %s", item)
+		}
+		attempts[class] = n
+	}
+	return attempts, nil
+}
+
+// formatRetryOn formats the attempts for each error class in the same way as parseRetryOn parses them.
+func formatRetryOn(attempts map[string]int) string {
+	items := []string{}
+	for class, n := range attempts {
+		items = append(items, fmt.Sprintf("%s=%d", class, n))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// retryConfig is the retry policy in the file given to --retry-config.
+type retryConfig struct {
+	MaxAttempts    *int           `json:"maxAttempts"`
+	Mode           *string        `json:"mode"`
+	MaxElapsedTime *string        `json:"maxElapsedTime"`
+	RetryOn        map[string]int `json:"retryOn"`
+	Delivery       *string        `json:"delivery"`
+}
+
+// loadRetryConfig loads the retry policy in the file. The options set by flags override the ones in the file.
+func loadRetryConfig(name string, policy retryPolicy, setFlags map[string]bool) (retryPolicy, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return retryPolicy{}, err
+	}
+	cfg := retryConfig{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return retryPolicy{}, fmt.Errorf("argument error: --retry-config %s is not valid: %w", name, err)
+	}
+
+	if cfg.MaxAttempts != nil && !setFlags["max-attempts"] {
+		policy.maxAttempts = *cfg.MaxAttempts
+	}
+	if cfg.Mode != nil && !setFlags["retry-mode"] {
+		policy.mode = *cfg.Mode
+	}
+	if cfg.MaxElapsedTime != nil && !setFlags["max-elapsed-time"] {
+		if policy.maxElapsedTime, err = time.ParseDuration(*cfg.MaxElapsedTime); err != nil {
+			return retryPolicy{}, fmt.Errorf("argument error: --retry-config %s has invalid maxElapsedTime: %w", name, err)
+		}
+	}
+	if cfg.RetryOn != nil && !setFlags["retry-on"] {
+		// The attempts are validated in the same way as --retry-on
+		if policy.batchAttempts, err = parseRetryOn(formatRetryOn(cfg.RetryOn)); err != nil {
+			return retryPolicy{}, err
+		}
+	}
+	if cfg.Delivery != nil && !setFlags["delivery"] {
+		policy.delivery = *cfg.Delivery
+	}
+	return policy, nil
+}
+
+// errorClass returns the class of the error returned by an AWS API call.
+func errorClass(err error) string {
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool() {
+		return errorClassThrottling
+	}
+	// The SDK wraps errors without responses in ResponseError, too
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() != 0 {
+		if responseErr.HTTPStatusCode() >= 500 {
+			return errorClassServer
+		}
+		return errorClassClient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || (retry.RetryableConnectionError{}).IsErrorRetryable(err).Bool() {
+		return errorClassNetwork
+	}
+	return errorClassClient
+}
+
+// isAmbiguousError reports whether the call may have been received by CloudWatch Logs although it failed,
+// such as when it timed out or the connection was closed after sending the request.
+// Errors with responses and failures to connect are not ambiguous.
+func isAmbiguousError(err error) bool {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() != 0 {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	return errors.Is(err, context.DeadlineExceeded) || errorClass(err) == errorClassNetwork
+}
+
+// atMostOnceRetryer is the retryer of PutLogEvents which does not retry calls after ambiguous failures.
+type atMostOnceRetryer struct {
+	aws.RetryerV2
+}
+
+func (r atMostOnceRetryer) IsErrorRetryable(err error) bool {
+	return !isAmbiguousError(err) && r.RetryerV2.IsErrorRetryable(err)
+}
+
+// putRetryer returns the retryer of PutLogEvents for the delivery semantics.
+func (p retryPolicy) putRetryer(r aws.Retryer) aws.Retryer {
+	if p.delivery != deliveryAtMostOnce {
+		return r
+	}
+	if v2, ok := r.(aws.RetryerV2); ok {
+		return atMostOnceRetryer{v2}
+	}
+	return aws.NopRetryer{}
+}
+
+// shouldRetryBatch reports whether the batch which failed with err after the attempts should be retried.
+// It is not retried after ctx of the upload is done, but a call timed out by --batch-timeout is an ambiguous failure.
+// Without the attempts for the error class, errors other than client errors are retried until maxElapsedTime passes.
+func (p retryPolicy) shouldRetryBatch(ctx context.Context, err error, attempts int, elapsed time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if p.delivery == deliveryAtMostOnce && isAmbiguousError(err) {
+		return false
+	}
+	if p.maxElapsedTime > 0 && elapsed >= p.maxElapsedTime {
+		return false
+	}
+	class := errorClass(err)
+	if n, ok := p.batchAttempts[class]; ok {
+		return attempts < n
+	}
+	return p.maxElapsedTime > 0 && class != errorClassClient
+}
+
+// batchBackoff returns the time to wait before the next attempt to put the batch.
+func batchBackoff(attempts int) time.Duration {
+	backoff := time.Second << (attempts - 1)
+	if backoff <= 0 || backoff > maxBatchRetryBackoff {
+		return maxBatchRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func Test_parseRetryOn(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[string]int
+		wantErr bool
+	}{
+		{name: "Parse no classes", s: "", want: map[string]int{}},
+		{name: "Parse classes", s: "throttling=10, server=5", want: map[string]int{"throttling": 10, "server": 5}},
+		{name: "Parse unknown class", s: "timeout=3", wantErr: true},
+		{name: "Parse invalid attempts", s: "server=0", wantErr: true},
+		{name: "Parse no attempts", s: "server", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRetryOn(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRetryOn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRetryOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func responseError(status int, err error) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      err,
+		},
+	}
+}
+
+func Test_errorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "Throttling", err: responseError(400, &types.ThrottlingException{}), want: errorClassThrottling},
+		{name: "Server error", err: responseError(503, &types.ServiceUnavailableException{}), want: errorClassServer},
+		{name: "Client error", err: responseError(400, &types.InvalidParameterException{}), want: errorClassClient},
+		{name: "Network error", err: timeoutError{}, want: errorClassNetwork},
+		{name: "Network error without response", err: responseError(0, timeoutError{}), want: errorClassNetwork},
+		{name: "Wrapped server error", err: fmt.Errorf("put error: %w", responseError(500, errors.New("internal"))), want: errorClassServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.want {
+				t.Errorf("errorClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a timeout error of the network.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func Test_retryPolicy_shouldRetryBatch(t *testing.T) {
+	throttled := responseError(400, &types.ThrottlingException{})
+	invalid := responseError(400, &types.InvalidParameterException{})
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		done     bool
+		err      error
+		attempts int
+		elapsed  time.Duration
+		want     bool
+	}{
+		{name: "Don't retry by default", policy: retryPolicy{}, err: throttled, attempts: 1, want: false},
+		{name: "Retry within attempts for the class", policy: retryPolicy{batchAttempts: map[string]int{"throttling": 3}}, err: throttled, attempts: 2, want: true},
+		{name: "Don't retry after attempts for the class", policy: retryPolicy{batchAttempts: map[string]int{"throttling": 3}}, err: throttled, attempts: 3, want: false},
+		{name: "Retry within elapsed time", policy: retryPolicy{maxElapsedTime: time.Minute}, err: throttled, attempts: 10, elapsed: 30 * time.Second, want: true},
+		{name: "Don't retry after elapsed time", policy: retryPolicy{maxElapsedTime: time.Minute}, err: throttled, attempts: 2, elapsed: time.Minute, want: false},
+		{name: "Don't retry client error within elapsed time", policy: retryPolicy{maxElapsedTime: time.Minute}, err: invalid, attempts: 1, want: false},
+		{name: "Retry client error with attempts for the class", policy: retryPolicy{batchAttempts: map[string]int{"client": 2}}, err: invalid, attempts: 1, want: true},
+		{name: "Don't retry canceled call", policy: retryPolicy{maxElapsedTime: time.Minute}, err: context.Canceled, attempts: 1, want: false},
+		{name: "Retry ambiguous failure at least once", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtLeastOnce}, err: timeoutError{}, attempts: 1, want: true},
+		{name: "Don't retry ambiguous failure at most once", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtMostOnce}, err: timeoutError{}, attempts: 1, want: false},
+		{name: "Retry throttled call at most once", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtMostOnce}, err: throttled, attempts: 1, want: true},
+		{name: "Retry call timed out by batch timeout at least once", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtLeastOnce}, err: context.DeadlineExceeded, attempts: 1, want: true},
+		{name: "Don't retry call timed out by batch timeout at most once", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtMostOnce}, err: context.DeadlineExceeded, attempts: 1, want: false},
+		{name: "Don't retry after the upload is done", policy: retryPolicy{maxElapsedTime: time.Minute, delivery: deliveryAtLeastOnce}, done: true, err: context.DeadlineExceeded, attempts: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.done {
+				cancel()
+			}
+			if got := tt.policy.shouldRetryBatch(ctx, tt.err, tt.attempts, tt.elapsed); got != tt.want {
+				t.Errorf("retryPolicy.shouldRetryBatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isAmbiguousError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Timeout after sending the request", err: timeoutError{}, want: true},
+		{name: "Deadline exceeded", err: fmt.Errorf("put error: %w", context.DeadlineExceeded), want: true},
+		{name: "Server error", err: responseError(503, &types.ServiceUnavailableException{}), want: false},
+		{name: "Failure to connect", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: false},
+		{name: "Connection reset", err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAmbiguousError(tt.err); got != tt.want {
+				t.Errorf("isAmbiguousError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_uploader_putBatch_delivery(t *testing.T) {
+	tests := []struct {
+		name      string
+		delivery  string
+		wantCalls int
+	}{
+		{name: "Put again after ambiguous failures at least once", delivery: deliveryAtLeastOnce, wantCalls: 3},
+		{name: "Don't put again after ambiguous failures at most once", delivery: deliveryAtMostOnce, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				// The connection is closed after the request is received, so its result is unknown
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Fatalf("failed to hijack the connection: %v", err)
+				}
+				conn.Close()
+			}))
+			defer server.Close()
+			client, err := newClient(parameters{endpointURL: server.URL, region: "us-east-1"}, destination{})
+			if err != nil {
+				t.Fatalf("newClient() error = %v", err)
+			}
+			client = cloudwatchlogs.New(client.Options(), func(o *cloudwatchlogs.Options) {
+				o.Retryer = retry.NewStandard(func(o *retry.StandardOptions) {
+					o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+				})
+			})
+			u := &uploader{
+				client:       client,
+				dst:          destination{logGroup: "group", logStream: "stream"},
+				limits:       defaultBatchLimits,
+				withoutToken: true,
+				retry:        retryPolicy{delivery: tt.delivery},
+			}
+			err = u.putBatch(context.Background(), []types.InputLogEvent{{Message: aws.String("log"), Timestamp: aws.Int64(1)}})
+			if err == nil {
+				t.Fatal("uploader.putBatch() error = nil, want the error")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("PutLogEvents is called %d times, want %d", calls, tt.wantCalls)
+			}
+			if u.ambiguous != 1 {
+				t.Errorf("uploader.ambiguous = %d, want 1", u.ambiguous)
+			}
+		})
+	}
+}
+
+func Test_loadRetryConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "retry.json")
+	data := `{"maxAttempts": 5, "mode": "adaptive", "maxElapsedTime": "10m", "retryOn": {"throttling": 10}, "delivery": "at-most-once"}`
+	if err := ioutil.WriteFile(name, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		setFlags map[string]bool
+		want     retryPolicy
+	}{
+		{
+			name:   "Load retry policy",
+			policy: retryPolicy{mode: retryModeStandard, delivery: deliveryAtLeastOnce},
+			want:   retryPolicy{maxAttempts: 5, mode: retryModeAdaptive, maxElapsedTime: 10 * time.Minute, batchAttempts: map[string]int{"throttling": 10}, delivery: deliveryAtMostOnce},
+		},
+		{
+			name:     "Override retry policy by flags",
+			policy:   retryPolicy{maxAttempts: 2, mode: retryModeStandard, batchAttempts: map[string]int{"server": 3}, delivery: deliveryAtLeastOnce},
+			setFlags: map[string]bool{"max-attempts": true, "retry-on": true, "delivery": true},
+			want:     retryPolicy{maxAttempts: 2, mode: retryModeAdaptive, maxElapsedTime: 10 * time.Minute, batchAttempts: map[string]int{"server": 3}, delivery: deliveryAtLeastOnce},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadRetryConfig(name, tt.policy, tt.setFlags)
+			if err != nil {
+				t.Fatalf("loadRetryConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadRetryConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// routeComparison is the short form of a condition on a field of JSON log events, such as level=="error".
+var routeComparison = regexp.MustCompile(`^([A-Za-z_][\w.]*)\s*(==|!=)\s*(.+)$`)
+
+// routeRule routes log events matching the condition to the destination.
+type routeRule struct {
+	condition string
+	pattern   filterPattern
+	dst       destination
+}
+
+// parseRouteCondition parses the condition of the route. It is a filter pattern of CloudWatch Logs,
+// or the short form like level=="error", which is the same as { $.level = "error" }.
+func parseRouteCondition(condition string) (filterPattern, error) {
+	if m := routeComparison.FindStringSubmatch(condition); m != nil {
+		op := "="
+		if m[2] == "!=" {
+			op = "!="
+		}
+		condition = fmt.Sprintf("{ $.%s %s %s }", m[1], op, m[3])
+	}
+	p, kind, err := parseFilterPattern(condition)
+	if err != nil {
+		return nil, err
+	}
+	if kind == patternMatchAll {
+		return nil, fmt.Errorf("the condition is empty")
+	}
+	return p, nil
+}
+
+// parseRouteRule parses the route like CONDITION=>LOG GROUP[:LOG STREAM]. The destination can be the ARN of a log group.
+func parseRouteRule(v string) (routeRule, error) {
+	i := strings.LastIndex(v, "=>")
+	if i < 0 || strings.TrimSpace(v[:i]) == "" || strings.TrimSpace(v[i+2:]) == "" {
+		return routeRule{}, fmt.Errorf(`argument error: --route must be like 'level=="error" => /app/errors:errors-{date}', but got %q`, v)
+	}
+	r := routeRule{condition: strings.TrimSpace(v[:i])}
+	var err error
+	if r.pattern, err = parseRouteCondition(r.condition); err != nil {
+		return routeRule{}, fmt.Errorf("argument error: --route has the invalid condition %q: %w", r.condition, err)
+	}
+	if r.dst, err = parseTarget(strings.TrimSpace(v[i+2:])); err != nil {
+		return routeRule{}, err
+	}
+	if r.dst.logGroup == "" {
+		return routeRule{}, fmt.Errorf("argument error: --route must have the log group, but got %q", v)
+	}
+	return r, nil
+}
+
+// expand returns the destination whose {date} is replaced with the current date.
+func (r routeRule) expand(now time.Time) destination {
+	date := now.UTC().Format("2006-01-02")
+	dst := r.dst
+	dst.logGroup = strings.ReplaceAll(dst.logGroup, datePlaceholder, date)
+	dst.logStream = strings.ReplaceAll(dst.logStream, datePlaceholder, date)
+	return dst
+}
+
+// routeFlag adds a route each time --route is given. @name adds the routes named in the config file.
+type routeFlag struct {
+	routes *[]routeRule
+}
+
+func (f routeFlag) String() string {
+	if f.routes == nil {
+		return ""
+	}
+	routes := make([]string, len(*f.routes))
+	for i, r := range *f.routes {
+		routes[i] = r.condition + "=>" + r.dst.String()
+	}
+	return strings.Join(routes, ",")
+}
+
+func (f routeFlag) Set(v string) error {
+	rules := []string{v}
+	if strings.HasPrefix(v, aliasPrefix) {
+		var err error
+		if rules, err = loadRoutes(strings.TrimPrefix(v, aliasPrefix)); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		r, err := parseRouteRule(rule)
+		if err != nil {
+			return err
+		}
+		*f.routes = append(*f.routes, r)
+	}
+	return nil
+}
+
+// loadRoutes returns the routes named in the config file.
+func loadRoutes(name string) ([]string, error) {
+	path, err := defaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	c, err := loadUserConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, ok := c.Routes[name]
+	if !ok {
+		return nil, fmt.Errorf("argument error: routes %s are not found in the routes of the config file", name)
+	}
+	return rules, nil
+}
+
+// routeLogEvents sends each log event to the destination of the first route it matches, and others to dsts.
+// It returns the destinations which have log events and their log events in the same way as --map,
+// and the number of log events dropped because they match no routes and dsts is empty.
+func routeLogEvents(events []types.InputLogEvent, routes []routeRule, dsts []destination, now time.Time) ([]destination, [][]types.InputLogEvent, int) {
+	routed := []destination{}
+	mapped := [][]types.InputLogEvent{}
+	indexes := map[destination]int{}
+	add := func(dst destination) int {
+		i, ok := indexes[dst]
+		if !ok {
+			i = len(routed)
+			indexes[dst] = i
+			routed = append(routed, dst)
+			mapped = append(mapped, nil)
+		}
+		return i
+	}
+	for _, dst := range dsts {
+		add(dst)
+	}
+	targets := make([]int, len(routes))
+	for i, r := range routes {
+		targets[i] = add(r.expand(now))
+	}
+
+	dropped := 0
+	for _, event := range events {
+		matched := -1
+		for i, r := range routes {
+			if r.pattern.match(aws.ToString(event.Message)) {
+				matched = targets[i]
+				break
+			}
+		}
+		if matched >= 0 {
+			mapped[matched] = append(mapped[matched], event)
+			continue
+		}
+		if len(dsts) == 0 {
+			dropped++
+		}
+		for _, dst := range dsts {
+			i := indexes[dst]
+			mapped[i] = append(mapped[i], event)
+		}
+	}
+
+	// Log streams are not created for destinations without log events
+	nonEmpty := []destination{}
+	nonEmptyEvents := [][]types.InputLogEvent{}
+	for i, dst := range routed {
+		if len(mapped[i]) > 0 {
+			nonEmpty = append(nonEmpty, dst)
+			nonEmptyEvents = append(nonEmptyEvents, mapped[i])
+		}
+	}
+	return nonEmpty, nonEmptyEvents, dropped
+}
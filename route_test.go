@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseRouteRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       string
+		message string
+		want    destination
+		wantErr bool
+	}{
+		{
+			name:    "Parse route with comparison",
+			v:       `level=="error" => /app/errors:errors-{date}`,
+			message: `{"level": "error"}`,
+			want:    destination{logGroup: "/app/errors", logStream: "errors-{date}"},
+		},
+		{
+			name:    "Parse route with negative comparison",
+			v:       `level!="debug"=>/app/logs`,
+			message: `{"level": "info"}`,
+			want:    destination{logGroup: "/app/logs"},
+		},
+		{
+			name:    "Parse route with filter pattern",
+			v:       `{ $.latency >= 1000 } => arn:aws:logs:eu-west-1:123456789012:log-group:/app/slow:log-stream:slow`,
+			message: `{"latency": 1500}`,
+			want:    destination{logGroup: "/app/slow", logStream: "slow", region: "eu-west-1", account: "123456789012"},
+		},
+		{name: "Parse route without separator", v: `level=="error" /app/errors`, wantErr: true},
+		{name: "Parse route without condition", v: ` => /app/errors`, wantErr: true},
+		{name: "Parse route with invalid condition", v: `{ $.level = => /app/errors`, wantErr: true},
+		{name: "Parse route without log group", v: `level=="error" => :errors`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRouteRule(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRouteRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.dst, tt.want) {
+				t.Errorf("parseRouteRule() = %v, want %v", got.dst, tt.want)
+			}
+			if !got.pattern.match(tt.message) {
+				t.Errorf("parseRouteRule() does not match %s", tt.message)
+			}
+		})
+	}
+}
+
+func Test_routeLogEvents(t *testing.T) {
+	event := func(message string) types.InputLogEvent {
+		return types.InputLogEvent{Timestamp: aws.Int64(1), Message: aws.String(message)}
+	}
+	events := []types.InputLogEvent{
+		event(`{"level": "info", "msg": "Start Server"}`),
+		event(`{"level": "error", "msg": "Failed to Connect DB"}`),
+		event(`{"level": "warn", "msg": "Slow Query"}`),
+		event(`{"level": "error", "msg": "Stop Server"}`),
+	}
+	route := func(v string) routeRule {
+		r, err := parseRouteRule(v)
+		if err != nil {
+			t.Fatalf("failed to set up: %v", err)
+		}
+		return r
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		routes      []routeRule
+		dsts        []destination
+		want        []destination
+		wantEvents  [][]types.InputLogEvent
+		wantDropped int
+	}{
+		{
+			name:   "Route log events and others to destinations",
+			routes: []routeRule{route(`level=="error" => /app/errors:errors-{date}`), route(`level=="warn" => /app/warnings`)},
+			dsts:   []destination{{logGroup: "/app/all", logStream: "all"}},
+			want: []destination{
+				{logGroup: "/app/all", logStream: "all"},
+				{logGroup: "/app/errors", logStream: "errors-2024-01-02"},
+				{logGroup: "/app/warnings"},
+			},
+			wantEvents: [][]types.InputLogEvent{{events[0]}, {events[1], events[3]}, {events[2]}},
+		},
+		{
+			name:       "Route log events with the first matching route",
+			routes:     []routeRule{route(`level!="info" => /app/alerts`), route(`level=="error" => /app/errors`)},
+			dsts:       []destination{{logGroup: "/app/all"}},
+			want:       []destination{{logGroup: "/app/all"}, {logGroup: "/app/alerts"}},
+			wantEvents: [][]types.InputLogEvent{{events[0]}, {events[1], events[2], events[3]}},
+		},
+		{
+			name:        "Drop log events matching no routes without destinations",
+			routes:      []routeRule{route(`level=="error" => /app/errors`)},
+			want:        []destination{{logGroup: "/app/errors"}},
+			wantEvents:  [][]types.InputLogEvent{{events[1], events[3]}},
+			wantDropped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotEvents, gotDropped := routeLogEvents(events, tt.routes, tt.dsts, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("routeLogEvents() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotEvents, tt.wantEvents) {
+				t.Errorf("routeLogEvents() events = %v, want %v", gotEvents, tt.wantEvents)
+			}
+			if gotDropped != tt.wantDropped {
+				t.Errorf("routeLogEvents() dropped = %d, want %d", gotDropped, tt.wantDropped)
+			}
+		})
+	}
+}
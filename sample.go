@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// sampler keeps log events with the probability of rate.
+// If key is given, log events are kept or dropped together by the value of the key in JSON log events.
+type sampler struct {
+	rate float64
+	key  string
+	rand *rand.Rand
+}
+
+func newSampler(rate float64, key string, r *rand.Rand) *sampler {
+	return &sampler{rate: rate, key: key, rand: r}
+}
+
+func (s *sampler) keep(event types.InputLogEvent) bool {
+	if s.rate >= 1 {
+		return true
+	}
+	if value, ok := jsonFieldValue(aws.ToString(event.Message), s.key); ok {
+		// The same value always gets the same hash, so log events sharing it are kept or dropped together
+		h := fnv.New64a()
+		h.Write([]byte(value))
+		return float64(h.Sum64())/math.MaxUint64 < s.rate
+	}
+	return s.rand.Float64() < s.rate
+}
+
+// jsonFieldValue returns the value of the key in the JSON log event as a string.
+func jsonFieldValue(message, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(message), &fields); err != nil {
+		return "", false
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// sampleLogEvents returns log events kept by the sampler.
+func sampleLogEvents(events []types.InputLogEvent, s *sampler) []types.InputLogEvent {
+	sampled := []types.InputLogEvent{}
+	for _, event := range events {
+		if s.keep(event) {
+			sampled = append(sampled, event)
+		}
+	}
+	return sampled
+}
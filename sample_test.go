@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_sampler_keep(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		messages []string
+		wantMin  int
+		wantMax  int
+	}{
+		{
+			name:     "Keep all log events",
+			rate:     1,
+			messages: repeatMessages("[DEBUG] Retry", 1000),
+			wantMin:  1000,
+			wantMax:  1000,
+		},
+		{
+			name:     "Keep log events randomly",
+			rate:     0.1,
+			messages: repeatMessages("[DEBUG] Retry", 1000),
+			wantMin:  50,
+			wantMax:  150,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSampler(tt.rate, "", rand.New(rand.NewSource(1)))
+			got := 0
+			for _, message := range tt.messages {
+				if s.keep(types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(0)}) {
+					got++
+				}
+			}
+			if got < tt.wantMin || tt.wantMax < got {
+				t.Errorf("keep() kept %d log events, want %d to %d", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func Test_sampler_keep_key(t *testing.T) {
+	s := newSampler(0.5, "request_id", rand.New(rand.NewSource(1)))
+	for i := 0; i < 100; i++ {
+		message := fmt.Sprintf(`{"request_id":"%d","message":"Retry"}`, i)
+		kept := s.keep(types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(0)})
+		for j := 0; j < 10; j++ {
+			if got := s.keep(types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(0)}); got != kept {
+				t.Errorf("keep(%s) = %v, want %v as the other log events sharing the key", message, got, kept)
+			}
+		}
+	}
+}
+
+func Test_jsonFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		key     string
+		want    string
+		wantOK  bool
+	}{
+		{name: "Get the string value", message: `{"request_id":"abc"}`, key: "request_id", want: "abc", wantOK: true},
+		{name: "Get the number value", message: `{"user":42}`, key: "user", want: "42", wantOK: true},
+		{name: "Get no value without the key", message: `{"user":42}`, key: "request_id", wantOK: false},
+		{name: "Get no value from a string log", message: "[INFO] Start Server", key: "request_id", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jsonFieldValue(tt.message, tt.key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("jsonFieldValue() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func repeatMessages(message string, n int) []string {
+	messages := make([]string, n)
+	for i := range messages {
+		messages[i] = message
+	}
+	return messages
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// The behaviors when a log event does not match the schema
+const (
+	schemaErrorDrop       = "drop"
+	schemaErrorFail       = "fail"
+	schemaErrorDeadLetter = "dead-letter"
+)
+
+func validateOnSchemaError(onSchemaError string) error {
+	switch onSchemaError {
+	case schemaErrorDrop, schemaErrorFail, schemaErrorDeadLetter:
+		return nil
+	}
+	return fmt.Errorf("argument error: --on-schema-error must be %s, %s or %s, but got %s", schemaErrorDrop, schemaErrorFail, schemaErrorDeadLetter, onSchemaError)
+}
+
+// schemaValidator validates JSON log events against the JSON Schema. The nil schemaValidator accepts all log events.
+type schemaValidator struct {
+	schema        *jsonschema.Schema
+	onSchemaError string
+	deadLetters   *deadLetterFile
+}
+
+// newSchemaValidator compiles the JSON Schema in the file. If name is empty, it returns nil.
+func newSchemaValidator(name, onSchemaError string, deadLetters *deadLetterFile) (*schemaValidator, error) {
+	if name == "" {
+		return nil, nil
+	}
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("schema error: %s is not valid JSON Schema: %w", name, err)
+	}
+	return &schemaValidator{schema: schema, onSchemaError: onSchemaError, deadLetters: deadLetters}, nil
+}
+
+// validate returns the error if the message of the log event is not JSON or does not match the schema.
+func (v *schemaValidator) validate(event types.InputLogEvent) error {
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(aws.ToString(event.Message)))
+	if err != nil {
+		return fmt.Errorf("log event is not JSON: %w", err)
+	}
+	if err := v.schema.Validate(instance); err != nil {
+		// The first line has the location of the schema, and the details are in the following lines
+		details := strings.Split(err.Error(), "\n")
+		if len(details) > 1 {
+			details = details[1:]
+		}
+		for i, detail := range details {
+			details[i] = strings.TrimPrefix(strings.TrimSpace(detail), "- ")
+		}
+		return fmt.Errorf("log event does not match the schema: %s", strings.Join(details, ", "))
+	}
+	return nil
+}
+
+// keep reports whether the log event is uploaded. It returns the error if the log event does not match the schema
+// and --on-schema-error is fail. Otherwise the log event is dropped or written to the dead-letter file.
+func (v *schemaValidator) keep(event types.InputLogEvent) (bool, error) {
+	if v == nil {
+		return true, nil
+	}
+	err := v.validate(event)
+	if err == nil {
+		return true, nil
+	}
+	switch v.onSchemaError {
+	case schemaErrorFail:
+		return false, fmt.Errorf("schema error: %w: %s", err, aws.ToString(event.Message))
+	case schemaErrorDeadLetter:
+		v.deadLetters.writeInvalid(event, err)
+	default:
+		fmt.Fprintf(os.Stderr, "schema error: dropped the log event because %v: %s\n", err, aws.ToString(event.Message))
+	}
+	return false, nil
+}
+
+// filter returns log events which match the schema.
+func (v *schemaValidator) filter(events []types.InputLogEvent) ([]types.InputLogEvent, error) {
+	if v == nil {
+		return events, nil
+	}
+	kept := make([]types.InputLogEvent, 0, len(events))
+	for _, event := range events {
+		ok, err := v.keep(event)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, event)
+		}
+	}
+	return kept, nil
+}
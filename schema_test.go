@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_schemaValidator_filter(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	schema := `{
+		"type": "object",
+		"properties": {"level": {"enum": ["INFO", "ERROR"]}, "message": {"type": "string"}},
+		"required": ["level", "message"]
+	}`
+	if err := ioutil.WriteFile(schemaFile, []byte(schema), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := types.InputLogEvent{Message: aws.String(`{"level":"INFO","message":"Start Server"}`), Timestamp: aws.Int64(1)}
+	events := []types.InputLogEvent{
+		valid,
+		{Message: aws.String(`{"level":"DEBUG","message":"Connect DB"}`), Timestamp: aws.Int64(2)},
+		{Message: aws.String(`Stop Server`), Timestamp: aws.Int64(3)},
+	}
+	tests := []struct {
+		name           string
+		onSchemaError  string
+		want           []types.InputLogEvent
+		wantErr        bool
+		wantDeadLetter int
+	}{
+		{name: "Drop invalid log events", onSchemaError: schemaErrorDrop, want: []types.InputLogEvent{valid}},
+		{name: "Fail on invalid log events", onSchemaError: schemaErrorFail, wantErr: true},
+		{name: "Write invalid log events to dead-letter file", onSchemaError: schemaErrorDeadLetter, want: []types.InputLogEvent{valid}, wantDeadLetter: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "dead.ndjson")
+			deadLetters, err := openDeadLetterFile(name)
+			if err != nil {
+				t.Fatalf("openDeadLetterFile() error = %v", err)
+			}
+			v, err := newSchemaValidator(schemaFile, tt.onSchemaError, deadLetters)
+			if err != nil {
+				t.Fatalf("newSchemaValidator() error = %v", err)
+			}
+
+			got, err := v.filter(events)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("schemaValidator.filter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("schemaValidator.filter() = %v, want %v", got, tt.want)
+			}
+
+			if err := deadLetters.close(); err != nil {
+				t.Fatalf("deadLetterFile.close() error = %v", err)
+			}
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(data), `"error":`); n != tt.wantDeadLetter {
+				t.Errorf("schemaValidator.filter() wrote %d log events to dead-letter file, want %d", n, tt.wantDeadLetter)
+			}
+		})
+	}
+}
+
+func Test_newSchemaValidator(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "schema.json")
+	if err := ioutil.WriteFile(name, []byte(`{"type": "unknown"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newSchemaValidator(name, schemaErrorFail, nil); err == nil {
+		t.Errorf("newSchemaValidator() error = nil, want the error of invalid schema")
+	}
+	if v, err := newSchemaValidator("", schemaErrorFail, nil); v != nil || err != nil {
+		t.Errorf("newSchemaValidator() = %v, %v, want nil, nil", v, err)
+	}
+}
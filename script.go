@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// scriptFunction is the function of the script called for each log event.
+const scriptFunction = "transform"
+
+// scriptTransform calls the transform function of the Starlark script for each log event.
+// The function gets the log event as a dict with timestamp (milliseconds), message and fields, which is the parsed message
+// if it is a JSON object or None. It returns the log event, a list of log events, or None to drop the log event.
+// The nil scriptTransform does not change log events.
+type scriptTransform struct {
+	// mu serializes calls because the Starlark thread is not safe for concurrent use
+	mu     sync.Mutex
+	thread *starlark.Thread
+	fn     *starlark.Function
+}
+
+// newScriptTransform loads the script. If name is empty, it returns nil.
+func newScriptTransform(name string) (*scriptTransform, error) {
+	if name == "" {
+		return nil, nil
+	}
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+	predeclared := starlark.StringDict{"json": starlarkjson.Module}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, name, nil, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("script error: failed to load %s: %w", name, err)
+	}
+	fn, ok := globals[scriptFunction].(*starlark.Function)
+	if !ok || fn.NumParams() != 1 {
+		return nil, fmt.Errorf("script error: %s must define the function %s(event)", name, scriptFunction)
+	}
+	return &scriptTransform{thread: thread, fn: fn}, nil
+}
+
+func callJSON(thread *starlark.Thread, name string, arg starlark.Value) (starlark.Value, error) {
+	return starlark.Call(thread, starlarkjson.Module.Members[name], starlark.Tuple{arg}, nil)
+}
+
+// eventValue converts the log event into the dict passed to the script.
+func (s *scriptTransform) eventValue(event types.InputLogEvent) (*starlark.Dict, error) {
+	message := aws.ToString(event.Message)
+	var fields starlark.Value = starlark.None
+	if _, ok := parseJSONObject([]byte(message)); ok {
+		v, err := callJSON(s.thread, "decode", starlark.String(message))
+		if err != nil {
+			return nil, err
+		}
+		fields = v
+	}
+	d := starlark.NewDict(3)
+	d.SetKey(starlark.String("timestamp"), starlark.MakeInt64(aws.ToInt64(event.Timestamp)))
+	d.SetKey(starlark.String("message"), starlark.String(message))
+	d.SetKey(starlark.String("fields"), fields)
+	return d, nil
+}
+
+// logEvent converts the value returned by the script into the log event. The message is the changed message,
+// or the JSON of fields if the message is not changed and fields are not None.
+func (s *scriptTransform) logEvent(v starlark.Value, event types.InputLogEvent) (types.InputLogEvent, error) {
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return types.InputLogEvent{}, fmt.Errorf("script error: %s must return a dict, a list of dicts or None, but got %s", scriptFunction, v.Type())
+	}
+	if timestamp, found, _ := d.Get(starlark.String("timestamp")); found {
+		i, isInt := timestamp.(starlark.Int)
+		ms, exact := i.Int64()
+		if !isInt || !exact {
+			return types.InputLogEvent{}, fmt.Errorf("script error: timestamp must be milliseconds, but got %s", timestamp)
+		}
+		event.Timestamp = aws.Int64(ms)
+	}
+
+	if message, found, _ := d.Get(starlark.String("message")); found {
+		str, ok := starlark.AsString(message)
+		if !ok {
+			return types.InputLogEvent{}, fmt.Errorf("script error: message must be a string, but got %s", message.Type())
+		}
+		if str != aws.ToString(event.Message) {
+			event.Message = aws.String(str)
+			return event, nil
+		}
+	}
+	if fields, found, _ := d.Get(starlark.String("fields")); found && fields != starlark.None {
+		encoded, err := callJSON(s.thread, "encode", fields)
+		if err != nil {
+			return types.InputLogEvent{}, fmt.Errorf("script error: failed to encode fields: %w", err)
+		}
+		event.Message = aws.String(string(encoded.(starlark.String)))
+	}
+	return event, nil
+}
+
+// apply calls the script with the log event, and returns log events returned by it.
+func (s *scriptTransform) apply(event types.InputLogEvent) ([]types.InputLogEvent, error) {
+	if s == nil {
+		return []types.InputLogEvent{event}, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	arg, err := s.eventValue(event)
+	if err != nil {
+		return nil, fmt.Errorf("script error: %w", err)
+	}
+	v, err := starlark.Call(s.thread, s.fn, starlark.Tuple{arg}, nil)
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("script error: %s", evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("script error: %w", err)
+	}
+
+	values := []starlark.Value{v}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *starlark.List:
+		values = make([]starlark.Value, v.Len())
+		for i := range values {
+			values[i] = v.Index(i)
+		}
+	}
+	events := make([]types.InputLogEvent, 0, len(values))
+	for _, value := range values {
+		e, err := s.logEvent(value, event)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// scriptLogEvents returns log events transformed by the script.
+func scriptLogEvents(events []types.InputLogEvent, s *scriptTransform) ([]types.InputLogEvent, error) {
+	if s == nil {
+		return events, nil
+	}
+	transformed := make([]types.InputLogEvent, 0, len(events))
+	for _, event := range events {
+		e, err := s.apply(event)
+		if err != nil {
+			return nil, err
+		}
+		transformed = append(transformed, e...)
+	}
+	return transformed, nil
+}
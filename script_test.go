@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func writeScript(t *testing.T, script string) string {
+	name := filepath.Join(t.TempDir(), "transform.star")
+	if err := ioutil.WriteFile(name, []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func Test_newScriptTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "Load script", script: "def transform(event):\n    return event\n"},
+		{name: "Load script without transform", script: "def convert(event):\n    return event\n", wantErr: true},
+		{name: "Load script with transform without parameters", script: "def transform():\n    return None\n", wantErr: true},
+		{name: "Load invalid script", script: "def transform(event)\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScriptTransform(writeScript(t, tt.script))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newScriptTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_scriptLogEvents(t *testing.T) {
+	events := []types.InputLogEvent{
+		{Message: aws.String(`{"level":"DEBUG","message":"Connect"}`), Timestamp: aws.Int64(1000)},
+		{Message: aws.String(`{"level":"INFO","message":"Start","user":{"id":1}}`), Timestamp: aws.Int64(2000)},
+		{Message: aws.String("[WARN] Retry"), Timestamp: aws.Int64(3000)},
+	}
+	tests := []struct {
+		name    string
+		script  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "Return log events",
+			script: "def transform(event):\n    return event\n",
+			want:   []string{`{"level":"DEBUG","message":"Connect"}`, `{"level":"INFO","message":"Start","user":{"id":1}}`, "[WARN] Retry"},
+		},
+		{
+			name: "Filter and enrich log events",
+			script: `
+def transform(event):
+    fields = event["fields"]
+    if fields == None:
+        return {"message": json.encode({"message": event["message"], "source": "text"})}
+    if fields["level"] == "DEBUG":
+        return None
+    fields["source"] = "json"
+    fields.pop("user")
+    return event
+`,
+			want: []string{`{"level":"INFO","message":"Start","source":"json"}`, `{"message":"[WARN] Retry","source":"text"}`},
+		},
+		{
+			name: "Split log events",
+			script: `
+def transform(event):
+    return [event, {"message": "copy of " + event["message"], "timestamp": event["timestamp"] + 1}]
+`,
+			want: []string{
+				`{"level":"DEBUG","message":"Connect"}`, `copy of {"level":"DEBUG","message":"Connect"}`,
+				`{"level":"INFO","message":"Start","user":{"id":1}}`, `copy of {"level":"INFO","message":"Start","user":{"id":1}}`,
+				"[WARN] Retry", "copy of [WARN] Retry",
+			},
+		},
+		{
+			name:    "Return invalid value",
+			script:  "def transform(event):\n    return event[\"message\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "Fail in script",
+			script:  "def transform(event):\n    return event[\"unknown\"]\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newScriptTransform(writeScript(t, tt.script))
+			if err != nil {
+				t.Fatalf("newScriptTransform() error = %v", err)
+			}
+			got, err := scriptLogEvents(events, s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scriptLogEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			messages := make([]string, len(got))
+			for i, event := range got {
+				messages[i] = aws.ToString(event.Message)
+			}
+			if !reflect.DeepEqual(messages, tt.want) {
+				t.Errorf("scriptLogEvents() = %q, want %q", messages, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// checksumsAsset is the asset of releases listing the SHA-256 checksums of binaries, in the format of sha256sum.
+const checksumsAsset = "checksums.txt"
+
+// signatureAsset is the asset of releases with the Ed25519 signature of checksumsAsset.
+const signatureAsset = checksumsAsset + ".sig"
+
+// releasePublicKeyPEM is the Ed25519 public key whose private key signs checksumsAsset of releases.
+// It is empty until releases of awsputlogs are signed, and self-update fails without --public-key.
+var releasePublicKeyPEM []byte
+
+// downloadTimeout is the maximum time to download all assets of a release.
+const downloadTimeout = 5 * time.Minute
+
+// maxDownloadBytes is the maximum size of an asset, which is far larger than the binary. It is a variable to be replaced in tests.
+var maxDownloadBytes int64 = 256 << 20
+
+// releaseAssetName returns the name of the binary for the platform in releases, such as awsputlogs_linux_amd64.
+func releaseAssetName(goos, goarch string) string {
+	name := fmt.Sprintf("awsputlogs_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// parseChecksums parses the output of sha256sum into the checksums by file names.
+func parseChecksums(data []byte) map[string]string {
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks files read in binary mode with '*'
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums
+}
+
+// verifyChecksum checks the SHA-256 checksum of data is want.
+func verifyChecksum(data []byte, want string) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("update error: the checksum of the downloaded binary is %s, but %s is expected", got, want)
+	}
+	return nil
+}
+
+// verifySignature checks sig is the Ed25519 signature of data by the public key in PEM.
+func verifySignature(data, sig, publicKeyPEM []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return errors.New("update error: the public key is not a PEM file")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("update error: invalid public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return errors.New("update error: the public key must be an Ed25519 public key")
+	}
+	if !ed25519.Verify(edKey, data, sig) {
+		return fmt.Errorf("update error: the signature of %s is invalid", checksumsAsset)
+	}
+	return nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("update error: GET %s returned %s", url, resp.Status)
+	}
+	// Read one more byte than the limit to know the asset is too large
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("update error: %w", err)
+	}
+	if int64(len(data)) > maxDownloadBytes {
+		return nil, fmt.Errorf("update error: %s is larger than %d bytes", url, maxDownloadBytes)
+	}
+	return data, nil
+}
+
+// downloadRelease downloads the binary for the platform in the release, and verifies it with the checksums of the
+// release. The checksums are verified with their signature by publicKeyPEM, since they come from the same release as the binary.
+func downloadRelease(ctx context.Context, r release, goos, goarch string, publicKeyPEM []byte) ([]byte, error) {
+	name := releaseAssetName(goos, goarch)
+	binAsset, ok := r.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("update error: the release %s has no binary for %s/%s", r.TagName, goos, goarch)
+	}
+	sumsAsset, ok := r.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("update error: the release %s has no %s", r.TagName, checksumsAsset)
+	}
+
+	sums, err := download(ctx, sumsAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	sigAsset, ok := r.asset(signatureAsset)
+	if !ok {
+		return nil, fmt.Errorf("update error: the release %s has no %s", r.TagName, signatureAsset)
+	}
+	sig, err := download(ctx, sigAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(sums, sig, publicKeyPEM); err != nil {
+		return nil, err
+	}
+	want, ok := parseChecksums(sums)[name]
+	if !ok {
+		return nil, fmt.Errorf("update error: %s of the release %s has no checksum of %s", checksumsAsset, r.TagName, name)
+	}
+
+	bin, err := download(ctx, binAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(bin, want); err != nil {
+		return nil, err
+	}
+	return bin, nil
+}
+
+// replaceExecutable replaces the executable at path with bin. The new binary is written next to it and renamed,
+// so that the executable is never left half written. The running executable can not be removed on Windows,
+// so it is moved aside, and removed by the next update.
+func replaceExecutable(path string, bin []byte) error {
+	old := path + ".old"
+	os.Remove(old)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".awsputlogs-update-*")
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return fmt.Errorf("update error: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		// Put the current executable back not to lose it
+		os.Rename(old, path)
+		return fmt.Errorf("update error: %w", err)
+	}
+	os.Remove(old)
+	return nil
+}
+
+func runSelfUpdate(args []string) error {
+	force := false
+	publicKeyFile := ""
+
+	flags := newSubcommandFlagSet(args[0], "Update awsputlogs to the latest release on GitHub.", "[options]")
+	flags.BoolVar(&force, "force", false, "Replace awsputlogs with the latest release even if it is not newer, such as when it is built from the source.")
+	flags.StringVar(&publicKeyFile, "public-key", "", "The PEM file of the Ed25519 public key to verify the signature of the checksums of the release. Releases of awsputlogs are not signed yet, so it is required.")
+	flags.Parse(args[1:])
+
+	publicKeyPEM := releasePublicKeyPEM
+	if publicKeyFile != "" {
+		var err error
+		if publicKeyPEM, err = ioutil.ReadFile(publicKeyFile); err != nil {
+			return fmt.Errorf("argument error: %w", err)
+		}
+	}
+	if len(publicKeyPEM) == 0 {
+		return errors.New("update error: self-update is disabled because releases of awsputlogs are not signed yet. Download the new version from GitHub, or use --public-key to verify releases signed by another key")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	// Replace the binary itself rather than the symbolic link to it, such as one made by package managers
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	r, err := getLatestRelease(ctx, latestReleaseURL)
+	if err != nil {
+		return err
+	}
+	current, latest := version(), strings.TrimPrefix(r.TagName, "v")
+	if !force && !isNewerVersion(current, latest) {
+		if _, ok := parseVersion(current); !ok {
+			fmt.Printf("awsputlogs %s is not a release. Use --force to replace it with the latest release %s\n", current, latest)
+			return nil
+		}
+		fmt.Printf("awsputlogs %s is up to date\n", current)
+		return nil
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	bin, err := downloadRelease(ctx, r, runtime.GOOS, runtime.GOARCH, publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, bin); err != nil {
+		return err
+	}
+	fmt.Printf("Updated awsputlogs from %s to %s\n", current, latest)
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseChecksums(t *testing.T) {
+	data := []byte("0123abcd  awsputlogs_linux_amd64\n4567EF01 *awsputlogs_windows_amd64.exe\n\ninvalid line here\n")
+	want := map[string]string{
+		"awsputlogs_linux_amd64":       "0123abcd",
+		"awsputlogs_windows_amd64.exe": "4567ef01",
+	}
+	if got := parseChecksums(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseChecksums() = %v, want %v", got, want)
+	}
+}
+
+func Test_downloadRelease(t *testing.T) {
+	bin := []byte("new awsputlogs")
+	sum := sha256.Sum256(bin)
+	sums := []byte(hex.EncodeToString(sum[:]) + "  awsputlogs_linux_amd64\n" +
+		hex.EncodeToString(sum[:]) + "  awsputlogs_darwin_arm64\n")
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	assets := map[string][]byte{
+		"/awsputlogs_linux_amd64":  bin,
+		"/awsputlogs_darwin_arm64": []byte("tampered awsputlogs"),
+		"/" + checksumsAsset:       sums,
+		"/" + signatureAsset:       ed25519.Sign(privateKey, sums),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := assets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	r := release{TagName: "v1.3.0"}
+	for _, name := range []string{"awsputlogs_linux_amd64", "awsputlogs_darwin_arm64", checksumsAsset, signatureAsset} {
+		r.Assets = append(r.Assets, releaseAsset{Name: name, URL: server.URL + "/" + name})
+	}
+
+	tests := []struct {
+		name         string
+		goos         string
+		goarch       string
+		publicKeyPEM []byte
+		want         []byte
+		wantErr      bool
+	}{
+		{name: "Download the binary with the signature", goos: "linux", goarch: "amd64", publicKeyPEM: publicKeyPEM, want: bin},
+		{name: "Download the binary with the invalid signature", goos: "linux", goarch: "amd64", publicKeyPEM: otherKeyPEM, wantErr: true},
+		{name: "Download the binary without the public key", goos: "linux", goarch: "amd64", publicKeyPEM: nil, wantErr: true},
+		{name: "Download the binary with the invalid checksum", goos: "darwin", goarch: "arm64", publicKeyPEM: publicKeyPEM, wantErr: true},
+		{name: "Download the binary for the unknown platform", goos: "plan9", goarch: "386", publicKeyPEM: publicKeyPEM, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := downloadRelease(context.Background(), r, tt.goos, tt.goarch, tt.publicKeyPEM)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("downloadRelease() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("downloadRelease() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runSelfUpdate(t *testing.T) {
+	// Releases are not signed, so self-update fails before checking the latest release
+	err := runSelfUpdate([]string{"self-update", "--force"})
+	if err == nil || !strings.Contains(err.Error(), "--public-key") {
+		t.Errorf("runSelfUpdate() error = %v, want the error to use --public-key", err)
+	}
+}
+
+func Test_download(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/awsputlogs_linux_amd64" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("new awsputlogs"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		maxBytes int64
+		want     []byte
+		wantErr  bool
+	}{
+		{name: "Download the asset", path: "/awsputlogs_linux_amd64", maxBytes: 14, want: []byte("new awsputlogs")},
+		{name: "Download the asset larger than the limit", path: "/awsputlogs_linux_amd64", maxBytes: 13, wantErr: true},
+		{name: "Download the missing asset", path: "/checksums.txt", maxBytes: 14, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(n int64) { maxDownloadBytes = n }(maxDownloadBytes)
+			maxDownloadBytes = tt.maxBytes
+			got, err := download(context.Background(), server.URL+tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("download() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("download() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_replaceExecutable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "awsputlogs")
+	if err := ioutil.WriteFile(path, []byte("old awsputlogs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceExecutable(path, []byte("new awsputlogs")); err != nil {
+		t.Fatalf("replaceExecutable() error = %v", err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new awsputlogs" {
+		t.Errorf("replaceExecutable() wrote %s, want new awsputlogs", got)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("replaceExecutable() left %d files, want only the executable", len(files))
+	}
+}
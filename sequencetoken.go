@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// maxTokenConflicts is the maximum number of times a batch is put again after another writer changed
+// the sequence token of the log stream.
+const maxTokenConflicts = 10
+
+// tokenConflictBackoff is the base of the random wait before a batch is put again with the new sequence token.
+// Waiting for random time keeps writers from conflicting again at the same time.
+var tokenConflictBackoff = 50 * time.Millisecond
+
+// putWithToken calls PutLogEvents with the sequence token of the uploader.
+// If another writer put log events to the same log stream, the batch is put again with the token expected by
+// CloudWatch Logs, or the token described again, up to maxTokenConflicts times. A batch which was already
+// accepted is not put again. Without the token, CloudWatch Logs accepts calls of concurrent writers.
+func (u *uploader) putWithToken(ctx context.Context, param *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	// Another uploader of the run may have put log events to the same log stream
+	if token, ok := u.streams.cachedToken(u.client.Options().Region, u.dst); ok {
+		u.token = token
+	}
+	for conflicts := 0; ; conflicts++ {
+		param.SequenceToken = u.token
+		if u.withoutToken {
+			param.SequenceToken = nil
+		}
+		res, err := u.putLogEvents(ctx, param)
+
+		var accepted *types.DataAlreadyAcceptedException
+		if errors.As(err, &accepted) {
+			// The response of the previous call was lost after the batch was put
+			return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: accepted.ExpectedSequenceToken}, nil
+		}
+		var invalid *types.InvalidSequenceTokenException
+		if !errors.As(err, &invalid) || conflicts >= maxTokenConflicts {
+			return res, err
+		}
+
+		u.token = invalid.ExpectedSequenceToken
+		if u.token == nil {
+			if u.token, err = describeSequenceToken(ctx, u.client, u.dst); err != nil {
+				return nil, err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(rand.Int63n(int64(tokenConflictBackoff << conflicts)))):
+		}
+	}
+}
+
+// describeSequenceToken returns the current sequence token of the log stream.
+func describeSequenceToken(ctx context.Context, client *cloudwatchlogs.Client, dst destination) (*string, error) {
+	stream, err := describeLogStream(ctx, client, dst.logGroup, dst.logStream)
+	if err != nil || stream == nil {
+		return nil, err
+	}
+	return stream.UploadSequenceToken, nil
+}
+
+// logStreamCache keeps log streams resolved in the run and their sequence tokens, so that DescribeLogStreams is called
+// once for each log stream instead of each destination or batch. The sequence token is described again only after
+// PutLogEvents fails with a token error. A nil logStreamCache resolves log streams without caching them.
+type logStreamCache struct {
+	mu sync.Mutex
+	// streams has the latest log streams by the region, the log group and the prefix
+	streams map[string]string
+	// tokens has the sequence tokens by the region and the destination. nil is the token of a new log stream.
+	tokens map[string]*string
+}
+
+func newLogStreamCache() *logStreamCache {
+	return &logStreamCache{streams: map[string]string{}, tokens: map[string]*string{}}
+}
+
+func logStreamCacheKey(region string, parts ...string) string {
+	key := region
+	for _, part := range parts {
+		key += "\x00" + part
+	}
+	return key
+}
+
+// latest returns the latest log stream of the log group with the prefix, which is resolved by resolve only once.
+func (c *logStreamCache) latest(region, logGroup, prefix string, resolve func() (string, error)) (string, error) {
+	if c == nil {
+		return resolve()
+	}
+	key := logStreamCacheKey(region, logGroup, prefix)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stream, ok := c.streams[key]; ok {
+		return stream, nil
+	}
+	stream, err := resolve()
+	if err != nil {
+		return "", err
+	}
+	c.streams[key] = stream
+	return stream, nil
+}
+
+// token returns the sequence token of the destination, which is described by describe only if it is not cached.
+// describe reports whether the log stream exists.
+func (c *logStreamCache) token(region string, dst destination, describe func() (*string, bool, error)) (*string, error) {
+	key := logStreamCacheKey(region, dst.logGroup, dst.logStream)
+	if c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if token, ok := c.tokens[key]; ok {
+			return token, nil
+		}
+	}
+	token, found, err := describe()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("not log stream error: %s is not found in %s", dst.logStream, dst.logGroup)
+	}
+	if c != nil {
+		c.tokens[key] = token
+	}
+	return token, nil
+}
+
+// created records the log stream created in the run, which has no sequence token yet.
+func (c *logStreamCache) created(region string, dst destination) {
+	c.setToken(region, dst, nil)
+}
+
+// setToken records the sequence token returned by PutLogEvents.
+func (c *logStreamCache) setToken(region string, dst destination, token *string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[logStreamCacheKey(region, dst.logGroup, dst.logStream)] = token
+}
+
+// cachedToken returns the sequence token of the destination recorded by any uploader of the run.
+func (c *logStreamCache) cachedToken(region string, dst destination) (*string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, ok := c.tokens[logStreamCacheKey(region, dst.logGroup, dst.logStream)]
+	return token, ok
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_uploader_putWithToken(t *testing.T) {
+	setUpAWSConfig(t, "")
+	tokenConflictBackoff = time.Millisecond
+
+	conflict := map[string]interface{}{"__type": "InvalidSequenceTokenException", "message": "conflict", "expectedSequenceToken": "2"}
+	tests := []struct {
+		name         string
+		withoutToken bool
+		// responses are the responses of PutLogEvents in order. The last one is repeated.
+		responses  []map[string]interface{}
+		wantTokens []string
+		wantToken  string
+		wantErr    bool
+	}{
+		{
+			name:       "Put log events with the token",
+			responses:  []map[string]interface{}{{"nextSequenceToken": "2"}},
+			wantTokens: []string{"1"},
+			wantToken:  "2",
+		},
+		{
+			name:       "Put log events again with the expected token",
+			responses:  []map[string]interface{}{conflict, {"nextSequenceToken": "3"}},
+			wantTokens: []string{"1", "2"},
+			wantToken:  "3",
+		},
+		{
+			name:       "Put log events again with the described token",
+			responses:  []map[string]interface{}{{"__type": "InvalidSequenceTokenException", "message": "conflict"}, {"nextSequenceToken": "6"}},
+			wantTokens: []string{"1", "5"},
+			wantToken:  "6",
+		},
+		{
+			name:       "Put log events already accepted",
+			responses:  []map[string]interface{}{{"__type": "DataAlreadyAcceptedException", "message": "accepted", "expectedSequenceToken": "2"}},
+			wantTokens: []string{"1"},
+			wantToken:  "2",
+		},
+		{
+			name:         "Put log events without the token",
+			withoutToken: true,
+			responses:    []map[string]interface{}{{}},
+			wantTokens:   []string{""},
+			wantToken:    "",
+		},
+		{
+			name:       "Give up after too many conflicts",
+			responses:  []map[string]interface{}{conflict},
+			wantTokens: append([]string{"1"}, strings.Split(strings.Repeat("2", maxTokenConflicts), "")...),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := []string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				if strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".DescribeLogStreams") {
+					json.NewEncoder(w).Encode(map[string]interface{}{"logStreams": []map[string]interface{}{
+						{"logStreamName": "stream-2", "uploadSequenceToken": "9"},
+						{"logStreamName": "stream", "uploadSequenceToken": "5"},
+					}})
+					return
+				}
+				var in struct {
+					SequenceToken string `json:"sequenceToken"`
+				}
+				json.NewDecoder(r.Body).Decode(&in)
+				res := tt.responses[len(tt.responses)-1]
+				if len(tokens) < len(tt.responses) {
+					res = tt.responses[len(tokens)]
+				}
+				tokens = append(tokens, in.SequenceToken)
+				if _, ok := res["__type"]; ok {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				json.NewEncoder(w).Encode(res)
+			}))
+			defer server.Close()
+
+			client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+			if err != nil {
+				t.Fatalf("newClient() error = %v", err)
+			}
+			u := &uploader{
+				client:       client,
+				dst:          destination{logGroup: "group", logStream: "stream"},
+				limits:       defaultBatchLimits,
+				token:        aws.String("1"),
+				withoutToken: tt.withoutToken,
+			}
+			err = u.putBatch(context.Background(), []types.InputLogEvent{{Message: aws.String("log"), Timestamp: aws.Int64(1)}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("uploader.putBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("uploader.putBatch() sent tokens %v, want %v", tokens, tt.wantTokens)
+			}
+			if !tt.wantErr && aws.ToString(u.token) != tt.wantToken {
+				t.Errorf("uploader.token = %v, want %v", aws.ToString(u.token), tt.wantToken)
+			}
+		})
+	}
+}
+
+func Test_logStreamCache(t *testing.T) {
+	calls := map[string]int{}
+	tokens := []string{}
+	fake := fakelogs.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := r.Header.Get("X-Amz-Target")
+		calls[action[strings.LastIndex(action, ".")+1:]]++
+		if strings.HasSuffix(action, ".PutLogEvents") {
+			body, _ := ioutil.ReadAll(r.Body)
+			var in struct {
+				SequenceToken string `json:"sequenceToken"`
+			}
+			json.Unmarshal(body, &in)
+			tokens = append(tokens, in.SequenceToken)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	logGroup, logStreams, err := setUpLogGroupAndStreams(cli, 2)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	calls = map[string]int{}
+
+	streams := newLogStreamCache()
+	streams.created("us-east-1", destination{logGroup: logGroup, logStream: logStreams[1]})
+	dsts := []destination{
+		{logGroup: logGroup},
+		{logGroup: logGroup, logStream: logStreams[0]},
+		{logGroup: logGroup, logStream: logStreams[1]},
+	}
+	uploaders := []*uploader{}
+	for _, dst := range dsts {
+		u, err := newUploader(cli, dst, logStreams[0], defaultBatchLimits, streams)
+		if err != nil {
+			t.Fatalf("newUploader() error = %v", err)
+		}
+		uploaders = append(uploaders, u)
+	}
+	for i := 0; i < 3; i++ {
+		for _, u := range uploaders {
+			events := []types.InputLogEvent{{Message: aws.String("log"), Timestamp: aws.Int64(toMillis(time.Now()))}}
+			if err := u.put(context.Background(), events); err != nil {
+				t.Fatalf("uploader.put() error = %v", err)
+			}
+		}
+	}
+
+	// The latest log stream and the sequence token of the first log stream are described once for all destinations
+	if calls["DescribeLogStreams"] != 2 {
+		t.Errorf("DescribeLogStreams is called %d times, want 2", calls["DescribeLogStreams"])
+	}
+	// Uploaders of the same log stream put log events with the token returned to each other
+	want := []string{"0", "1", "", "2", "3", "1", "4", "5", "2"}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("PutLogEvents is called with tokens %v, want %v", tokens, want)
+	}
+}
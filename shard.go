@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// shardPlaceholder is replaced with the shard number in the name of the log stream.
+const shardPlaceholder = "{shard}"
+
+// shardDestinations expands each destination into the destinations of shards.
+func shardDestinations(dsts []destination, count int) ([]destination, error) {
+	sharded := make([]destination, 0, len(dsts)*count)
+	for _, dst := range dsts {
+		if !strings.Contains(dst.logStream, shardPlaceholder) {
+			return nil, fmt.Errorf("argument error: --log-stream must include %s with --shard-by-field, but got %q for %s", shardPlaceholder, dst.logStream, dst.logGroup)
+		}
+		for i := 0; i < count; i++ {
+			shard := dst
+			shard.logStream = strings.ReplaceAll(dst.logStream, shardPlaceholder, strconv.Itoa(i))
+			sharded = append(sharded, shard)
+		}
+	}
+	return sharded, nil
+}
+
+// shardLogEvents distributes log events into count shards by the hash of the field of JSON log events.
+// Log events without the field are distributed by the hash of the message.
+func shardLogEvents(events []types.InputLogEvent, field string, count int) [][]types.InputLogEvent {
+	shards := make([][]types.InputLogEvent, count)
+	for i := range shards {
+		shards[i] = []types.InputLogEvent{}
+	}
+	for _, event := range events {
+		value, ok := jsonFieldValue(aws.ToString(event.Message), field)
+		if !ok {
+			value = aws.ToString(event.Message)
+		}
+		h := fnv.New32a()
+		h.Write([]byte(value))
+		i := h.Sum32() % uint32(count)
+		shards[i] = append(shards[i], event)
+	}
+	return shards
+}
+
+// ensureLogStream creates the log stream if it does not exist. It reports whether the log stream is created.
+func ensureLogStream(client *cloudwatchlogs.Client, logGroup, logStream string) (bool, error) {
+	err := createLogStream(client, logGroup, logStream)
+	var exists *types.ResourceAlreadyExistsException
+	if errors.As(err, &exists) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// putSharded puts log events of each shard in parallel. The uploaders are in order of the shards for each destination.
+func putSharded(ctx context.Context, uploaders []*uploader, events []types.InputLogEvent, field string, count int) {
+	shards := shardLogEvents(events, field, count)
+	wg := sync.WaitGroup{}
+	for _, u := range uploaders {
+		wg.Add(1)
+		go func(u *uploader) {
+			defer wg.Done()
+			u.err = u.put(ctx, shards[u.shard])
+		}(u)
+	}
+	wg.Wait()
+}
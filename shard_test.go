@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_shardDestinations(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsts    []destination
+		count   int
+		want    []destination
+		wantErr bool
+	}{
+		{
+			name:  "Shard destinations",
+			dsts:  []destination{{logGroup: "/test/group", logStream: "api-shard-{shard}", region: "eu-west-1"}},
+			count: 2,
+			want: []destination{
+				{logGroup: "/test/group", logStream: "api-shard-0", region: "eu-west-1"},
+				{logGroup: "/test/group", logStream: "api-shard-1", region: "eu-west-1"},
+			},
+		},
+		{
+			name:    "Shard destination without the placeholder",
+			dsts:    []destination{{logGroup: "/test/group", logStream: "api"}},
+			count:   2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shardDestinations(tt.dsts, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("shardDestinations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shardDestinations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_shardLogEvents(t *testing.T) {
+	events := []types.InputLogEvent{}
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 3; j++ {
+			message := fmt.Sprintf(`{"request_id":"%d","step":%d}`, i, j)
+			events = append(events, types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(int64(j))})
+		}
+	}
+
+	shards := shardLogEvents(events, "request_id", 4)
+	if len(shards) != 4 {
+		t.Fatalf("shardLogEvents() returns %d shards, want %d", len(shards), 4)
+	}
+	total := 0
+	shardOf := map[string]int{}
+	for i, shard := range shards {
+		if len(shard) == 0 {
+			t.Errorf("shardLogEvents() shard %d is empty", i)
+		}
+		total += len(shard)
+		for _, event := range shard {
+			id, _ := jsonFieldValue(aws.ToString(event.Message), "request_id")
+			if prev, ok := shardOf[id]; ok && prev != i {
+				t.Errorf("shardLogEvents() puts request_id %s into shards %d and %d", id, prev, i)
+			}
+			shardOf[id] = i
+		}
+	}
+	if total != len(events) {
+		t.Errorf("shardLogEvents() returns %d log events, want %d", total, len(events))
+	}
+}
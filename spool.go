@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const defaultSpoolMaxBytes = 100 * 1024 * 1024
+
+// spooledEvent is a log event persisted in the spool.
+type spooledEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// spool persists log events which failed to be uploaded to a destination in a directory.
+// Each file in the directory has a batch of log events, and the name of the file keeps the order of batches.
+type spool struct {
+	dir      string
+	maxBytes int64
+	seq      int
+}
+
+// newSpool creates the spool for the destination in dir. Log events spooled by the previous process are kept.
+func newSpool(dir string, dst destination, maxBytes int64) (*spool, error) {
+	// The destination is identified by its region and role as well as its name
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %s %s", dst.region, dst.roleARN, dst)))
+	s := &spool{
+		dir:      filepath.Join(dir, hex.EncodeToString(sum[:8])),
+		maxBytes: maxBytes,
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// files returns spooled files from the oldest.
+func (s *spool) files() ([]os.FileInfo, error) {
+	// ioutil.ReadDir sorts files by the name
+	return ioutil.ReadDir(s.dir)
+}
+
+// push persists log events. It removes the oldest files if the spool exceeds the max bytes.
+func (s *spool) push(events []types.InputLogEvent) error {
+	spooled := make([]spooledEvent, len(events))
+	for i, event := range events {
+		spooled[i] = spooledEvent{
+			Timestamp: aws.ToInt64(event.Timestamp),
+			Message:   aws.ToString(event.Message),
+		}
+	}
+	data, err := json.Marshal(spooled)
+	if err != nil {
+		return err
+	}
+
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), s.seq))
+	if err := ioutil.WriteFile(name, data, 0600); err != nil {
+		return err
+	}
+	return s.trim()
+}
+
+func (s *spool) trim() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	total := int64(0)
+	for _, f := range files {
+		total += f.Size()
+	}
+	for _, f := range files {
+		if total <= s.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.Name())); err != nil {
+			return err
+		}
+		total -= f.Size()
+		fmt.Fprintf(os.Stderr, "spool error: dropped the oldest spooled logs %s because the spool exceeds %d bytes\n", f.Name(), s.maxBytes)
+	}
+	return nil
+}
+
+// drain calls put with spooled log events from the oldest, and removes them if put succeeds.
+// It stops at the first error, and the rest are kept in the spool.
+func (s *spool) drain(put func([]types.InputLogEvent) error) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := filepath.Join(s.dir, f.Name())
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		spooled := []spooledEvent{}
+		if err := json.Unmarshal(data, &spooled); err != nil {
+			return fmt.Errorf("spool error: %s is broken: %w", name, err)
+		}
+
+		events := make([]types.InputLogEvent, len(spooled))
+		for i, event := range spooled {
+			events[i] = types.InputLogEvent{
+				Message:   aws.String(event.Message),
+				Timestamp: aws.Int64(event.Timestamp),
+			}
+		}
+		if err := put(events); err != nil {
+			return err
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_spool(t *testing.T) {
+	s, err := newSpool(t.TempDir(), destination{logGroup: "/test/group", logStream: "test-stream"}, defaultSpoolMaxBytes)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+
+	first := newTestEvents(2, "[INFO] Start Server", time.Second)
+	second := newTestEvents(1, "[INFO] Stop Server", time.Second)
+	for _, events := range [][]types.InputLogEvent{first, second} {
+		if err := s.push(events); err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+	}
+
+	// Spooled log events are kept if put fails
+	errPut := errors.New("put error")
+	err = s.drain(func(events []types.InputLogEvent) error {
+		return errPut
+	})
+	if !errors.Is(err, errPut) {
+		t.Errorf("drain() error = %v, want %v", err, errPut)
+	}
+
+	got := [][]types.InputLogEvent{}
+	err = s.drain(func(events []types.InputLogEvent) error {
+		got = append(got, events)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("drain() error = %v", err)
+	}
+	if want := [][]types.InputLogEvent{first, second}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drain() put %v, want %v", got, want)
+	}
+	if files, _ := s.files(); len(files) != 0 {
+		t.Errorf("drain() left %d files, want 0", len(files))
+	}
+}
+
+func Test_spool_maxBytes(t *testing.T) {
+	events := newTestEvents(1, "[INFO] Start Server", time.Second)
+	s, err := newSpool(t.TempDir(), destination{logGroup: "/test/group"}, 100)
+	if err != nil {
+		t.Fatalf("newSpool() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.push(events); err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+	}
+
+	files, err := s.files()
+	if err != nil {
+		t.Fatalf("files() error = %v", err)
+	}
+	total := int64(0)
+	for _, f := range files {
+		total += f.Size()
+	}
+	if len(files) == 0 || total > 100 {
+		t.Errorf("push() kept %d files of %d bytes, want the latest files within 100 bytes", len(files), total)
+	}
+}
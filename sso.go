@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+// ssoToken is the SSO access token cached in ~/.aws/sso/cache, which is shared with the AWS CLI.
+type ssoToken struct {
+	StartURL              string `json:"startUrl"`
+	Region                string `json:"region"`
+	AccessToken           string `json:"accessToken"`
+	ExpiresAt             string `json:"expiresAt"`
+	RefreshToken          string `json:"refreshToken,omitempty"`
+	ClientID              string `json:"clientId,omitempty"`
+	ClientSecret          string `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt string `json:"registrationExpiresAt,omitempty"`
+}
+
+// ssoProfile is the SSO configuration of the profile.
+type ssoProfile struct {
+	startURL string
+	region   string
+	// cacheKey is the sso-session name, or the start URL for the legacy configuration
+	cacheKey string
+	scopes   []string
+}
+
+// loadSSOProfile returns the SSO configuration of the profile loaded in cfg. It returns false if the profile does not use SSO.
+func loadSSOProfile(cfg aws.Config) (ssoProfile, bool) {
+	for _, source := range cfg.ConfigSources {
+		shared, ok := source.(config.SharedConfig)
+		if !ok {
+			continue
+		}
+		if shared.SSOSession != nil {
+			return ssoProfile{
+				startURL: shared.SSOSession.SSOStartURL,
+				region:   shared.SSOSession.SSORegion,
+				cacheKey: shared.SSOSession.Name,
+				scopes:   []string{"sso:account:access"},
+			}, true
+		}
+		if shared.SSOStartURL != "" {
+			return ssoProfile{
+				startURL: shared.SSOStartURL,
+				region:   shared.SSORegion,
+				cacheKey: shared.SSOStartURL,
+			}, true
+		}
+	}
+	return ssoProfile{}, false
+}
+
+// loadSSOToken loads the cached SSO access token. It returns false if the token is not cached or expires soon.
+func loadSSOToken(cacheFile string, now time.Time) (ssoToken, bool) {
+	data, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return ssoToken{}, false
+	}
+	token := ssoToken{}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return ssoToken{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil || token.AccessToken == "" || !expiresAt.After(now.Add(time.Minute)) {
+		return ssoToken{}, false
+	}
+	return token, true
+}
+
+// ssoLogin signs in to SSO of the profile loaded in cfg with the device authorization if the cached access token is expired,
+// and caches the new access token to be used by the SDK.
+func ssoLogin(cfg aws.Config) error {
+	sso, ok := loadSSOProfile(cfg)
+	if !ok {
+		return errors.New("argument error: --sso-login needs the profile which uses SSO")
+	}
+	cacheFile, err := ssocreds.StandardCachedTokenFilepath(sso.cacheKey)
+	if err != nil {
+		return err
+	}
+	if _, ok := loadSSOToken(cacheFile, time.Now()); ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	client := ssooidc.NewFromConfig(cfg, func(o *ssooidc.Options) {
+		o.Region = sso.region
+	})
+	registered, err := client.RegisterClient(ctx, &ssooidc.RegisterClientInput{
+		ClientName: aws.String("awsputlogs"),
+		ClientType: aws.String("public"),
+		Scopes:     sso.scopes,
+	})
+	if err != nil {
+		return fmt.Errorf("sso error: failed to register the client: %w", err)
+	}
+	authorization, err := client.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     registered.ClientId,
+		ClientSecret: registered.ClientSecret,
+		StartUrl:     aws.String(sso.startURL),
+	})
+	if err != nil {
+		return fmt.Errorf("sso error: failed to start the device authorization: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Open %s in your browser, and confirm the code %s to sign in.\n", aws.ToString(authorization.VerificationUriComplete), aws.ToString(authorization.UserCode))
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+	for {
+		time.Sleep(interval)
+		created, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     registered.ClientId,
+			ClientSecret: registered.ClientSecret,
+			DeviceCode:   authorization.DeviceCode,
+			GrantType:    aws.String("urn:ietf:params:oauth:grant-type:device_code"),
+		})
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		switch {
+		case errors.As(err, &pending):
+			if time.Now().After(deadline) {
+				return errors.New("sso error: the device authorization expired before signing in")
+			}
+			continue
+		case errors.As(err, &slowDown):
+			interval += 5 * time.Second
+			continue
+		case err != nil:
+			return fmt.Errorf("sso error: failed to create the access token: %w", err)
+		}
+
+		now := time.Now().UTC()
+		token := ssoToken{
+			StartURL:              sso.startURL,
+			Region:                sso.region,
+			AccessToken:           aws.ToString(created.AccessToken),
+			ExpiresAt:             now.Add(time.Duration(created.ExpiresIn) * time.Second).Format(time.RFC3339),
+			RefreshToken:          aws.ToString(created.RefreshToken),
+			ClientID:              aws.ToString(registered.ClientId),
+			ClientSecret:          aws.ToString(registered.ClientSecret),
+			RegistrationExpiresAt: time.Unix(registered.ClientSecretExpiresAt, 0).UTC().Format(time.RFC3339),
+		}
+		data, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(cacheFile, data, 0600)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+)
+
+// setUpAWSConfig isolates the test from AWS configurations in the environment, and writes config to the config file.
+func setUpAWSConfig(t *testing.T, config string) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	for _, key := range []string{"AWS_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ENDPOINT_URL"} {
+		t.Setenv(key, "")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loadSSOToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		cached string
+		wantOK bool
+	}{
+		{
+			name:   "Load valid token",
+			cached: `{"accessToken":"token","expiresAt":"2024-01-01T01:00:00Z"}`,
+			wantOK: true,
+		},
+		{
+			name:   "Load expired token",
+			cached: `{"accessToken":"token","expiresAt":"2023-12-31T23:00:00Z"}`,
+			wantOK: false,
+		},
+		{
+			name:   "Load token which expires soon",
+			cached: `{"accessToken":"token","expiresAt":"2024-01-01T00:00:30Z"}`,
+			wantOK: false,
+		},
+		{
+			name:   "Load broken token",
+			cached: `{"accessToken":`,
+			wantOK: false,
+		},
+		{
+			name:   "Load no token",
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheFile := filepath.Join(t.TempDir(), "token.json")
+			if tt.cached != "" {
+				if err := ioutil.WriteFile(cacheFile, []byte(tt.cached), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, ok := loadSSOToken(cacheFile, now); ok != tt.wantOK {
+				t.Errorf("loadSSOToken() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_ssoLogin(t *testing.T) {
+	setUpAWSConfig(t, `[profile dev]
+sso_session = corp
+sso_account_id = 123456789012
+sso_role_name = Developer
+region = us-east-1
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+`)
+
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/client/register":
+			fmt.Fprint(w, `{"clientId":"client","clientSecret":"secret","clientSecretExpiresAt":4102444800}`)
+		case "/device_authorization":
+			fmt.Fprint(w, `{"deviceCode":"device","userCode":"ABCD-EFGH","verificationUriComplete":"https://device.sso.us-east-1.amazonaws.com/?user_code=ABCD-EFGH","interval":1,"expiresIn":600}`)
+		case "/token":
+			fmt.Fprint(w, `{"accessToken":"token","expiresIn":3600,"refreshToken":"refresh"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_SSO_OIDC", server.URL)
+
+	params := parameters{profile: "dev", ssoLogin: true}
+	if _, err := loadConfig(params); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	cacheFile, err := ssocreds.StandardCachedTokenFilepath("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatalf("ssoLogin() did not cache the token: %v", err)
+	}
+	token := ssoToken{}
+	if err := json.Unmarshal(data, &token); err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token" || token.StartURL != "https://corp.awsapps.com/start" {
+		t.Errorf("ssoLogin() cached %+v", token)
+	}
+
+	// The cached token is used without signing in again
+	if _, err := loadConfig(params); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("ssoLogin() requested %v, want to sign in once", requests)
+	}
+}
+
+func Test_ssoLogin_noSSO(t *testing.T) {
+	setUpAWSConfig(t, `[profile dev]
+region = us-east-1
+`)
+	if _, err := loadConfig(parameters{profile: "dev", ssoLogin: true}); err == nil {
+		t.Errorf("loadConfig() error = nil, want the error for the profile without SSO")
+	}
+}
+
+func Test_loadConfig_webIdentity(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("web-identity-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/awsputlogs")
+
+	gotToken := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotToken = r.Form.Get("WebIdentityToken")
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>AKID</AccessKeyId><SecretAccessKey>SECRET</SecretAccessKey><SessionToken>SESSION</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration>
+</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+
+	cfg, err := loadConfig(parameters{region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("loadConfig() credentials error = %v", err)
+	}
+	if creds.AccessKeyID != "AKID" || gotToken != "web-identity-token" {
+		t.Errorf("loadConfig() credentials = %v with token %v, want AKID with web-identity-token", creds.AccessKeyID, gotToken)
+	}
+}
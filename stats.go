@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type ingestionBin struct {
+	Time   string `json:"time"`
+	Events int64  `json:"events"`
+}
+
+type logGroupStats struct {
+	Name            string         `json:"name"`
+	StoredBytes     int64          `json:"storedBytes"`
+	RetentionInDays *int32         `json:"retentionInDays,omitempty"`
+	Streams         int            `json:"streams"`
+	FirstEventTime  *time.Time     `json:"firstEventTime,omitempty"`
+	LastEventTime   *time.Time     `json:"lastEventTime,omitempty"`
+	Ingestion       []ingestionBin `json:"ingestion"`
+}
+
+// summarizeLogStreams sets the number of log streams and the time of the first and the last log events to stats.
+func summarizeLogStreams(stats *logGroupStats, streams []logStreamInfo) {
+	stats.Streams = len(streams)
+	for _, stream := range streams {
+		if t := stream.FirstEventTime; t != nil && (stats.FirstEventTime == nil || t.Before(*stats.FirstEventTime)) {
+			stats.FirstEventTime = t
+		}
+		if t := stream.LastEventTime; t != nil && (stats.LastEventTime == nil || t.After(*stats.LastEventTime)) {
+			stats.LastEventTime = t
+		}
+	}
+}
+
+func getIngestionBins(client *cloudwatchlogs.Client, logGroup string, hours int) ([]ingestionBin, error) {
+	end := time.Now()
+	results, err := runInsightsQuery(client, []string{logGroup}, "stats count(*) as events by bin(5m) as time", end.Add(-time.Duration(hours)*time.Hour), end)
+	if err != nil {
+		return nil, err
+	}
+
+	bins := make([]ingestionBin, len(results))
+	for i, result := range results {
+		n, err := strconv.ParseInt(result["events"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query error: unexpected result %v: %w", result, err)
+		}
+		bins[i] = ingestionBin{Time: result["time"], Events: n}
+	}
+	sort.Slice(bins, func(i, j int) bool {
+		return bins[i].Time < bins[j].Time
+	})
+	return bins, nil
+}
+
+func runStats(args []string) error {
+	params := parameters{}
+	logGroup := ""
+	hours := 3
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "Show statistics of the log group.", "--log-group <LOG GROUP NAME> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.IntVar(&hours, "hours", 3, "The number of recent hours to show the ingestion rate.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if hours <= 0 {
+		return fmt.Errorf("argument error: --hours must be positive, but got %d", hours)
+	}
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	group, err := findLogGroup(client, logGroup)
+	if err != nil {
+		return err
+	}
+	streams, err := listLogStreams(client, logGroup, types.OrderByLogStreamName)
+	if err != nil {
+		return err
+	}
+	bins, err := getIngestionBins(client, logGroup, hours)
+	if err != nil {
+		return err
+	}
+
+	stats := logGroupStats{
+		Name:            group.Name,
+		StoredBytes:     group.StoredBytes,
+		RetentionInDays: group.RetentionInDays,
+		Ingestion:       bins,
+	}
+	summarizeLogStreams(&stats, streams)
+
+	if output == outputJSON {
+		return writeJSON(os.Stdout, stats)
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	retention := "-"
+	if stats.RetentionInDays != nil {
+		retention = strconv.Itoa(int(*stats.RetentionInDays))
+	}
+	rows := [][]string{
+		{"Name", stats.Name},
+		{"Stored bytes", strconv.FormatInt(stats.StoredBytes, 10)},
+		{"Retention days", retention},
+		{"Log streams", strconv.Itoa(stats.Streams)},
+		{"First event", formatTime(stats.FirstEventTime)},
+		{"Last event", formatTime(stats.LastEventTime)},
+	}
+	if err := writeTable(os.Stdout, []string{"STAT", "VALUE"}, rows); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nIngestion in the last %d hours (events per 5 minutes):\n", hours)
+	rows = make([][]string, len(stats.Ingestion))
+	for i, bin := range stats.Ingestion {
+		rows[i] = []string{bin.Time, strconv.FormatInt(bin.Events, 10)}
+	}
+	return writeTable(os.Stdout, []string{"TIME", "EVENTS"}, rows)
+}
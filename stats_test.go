@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_summarizeLogStreams(t *testing.T) {
+	t1 := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		streams []logStreamInfo
+		want    logGroupStats
+	}{
+		{
+			name: "Summarize log streams",
+			streams: []logStreamInfo{
+				{Name: "log-stream-0", FirstEventTime: &t2, LastEventTime: &t2},
+				{Name: "log-stream-1", FirstEventTime: &t1, LastEventTime: &t3},
+				{Name: "log-stream-2"},
+			},
+			want: logGroupStats{
+				Streams:        3,
+				FirstEventTime: &t1,
+				LastEventTime:  &t3,
+			},
+		},
+		{
+			name:    "Summarize no log streams",
+			streams: []logStreamInfo{},
+			want:    logGroupStats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logGroupStats{}
+			summarizeLogStreams(&got, tt.streams)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeLogStreams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
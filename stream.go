@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The behaviors when log events are read faster than they are uploaded and the buffer is full
+const (
+	backpressureBlock      = "block"
+	backpressureDropOldest = "drop-oldest"
+	backpressureDropNewest = "drop-newest"
+)
+
+const defaultMaxBufferBytes = 8 * 1024 * 1024
+
+func validateBackpressure(onBackpressure string) error {
+	switch onBackpressure {
+	case backpressureBlock, backpressureDropOldest, backpressureDropNewest:
+		return nil
+	}
+	return fmt.Errorf("argument error: --on-backpressure must be %s, %s or %s, but got %s", backpressureBlock, backpressureDropOldest, backpressureDropNewest, onBackpressure)
+}
+
+// streamOptions are the options to stream log events.
+type streamOptions struct {
+	limits         batchLimits
+	flushInterval  time.Duration
+	drainTimeout   time.Duration
+	maxBufferBytes int
+	onBackpressure string
+	dedupeWindow   time.Duration
+	sampler        *sampler
+	schema         *schemaValidator
+	enricher       *enricher
+	emf            *emfFormat
+	timestamps     timestampOptions
+	lines          *lineLimiter
+	wrapKey        string
+	fields         *fieldTransform
+	anonymizer     *ipAnonymizer
+	script         *scriptTransform
+	metrics        *shipperMetrics
+	// flushes receives requests to flush buffered log events, which are answered with the error of flushing
+	flushes chan chan error
+}
+
+// eventQueue buffers log events until they are flushed. The size of buffered log events is bounded by maxBytes,
+// and onBackpressure decides whether a new log event waits, drops the oldest log events or is dropped when it is exceeded.
+type eventQueue struct {
+	mu             sync.Mutex
+	taken          *sync.Cond
+	events         []types.InputLogEvent
+	size           int
+	maxBytes       int
+	onBackpressure string
+	// blocked is true while a new log event waits for buffered log events to be taken
+	blocked bool
+	dropped int
+	closed  bool
+	// ready is notified when log events are pushed or the queue is closed
+	ready chan struct{}
+}
+
+func newEventQueue(maxBytes int, onBackpressure string) *eventQueue {
+	q := &eventQueue{
+		maxBytes:       maxBytes,
+		onBackpressure: onBackpressure,
+		ready:          make(chan struct{}, 1),
+	}
+	q.taken = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *eventQueue) notify() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue) push(event types.InputLogEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	size := eventSize(event)
+	for len(q.events) > 0 && q.size+size > q.maxBytes {
+		switch q.onBackpressure {
+		case backpressureDropNewest:
+			q.dropped++
+			return
+		case backpressureDropOldest:
+			q.size -= eventSize(q.events[0])
+			q.events = q.events[1:]
+			q.dropped++
+		default:
+			q.blocked = true
+			q.notify()
+			q.taken.Wait()
+			q.blocked = false
+		}
+	}
+	q.events = append(q.events, event)
+	q.size += size
+	q.notify()
+}
+
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notify()
+}
+
+// take takes log events within the limits from the oldest.
+// If full is true, it takes them only when they reach the limits or a new log event waits for them to be taken.
+func (q *eventQueue) take(limits batchLimits, full bool) []types.InputLogEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n, size := 0, 0
+	for n < len(q.events) && n < limits.maxEvents && size+eventSize(q.events[n]) <= limits.maxBytes {
+		size += eventSize(q.events[n])
+		n++
+	}
+	if n == 0 && len(q.events) > 0 {
+		// The log event is larger than the limits, and it is rejected by PutLogEvents
+		n, size = 1, eventSize(q.events[0])
+	}
+	if full && n == len(q.events) && n < limits.maxEvents && !q.blocked {
+		return nil
+	}
+
+	events := q.events[:n:n]
+	q.events = q.events[n:]
+	q.size -= size
+	q.taken.Broadcast()
+	return events
+}
+
+func (q *eventQueue) status() (n int, closed bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events), q.closed
+}
+
+// takeDropped returns the number of log events dropped since the last call.
+func (q *eventQueue) takeDropped() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dropped := q.dropped
+	q.dropped = 0
+	return dropped
+}
+
+// scanLogEvents reads log events from r line by line until EOF, and emits them with the time in the timestamp field or now.
+// Lines are limited by lines before they are parsed.
+func scanLogEvents(r io.Reader, lines *lineLimiter, timestamps timestampOptions, emit func(types.InputLogEvent) error) error {
+	return lines.scan(r, func(line string) error {
+		if line == "" {
+			return nil
+		}
+		t, ok := timestamps.parse(line)
+		if !ok {
+			t = time.Now()
+		}
+		return emit(types.InputLogEvent{
+			Message:   aws.String(line),
+			Timestamp: aws.Int64(toMillis(t)),
+		})
+	})
+}
+
+// streamLogEvents reads log events from r line by line until EOF or ctx is done, and calls flush with buffered log events.
+func streamLogEvents(ctx context.Context, r io.Reader, opts streamOptions, flush func(context.Context, []types.InputLogEvent) error) error {
+	read := func(emit func(types.InputLogEvent) error) error {
+		return scanLogEvents(r, opts.lines, opts.timestamps, emit)
+	}
+	return streamEvents(ctx, read, opts, flush)
+}
+
+// requestFlush returns the function which requests streamEvents to flush buffered log events with flushes, and waits for it.
+func requestFlush(flushes chan chan error) func() error {
+	return func() error {
+		done := make(chan error, 1)
+		flushes <- done
+		return <-done
+	}
+}
+
+// streamEvents calls read with the function to emit log events until read returns or ctx is done, and calls flush with buffered log events.
+// It flushes log events when they reach the limits or when the flush interval passes since the first buffered log event.
+// When ctx is done, it stops reading and flushes buffered log events within the drain timeout.
+func streamEvents(ctx context.Context, read func(emit func(types.InputLogEvent) error) error, opts streamOptions, flush func(context.Context, []types.InputLogEvent) error) error {
+	q := newEventQueue(opts.maxBufferBytes, opts.onBackpressure)
+	readErr := make(chan error, 1)
+	// The deduper is shared with the shutdown, which flushes its summary while read may still be running
+	var dmu sync.Mutex
+	d := newDeduper(opts.dedupeWindow)
+	stopped := false
+	dedupe := func(event types.InputLogEvent) []types.InputLogEvent {
+		dmu.Lock()
+		defer dmu.Unlock()
+		if stopped {
+			return nil
+		}
+		return d.add(event)
+	}
+	flushDeduper := func() []types.InputLogEvent {
+		dmu.Lock()
+		defer dmu.Unlock()
+		stopped = true
+		events := d.flush()
+		for i, event := range events {
+			events[i] = opts.emf.format(opts.enricher.enrich(event))
+		}
+		return events
+	}
+	go func() {
+		defer q.close()
+		emit := func(event types.InputLogEvent) error {
+			opts.metrics.addReceived(1)
+			event = opts.anonymizer.anonymize(wrapLogEvent(event, opts.wrapKey))
+			ok, err := opts.schema.keep(event)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			events, err := opts.script.apply(opts.fields.transform(event))
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if !opts.sampler.keep(event) {
+					continue
+				}
+				for _, event := range dedupe(event) {
+					q.push(opts.emf.format(opts.enricher.enrich(event)))
+				}
+			}
+			n, _ := q.status()
+			opts.metrics.setQueueDepth(n)
+			return nil
+		}
+		if err := read(emit); err != nil {
+			readErr <- err
+			return
+		}
+		for _, event := range flushDeduper() {
+			q.push(event)
+		}
+		readErr <- nil
+	}()
+
+	flushQueue := func(ctx context.Context, full bool) error {
+		for {
+			events := q.take(opts.limits, full)
+			if len(events) == 0 {
+				return nil
+			}
+			n, _ := q.status()
+			opts.metrics.setQueueDepth(n)
+			if dropped := q.takeDropped(); dropped > 0 {
+				opts.metrics.addDropped(dropped)
+				fmt.Fprintf(os.Stderr, "backpressure: dropped %d log events because the buffer exceeds %d bytes\n", dropped, opts.maxBufferBytes)
+			}
+			if err := flush(ctx, events); err != nil {
+				return err
+			}
+		}
+	}
+
+	timer := time.NewTimer(opts.flushInterval)
+	timer.Stop()
+	waiting := false
+	for {
+		select {
+		case <-q.ready:
+			if err := flushQueue(context.Background(), true); err != nil {
+				return err
+			}
+			n, closed := q.status()
+			if closed {
+				if err := flushQueue(context.Background(), false); err != nil {
+					return err
+				}
+				return <-readErr
+			}
+			if n > 0 && !waiting {
+				timer.Reset(opts.flushInterval)
+				waiting = true
+			}
+		case <-timer.C:
+			waiting = false
+			if err := flushQueue(context.Background(), false); err != nil {
+				return err
+			}
+		case done := <-opts.flushes:
+			err := flushQueue(context.Background(), false)
+			done <- err
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			// The summary of repeated log events is taken before draining, and put after the log events before it.
+			// It is not pushed to the queue, which may be full and block until the queue is drained.
+			pending := flushDeduper()
+			drainCtx, cancel := context.WithTimeout(context.Background(), opts.drainTimeout)
+			defer cancel()
+			if err := flushQueue(drainCtx, false); err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+			return flush(drainCtx, pending)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func newTestStreamOptions(limits batchLimits, flushInterval time.Duration) streamOptions {
+	return streamOptions{
+		limits:         limits,
+		flushInterval:  flushInterval,
+		drainTimeout:   time.Second,
+		maxBufferBytes: defaultMaxBufferBytes,
+		onBackpressure: backpressureBlock,
+		sampler:        newSampler(1, "", nil),
+	}
+}
+
+func Test_streamLogEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limits batchLimits
+		want   [][]string
+	}{
+		{
+			name:   "Stream no logs",
+			input:  "",
+			limits: defaultBatchLimits,
+			want:   [][]string{},
+		},
+		{
+			name:   "Stream logs at EOF",
+			input:  "[INFO] Start Server\n\n[INFO] Stop Server\n",
+			limits: defaultBatchLimits,
+			want:   [][]string{{"[INFO] Start Server", "[INFO] Stop Server"}},
+		},
+		{
+			name:   "Stream logs by max events",
+			input:  "a\nb\nc\n",
+			limits: batchLimits{maxEvents: 2, maxBytes: maxBatchBytes},
+			want:   [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:   "Stream logs by max bytes",
+			input:  "a\nb\nc\n",
+			limits: batchLimits{maxEvents: maxBatchEvents, maxBytes: 2 * (eventOverheadBytes + 1)},
+			want:   [][]string{{"a", "b"}, {"c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [][]string{}
+			err := streamLogEvents(context.Background(), strings.NewReader(tt.input), newTestStreamOptions(tt.limits, time.Hour), func(_ context.Context, events []types.InputLogEvent) error {
+				got = append(got, messages(events))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("streamLogEvents() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("streamLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_streamLogEvents_flushInterval(t *testing.T) {
+	r, w := io.Pipe()
+	flushed := make(chan []string)
+	done := make(chan error)
+	go func() {
+		done <- streamLogEvents(context.Background(), r, newTestStreamOptions(defaultBatchLimits, 10*time.Millisecond), func(_ context.Context, events []types.InputLogEvent) error {
+			flushed <- messages(events)
+			return nil
+		})
+	}()
+
+	io.WriteString(w, "[INFO] Start Server\n")
+	select {
+	case got := <-flushed:
+		if want := []string{"[INFO] Start Server"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("streamLogEvents() flushed %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("streamLogEvents() did not flush logs after the flush interval")
+	}
+
+	w.Close()
+	if err := <-done; err != nil {
+		t.Errorf("streamLogEvents() error = %v", err)
+	}
+}
+
+func Test_streamEvents_flushes(t *testing.T) {
+	opts := newTestStreamOptions(defaultBatchLimits, time.Hour)
+	opts.flushes = make(chan chan error)
+	flush := requestFlush(opts.flushes)
+	var got [][]string
+	read := func(emit func(types.InputLogEvent) error) error {
+		emit(types.InputLogEvent{Message: aws.String("[INFO] Start"), Timestamp: aws.Int64(0)})
+		if err := flush(); err != nil {
+			return err
+		}
+		// The log events are flushed before the flush returns
+		if want := [][]string{{"[INFO] Start"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("streamEvents() flushed %v on request, want %v", got, want)
+		}
+		return emit(types.InputLogEvent{Message: aws.String("[INFO] Stop"), Timestamp: aws.Int64(0)})
+	}
+	err := streamEvents(context.Background(), read, opts, func(_ context.Context, events []types.InputLogEvent) error {
+		got = append(got, messages(events))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("streamEvents() error = %v", err)
+	}
+	if want := [][]string{{"[INFO] Start"}, {"[INFO] Stop"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("streamEvents() = %v, want %v", got, want)
+	}
+}
+
+func Test_streamLogEvents_shutdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		dedupeWindow time.Duration
+		want         [][]string
+	}{
+		{
+			name:  "Drain buffered logs",
+			input: "[INFO] Start Server\n",
+			want:  [][]string{{"[INFO] Start Server"}},
+		},
+		{
+			name:         "Drain buffered logs with the summary of repeated logs",
+			input:        "[ERROR] Timeout\n[ERROR] Timeout\n[ERROR] Timeout\n",
+			dedupeWindow: time.Hour,
+			want:         [][]string{{"[ERROR] Timeout"}, {"last message repeated 2 times"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w := io.Pipe()
+			defer w.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			got := [][]string{}
+			done := make(chan error)
+			opts := newTestStreamOptions(defaultBatchLimits, time.Hour)
+			opts.dedupeWindow = tt.dedupeWindow
+			go func() {
+				done <- streamLogEvents(ctx, r, opts, func(ctx context.Context, events []types.InputLogEvent) error {
+					if _, ok := ctx.Deadline(); !ok {
+						t.Errorf("streamLogEvents() flushed logs without the drain timeout")
+					}
+					got = append(got, messages(events))
+					return nil
+				})
+			}()
+
+			io.WriteString(w, tt.input)
+			// Wait for the logs to be buffered
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("streamLogEvents() error = %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("streamLogEvents() did not stop after the context was canceled")
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("streamLogEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_eventQueue_push(t *testing.T) {
+	tests := []struct {
+		name           string
+		onBackpressure string
+		want           []string
+	}{
+		{
+			name:           "Drop the oldest log events",
+			onBackpressure: backpressureDropOldest,
+			want:           []string{"b", "c"},
+		},
+		{
+			name:           "Drop new log events",
+			onBackpressure: backpressureDropNewest,
+			want:           []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newEventQueue(2*(eventOverheadBytes+1), tt.onBackpressure)
+			for _, message := range []string{"a", "b", "c"} {
+				q.push(types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(0)})
+			}
+			if got := messages(q.take(defaultBatchLimits, false)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("take() = %v, want %v", got, tt.want)
+			}
+			if got := q.takeDropped(); got != 1 {
+				t.Errorf("takeDropped() = %v, want %v", got, 1)
+			}
+		})
+	}
+}
+
+func Test_eventQueue_push_block(t *testing.T) {
+	q := newEventQueue(eventOverheadBytes+1, backpressureBlock)
+	q.push(types.InputLogEvent{Message: aws.String("a"), Timestamp: aws.Int64(0)})
+
+	pushed := make(chan struct{})
+	go func() {
+		q.push(types.InputLogEvent{Message: aws.String("b"), Timestamp: aws.Int64(0)})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatalf("push() did not block when the buffer is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// The blocked log event makes the buffered log events ready to be taken
+	if got, want := messages(q.take(defaultBatchLimits, true)), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take() = %v, want %v", got, want)
+	}
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatalf("push() was blocked after log events were taken")
+	}
+	if got, want := messages(q.take(defaultBatchLimits, false)), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take() = %v, want %v", got, want)
+	}
+}
+
+func messages(events []types.InputLogEvent) []string {
+	msgs := make([]string, len(events))
+	for i, event := range events {
+		msgs[i] = aws.ToString(event.Message)
+	}
+	return msgs
+}
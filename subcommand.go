@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+type subcommand struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+// subcommands are run by giving its name as the first argument. Without them, awsputlogs puts logs.
+var subcommands = []subcommand{
+	{name: "groups", description: "List log groups.", run: runGroups},
+	{name: "streams", description: "List log streams in the log group.", run: runStreams},
+	{name: "stats", description: "Show statistics of the log group.", run: runStats},
+	{name: "query", description: "Run the CloudWatch Logs Insights query.", run: runQuery},
+	{name: "get", description: "Download log events.", run: runGet},
+	{name: "tail", description: "Show recent log events of log groups merged in order of the timestamp.", run: runTail},
+	{name: "copy", description: "Copy log events to another log stream with the original timestamps.", run: runCopy},
+	{name: "validate", description: "Validate log events in the file locally without calling AWS APIs.", run: runValidate},
+	{name: "verify", description: "Verify that log events in the file are stored in CloudWatch Logs.", run: runVerify},
+	{name: "diff", description: "Show differences between log events in the file and in CloudWatch Logs.", run: runDiff},
+	{name: "export", description: "Export log events in the log group to S3.", run: runExport},
+	{name: "generate", description: "Generate log events for load testing.", run: runGenerate},
+	{name: "create-group", description: "Create a log group.", run: runCreateGroup},
+	{name: "create-stream", description: "Create a log stream in the log group.", run: runCreateStream},
+	{name: "delete-group", description: "Delete a log group and all log streams in it.", run: runDeleteGroup},
+	{name: "delete-stream", description: "Delete a log stream in the log group.", run: runDeleteStream},
+	{name: "anomaly", description: "Manage anomaly detectors of log groups.", run: runAnomaly},
+	{name: "data-protection", description: "Manage the data protection policy of the log group.", run: runDataProtection},
+	{name: "check-pattern", description: "Check the syntax of the filter pattern, and test it against log events in the file.", run: runCheckPattern},
+	{name: "metric-filter", description: "Manage metric filters of the log group.", run: runMetricFilter},
+	{name: "prune-streams", description: "Delete idle or empty log streams in the log group.", run: runPruneStreams},
+	{name: "retention", description: "Set the retention of log events in the log group.", run: runRetention},
+	{name: "alias", description: "Manage aliases of destinations.", run: runAlias},
+	{name: "doctor", description: "Check the config, the credentials, the endpoint and the permissions to use awsputlogs.", run: runDoctor},
+	{name: "version", description: "Show the version and the build metadata of awsputlogs.", run: runVersion},
+	{name: "self-update", description: "Update awsputlogs to the latest release on GitHub.", run: runSelfUpdate},
+	{name: "fake-server", description: "Run a fake CloudWatch Logs in memory for testing.", run: runFakeServer},
+}
+
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+func printSubcommands(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range subcommands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.description)
+	}
+	tw.Flush()
+}
+
+// newSubcommandFlagSet creates the flag set of the subcommand whose usage shows the description and the synopsis.
+func newSubcommandFlagSet(name, description, synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stdout, "%s\n\n", description)
+		fmt.Fprintf(os.Stdout, "Usage: \n")
+		fmt.Fprintf(os.Stdout, "  awsputlogs %s %s\n\n", name, synopsis)
+		fmt.Fprintf(os.Stdout, "Options: \n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseInterspersed parses flags in args even if they are given after positional arguments, and returns the positional arguments.
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	positionals := []string{}
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positionals
+		}
+		positionals = append(positionals, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// runActions runs the action of the subcommand given as the second argument, like "metric-filter put".
+func runActions(args []string, description string, actions []subcommand) error {
+	if len(args) > 1 {
+		for _, action := range actions {
+			if action.name == args[1] {
+				return action.run(append([]string{args[0] + " " + action.name}, args[2:]...))
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "%s\n\n", description)
+	fmt.Fprintf(os.Stdout, "Usage: \n")
+	fmt.Fprintf(os.Stdout, "  awsputlogs %s <action> [options]\n\n", args[0])
+	fmt.Fprintf(os.Stdout, "Actions: \n")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, action := range actions {
+		fmt.Fprintf(tw, "  %s\t%s\n", action.name, action.description)
+	}
+	tw.Flush()
+	return fmt.Errorf("argument error: an action of %s is required", args[0])
+}
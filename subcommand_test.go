@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func Test_parseInterspersed(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantPositions []string
+		wantRetention int
+	}{
+		{
+			name:          "Parse flags after positional argument",
+			args:          []string{"/app/api", "--retention", "30"},
+			wantPositions: []string{"/app/api"},
+			wantRetention: 30,
+		},
+		{
+			name:          "Parse flags before positional argument",
+			args:          []string{"--retention", "30", "/app/api"},
+			wantPositions: []string{"/app/api"},
+			wantRetention: 30,
+		},
+		{
+			name:          "Parse flags between positional arguments",
+			args:          []string{"/app/api", "--retention", "30", "/app/worker"},
+			wantPositions: []string{"/app/api", "/app/worker"},
+			wantRetention: 30,
+		},
+		{
+			name:          "Parse no arguments",
+			args:          []string{},
+			wantPositions: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			retention := flags.Int("retention", 0, "")
+			got := parseInterspersed(flags, tt.args)
+			if !reflect.DeepEqual(got, tt.wantPositions) {
+				t.Errorf("parseInterspersed() = %v, want %v", got, tt.wantPositions)
+			}
+			if *retention != tt.wantRetention {
+				t.Errorf("parseInterspersed() retention = %v, want %v", *retention, tt.wantRetention)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// logLevelUnknown is the level of log events whose level is not detected.
+const logLevelUnknown = "UNKNOWN"
+
+// logLevels maps the names of log levels found in log events to the levels counted in the summary.
+var logLevels = map[string]string{
+	"TRACE":    "TRACE",
+	"DEBUG":    "DEBUG",
+	"INFO":     "INFO",
+	"NOTICE":   "INFO",
+	"WARN":     "WARN",
+	"WARNING":  "WARN",
+	"ERR":      "ERROR",
+	"ERROR":    "ERROR",
+	"CRIT":     "FATAL",
+	"CRITICAL": "FATAL",
+	"FATAL":    "FATAL",
+	"PANIC":    "FATAL",
+}
+
+// logLevelKeys are the fields of JSON log events which have the level.
+var logLevelKeys = []string{"level", "severity", "lvl", "loglevel"}
+
+// detectLogLevel returns the level of the log event. The level is taken from the level field of JSON log events,
+// or the first upper case word naming a level in other log events, such as [ERROR].
+func detectLogLevel(message string) string {
+	fields := map[string]interface{}{}
+	if json.Unmarshal([]byte(message), &fields) == nil {
+		for _, key := range logLevelKeys {
+			if value, ok := fields[key].(string); ok {
+				if level, ok := logLevels[strings.ToUpper(value)]; ok {
+					return level
+				}
+			}
+		}
+		return logLevelUnknown
+	}
+
+	words := strings.FieldsFunc(message, func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		if level, ok := logLevels[word]; ok {
+			return level
+		}
+	}
+	return logLevelUnknown
+}
+
+// add counts log events ingested into the destination.
+func (in *ingestion) add(events []types.InputLogEvent) {
+	if in.levels == nil {
+		in.levels = map[string]int{}
+	}
+	for _, event := range events {
+		timestamp := aws.ToInt64(event.Timestamp)
+		if in.events == 0 || timestamp < in.oldest {
+			in.oldest = timestamp
+		}
+		if in.events == 0 || timestamp > in.newest {
+			in.newest = timestamp
+		}
+		in.levels[detectLogLevel(aws.ToString(event.Message))]++
+		in.events++
+	}
+	in.bytes += ingestedBytes(events)
+}
+
+type ingestionReport struct {
+	LogGroup       string         `json:"logGroup,omitempty"`
+	LogStream      string         `json:"logStream,omitempty"`
+	Region         string         `json:"region,omitempty"`
+	Events         int            `json:"events"`
+	Bytes          int            `json:"bytes"`
+	EstimatedCost  float64        `json:"estimatedCost"`
+	Levels         map[string]int `json:"levels"`
+	FirstEventTime *time.Time     `json:"firstEventTime,omitempty"`
+	LastEventTime  *time.Time     `json:"lastEventTime,omitempty"`
+	Batches        []batchPlan    `json:"batches,omitempty"`
+	Status         uploadStatus   `json:"status,omitempty"`
+	Rejected       int            `json:"rejected,omitempty"`
+	Failed         int            `json:"failed,omitempty"`
+	Ambiguous      int            `json:"ambiguous,omitempty"`
+}
+
+type uploadReport struct {
+	DryRun bool `json:"dryRun"`
+	// Delivery is the delivery semantics of the run, which is not set by --dry-run
+	Delivery     string            `json:"delivery,omitempty"`
+	Destinations []ingestionReport `json:"destinations"`
+	ingestionReport
+	// LongLines is the number of lines truncated, split or dropped by --max-line-bytes
+	LongLines int `json:"longLines"`
+}
+
+// addIngestion adds the counts of the destination to the report.
+func (r *ingestionReport) addIngestion(in ingestion, pricePerGB float64) {
+	if r.Levels == nil {
+		r.Levels = map[string]int{}
+	}
+	for level, n := range in.levels {
+		r.Levels[level] += n
+	}
+	r.EstimatedCost += estimateIngestionCost(in.bytes, in.region, pricePerGB)
+	r.Bytes += in.bytes
+	r.Rejected += in.rejected
+	r.Failed += in.failed
+	r.Ambiguous += in.ambiguous
+	if in.events == 0 {
+		return
+	}
+	if oldest := fromMillis(in.oldest).UTC(); r.FirstEventTime == nil || oldest.Before(*r.FirstEventTime) {
+		r.FirstEventTime = &oldest
+	}
+	if newest := fromMillis(in.newest).UTC(); r.LastEventTime == nil || newest.After(*r.LastEventTime) {
+		r.LastEventTime = &newest
+	}
+	r.Events += in.events
+}
+
+func newUploadReport(dryRun bool, ingestions []ingestion, pricePerGB float64, lines *lineLimiter, delivery string) uploadReport {
+	report := uploadReport{DryRun: dryRun, Delivery: delivery, Destinations: []ingestionReport{}, ingestionReport: ingestionReport{Levels: map[string]int{}}}
+	for _, in := range ingestions {
+		dst := ingestionReport{
+			LogGroup:  in.dst.logGroup,
+			LogStream: in.dst.logStream,
+			Region:    in.region,
+			Batches:   in.batches,
+			Status:    in.status,
+			Rejected:  in.rejected,
+			Failed:    in.failed,
+			Ambiguous: in.ambiguous,
+		}
+		dst.addIngestion(in, pricePerGB)
+		report.Destinations = append(report.Destinations, dst)
+		report.addIngestion(in, pricePerGB)
+	}
+	report.LongLines = lines.count()
+	return report
+}
+
+// writeLogLevelSummary writes the number of log events of each level and the time range of all destinations.
+func writeLogLevelSummary(w io.Writer, ingestions []ingestion) {
+	report := newUploadReport(false, ingestions, 0, nil, "")
+	if report.Events == 0 {
+		return
+	}
+	levels := make([]string, 0, len(report.Levels))
+	for level, n := range report.Levels {
+		levels = append(levels, fmt.Sprintf("%s %d", level, n))
+	}
+	sort.Strings(levels)
+	fmt.Fprintf(w, "Log levels: %s\n", strings.Join(levels, ", "))
+	fmt.Fprintf(w, "Time range of log events: %s to %s\n", report.FirstEventTime.Format(time.RFC3339), report.LastEventTime.Format(time.RFC3339))
+}
+
+// writeDeliverySummary writes the delivery semantics of the run and the number of log events whose results are unknown.
+func writeDeliverySummary(w io.Writer, delivery string, ingestions []ingestion) {
+	if delivery == "" {
+		return
+	}
+	switch delivery {
+	case deliveryAtMostOnce:
+		fmt.Fprintf(w, "Delivery: %s (batches are not put again after ambiguous failures, so log events may be lost)\n", delivery)
+	default:
+		fmt.Fprintf(w, "Delivery: %s (batches are put again after ambiguous failures, so log events may be duplicated)\n", delivery)
+	}
+	ambiguous := 0
+	for _, in := range ingestions {
+		ambiguous += in.ambiguous
+	}
+	if ambiguous > 0 {
+		fmt.Fprintf(w, "%d log events failed ambiguously and may or may not be stored\n", ambiguous)
+	}
+}
+
+// writeUploadSummary writes the summary of log events ingested into destinations in the output format.
+// delivery is the delivery semantics of the run, which is empty for --dry-run.
+func writeUploadSummary(w io.Writer, output string, dryRun bool, ingestions []ingestion, pricePerGB float64, lines *lineLimiter, delivery string) error {
+	if output == outputJSON {
+		return writeJSON(w, newUploadReport(dryRun, ingestions, pricePerGB, lines, delivery))
+	}
+	verb := "Put"
+	if dryRun {
+		verb = "Would put"
+	}
+	writeIngestionSummary(w, verb, ingestions, pricePerGB)
+	writeDeliverySummary(w, delivery, ingestions)
+	writeLogLevelSummary(w, ingestions)
+	lines.report(w)
+	return writeBatchPlans(w, ingestions)
+}
+
+// writeBatchPlans writes the batches of each destination planned by --dry-run.
+func writeBatchPlans(w io.Writer, ingestions []ingestion) error {
+	for _, in := range ingestions {
+		if len(in.batches) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nBatches to %s:\n", in.dst)
+		rows := make([][]string, len(in.batches))
+		for i, b := range in.batches {
+			splitBy := b.SplitBy
+			if splitBy == "" {
+				splitBy = "-"
+			}
+			rows[i] = []string{
+				strconv.Itoa(i + 1),
+				fmt.Sprintf("%d-%d", b.First, b.Last),
+				strconv.Itoa(b.Events),
+				strconv.Itoa(b.Bytes),
+				b.FirstEventTime.Format(time.RFC3339),
+				b.LastEventTime.Format(time.RFC3339),
+				splitBy,
+			}
+		}
+		if err := writeTable(w, []string{"BATCH", "INDEXES", "EVENTS", "BYTES", "FIRST EVENT", "LAST EVENT", "SPLIT BY"}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
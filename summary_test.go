@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_detectLogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "Detect level of JSON log event", message: `{"level":"error","message":"Failed"}`, want: "ERROR"},
+		{name: "Detect severity of JSON log event", message: `{"severity":"WARNING","message":"Slow"}`, want: "WARN"},
+		{name: "Detect no level of JSON log event", message: `{"message":"INFO"}`, want: logLevelUnknown},
+		{name: "Detect level in brackets", message: "[INFO] Start Server", want: "INFO"},
+		{name: "Detect level after time", message: "2024-01-02 03:04:05 CRITICAL disk is full", want: "FATAL"},
+		{name: "Detect no level of lower case words", message: "error is not a level here", want: logLevelUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLogLevel(tt.message); got != tt.want {
+				t.Errorf("detectLogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testIngestions() []ingestion {
+	api := ingestion{dst: destination{logGroup: "/test/group", logStream: "api"}, region: "us-east-1"}
+	api.add([]types.InputLogEvent{
+		{Message: aws.String("[INFO] Start API"), Timestamp: aws.Int64(1704164645000)},
+		{Message: aws.String("[ERROR] Failed to Start API"), Timestamp: aws.Int64(1704164646000)},
+	})
+	worker := ingestion{dst: destination{logGroup: "/test/group", logStream: "worker"}, region: "us-east-1"}
+	worker.add([]types.InputLogEvent{
+		{Message: aws.String(`{"level":"info","message":"Start Worker"}`), Timestamp: aws.Int64(1704164644000)},
+	})
+	return []ingestion{api, worker}
+}
+
+func Test_writeUploadSummary(t *testing.T) {
+	puts := "Put 2 log events (95 bytes) to /test/group:api in us-east-1: $0.000000\n" +
+		"Put 1 log events (67 bytes) to /test/group:worker in us-east-1: $0.000000\n" +
+		"Estimated ingestion cost: $0.000000\n"
+	levels := "Log levels: ERROR 1, INFO 2\n" +
+		"Time range of log events: 2024-01-02T03:04:04Z to 2024-01-02T03:04:06Z\n"
+	tests := []struct {
+		name      string
+		delivery  string
+		ambiguous int
+		want      string
+	}{
+		{name: "Write summary", want: puts + levels},
+		{
+			name:     "Write summary with at-least-once delivery",
+			delivery: deliveryAtLeastOnce,
+			want:     puts + "Delivery: at-least-once (batches are put again after ambiguous failures, so log events may be duplicated)\n" + levels,
+		},
+		{
+			name:      "Write summary with at-most-once delivery and ambiguous failures",
+			delivery:  deliveryAtMostOnce,
+			ambiguous: 4,
+			want: puts + "Delivery: at-most-once (batches are not put again after ambiguous failures, so log events may be lost)\n" +
+				"4 log events failed ambiguously and may or may not be stored\n" + levels,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingestions := testIngestions()
+			ingestions[1].ambiguous = tt.ambiguous
+			buf := &bytes.Buffer{}
+			if err := writeUploadSummary(buf, outputTable, false, ingestions, 0, nil, tt.delivery); err != nil {
+				t.Fatalf("writeUploadSummary() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("writeUploadSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeUploadSummary_json(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lines := &lineLimiter{maxBytes: 1024, policy: longLineDrop, affected: 1}
+	if err := writeUploadSummary(buf, outputJSON, true, testIngestions(), 0, lines, ""); err != nil {
+		t.Fatalf("writeUploadSummary() error = %v", err)
+	}
+
+	var got struct {
+		DryRun       bool `json:"dryRun"`
+		Destinations []struct {
+			LogStream string         `json:"logStream"`
+			Events    int            `json:"events"`
+			Levels    map[string]int `json:"levels"`
+		} `json:"destinations"`
+		Events         int            `json:"events"`
+		Bytes          int            `json:"bytes"`
+		Levels         map[string]int `json:"levels"`
+		FirstEventTime string         `json:"firstEventTime"`
+		LastEventTime  string         `json:"lastEventTime"`
+		LongLines      int            `json:"longLines"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("writeUploadSummary() wrote invalid JSON %s: %v", buf.String(), err)
+	}
+	if !got.DryRun || got.Events != 3 || got.Bytes != 162 || got.LongLines != 1 {
+		t.Errorf("writeUploadSummary() = %s, want 3 log events of 162 bytes in dry run with 1 long line", buf.String())
+	}
+	if want := map[string]int{"INFO": 2, "ERROR": 1}; !reflect.DeepEqual(got.Levels, want) {
+		t.Errorf("writeUploadSummary() levels = %v, want %v", got.Levels, want)
+	}
+	if got.FirstEventTime != "2024-01-02T03:04:04Z" || got.LastEventTime != "2024-01-02T03:04:06Z" {
+		t.Errorf("writeUploadSummary() time range = %s to %s", got.FirstEventTime, got.LastEventTime)
+	}
+	if len(got.Destinations) != 2 || got.Destinations[1].LogStream != "worker" || got.Destinations[1].Levels["INFO"] != 1 {
+		t.Errorf("writeUploadSummary() destinations = %+v", got.Destinations)
+	}
+}
+
+func Test_writeBatchPlans(t *testing.T) {
+	in := ingestion{dst: destination{logGroup: "/test/group", logStream: "api"}}
+	in.batches = planBatches(newTestEvents(3, "[INFO] Start Server", time.Second), batchLimits{maxEvents: 2, maxBytes: maxBatchBytes})
+	want := "\nBatches to /test/group:api:\n" +
+		"BATCH  INDEXES  EVENTS  BYTES  FIRST EVENT           LAST EVENT            SPLIT BY\n" +
+		"1      0-1      2       90     2021-03-01T00:00:00Z  2021-03-01T00:00:01Z  max-batch-events\n" +
+		"2      2-2      1       45     2021-03-01T00:00:02Z  2021-03-01T00:00:02Z  -\n"
+
+	buf := &bytes.Buffer{}
+	if err := writeBatchPlans(buf, []ingestion{in, {dst: destination{logGroup: "/test/empty"}}}); err != nil {
+		t.Fatalf("writeBatchPlans() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("writeBatchPlans() = %q, want %q", got, want)
+	}
+}
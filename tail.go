@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+const (
+	// defaultTailSince is the start of log events shown by tail at first.
+	defaultTailSince = "10m"
+	// tailPollInterval is the interval to read new log events with --follow.
+	tailPollInterval = 2 * time.Second
+)
+
+// tailer reads log events of several log groups and merges them in order of the timestamp.
+type tailer struct {
+	client        *cloudwatchlogs.Client
+	logGroups     []string
+	filterPattern string
+	// cursor is the newest timestamp read so far, and seen has the IDs of log events at it.
+	// FilterLogEvents returns them again from the next poll, since the start time is inclusive.
+	cursor int64
+	seen   map[string]bool
+}
+
+func newTailer(client *cloudwatchlogs.Client, logGroups []string, filterPattern string, start time.Time) *tailer {
+	return &tailer{
+		client:        client,
+		logGroups:     logGroups,
+		filterPattern: filterPattern,
+		cursor:        toMillis(start),
+		seen:          map[string]bool{},
+	}
+}
+
+// poll returns log events of all log groups from the cursor until end, which are not returned yet.
+func (t *tailer) poll(end time.Time) ([]storedEvent, error) {
+	events := []storedEvent{}
+	for _, logGroup := range t.logGroups {
+		q := eventQuery{logGroup: logGroup, start: fromMillis(t.cursor), end: end, filterPattern: t.filterPattern}
+		err := filterLogEvents(t.client, q, eachLogEvent(func(event storedEvent) error {
+			if event.Timestamp == t.cursor && t.seen[event.EventID] {
+				return nil
+			}
+			event.LogGroup = logGroup
+			events = append(events, event)
+			return nil
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Log groups are read one by one, so log events are merged here. Ties keep the order of --log-group.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	for _, event := range events {
+		if event.Timestamp > t.cursor {
+			t.cursor = event.Timestamp
+			t.seen = map[string]bool{}
+		}
+		if event.Timestamp == t.cursor {
+			t.seen[event.EventID] = true
+		}
+	}
+	return events, nil
+}
+
+func runTail(args []string) error {
+	params := parameters{}
+	logGroups := []string{}
+	filterPattern := ""
+	since := defaultTailSince
+	follow := false
+	view := eventView{}
+	format, timezone, highlight := formatShort, "Local", ""
+	noColor := false
+
+	flags := newSubcommandFlagSet(args[0], "Show recent log events of log groups merged in order of the timestamp.", "--log-group <LOG GROUP NAME> [--log-group <LOG GROUP NAME>...] [options]")
+	addClientFlags(flags, &params)
+	flags.Var((*filesFlag)(&logGroups), "log-group", "The name of the log group. It is required. Repeat it to merge log events of several log groups.")
+	flags.StringVar(&filterPattern, "filter-pattern", "", "The filter pattern of log events to show, such as ERROR. If you do not use this parameters, all log events are shown.")
+	flags.StringVar(&since, "since", defaultTailSince, "The start of log events to show. now, duration before now such as 2h or 7d, RFC3339 or 2006-01-02.")
+	flags.BoolVar(&follow, "follow", false, "Keep showing new log events until it is interrupted.")
+	addEventViewFlags(flags, &format, &timezone, &highlight, &noColor)
+	flags.Parse(args[1:])
+
+	if len(logGroups) == 0 {
+		return errors.New("argument error: --log-group is required")
+	}
+	start, _, err := parseTimeRange(since, "")
+	if err != nil {
+		return err
+	}
+	view.colors = newPalette(os.Stdout, noColor)
+	view.source = len(logGroups) > 1
+	if err := view.parse(format, timezone, highlight); err != nil {
+		return err
+	}
+	write := newEventWriter(os.Stdout, view)
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	// Stop following on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	t := newTailer(client, logGroups, filterPattern, start)
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	for {
+		events, err := t.poll(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := write(event); err != nil {
+				return err
+			}
+		}
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/x-color/awsputlogs/fakelogs"
+)
+
+func Test_tailer_poll(t *testing.T) {
+	server := httptest.NewServer(fakelogs.New())
+	defer server.Close()
+	cli, err := setUpClient(server.URL, "us-east-1")
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	api, apiStreams, err := setUpLogGroupAndStreams(cli, 1)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+	worker, workerStreams, err := setUpLogGroupAndStreams(cli, 1)
+	if err != nil {
+		t.Fatalf("failed to set up: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	put := func(logGroup, logStream string, offset time.Duration, message string) {
+		_, err := cli.PutLogEvents(context.Background(), &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(logGroup),
+			LogStreamName: aws.String(logStream),
+			LogEvents:     []types.InputLogEvent{{Message: aws.String(message), Timestamp: aws.Int64(toMillis(now.Add(offset)))}},
+		})
+		if err != nil {
+			t.Fatalf("failed to put logs: %v", err)
+		}
+	}
+	put(api, apiStreams[0], -3*time.Second, "[ERROR] API timeout")
+	put(worker, workerStreams[0], -2*time.Second, "[ERROR] Worker timeout")
+	put(api, apiStreams[0], -time.Second, "[INFO] API recovered")
+	put(worker, workerStreams[0], -time.Second, "[ERROR] Worker retry")
+
+	messages := func(events []storedEvent) []string {
+		got := []string{}
+		for _, event := range events {
+			got = append(got, event.LogGroup+"/"+event.LogStream+" "+event.Message)
+		}
+		return got
+	}
+
+	tl := newTailer(cli, []string{api, worker}, "ERROR", now.Add(-time.Minute))
+	events, err := tl.poll(now)
+	if err != nil {
+		t.Fatalf("tailer.poll() error = %v", err)
+	}
+	want := []string{
+		api + "/" + apiStreams[0] + " [ERROR] API timeout",
+		worker + "/" + workerStreams[0] + " [ERROR] Worker timeout",
+		worker + "/" + workerStreams[0] + " [ERROR] Worker retry",
+	}
+	if got := messages(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("tailer.poll() = %v, want %v", got, want)
+	}
+
+	// Log events at the cursor are not returned twice
+	put(api, apiStreams[0], -time.Second, "[ERROR] API retry")
+	put(api, apiStreams[0], time.Second, "[ERROR] API down")
+	events, err = tl.poll(now.Add(2 * time.Second))
+	if err != nil {
+		t.Fatalf("tailer.poll() error = %v", err)
+	}
+	want = []string{
+		api + "/" + apiStreams[0] + " [ERROR] API retry",
+		api + "/" + apiStreams[0] + " [ERROR] API down",
+	}
+	if got := messages(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("tailer.poll() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+const defaultMetricsNamespace = "AwsPutLogs"
+
+// runMetricData returns the metric data of the run for each region of the uploaders.
+// The number of uploaded, rejected and failed log events and the uploaded bytes are published with the LogGroup dimension.
+func runMetricData(uploaders []*uploader) map[string][]cwtypes.MetricDatum {
+	data := map[string][]cwtypes.MetricDatum{}
+	for _, u := range uploaders {
+		dimensions := []cwtypes.Dimension{{Name: aws.String("LogGroup"), Value: aws.String(u.dst.logGroup)}}
+		datum := func(name string, value int, unit cwtypes.StandardUnit) cwtypes.MetricDatum {
+			return cwtypes.MetricDatum{
+				MetricName: aws.String(name),
+				Dimensions: dimensions,
+				Value:      aws.Float64(float64(value)),
+				Unit:       unit,
+			}
+		}
+		region := u.ingested.region
+		data[region] = append(data[region],
+			datum("UploadedEvents", u.ingested.events-u.rejected, cwtypes.StandardUnitCount),
+			datum("RejectedEvents", u.rejected, cwtypes.StandardUnitCount),
+			datum("FailedEvents", u.failed, cwtypes.StandardUnitCount),
+			datum("UploadedBytes", u.ingested.bytes, cwtypes.StandardUnitBytes),
+		)
+	}
+	return data
+}
+
+// emitRunMetrics publishes the metrics of the run to CloudWatch in the region of each destination.
+func emitRunMetrics(params parameters, uploaders []*uploader) error {
+	for region, data := range runMetricData(uploaders) {
+		params.region = region
+		cfg, err := loadConfig(params)
+		if err != nil {
+			return err
+		}
+		client := cloudwatch.NewFromConfig(cfg)
+		// PutMetricData accepts up to 1000 metrics in a call
+		for len(data) > 0 {
+			n := min(len(data), 1000)
+			in := &cloudwatch.PutMetricDataInput{
+				Namespace:  aws.String(params.metricsNamespace),
+				MetricData: data[:n],
+			}
+			if _, err := client.PutMetricData(context.Background(), in); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
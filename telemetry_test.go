@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func Test_runMetricData(t *testing.T) {
+	uploaders := []*uploader{
+		{
+			dst:      destination{logGroup: "/app/api"},
+			ingested: ingestion{region: "us-east-1", events: 10, bytes: 400},
+			rejected: 2,
+			failed:   3,
+		},
+		{
+			dst:      destination{logGroup: "/central"},
+			ingested: ingestion{region: "eu-west-1", events: 5, bytes: 200},
+		},
+	}
+	data := runMetricData(uploaders)
+
+	want := map[string]map[string]float64{
+		"us-east-1": {"UploadedEvents": 8, "RejectedEvents": 2, "FailedEvents": 3, "UploadedBytes": 400},
+		"eu-west-1": {"UploadedEvents": 5, "RejectedEvents": 0, "FailedEvents": 0, "UploadedBytes": 200},
+	}
+	if len(data) != len(want) {
+		t.Fatalf("runMetricData() regions = %v, want %v", len(data), len(want))
+	}
+	for region, metrics := range want {
+		got := map[string]float64{}
+		for _, datum := range data[region] {
+			got[aws.ToString(datum.MetricName)] = aws.ToFloat64(datum.Value)
+		}
+		for name, value := range metrics {
+			if got[name] != value {
+				t.Errorf("runMetricData() %s %s = %v, want %v", region, name, got[name], value)
+			}
+		}
+	}
+}
+
+func Test_emitRunMetrics(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	body := []byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		// CloudWatch uses the Smithy RPC v2 CBOR protocol, and 0xa0 is an empty map
+		w.Header().Set("Smithy-Protocol", "rpc-v2-cbor")
+		w.Header().Set("Content-Type", "application/cbor")
+		w.Write([]byte{0xa0})
+	}))
+	defer server.Close()
+
+	uploaders := []*uploader{{dst: destination{logGroup: "/app/api"}, ingested: ingestion{region: "us-east-1", events: 1, bytes: 40}}}
+	params := parameters{endpointURL: server.URL, metricsNamespace: "Backfill"}
+	if err := emitRunMetrics(params, uploaders); err != nil {
+		t.Fatalf("emitRunMetrics() error = %v", err)
+	}
+	for _, want := range []string{"Backfill", "UploadedEvents", "/app/api"} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("emitRunMetrics() request = %q, want to include %v", body, want)
+		}
+	}
+}
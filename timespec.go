@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// parseDuration parses the duration like time.ParseDuration, and also accepts days such as "30d" or "1d12h".
+func parseDuration(s string) (time.Duration, error) {
+	if i := strings.Index(s, "d"); i >= 0 {
+		days, err := strconv.Atoi(s[:i])
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		d := time.Duration(days) * 24 * time.Hour
+		if rest := s[i+1:]; rest != "" {
+			r, err := time.ParseDuration(rest)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %s", s)
+			}
+			d += r
+		}
+		return d, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseTimeSpec parses the time given by users. It accepts "now", the duration before now such as "2h" or "7d",
+// RFC3339 and the date formatted as 2006-01-02.
+func parseTimeSpec(s string, now time.Time) (time.Time, error) {
+	if s == "now" {
+		return now, nil
+	}
+	if d, err := parseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s. it must be now, duration such as 2h or 7d, RFC3339 or 2006-01-02", s)
+}
+
+// filterTimeRange returns log events whose timestamps are between since and until inclusive.
+// The zero time does not bound the range.
+func filterTimeRange(events []types.InputLogEvent, since, until time.Time) []types.InputLogEvent {
+	if since.IsZero() && until.IsZero() {
+		return events
+	}
+	filtered := []types.InputLogEvent{}
+	for _, event := range events {
+		t := aws.ToInt64(event.Timestamp)
+		if !since.IsZero() && t < toMillis(since) {
+			continue
+		}
+		if !until.IsZero() && t > toMillis(until) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
+
+// toMillis converts the time to the milliseconds since the epoch used by CloudWatch Logs.
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// addTimeRangeFlags adds --since and --until to flags.
+func addTimeRangeFlags(flags *flag.FlagSet, since, until *string) {
+	flags.StringVar(since, "since", "", "The start of the time range. now, duration before now such as 2h or 7d, RFC3339 or 2006-01-02.")
+	flags.StringVar(until, "until", "", "The end of the time range. now, duration before now such as 2h or 7d, RFC3339 or 2006-01-02.")
+}
+
+// parseTimeRange parses --since and --until. The empty value becomes the zero time.
+func parseTimeRange(since, until string) (time.Time, time.Time, error) {
+	now := time.Now()
+	var start, end time.Time
+	var err error
+	if since != "" {
+		if start, err = parseTimeSpec(since, now); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("argument error: --since: %w", err)
+		}
+	}
+	if until != "" {
+		if end, err = parseTimeSpec(until, now); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("argument error: --until: %w", err)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("argument error: --until must be after --since")
+	}
+	return start, end, nil
+}
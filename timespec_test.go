@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "Parse hours",
+			s:       "2h",
+			want:    2 * time.Hour,
+			wantErr: false,
+		},
+		{
+			name:    "Parse days",
+			s:       "30d",
+			want:    30 * 24 * time.Hour,
+			wantErr: false,
+		},
+		{
+			name:    "Parse days and hours",
+			s:       "1d12h",
+			want:    36 * time.Hour,
+			wantErr: false,
+		},
+		{
+			name:    "Parse invalid days",
+			s:       "xd",
+			wantErr: true,
+		},
+		{
+			name:    "Parse invalid duration",
+			s:       "yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDuration(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTimeSpec(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:    "Parse now",
+			s:       "now",
+			want:    now,
+			wantErr: false,
+		},
+		{
+			name:    "Parse duration before now",
+			s:       "2h",
+			want:    time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+			wantErr: false,
+		},
+		{
+			name:    "Parse days before now",
+			s:       "1d",
+			want:    time.Date(2021, 2, 28, 12, 0, 0, 0, time.UTC),
+			wantErr: false,
+		},
+		{
+			name:    "Parse RFC3339",
+			s:       "2021-02-01T09:30:00Z",
+			want:    time.Date(2021, 2, 1, 9, 30, 0, 0, time.UTC),
+			wantErr: false,
+		},
+		{
+			name:    "Parse date",
+			s:       "2021-02-01",
+			want:    time.Date(2021, 2, 1, 0, 0, 0, 0, time.Local),
+			wantErr: false,
+		},
+		{
+			name:    "Parse invalid time",
+			s:       "yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeSpec(tt.s, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTimeSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimeSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterTimeRange(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []types.InputLogEvent{}
+	for i := 0; i < 4; i++ {
+		events = append(events, types.InputLogEvent{Message: aws.String(fmt.Sprint(i)), Timestamp: aws.Int64(toMillis(start.Add(time.Duration(i) * time.Hour)))})
+	}
+	tests := []struct {
+		name  string
+		since time.Time
+		until time.Time
+		want  []types.InputLogEvent
+	}{
+		{name: "Keep all log events without range", want: events},
+		{name: "Keep log events since the time", since: start.Add(2 * time.Hour), want: events[2:]},
+		{name: "Keep log events until the time", until: start.Add(time.Hour), want: events[:2]},
+		{name: "Keep log events in the range", since: start.Add(time.Hour), until: start.Add(2 * time.Hour), want: events[1:3]},
+		{name: "Keep no log events out of the range", since: start.Add(5 * time.Hour), want: []types.InputLogEvent{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterTimeRange(events, tt.since, tt.until); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTimeRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/x-color/awsputlogs"
+
+// tracer returns the tracer of awsputlogs. Spans are not exported unless setUpTracing is called.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// setUpTracing exports spans to the OTLP/HTTP endpoint. If the endpoint has no path, spans are sent to /v1/traces.
+// It returns the function to export the rest of spans and stop exporting.
+func setUpTracing(endpoint string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("argument error: --otel-endpoint must be a URL such as http://localhost:4318, but got %s", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(u.String()))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "awsputlogs"),
+			attribute.String("service.version", version()),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// endSpan ends the span recording err if it is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// attempts returns the number of attempts including retries to call PutLogEvents.
+func attempts(res *cloudwatchlogs.PutLogEventsOutput, err error) int {
+	var maxAttempts *retry.MaxAttemptsError
+	if errors.As(err, &maxAttempts) {
+		return maxAttempts.Attempt
+	}
+	if res != nil {
+		if results, ok := retry.GetAttemptResults(res.ResultMetadata); ok && len(results.Results) > 0 {
+			return len(results.Results)
+		}
+	}
+	return 1
+}
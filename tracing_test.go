@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func Test_setUpTracing(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "Disable tracing", endpoint: ""},
+		{name: "Export to endpoint", endpoint: "http://localhost:4318"},
+		{name: "Export to endpoint without scheme", endpoint: "localhost:4318", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+			shutdown, err := setUpTracing(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setUpTracing() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				shutdown(context.Background())
+			}
+		})
+	}
+}
+
+func Test_uploader_put_tracing(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	recorder := tracetest.NewSpanRecorder()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	u := &uploader{
+		client: client,
+		dst:    destination{logGroup: "group", logStream: "stream"},
+		limits: batchLimits{maxEvents: 2, maxBytes: maxBatchBytes},
+	}
+	events := []types.InputLogEvent{
+		{Message: aws.String("log1"), Timestamp: aws.Int64(1)},
+		{Message: aws.String("log2"), Timestamp: aws.Int64(2)},
+		{Message: aws.String("log3"), Timestamp: aws.Int64(3)},
+	}
+	if err := u.put(context.Background(), events); err != nil {
+		t.Fatalf("uploader.put() error = %v", err)
+	}
+
+	names := []string{}
+	attrs := map[string]attribute.Value{}
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		for _, kv := range span.Attributes() {
+			attrs[span.Name()+" "+string(kv.Key)] = kv.Value
+		}
+	}
+	wantNames := []string{"put", "put", "batch"}
+	if len(names) != len(wantNames) {
+		t.Fatalf("uploader.put() spans = %v, want %v", names, wantNames)
+	}
+	for i := range names {
+		if names[i] != wantNames[i] {
+			t.Errorf("uploader.put() spans = %v, want %v", names, wantNames)
+		}
+	}
+	if got := attrs["batch awsputlogs.batches"].AsInt64(); got != 2 {
+		t.Errorf("uploader.put() batches = %v, want %v", got, 2)
+	}
+	if got := attrs["put awsputlogs.attempts"].AsInt64(); got != 1 {
+		t.Errorf("uploader.put() attempts = %v, want %v", got, 1)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// userAgentName is the product token in the User-Agent of requests, to find API calls of awsputlogs in CloudTrail.
+const userAgentName = "awsputlogs"
+
+// userAgentOptions returns the API options to add the product token and extra to the User-Agent of requests.
+func userAgentOptions(extra string) ([]func(*middleware.Stack) error, error) {
+	options := []func(*middleware.Stack) error{
+		awsmiddleware.AddUserAgentKeyValue(userAgentName, version()),
+	}
+	for _, token := range strings.Fields(extra) {
+		if strings.ContainsAny(token, "()\"\\") {
+			return nil, fmt.Errorf("argument error: --user-agent-extra must not include parentheses, quotes or backslashes, but got %s", extra)
+		}
+		if name, value, ok := strings.Cut(token, "/"); ok {
+			options = append(options, awsmiddleware.AddUserAgentKeyValue(name, value))
+			continue
+		}
+		options = append(options, awsmiddleware.AddUserAgentKey(token))
+	}
+	return options, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func Test_newClient_userAgent(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	tests := []struct {
+		name           string
+		userAgentExtra string
+		want           []string
+		wantErr        bool
+	}{
+		{
+			name: "Add product token",
+			want: []string{"awsputlogs/" + version()},
+		},
+		{
+			name:           "Add extra tokens",
+			userAgentExtra: "pipeline/nightly team/platform",
+			want:           []string{"awsputlogs/" + version(), "pipeline/nightly", "team/platform"},
+		},
+		{
+			name:           "Add invalid extra token",
+			userAgentExtra: "pipeline(nightly)",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userAgent := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userAgent = r.Header.Get("User-Agent")
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.Write([]byte(`{"logGroups":[]}`))
+			}))
+			defer server.Close()
+
+			client, err := newClient(parameters{endpointURL: server.URL, userAgentExtra: tt.userAgentExtra}, destination{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newClient() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, err := client.DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{}); err != nil {
+				t.Fatalf("DescribeLogGroups() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(userAgent, want) {
+					t.Errorf("newClient() User-Agent = %v, want to include %v", userAgent, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// The range of timestamps accepted by PutLogEvents.
+// See https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	maxEventAge    = 14 * 24 * time.Hour
+	maxEventFuture = 2 * time.Hour
+)
+
+// sizeDistribution is the distribution of the size of log events including the overhead.
+type sizeDistribution struct {
+	Min int `json:"min"`
+	P50 int `json:"p50"`
+	P90 int `json:"p90"`
+	P99 int `json:"p99"`
+	Max int `json:"max"`
+}
+
+// invalidEvent is a log event which would be rejected or which does not match the schema.
+type invalidEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+	Reason    string `json:"reason"`
+}
+
+// payloadReport is the result of validating log events without uploading them.
+type payloadReport struct {
+	Events         int              `json:"events"`
+	Bytes          int              `json:"bytes"`
+	Batches        int              `json:"batches"`
+	FirstEventTime *time.Time       `json:"firstEventTime,omitempty"`
+	LastEventTime  *time.Time       `json:"lastEventTime,omitempty"`
+	Sizes          sizeDistribution `json:"sizes"`
+	Oversize       int              `json:"oversize"`
+	Invalid        []invalidEvent   `json:"invalid"`
+}
+
+// payloadChecks are what log events are validated against.
+type payloadChecks struct {
+	limits     batchLimits
+	timestamps timestampOptions
+	schema     *schemaValidator
+	now        time.Time
+}
+
+// invalidReason returns why the log event would be rejected, or it is empty if the log event is valid.
+func (c payloadChecks) invalidReason(event types.InputLogEvent) string {
+	message := aws.ToString(event.Message)
+	t := fromMillis(aws.ToInt64(event.Timestamp))
+	switch {
+	case message == "":
+		return "the message is empty"
+	case !utf8.ValidString(message):
+		return "the message is not valid UTF-8"
+	case eventSize(event) > c.limits.maxBytes:
+		return fmt.Sprintf("the size %d bytes exceeds the limit of a batch %d bytes", eventSize(event), c.limits.maxBytes)
+	case c.timestamps.field != "" && !hasTimestamp(c.timestamps, message):
+		return fmt.Sprintf("no valid time in %s, so it uses the current time", c.timestamps.field)
+	case t.Before(c.now.Add(-maxEventAge)):
+		return "the timestamp is older than 14 days"
+	case t.After(c.now.Add(maxEventFuture)):
+		return "the timestamp is more than 2 hours in the future"
+	}
+	if c.schema != nil {
+		if err := c.schema.validate(event); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+func hasTimestamp(timestamps timestampOptions, message string) bool {
+	_, ok := timestamps.parse(message)
+	return ok
+}
+
+// percentile returns the size at the rank of q in the sorted sizes.
+func percentile(sorted []int, q float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// validatePayload validates log events through the same batching as uploading them.
+func validatePayload(events []types.InputLogEvent, c payloadChecks) payloadReport {
+	report := payloadReport{
+		Events:  len(events),
+		Bytes:   ingestedBytes(events),
+		Batches: len(splitBatches(events, c.limits)),
+		Invalid: []invalidEvent{},
+	}
+
+	sizes := make([]int, len(events))
+	for i, event := range events {
+		sizes[i] = eventSize(event)
+		if sizes[i] > c.limits.maxBytes {
+			report.Oversize++
+		}
+		if reason := c.invalidReason(event); reason != "" {
+			report.Invalid = append(report.Invalid, invalidEvent{
+				Timestamp: aws.ToInt64(event.Timestamp),
+				Message:   aws.ToString(event.Message),
+				Reason:    reason,
+			})
+		}
+
+		t := fromMillis(aws.ToInt64(event.Timestamp)).UTC()
+		if report.FirstEventTime == nil || t.Before(*report.FirstEventTime) {
+			report.FirstEventTime = &t
+		}
+		if report.LastEventTime == nil || t.After(*report.LastEventTime) {
+			report.LastEventTime = &t
+		}
+	}
+
+	sort.Ints(sizes)
+	if len(sizes) > 0 {
+		report.Sizes = sizeDistribution{
+			Min: sizes[0],
+			P50: percentile(sizes, 0.5),
+			P90: percentile(sizes, 0.9),
+			P99: percentile(sizes, 0.99),
+			Max: sizes[len(sizes)-1],
+		}
+	}
+	return report
+}
+
+func runValidate(args []string) error {
+	params := parameters{batchLimits: defaultBatchLimits}
+	maxBatchBytesSize := ""
+	schemaFile := ""
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "Validate log events in the file locally without calling AWS APIs.", "--logs-file <FILE PATH> [options]")
+	flags.Var((*filesFlag)(&params.fileNames), "logs-file", "The path of file that includes log events. It is required. Repeat it to validate several files.")
+	flags.StringVar(&params.inputFormat, "input-format", inputFormatAuto, "The format of --logs-file. auto, json, ndjson, logfmt or text. auto detects it from the content.")
+	flags.Var((*filesFlag)(&params.archives.include), "archive-include", "The glob of member files read from .zip and .tar.gz archives given as --logs-file, such as *.log. Repeat it to read several kinds of files.")
+	flags.Var((*filesFlag)(&params.archives.exclude), "archive-exclude", "The glob of member files skipped in .zip and .tar.gz archives given as --logs-file. Repeat it to skip several kinds of files.")
+	flags.StringVar(&params.inputEncoding, "input-encoding", inputEncodingUTF8, "The character encoding of --logs-file transcoded into UTF-8. utf-8, shift-jis, euc-jp, latin-1, windows-1252, utf-16, utf-16le or utf-16be.")
+	flags.StringVar(&params.parserPlugin, "parser-plugin", "", "The path of WebAssembly (WASI) module which parses --logs-file of a custom format instead of --input-format.")
+	flags.StringVar(&params.timestampField, "timestamp-field", "", "The field of JSON log events that has the time of log events in RFC3339 or an epoch timestamp. Log events without it are reported.")
+	flags.StringVar(&params.timestampUnit, "timestamp-unit", timestampUnitAuto, "The unit of epoch timestamps in --timestamp-field. auto, s, ms, us or ns.")
+	flags.IntVar(&params.batchLimits.maxEvents, "max-batch-events", maxBatchEvents, "The maximum number of log events uploaded by a request.")
+	flags.StringVar(&maxBatchBytesSize, "max-batch-bytes", "", "The maximum size of log events uploaded by a request, such as 512k. If you do not use this parameters, it is the limit of CloudWatch Logs (1m).")
+	flags.StringVar(&schemaFile, "schema", "", "The path of JSON Schema file to validate each JSON log event against.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if len(params.fileNames) == 0 {
+		return errors.New("argument error: --logs-file is required")
+	}
+	if err := validateInputFormat(params.inputFormat); err != nil {
+		return err
+	}
+	if err := validateTimestampUnit(params.timestampUnit); err != nil {
+		return err
+	}
+	if err := params.archives.validate(); err != nil {
+		return err
+	}
+	if err := validateInputEncoding(params.inputEncoding); err != nil {
+		return err
+	}
+	if maxBatchBytesSize != "" {
+		size, err := parseByteSize(maxBatchBytesSize)
+		if err != nil {
+			return fmt.Errorf("argument error: --max-batch-bytes: %w", err)
+		}
+		params.batchLimits.maxBytes = size
+	}
+	if err := params.batchLimits.validate(); err != nil {
+		return err
+	}
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+	schema, err := newSchemaValidator(schemaFile, schemaErrorFail, nil)
+	if err != nil {
+		return err
+	}
+	plugin, err := newParserPlugin(params.parserPlugin)
+	if err != nil {
+		return err
+	}
+	defer plugin.close()
+
+	now := time.Now()
+	timestamps := timestampOptions{field: params.timestampField, unit: params.timestampUnit}
+	events, err := readLogEventsFiles(params.fileNames, inputOptions{format: params.inputFormat, encoding: params.inputEncoding, plugin: plugin, archives: params.archives}, timestamps, false, now)
+	if err != nil {
+		return err
+	}
+	report := validatePayload(events, payloadChecks{limits: params.batchLimits, timestamps: timestamps, schema: schema, now: now})
+
+	if output == outputJSON {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else if err := writePayloadReport(report); err != nil {
+		return err
+	}
+	if len(report.Invalid) > 0 {
+		return fmt.Errorf("validation error: %d of %d log events are invalid", len(report.Invalid), report.Events)
+	}
+	return nil
+}
+
+func writePayloadReport(report payloadReport) error {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	rows := [][]string{
+		{"Log events", strconv.Itoa(report.Events)},
+		{"Bytes", strconv.Itoa(report.Bytes)},
+		{"Batches", strconv.Itoa(report.Batches)},
+		{"First event", formatTime(report.FirstEventTime)},
+		{"Last event", formatTime(report.LastEventTime)},
+		{"Size min/p50/p90/p99/max", fmt.Sprintf("%d/%d/%d/%d/%d", report.Sizes.Min, report.Sizes.P50, report.Sizes.P90, report.Sizes.P99, report.Sizes.Max)},
+		{"Oversize events", strconv.Itoa(report.Oversize)},
+		{"Invalid events", strconv.Itoa(len(report.Invalid))},
+	}
+	if err := writeTable(os.Stdout, []string{"STAT", "VALUE"}, rows); err != nil {
+		return err
+	}
+	if len(report.Invalid) == 0 {
+		return nil
+	}
+
+	fmt.Println("\nInvalid log events:")
+	rows = make([][]string, len(report.Invalid))
+	for i, event := range report.Invalid {
+		rows[i] = []string{fromMillis(event.Timestamp).UTC().Format(time.RFC3339), truncate(event.Message, 60), event.Reason}
+	}
+	return writeTable(os.Stdout, []string{"TIME", "MESSAGE", "REASON"}, rows)
+}
+
+// truncate shortens the message to n runes.
+func truncate(message string, n int) string {
+	runes := []rune(message)
+	if len(runes) <= n {
+		return message
+	}
+	return string(runes[:n-3]) + "..."
+}
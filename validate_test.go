@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func Test_validatePayload(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	event := func(t time.Time, message string) types.InputLogEvent {
+		return types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(toMillis(t))}
+	}
+	events := []types.InputLogEvent{
+		event(now.Add(-15*24*time.Hour), `{"level":"INFO"}`),
+		event(now.Add(-time.Hour), `{"level":"INFO","message":"Start Server"}`),
+		event(now.Add(-time.Minute), "\xff\xfe"),
+		event(now, "[INFO] "+strings.Repeat("x", 100)),
+		event(now.Add(3*time.Hour), `{"level":"INFO"}`),
+	}
+	checks := payloadChecks{limits: batchLimits{maxEvents: 2, maxBytes: 100}, now: now}
+
+	got := validatePayload(events, checks)
+	first, last := now.Add(-15*24*time.Hour), now.Add(3*time.Hour)
+	want := payloadReport{
+		Events:         5,
+		Bytes:          ingestedBytes(events),
+		Batches:        4,
+		FirstEventTime: &first,
+		LastEventTime:  &last,
+		Sizes:          sizeDistribution{Min: 28, P50: 42, P90: 133, P99: 133, Max: 133},
+		Oversize:       1,
+		Invalid: []invalidEvent{
+			{Timestamp: toMillis(now.Add(-15 * 24 * time.Hour)), Message: `{"level":"INFO"}`, Reason: "the timestamp is older than 14 days"},
+			{Timestamp: toMillis(now.Add(-time.Minute)), Message: "\xff\xfe", Reason: "the message is not valid UTF-8"},
+			{Timestamp: toMillis(now), Message: "[INFO] " + strings.Repeat("x", 100), Reason: "the size 133 bytes exceeds the limit of a batch 100 bytes"},
+			{Timestamp: toMillis(now.Add(3 * time.Hour)), Message: `{"level":"INFO"}`, Reason: "the timestamp is more than 2 hours in the future"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validatePayload() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_payloadChecks_invalidReason_timestampField(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	checks := payloadChecks{
+		limits:     defaultBatchLimits,
+		timestamps: timestampOptions{field: "time", unit: timestampUnitAuto},
+		now:        now,
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "Log event with time", message: `{"time":"2024-01-14T00:00:00Z"}`, want: ""},
+		{name: "Log event without time", message: `{"level":"INFO"}`, want: "no valid time in time, so it uses the current time"},
+		{name: "Empty log event", message: "", want: "the message is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := types.InputLogEvent{Message: aws.String(tt.message), Timestamp: aws.Int64(toMillis(now))}
+			if got := checks.invalidReason(event); got != tt.want {
+				t.Errorf("payloadChecks.invalidReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	sizes := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		q    float64
+		want int
+	}{
+		{q: 0.5, want: 5},
+		{q: 0.9, want: 9},
+		{q: 0.99, want: 10},
+		{q: 0, want: 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(sizes, tt.q); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
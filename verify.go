@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// sampleEvents returns n log events chosen randomly keeping the original order. It returns all log events if n is 0 or more than them.
+func sampleEvents(events []string, n int, r *rand.Rand) []string {
+	if n <= 0 || n >= len(events) {
+		return events
+	}
+	indexes := r.Perm(len(events))[:n]
+	chosen := make([]bool, len(events))
+	for _, i := range indexes {
+		chosen[i] = true
+	}
+	sampled := make([]string, 0, n)
+	for i, event := range events {
+		if chosen[i] {
+			sampled = append(sampled, event)
+		}
+	}
+	return sampled
+}
+
+// findMissingEvents returns the local log events not found in the stored messages.
+// Each stored message matches only one local log event, so duplicated log events have to be stored as many times.
+func findMissingEvents(local []string, stored map[string]int) []string {
+	remaining := make(map[string]int, len(stored))
+	for message, n := range stored {
+		remaining[message] = n
+	}
+
+	missing := []string{}
+	for _, event := range local {
+		if remaining[event] > 0 {
+			remaining[event]--
+			continue
+		}
+		missing = append(missing, event)
+	}
+	return missing
+}
+
+func runVerify(args []string) error {
+	params := parameters{}
+	q := eventQuery{}
+	fileName := ""
+	sample := 0
+	since, until := "", ""
+
+	flags := newSubcommandFlagSet(args[0], "Verify that log events in the file are stored in CloudWatch Logs.", "--log-group <LOG GROUP NAME> --logs-file <FILE PATH> [options]")
+	addClientFlags(flags, &params)
+	flags.StringVar(&q.logGroup, "log-group", "", "The name of the log group. It is required.")
+	flags.StringVar(&q.logStream, "log-stream", "", "The name of the log stream. If you do not use this parameters, it looks for log events in all log streams.")
+	flags.StringVar(&fileName, "logs-file", "", "The path of file that includes log events. It is required.")
+	flags.IntVar(&sample, "sample", 0, "The number of log events verified. They are chosen randomly. If you do not use this parameters, it verifies all log events.")
+	addTimeRangeFlags(flags, &since, &until)
+	flags.Parse(args[1:])
+
+	if q.logGroup == "" {
+		return errors.New("argument error: --log-group is required")
+	}
+	if fileName == "" {
+		return errors.New("argument error: --logs-file is required")
+	}
+	var err error
+	q.start, q.end, err = parseTimeRange(since, until)
+	if err != nil {
+		return err
+	}
+
+	logs, err := getLogEventsFromFile(fileName, inputFormatAuto)
+	if err != nil {
+		return err
+	}
+	sampled := sampleEvents(logs, sample, rand.New(rand.NewSource(time.Now().UnixNano())))
+
+	client, err := newClient(params, destination{})
+	if err != nil {
+		return err
+	}
+
+	stored := map[string]int{}
+	err = fetchLogEvents(client, q, func(event storedEvent) error {
+		stored[event.Message]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	missing := findMissingEvents(sampled, stored)
+	fmt.Printf("Matched %d of %d log events\n", len(sampled)-len(missing), len(sampled))
+	if len(missing) == 0 {
+		return nil
+	}
+	fmt.Println("Missing log events:")
+	for _, event := range missing {
+		fmt.Printf("  %s\n", event)
+	}
+	return fmt.Errorf("verification error: %d log events are not found in %s", len(missing), destination{logGroup: q.logGroup, logStream: q.logStream})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func Test_sampleEvents(t *testing.T) {
+	events := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name    string
+		n       int
+		wantLen int
+	}{
+		{
+			name:    "Sample some events",
+			n:       3,
+			wantLen: 3,
+		},
+		{
+			name:    "Sample all events",
+			n:       0,
+			wantLen: 5,
+		},
+		{
+			name:    "Sample more than events",
+			n:       10,
+			wantLen: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sampleEvents(events, tt.n, rand.New(rand.NewSource(1)))
+			if len(got) != tt.wantLen {
+				t.Errorf("sampleEvents() returns %d events, want %d", len(got), tt.wantLen)
+				return
+			}
+			// The sampled events keep the original order
+			last := -1
+			for _, event := range got {
+				i := int(event[0] - 'a')
+				if i <= last {
+					t.Errorf("sampleEvents() = %v, which is not in the original order", got)
+					return
+				}
+				last = i
+			}
+		})
+	}
+}
+
+func Test_findMissingEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  []string
+		stored map[string]int
+		want   []string
+	}{
+		{
+			name:   "All events are stored",
+			local:  []string{"[INFO] Start Server", "[ERROR] Failed to Start Server"},
+			stored: map[string]int{"[INFO] Start Server": 1, "[ERROR] Failed to Start Server": 1, "[INFO] Stop Server": 1},
+			want:   []string{},
+		},
+		{
+			name:   "Some events are missing",
+			local:  []string{"[INFO] Start Server", "[ERROR] Failed to Start Server"},
+			stored: map[string]int{"[INFO] Start Server": 1},
+			want:   []string{"[ERROR] Failed to Start Server"},
+		},
+		{
+			name:   "Duplicated events are stored only once",
+			local:  []string{"[INFO] Start Server", "[INFO] Start Server"},
+			stored: map[string]int{"[INFO] Start Server": 1},
+			want:   []string{"[INFO] Start Server"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findMissingEvents(tt.local, tt.stored); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findMissingEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
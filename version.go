@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// The build metadata is injected by the linker when releases are built, such as
+// go build -ldflags "-X main.buildVersion=1.2.0 -X main.buildCommit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+var (
+	buildVersion = ""
+	buildCommit  = ""
+	buildDate    = ""
+)
+
+// latestReleaseURL is the GitHub API returning the latest release of awsputlogs.
+var latestReleaseURL = "https://api.github.com/repos/x-color/awsputlogs/releases/latest"
+
+// updateCheckTimeout is the maximum time to wait for the GitHub API, so that the version is shown without the network.
+const updateCheckTimeout = 5 * time.Second
+
+// version returns the version injected by the linker, the version of awsputlogs installed by go install,
+// or "dev" if it is built from the source.
+func version() string {
+	if buildVersion != "" {
+		return strings.TrimPrefix(buildVersion, "v")
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return strings.TrimPrefix(info.Main.Version, "v")
+	}
+	return "dev"
+}
+
+type buildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	Date       string `json:"date"`
+	GoVersion  string `json:"goVersion"`
+	SDKVersion string `json:"sdkVersion"`
+	Latest     string `json:"latestVersion,omitempty"`
+}
+
+// getBuildInfo returns the build metadata injected by the linker. If it is not injected, the commit and the date
+// of the VCS recorded by go build are used instead.
+func getBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:    version(),
+		Commit:     buildCommit,
+		Date:       buildDate,
+		GoVersion:  runtime.Version(),
+		SDKVersion: aws.SDKVersion,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+// asset returns the asset of the release with the name.
+func (r release) asset(name string) (releaseAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// getLatestRelease returns the latest release on GitHub.
+func getLatestRelease(ctx context.Context, url string) (release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return release{}, fmt.Errorf("update check error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("update check error: GET %s returned %s", url, resp.Status)
+	}
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return release{}, fmt.Errorf("update check error: %w", err)
+	}
+	if r.TagName == "" {
+		return release{}, fmt.Errorf("update check error: the latest release of %s has no tag", url)
+	}
+	return r, nil
+}
+
+// getLatestVersion returns the version of the latest release on GitHub.
+func getLatestVersion(ctx context.Context, url string) (string, error) {
+	r, err := getLatestRelease(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(r.TagName, "v"), nil
+}
+
+// parseVersion splits the version, such as 1.2.0, into numbers. Pre-release and build suffixes are ignored.
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// isNewerVersion reports whether latest is newer than current. Versions which can not be parsed, such as "dev",
+// are never older.
+func isNewerVersion(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(c) || i < len(l); i++ {
+		cn, ln := 0, 0
+		if i < len(c) {
+			cn = c[i]
+		}
+		if i < len(l) {
+			ln = l[i]
+		}
+		if cn != ln {
+			return ln > cn
+		}
+	}
+	return false
+}
+
+func writeVersion(w io.Writer, info buildInfo, output string) error {
+	if output == outputJSON {
+		return writeJSON(w, info)
+	}
+	rows := [][]string{
+		{"Version", info.Version},
+		{"Commit", info.Commit},
+		{"Build date", info.Date},
+		{"Go version", info.GoVersion},
+		{"AWS SDK version", info.SDKVersion},
+	}
+	if err := writeTable(w, []string{"BUILD", "VALUE"}, rows); err != nil {
+		return err
+	}
+	switch {
+	case info.Latest == "":
+	case isNewerVersion(info.Version, info.Latest):
+		fmt.Fprintf(w, "\nA new version %s is available: https://github.com/x-color/awsputlogs/releases/tag/v%s\n", info.Latest, info.Latest)
+	case info.Version == "dev":
+		fmt.Fprintf(w, "\nThe latest release is %s\n", info.Latest)
+	default:
+		fmt.Fprintln(w, "\nawsputlogs is up to date")
+	}
+	return nil
+}
+
+func runVersion(args []string) error {
+	checkUpdate := false
+	output := outputTable
+
+	flags := newSubcommandFlagSet(args[0], "Show the version and the build metadata of awsputlogs.", "[options]")
+	flags.BoolVar(&checkUpdate, "check-update", false, "Check whether a newer version is released on GitHub. If you do not use this parameters, awsputlogs does not access the network.")
+	flags.StringVar(&output, "output", outputTable, "The output format. table or json.")
+	flags.Parse(args[1:])
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	info := getBuildInfo()
+	if checkUpdate {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		latest, err := getLatestVersion(ctx, latestReleaseURL)
+		if err != nil {
+			return err
+		}
+		info.Latest = latest
+	}
+	return writeVersion(os.Stdout, info, output)
+}
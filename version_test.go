@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_isNewerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		latest  string
+		want    bool
+	}{
+		{name: "Newer patch version", current: "1.2.0", latest: "1.2.1", want: true},
+		{name: "Newer minor version compared as numbers", current: "1.9.0", latest: "1.10.0", want: true},
+		{name: "Same version", current: "1.2.0", latest: "v1.2.0", want: false},
+		{name: "Older version", current: "1.3.0", latest: "1.2.9", want: false},
+		{name: "Newer version than pre-release", current: "1.2.0-rc.1", latest: "1.2.1", want: true},
+		{name: "Shorter version", current: "1.2", latest: "1.2.1", want: true},
+		{name: "Version built from the source", current: "dev", latest: "1.2.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNewerVersion(tt.current, tt.latest); got != tt.want {
+				t.Errorf("isNewerVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getLatestVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Get the latest version", status: http.StatusOK, body: `{"tag_name":"v1.3.0","name":"v1.3.0"}`, want: "1.3.0"},
+		{name: "Get no release", status: http.StatusNotFound, body: `{"message":"Not Found"}`, wantErr: true},
+		{name: "Get release without tag", status: http.StatusOK, body: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			got, err := getLatestVersion(context.Background(), server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getLatestVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("getLatestVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeVersion(t *testing.T) {
+	info := buildInfo{Version: "1.2.0", Commit: "0123abc", Date: "2024-01-02T03:04:05Z", GoVersion: "go1.24.0", SDKVersion: "1.47.1"}
+	tests := []struct {
+		name   string
+		latest string
+		output string
+		want   []string
+	}{
+		{
+			name:   "Write build metadata",
+			output: outputTable,
+			want:   []string{"Version          1.2.0", "Commit           0123abc", "Build date       2024-01-02T03:04:05Z", "Go version       go1.24.0", "AWS SDK version  1.47.1"},
+		},
+		{
+			name:   "Write the new version",
+			latest: "1.3.0",
+			output: outputTable,
+			want:   []string{"A new version 1.3.0 is available: https://github.com/x-color/awsputlogs/releases/tag/v1.3.0"},
+		},
+		{
+			name:   "Write up to date",
+			latest: "1.2.0",
+			output: outputTable,
+			want:   []string{"awsputlogs is up to date"},
+		},
+		{
+			name:   "Write build metadata as JSON",
+			latest: "1.3.0",
+			output: outputJSON,
+			want:   []string{`"commit": "0123abc"`, `"sdkVersion": "1.47.1"`, `"latestVersion": "1.3.0"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := info
+			info.Latest = tt.latest
+			w := &bytes.Buffer{}
+			if err := writeVersion(w, info, tt.output); err != nil {
+				t.Fatalf("writeVersion() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(w.String(), want) {
+					t.Errorf("writeVersion() = %v, want to include %v", w.String(), want)
+				}
+			}
+		})
+	}
+}
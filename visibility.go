@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+const waitPollInterval = 2 * time.Second
+
+// countVisibleEvents returns the number of log events between the oldest and newest timestamps
+// in the log stream which FilterLogEvents returns.
+func countVisibleEvents(ctx context.Context, client *cloudwatchlogs.Client, dst destination, oldest, newest int64) (int, error) {
+	param := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   aws.String(dst.logGroup),
+		LogStreamNames: []string{dst.logStream},
+		StartTime:      aws.Int64(oldest),
+		// EndTime is exclusive
+		EndTime: aws.Int64(newest + 1),
+	}
+
+	n := 0
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, param)
+	for paginator.HasMorePages() {
+		res, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		n += len(res.Events)
+	}
+	return n, nil
+}
+
+// waitForVisibility polls FilterLogEvents every interval until at least the log events put by the uploader are visible.
+// It returns the error if they are not visible until ctx is done.
+func waitForVisibility(ctx context.Context, u *uploader, interval time.Duration) error {
+	want := u.ingested.events - u.rejected
+	if want <= 0 {
+		return nil
+	}
+
+	start := time.Now()
+	visible := 0
+	for ctx.Err() == nil {
+		n, err := countVisibleEvents(ctx, u.client, u.dst, u.ingested.oldest, u.ingested.newest)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return fmt.Errorf("visibility error: %s: %w", u.dst, err)
+		}
+		visible = n
+		if visible >= want {
+			fmt.Printf("Visible %d of %d log events in %s after %s\n", visible, want, u.dst, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+	return fmt.Errorf("visibility error: only %d of %d log events are visible in %s after %s", visible, want, u.dst, time.Since(start).Round(time.Second))
+}
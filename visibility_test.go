@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_waitForVisibility(t *testing.T) {
+	setUpAWSConfig(t, "")
+
+	tests := []struct {
+		name    string
+		visible []int
+		wantErr bool
+	}{
+		{name: "Wait until log events are visible", visible: []int{0, 1, 2}, wantErr: false},
+		{name: "Time out before log events are visible", visible: []int{0, 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if target := r.Header.Get("X-Amz-Target"); !strings.HasSuffix(target, ".FilterLogEvents") {
+					t.Errorf("server got %s, want FilterLogEvents", target)
+				}
+				n := tt.visible[len(tt.visible)-1]
+				if calls < len(tt.visible) {
+					n = tt.visible[calls]
+				}
+				calls++
+				events := []map[string]interface{}{}
+				for i := 0; i < n; i++ {
+					events = append(events, map[string]interface{}{"message": "log", "timestamp": i + 1, "logStreamName": "stream"})
+				}
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				json.NewEncoder(w).Encode(map[string]interface{}{"events": events})
+			}))
+			defer server.Close()
+
+			client, err := newClient(parameters{endpointURL: server.URL}, destination{})
+			if err != nil {
+				t.Fatalf("newClient() error = %v", err)
+			}
+			u := &uploader{
+				client:   client,
+				dst:      destination{logGroup: "group", logStream: "stream"},
+				ingested: ingestion{events: 2, oldest: 1, newest: 2},
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err = waitForVisibility(ctx, u, 10*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("waitForVisibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
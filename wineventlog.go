@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// sourceWinEventLog is the source of log events read from the Windows Event Log.
+const sourceWinEventLog = "wineventlog"
+
+// winEventLevels are the names of the levels of Windows events. Level 0 is used by classic event sources for information.
+var winEventLevels = map[int]string{
+	0: "Information",
+	1: "Critical",
+	2: "Error",
+	3: "Warning",
+	4: "Information",
+	5: "Verbose",
+}
+
+// winEventLogSource reads the records of the channel of the Windows Event Log.
+type winEventLogSource struct {
+	channel string
+	// since is the time of the oldest record read. If it is zero, all records are read.
+	since time.Time
+}
+
+// winEventRecord is a record rendered by the Windows Event Log API.
+type winEventRecord struct {
+	// xml is the record rendered as XML
+	xml string
+	// message is the message formatted by the provider, which is empty if the provider has no message for the record
+	message string
+}
+
+// winEvent is the XML of a record of the Windows Event Log.
+type winEvent struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     int `xml:"EventID"`
+		Level       int `xml:"Level"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID uint64 `xml:"EventRecordID"`
+		Channel       string `xml:"Channel"`
+		Computer      string `xml:"Computer"`
+		Security      struct {
+			UserID string `xml:"UserID,attr"`
+		} `xml:"Security"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+}
+
+// winEventQuery returns the XPath query of records created at or after since.
+func winEventQuery(since time.Time) string {
+	if since.IsZero() {
+		return "*"
+	}
+	return fmt.Sprintf("*[System[TimeCreated[@SystemTime>='%s']]]", since.UTC().Format("2006-01-02T15:04:05.000Z"))
+}
+
+// winEventLogEvent converts the record into the JSON log event at the time when the record was created.
+func winEventLogEvent(record winEventRecord) (types.InputLogEvent, error) {
+	var e winEvent
+	if err := xml.Unmarshal([]byte(record.xml), &e); err != nil {
+		return types.InputLogEvent{}, fmt.Errorf("source error: failed to parse the event record: %w", err)
+	}
+	t, err := time.Parse(time.RFC3339Nano, e.System.TimeCreated.SystemTime)
+	if err != nil {
+		return types.InputLogEvent{}, fmt.Errorf("source error: the event record %d has the invalid time %q", e.System.EventRecordID, e.System.TimeCreated.SystemTime)
+	}
+
+	fields := map[string]interface{}{
+		"time":      t.UTC().Format(time.RFC3339Nano),
+		"channel":   e.System.Channel,
+		"provider":  e.System.Provider.Name,
+		"event_id":  e.System.EventID,
+		"level":     winEventLevels[e.System.Level],
+		"record_id": e.System.EventRecordID,
+		"computer":  e.System.Computer,
+	}
+	if e.System.Security.UserID != "" {
+		fields["user_id"] = e.System.Security.UserID
+	}
+	if record.message != "" {
+		fields["message"] = record.message
+	}
+	if len(e.EventData.Data) > 0 {
+		data := map[string]string{}
+		for i, d := range e.EventData.Data {
+			// Classic event sources do not name their data, so they are named like the insertion strings
+			name := d.Name
+			if name == "" {
+				name = "param" + strconv.Itoa(i+1)
+			}
+			data[name] = d.Value
+		}
+		fields["data"] = data
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return types.InputLogEvent{}, err
+	}
+	return types.InputLogEvent{
+		Message:   aws.String(string(b)),
+		Timestamp: aws.Int64(toMillis(t)),
+	}, nil
+}
+
+// read reads the records of the channel as log events sorted by the time.
+func (s *winEventLogSource) read() ([]types.InputLogEvent, error) {
+	records, err := queryWinEventLog(s.channel, winEventQuery(s.since))
+	if err != nil {
+		return nil, err
+	}
+	events := make([]types.InputLogEvent, 0, len(records))
+	for _, record := range records {
+		event, err := winEventLogEvent(record)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+	})
+	return events, nil
+}
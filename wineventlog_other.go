@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// queryWinEventLog is not supported because the Windows Event Log API is only available on Windows.
+func queryWinEventLog(channel, query string) ([]winEventRecord, error) {
+	return nil, errors.New("source error: --source wineventlog is only supported on Windows")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func Test_winEventQuery(t *testing.T) {
+	if got := winEventQuery(time.Time{}); got != "*" {
+		t.Errorf("winEventQuery() = %s, want *", got)
+	}
+	since := time.Date(2024, 1, 2, 12, 4, 5, 0, time.FixedZone("JST", 9*60*60))
+	want := "*[System[TimeCreated[@SystemTime>='2024-01-02T03:04:05.000Z']]]"
+	if got := winEventQuery(since); got != want {
+		t.Errorf("winEventQuery() = %s, want %s", got, want)
+	}
+}
+
+func Test_winEventLogEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		record        winEventRecord
+		want          string
+		wantTimestamp int64
+		wantErr       bool
+	}{
+		{
+			name: "Convert record with named data",
+			record: winEventRecord{
+				xml: `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event"><System>` +
+					`<Provider Name="Microsoft-Windows-Security-Auditing" Guid="{54849625-5478-4994-a5ba-3e3b0328c30d}"/>` +
+					`<EventID>4625</EventID><Level>0</Level><TimeCreated SystemTime="2024-01-02T03:04:05.1234567Z"/>` +
+					`<EventRecordID>123</EventRecordID><Channel>Security</Channel><Computer>WEB01</Computer><Security/></System>` +
+					`<EventData><Data Name="TargetUserName">admin</Data><Data Name="IpAddress">192.0.2.10</Data></EventData></Event>`,
+				message: "An account failed to log on.",
+			},
+			want:          `{"channel":"Security","computer":"WEB01","data":{"IpAddress":"192.0.2.10","TargetUserName":"admin"},"event_id":4625,"level":"Information","message":"An account failed to log on.","provider":"Microsoft-Windows-Security-Auditing","record_id":123,"time":"2024-01-02T03:04:05.1234567Z"}`,
+			wantTimestamp: 1704164645123,
+		},
+		{
+			name: "Convert record of classic event source",
+			record: winEventRecord{
+				xml: `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event"><System>` +
+					`<Provider Name="Application Error"/><EventID Qualifiers="0">1000</EventID><Level>2</Level>` +
+					`<TimeCreated SystemTime="2024-01-02T03:04:05Z"/><EventRecordID>7</EventRecordID><Channel>Application</Channel>` +
+					`<Computer>WEB01</Computer><Security UserID="S-1-5-18"/></System>` +
+					`<EventData><Data>app.exe</Data><Data>1.0.0</Data></EventData></Event>`,
+			},
+			want:          `{"channel":"Application","computer":"WEB01","data":{"param1":"app.exe","param2":"1.0.0"},"event_id":1000,"level":"Error","provider":"Application Error","record_id":7,"time":"2024-01-02T03:04:05Z","user_id":"S-1-5-18"}`,
+			wantTimestamp: 1704164645000,
+		},
+		{
+			name:    "Convert record without time",
+			record:  winEventRecord{xml: `<Event><System><EventRecordID>7</EventRecordID></System></Event>`},
+			wantErr: true,
+		},
+		{
+			name:    "Convert invalid record",
+			record:  winEventRecord{xml: `<Event><System>`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := winEventLogEvent(tt.record)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("winEventLogEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if aws.ToString(got.Message) != tt.want {
+				t.Errorf("winEventLogEvent() = %s, want %s", aws.ToString(got.Message), tt.want)
+			}
+			if aws.ToInt64(got.Timestamp) != tt.wantTimestamp {
+				t.Errorf("winEventLogEvent() timestamp = %d, want %d", aws.ToInt64(got.Timestamp), tt.wantTimestamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wevtapi                      = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtQuery                 = wevtapi.NewProc("EvtQuery")
+	procEvtNext                  = wevtapi.NewProc("EvtNext")
+	procEvtRender                = wevtapi.NewProc("EvtRender")
+	procEvtClose                 = wevtapi.NewProc("EvtClose")
+	procEvtOpenPublisherMetadata = wevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtFormatMessage         = wevtapi.NewProc("EvtFormatMessage")
+)
+
+// The flags of the Windows Event Log API.
+const (
+	evtQueryChannelPath      = 0x1
+	evtQueryForwardDirection = 0x100
+	evtRenderEventXML        = 1
+	evtFormatMessageEvent    = 1
+	// evtNextBatch is the number of records got by an EvtNext call
+	evtNextBatch = 64
+)
+
+// evtHandle is the EVT_HANDLE of the Windows Event Log API.
+type evtHandle uintptr
+
+func (h evtHandle) close() {
+	procEvtClose.Call(uintptr(h))
+}
+
+// queryWinEventLog reads the records of the channel matching the XPath query in order of the time.
+func queryWinEventLog(channel, query string) ([]winEventRecord, error) {
+	c, err := windows.UTF16PtrFromString(channel)
+	if err != nil {
+		return nil, err
+	}
+	q, err := windows.UTF16PtrFromString(query)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := procEvtQuery.Call(0, uintptr(unsafe.Pointer(c)), uintptr(unsafe.Pointer(q)), evtQueryChannelPath|evtQueryForwardDirection)
+	if r == 0 {
+		return nil, fmt.Errorf("source error: failed to query the channel %s of the Windows Event Log: %w", channel, err)
+	}
+	results := evtHandle(r)
+	defer results.close()
+
+	// Publisher metadata is opened once for each provider to format messages
+	publishers := map[string]evtHandle{}
+	defer func() {
+		for _, h := range publishers {
+			if h != 0 {
+				h.close()
+			}
+		}
+	}()
+
+	records := []winEventRecord{}
+	handles := make([]evtHandle, evtNextBatch)
+	for {
+		var returned uint32
+		r, _, err := procEvtNext.Call(uintptr(results), uintptr(len(handles)), uintptr(unsafe.Pointer(&handles[0])), uintptr(windows.INFINITE), 0, uintptr(unsafe.Pointer(&returned)))
+		if r == 0 {
+			if errors.Is(err, windows.ERROR_NO_MORE_ITEMS) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("source error: failed to read the channel %s of the Windows Event Log: %w", channel, err)
+		}
+		for _, h := range handles[:returned] {
+			record, err := renderWinEvent(h, publishers)
+			h.close()
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	}
+}
+
+// renderWinEvent renders the record as XML, and formats its message with the metadata of the provider.
+func renderWinEvent(h evtHandle, publishers map[string]evtHandle) (winEventRecord, error) {
+	buf := make([]uint16, 4096)
+	for {
+		var used, count uint32
+		r, _, err := procEvtRender.Call(0, uintptr(h), evtRenderEventXML, uintptr(len(buf)*2), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&count)))
+		if r != 0 {
+			break
+		}
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+			return winEventRecord{}, fmt.Errorf("source error: failed to render the event record: %w", err)
+		}
+		buf = make([]uint16, used/2+1)
+	}
+	record := winEventRecord{xml: windows.UTF16ToString(buf)}
+
+	var e winEvent
+	if err := xml.Unmarshal([]byte(record.xml), &e); err != nil {
+		return record, nil
+	}
+	provider := e.System.Provider.Name
+	metadata, ok := publishers[provider]
+	if !ok {
+		// Records of uninstalled providers have no metadata, and they are shipped without the message
+		if p, err := windows.UTF16PtrFromString(provider); err == nil {
+			r, _, _ := procEvtOpenPublisherMetadata.Call(0, uintptr(unsafe.Pointer(p)), 0, 0, 0)
+			metadata = evtHandle(r)
+		}
+		publishers[provider] = metadata
+	}
+	if metadata != 0 {
+		record.message = formatWinEventMessage(metadata, h)
+	}
+	return record, nil
+}
+
+// formatWinEventMessage returns the message of the record, or an empty string if it can not be formatted.
+func formatWinEventMessage(metadata, h evtHandle) string {
+	buf := make([]uint16, 1024)
+	for {
+		var used uint32
+		r, _, err := procEvtFormatMessage.Call(uintptr(metadata), uintptr(h), 0, 0, 0, evtFormatMessageEvent, uintptr(len(buf)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&used)))
+		if r != 0 {
+			return windows.UTF16ToString(buf)
+		}
+		if !errors.Is(err, windows.ERROR_INSUFFICIENT_BUFFER) {
+			return ""
+		}
+		buf = make([]uint16, used)
+	}
+}